@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
 	"net/http"
 	"os"
@@ -10,7 +11,9 @@ import (
 	"github.com/akhilmk/packup/internal/auth"
 	"github.com/akhilmk/packup/internal/config"
 	"github.com/akhilmk/packup/internal/database"
+	"github.com/akhilmk/packup/internal/store/localfs"
 	"github.com/akhilmk/packup/internal/todo"
+	"github.com/akhilmk/packup/internal/web"
 
 	_ "github.com/akhilmk/packup/docs"
 	httpSwagger "github.com/swaggo/http-swagger"
@@ -24,17 +27,43 @@ import (
 func main() {
 	ctx := context.Background() // Wait, need to import context
 
-	// Initialize DB
-	pool, err := database.New(ctx)
+	// "packup migrate ..." manages the schema instead of starting the server
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(ctx, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	frontendDir := flag.String("frontend-dir", os.Getenv("FRONTEND_DIR"), "serve the frontend from this directory instead of the embedded build")
+	flag.Parse()
+
+	// Initialize DB (applies pending migrations)
+	db, err := database.New(ctx)
 	if err != nil {
 		log.Fatalf("failed to connect to db: %v", err)
 	}
-	defer pool.Close()
+	defer db.Close()
+
+	// Attachment contents are kept on disk, apart from the database
+	blobs, err := localfs.New(attachmentsDir())
+	if err != nil {
+		log.Fatalf("failed to open attachment storage: %v", err)
+	}
+
+	// Permanently remove todos that outlived the trash retention period
+	retention, err := trashRetention()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if retention > 0 {
+		go purgeTrash(ctx, db, db, blobs, retention)
+	}
 
 	// Initialize Handlers
-	authHandler := auth.NewHandler(pool)
-	todoHandler := todo.NewHandler(pool)
-	adminHandler := admin.NewHandler(pool)
+	authHandler := auth.NewHandler(db, db, db)
+	todoHandler := todo.NewHandler(db, blobs)
+	adminHandler := admin.NewHandler(db, blobs)
 	configHandler := config.NewHandler()
 
 	mux := http.NewServeMux()
@@ -58,26 +87,16 @@ func main() {
 	mux.HandleFunc("GET /swagger/", authHandler.AdminMiddlewareWithRedirect(httpSwagger.WrapHandler))
 
 	// Serve static frontend files with SPA fallback
-	staticDir := "frontend/build"
-	if _, err := os.Stat(staticDir); err == nil {
-		// SPA fallback handler
-		spaHandler := func(w http.ResponseWriter, r *http.Request) {
-			path := staticDir + r.URL.Path
-			
-			// Check if file exists
-			if _, err := os.Stat(path); err == nil {
-				http.ServeFile(w, r, path)
-				return
-			}
-			
-			// Serve index.html for SPA routing
-			http.ServeFile(w, r, staticDir+"/index.html")
+	frontend := web.FS(*frontendDir)
+	if web.Available(frontend) {
+		mux.Handle("/", web.Handler(frontend))
+		if *frontendDir != "" {
+			log.Printf("Serving static files from: %s", *frontendDir)
+		} else {
+			log.Printf("Serving embedded static files")
 		}
-		
-		mux.HandleFunc("/", spaHandler)
-		log.Printf("Serving static files from: %s", staticDir)
 	} else {
-		log.Printf("Warning: %s not found. Frontend will not be served.", staticDir)
+		log.Printf("Warning: frontend build not found. Frontend will not be served.")
 	}
 
 	addr := ":8080"
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// defaultAttachmentsDir is used when ATTACHMENTS_DIR is not set.
+const defaultAttachmentsDir = "data/attachments"
+
+// attachmentsDir reads ATTACHMENTS_DIR, the directory attachment contents
+// are kept in.
+func attachmentsDir() string {
+	if dir := os.Getenv("ATTACHMENTS_DIR"); dir != "" {
+		return dir
+	}
+	return defaultAttachmentsDir
+}
+
+// defaultTrashRetentionDays is used when TRASH_RETENTION_DAYS is not set.
+const defaultTrashRetentionDays = 30
+
+// trashPurgeInterval is how often the trash is checked for expired todos.
+const trashPurgeInterval = time.Hour
+
+// trashRetention reads TRASH_RETENTION_DAYS. Zero disables purging.
+func trashRetention() (time.Duration, error) {
+	days := defaultTrashRetentionDays
+	if v := os.Getenv("TRASH_RETENTION_DAYS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid TRASH_RETENTION_DAYS %q", v)
+		}
+		days = n
+	}
+	return time.Duration(days) * 24 * time.Hour, nil
+}
+
+// purgeTrash permanently removes todos that have been in the trash for longer
+// than retention, with the contents of their attachments, once at startup
+// and then every trashPurgeInterval.
+func purgeTrash(ctx context.Context, todos store.TodoStore, attachments store.AttachmentStore, blobs store.BlobStore, retention time.Duration) {
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := purgeOnce(ctx, todos, attachments, blobs, time.Now().Add(-retention)); err != nil {
+			log.Printf("Failed to purge trash: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeOnce removes the todos deleted before the given time and the
+// contents of their attachments.
+func purgeOnce(ctx context.Context, todos store.TodoStore, attachments store.AttachmentStore, blobs store.BlobStore, before time.Time) error {
+	// List the attachments first: they are gone with their todos
+	purged, err := attachments.ListPurgeableAttachments(ctx, before)
+	if err != nil {
+		return err
+	}
+
+	n, err := todos.PurgeDeletedTodos(ctx, before)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("Purged %d todos from the trash", n)
+	}
+
+	for _, a := range purged {
+		if err := blobs.DeleteBlob(ctx, a.ID); err != nil {
+			log.Printf("Failed to delete attachment contents %s: %v", a.ID, err)
+		}
+	}
+	return nil
+}
@@ -1,24 +1,38 @@
 package admin
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/akhilmk/packup/internal/audit"
 	"github.com/akhilmk/packup/internal/auth"
 	"github.com/akhilmk/packup/internal/httputil"
+	"github.com/akhilmk/packup/internal/markdown"
 	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Handler struct {
-	db *pgxpool.Pool
+	users       store.UserStore
+	todos       store.TodoStore
+	tags        store.TagStore
+	comments    store.CommentStore
+	attachments store.AttachmentStore
+	blobs       store.BlobStore
+	workflow    store.WorkflowStore
+	templates   store.TemplateStore
+	groups      store.GroupStore
+	events      store.AuditStore
+	audit       *audit.Recorder
 }
 
-func NewHandler(db *pgxpool.Pool) *Handler {
-	return &Handler{db: db}
+func NewHandler(s store.Store, blobs store.BlobStore) *Handler {
+	return &Handler{users: s, todos: s, tags: s, comments: s, attachments: s, blobs: blobs, workflow: s, templates: s, groups: s, events: s, audit: audit.NewRecorder(s)}
 }
 
 // RegisterRoutes registers the admin routes to a mux using Go 1.22 enhanced routing
@@ -26,12 +40,54 @@ func (h *Handler) RegisterRoutes(mux *http.ServeMux, adminMiddleware func(http.H
 	mux.HandleFunc("GET /api/admin/users", adminMiddleware(h.ListUsers))
 	mux.HandleFunc("GET /api/admin/todos", adminMiddleware(h.ListAdminTodos))
 	mux.HandleFunc("POST /api/admin/todos", adminMiddleware(h.CreateAdminTodo))
+	mux.HandleFunc("GET /api/admin/todos/{id}", adminMiddleware(h.GetAdminTodo))
+	mux.HandleFunc("PUT /api/admin/todos/reorder", adminMiddleware(h.ReorderAdminTodos))
 	mux.HandleFunc("PUT /api/admin/todos/{id}", adminMiddleware(h.UpdateAdminTodo))
 	mux.HandleFunc("DELETE /api/admin/todos/{id}", adminMiddleware(h.DeleteAdminTodo))
+	mux.HandleFunc("POST /api/admin/todos/{id}/publish", adminMiddleware(h.PublishAdminTodo))
 	mux.HandleFunc("GET /api/admin/users/{userId}/todos", adminMiddleware(h.ListUserTodos))
 	mux.HandleFunc("POST /api/admin/users/{userId}/todos", adminMiddleware(h.CreateUserTodo))
+	mux.HandleFunc("GET /api/admin/users/{userId}/todos/{todoId}", adminMiddleware(h.GetUserTodo))
 	mux.HandleFunc("PUT /api/admin/users/{userId}/todos/{todoId}", adminMiddleware(h.UpdateUserTodo))
 	mux.HandleFunc("DELETE /api/admin/users/{userId}/todos/{todoId}", adminMiddleware(h.DeleteUserTodo))
+	mux.HandleFunc("GET /api/admin/trash", adminMiddleware(h.ListTrash))
+	mux.HandleFunc("POST /api/admin/todos/{id}/restore", adminMiddleware(h.RestoreAdminTodo))
+	mux.HandleFunc("GET /api/admin/users/{userId}/trash", adminMiddleware(h.ListUserTrash))
+	mux.HandleFunc("POST /api/admin/users/{userId}/todos/{todoId}/restore", adminMiddleware(h.RestoreUserTodo))
+	mux.HandleFunc("GET /api/admin/users/{userId}/todos/{todoId}/comments", adminMiddleware(h.ListComments))
+	mux.HandleFunc("POST /api/admin/users/{userId}/todos/{todoId}/comments", adminMiddleware(h.CreateComment))
+	mux.HandleFunc("PUT /api/admin/users/{userId}/todos/{todoId}/comments/{commentId}", adminMiddleware(h.UpdateComment))
+	mux.HandleFunc("DELETE /api/admin/users/{userId}/todos/{todoId}/comments/{commentId}", adminMiddleware(h.DeleteComment))
+	mux.HandleFunc("GET /api/admin/users/{userId}/todos/{todoId}/attachments", adminMiddleware(h.ListAttachments))
+	mux.HandleFunc("POST /api/admin/users/{userId}/todos/{todoId}/attachments", adminMiddleware(h.UploadAttachment))
+	mux.HandleFunc("GET /api/admin/users/{userId}/todos/{todoId}/attachments/{attachmentId}", adminMiddleware(h.DownloadAttachment))
+	mux.HandleFunc("DELETE /api/admin/users/{userId}/todos/{todoId}/attachments/{attachmentId}", adminMiddleware(h.DeleteAttachment))
+	mux.HandleFunc("GET /api/admin/audit", adminMiddleware(h.ListAudit))
+	mux.HandleFunc("GET /api/admin/search", adminMiddleware(h.SearchTodos))
+	mux.HandleFunc("GET /api/admin/overdue", adminMiddleware(h.ListOverdue))
+	mux.HandleFunc("GET /api/admin/tags", adminMiddleware(h.ListTags))
+	mux.HandleFunc("PUT /api/admin/tags/{name}", adminMiddleware(h.SaveTag))
+	mux.HandleFunc("DELETE /api/admin/tags/{name}", adminMiddleware(h.RemoveTag))
+	mux.HandleFunc("GET /api/admin/workflow", adminMiddleware(h.GetWorkflow))
+	mux.HandleFunc("PUT /api/admin/workflow", adminMiddleware(h.SaveWorkflow))
+	mux.HandleFunc("GET /api/admin/templates", adminMiddleware(h.ListTemplates))
+	mux.HandleFunc("POST /api/admin/templates", adminMiddleware(h.CreateTemplate))
+	mux.HandleFunc("GET /api/admin/templates/{id}", adminMiddleware(h.GetTemplate))
+	mux.HandleFunc("PUT /api/admin/templates/{id}", adminMiddleware(h.UpdateTemplate))
+	mux.HandleFunc("DELETE /api/admin/templates/{id}", adminMiddleware(h.DeleteTemplate))
+	mux.HandleFunc("POST /api/admin/templates/{id}/apply", adminMiddleware(h.ApplyTemplate))
+	mux.HandleFunc("POST /api/admin/templates/{id}/sync", adminMiddleware(h.SyncTemplate))
+	mux.HandleFunc("GET /api/admin/groups", adminMiddleware(h.ListGroups))
+	mux.HandleFunc("POST /api/admin/groups", adminMiddleware(h.CreateGroup))
+	mux.HandleFunc("GET /api/admin/groups/{id}", adminMiddleware(h.GetGroup))
+	mux.HandleFunc("PUT /api/admin/groups/{id}", adminMiddleware(h.UpdateGroup))
+	mux.HandleFunc("DELETE /api/admin/groups/{id}", adminMiddleware(h.DeleteGroup))
+	mux.HandleFunc("GET /api/admin/groups/{id}/members", adminMiddleware(h.ListGroupMembers))
+	mux.HandleFunc("PUT /api/admin/groups/{id}/members/{userId}", adminMiddleware(h.AddGroupMember))
+	mux.HandleFunc("DELETE /api/admin/groups/{id}/members/{userId}", adminMiddleware(h.RemoveGroupMember))
+	mux.HandleFunc("GET /api/admin/todos/{id}/history", adminMiddleware(h.AdminTodoHistory))
+	mux.HandleFunc("POST /api/admin/todos/{id}/revert", adminMiddleware(h.RevertAdminTodo))
+	mux.HandleFunc("GET /api/admin/users/{userId}/todos/{todoId}/history", adminMiddleware(h.UserTodoHistory))
 }
 
 // Middleware to check if user is admin
@@ -48,90 +104,151 @@ func (h *Handler) RequireAdmin(next http.HandlerFunc) http.HandlerFunc {
 // ListUsers returns all users (admin only)
 // ListUsers returns all users excluding admins.
 // @Summary List users
-// @Description Get a list of all users excluding admins.
+// @Description Get a list of all users excluding admins, newest first. Results are paged; pass next_cursor back as cursor to get the next page.
 // @Tags admin
 // @Produce json
+// @Param created_from query string false "Only users who signed up at or after this time (RFC 3339)"
+// @Param created_to query string false "Only users who signed up before this time (RFC 3339)"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param limit query int false "Maximum number of users (default 100, max 500)"
 // @Success 200 {object} map[string][]models.User
+// @Failure 400 {object} httputil.APIError
 // @Failure 401 {object} httputil.APIError
 // @Failure 403 {object} httputil.APIError
 // @Failure 500 {object} httputil.APIError
 // @Router /api/admin/users [get]
 func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
 	// ListUsers returns all users excluding admins (admin only)
-	rows, err := h.db.Query(r.Context(), `
-		SELECT id, email, name, avatar_url, role, created_at 
-		FROM users 
-		WHERE role != 'admin'
-		ORDER BY created_at DESC
-	`)
+	filter, err := httputil.ParseUserFilter(r.URL.Query())
 	if err != nil {
-		httputil.InternalError(w, err.Error())
+		httputil.BadRequest(w, err.Error())
 		return
 	}
-	defer rows.Close()
-
-	var users []models.User
-	for rows.Next() {
-		var u models.User
-		if err := rows.Scan(&u.ID, &u.Email, &u.Name, &u.AvatarURL, &u.Role, &u.CreatedAt); err != nil {
-			httputil.InternalError(w, err.Error())
-			return
-		}
-		users = append(users, u)
-	}
+	limit := filter.Limit
+	filter.Limit++ // One extra user tells whether another page follows
 
-	if users == nil {
-		users = []models.User{}
+	users, err := h.users.ListUsers(r.Context(), filter)
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
 	}
 
-	httputil.WriteJSON(w, map[string]any{"users": users}, http.StatusOK)
+	users, next := store.Paginate(users, limit, store.UserCursor)
+	httputil.WritePage(w, "users", users, next)
 }
 
 // ListAdminTodos returns all admin todos (admin only)
 // ListAdminTodos returns all global default tasks.
 // @Summary List global default tasks
-// @Description Get a list of all global default tasks.
+// @Description Get a list of all global default tasks. Results are paged; pass next_cursor back as cursor to get the next page.
 // @Tags admin
 // @Produce json
+// @Param status query string false "Only todos with this status, one of the workflow's"
+// @Param source query string false "Only todos from this source" Enums(default, admin, personal)
+// @Param parent_id query string false "Only the subtasks of this todo"
+// @Param tag query []string false "Only todos with every one of these tags" collectionFormat(multi)
+// @Param created_from query string false "Only todos created at or after this time (RFC 3339)"
+// @Param created_to query string false "Only todos created before this time (RFC 3339)"
+// @Param due_from query string false "Only todos due at or after this time (RFC 3339)"
+// @Param due_to query string false "Only todos due before this time (RFC 3339)"
+// @Param overdue query bool false "Only todos past their due date and not done"
+// @Param publication query string false "Only tasks in this publication state" Enums(draft, scheduled, live, expired)
+// @Param sort query string false "Order by position (default), due date with undated todos last, or priority with the most urgent first" Enums(position, due, priority)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param limit query int false "Maximum number of todos (default 100, max 500)"
+// @Param render query string false "With html, also render descriptions to sanitized HTML in description_html" Enums(markdown, html)
 // @Success 200 {object} map[string][]models.Todo
+// @Failure 400 {object} httputil.APIError
 // @Failure 401 {object} httputil.APIError
 // @Failure 403 {object} httputil.APIError
 // @Failure 500 {object} httputil.APIError
 // @Router /api/admin/todos [get]
 func (h *Handler) ListAdminTodos(w http.ResponseWriter, r *http.Request) {
-	rows, err := h.db.Query(r.Context(), `
-		SELECT id, text, status, created, position, created_by_user_id, is_default_task, shared_with_admin
-		FROM todos 
-		WHERE is_default_task = true 
-		ORDER BY position ASC, created DESC
-	`)
+	workflow, err := h.workflow.GetWorkflow(r.Context())
 	if err != nil {
 		httputil.InternalError(w, err.Error())
 		return
 	}
-	defer rows.Close()
-
-	var todos []models.Todo
-	for rows.Next() {
-		var t models.Todo
-		if err := rows.Scan(&t.ID, &t.Text, &t.Status, &t.Created, &t.Position, &t.CreatedByUserID, &t.IsDefaultTask, &t.SharedWithAdmin); err != nil {
-			httputil.InternalError(w, err.Error())
+	filter, err := httputil.ParseTodoFilter(r.URL.Query(), workflow)
+	if err != nil {
+		httputil.BadRequest(w, err.Error())
+		return
+	}
+	if p := models.Publication(r.URL.Query().Get("publication")); p != "" {
+		if !p.IsValid() {
+			httputil.BadRequest(w, "publication must be draft, scheduled, live or expired")
 			return
 		}
-		todos = append(todos, t)
+		filter.Publication = p
+	}
+	render, err := httputil.ParseRender(r.URL.Query())
+	if err != nil {
+		httputil.BadRequest(w, err.Error())
+		return
 	}
+	limit := filter.Limit
+	filter.Limit++ // One extra todo tells whether another page follows
 
-	if todos == nil {
-		todos = []models.Todo{}
+	todos, err := h.todos.ListDefaultTodos(r.Context(), filter)
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
 	}
 
-	httputil.WriteJSON(w, map[string]any{"todos": todos}, http.StatusOK)
+	todos, next := store.Paginate(todos, limit, store.TodoCursor)
+	if render {
+		markdown.RenderDescriptions(todos)
+	}
+	httputil.WritePage(w, "todos", todos, next)
+}
+
+// GetAdminTodo returns a global default task.
+// @Summary Get global default task
+// @Description Get a single global default task. Send the returned ETag back in If-Match to update or delete the task only if nobody has changed it since.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Todo ID"
+// @Param render query string false "With html, also render descriptions to sanitized HTML in description_html" Enums(markdown, html)
+// @Success 200 {object} models.Todo
+// @Header 200 {string} ETag "Version of the task"
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Router /api/admin/todos/{id} [get]
+func (h *Handler) GetAdminTodo(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		httputil.BadRequest(w, "id required")
+		return
+	}
+	render, err := httputil.ParseRender(r.URL.Query())
+	if err != nil {
+		httputil.BadRequest(w, err.Error())
+		return
+	}
+
+	t, err := h.todos.GetTodo(r.Context(), id)
+	if err != nil {
+		httputil.NotFound(w, "todo not found")
+		return
+	}
+	if !t.IsDefaultTask {
+		httputil.BadRequest(w, "not a default task")
+		return
+	}
+
+	if render {
+		markdown.RenderDescription(&t)
+	}
+	httputil.SetETag(w, t.ETag())
+	httputil.WriteJSON(w, t, http.StatusOK)
 }
 
 // CreateAdminTodo creates a new admin todo (admin only)
 // CreateAdminTodo creates a new global default task.
 // @Summary Create global default task
-// @Description Create a new global default task.
+// @Description Create a new global default task, with an optional markdown description. With parent_id, the task is added as a subtask of another default task. With recurrence, a rule such as FREQ=WEEKLY;INTERVAL=2, each user moves on to the task's next occurrence once they mark it done. With blocked_by, users cannot mark the task done until they have finished those default tasks, which must be meant for every user the task is. With groups, only the members of those groups see the task; subtasks target the groups of their parent unless given their own. A draft is kept from users until published, and publish_at and expires_at bound when users see the task; subtasks take their parent's draft flag and dates unless given their own.
 // @Tags admin
 // @Accept json
 // @Produce json
@@ -150,7 +267,18 @@ func (h *Handler) CreateAdminTodo(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Text string `json:"text"`
+		Text        string     `json:"text"`
+		Description string     `json:"description"`
+		DueAt       *time.Time `json:"due_at"`
+		ParentID    string     `json:"parent_id"`
+		Tags        []string   `json:"tags"`
+		Priority    string     `json:"priority"`
+		Recurrence  string     `json:"recurrence"`
+		BlockedBy   []string   `json:"blocked_by"`
+		Groups      []string   `json:"groups"`
+		Draft       bool       `json:"draft"`
+		PublishAt   *time.Time `json:"publish_at"`
+		ExpiresAt   *time.Time `json:"expires_at"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		httputil.BadRequest(w, "invalid json")
@@ -161,53 +289,121 @@ func (h *Handler) CreateAdminTodo(w http.ResponseWriter, r *http.Request) {
 		httputil.BadRequest(w, fmt.Sprintf("text cannot be empty or exceed %d characters", models.MaxTextLength))
 		return
 	}
+	if !models.ValidateDescription(req.Description) {
+		httputil.BadRequest(w, fmt.Sprintf("description limit of %d characters exceeded", models.MaxDescriptionLength))
+		return
+	}
+	tags, err := models.NormalizeTags(req.Tags)
+	if err != nil {
+		httputil.BadRequest(w, err.Error())
+		return
+	}
+	priority, ok := models.ParsePriority(req.Priority)
+	if !ok {
+		httputil.BadRequest(w, "invalid priority")
+		return
+	}
+	recurrence, err := models.NormalizeRecurrence(req.Recurrence)
+	if err != nil {
+		httputil.BadRequest(w, err.Error())
+		return
+	}
+	blockedBy, err := models.NormalizeBlockers(req.BlockedBy)
+	if err != nil {
+		httputil.BadRequest(w, err.Error())
+		return
+	}
+	groups, err := models.NormalizeGroups(req.Groups)
+	if err != nil {
+		httputil.BadRequest(w, err.Error())
+		return
+	}
+	if !h.checkGroups(w, r, groups) {
+		return
+	}
+	if !models.ValidateSchedule(req.PublishAt, req.ExpiresAt) {
+		httputil.BadRequest(w, "expires_at must be after publish_at")
+		return
+	}
 
 	id := uuid.NewString()
 	status := string(models.StatusPending)
 	created := time.Now()
 
-	// Get min position to put at top
-	var minPos float64
-	_ = h.db.QueryRow(r.Context(), `SELECT COALESCE(MIN(position), 0) FROM todos WHERE is_default_task=true`).Scan(&minPos)
-	position := minPos - models.PositionIncrement
-
-	// Insert admin todo (default task)
-	_, err := h.db.Exec(r.Context(), `
-		INSERT INTO todos(id, text, status, created, position, created_by_user_id, is_default_task, user_id, shared_with_admin) 
-		VALUES($1,$2,$3,$4,$5,$6,$7,NULL,$8)
-	`, id, req.Text, status, created, position, userID, true, false) // SharedWithAdmin is irrelevant for default tasks but defaulting to false
-	if err != nil {
-		httputil.InternalError(w, err.Error())
-		return
+	// Subtasks of default tasks are default tasks themselves, meant for the
+	// same users and published with their parent unless set otherwise
+	var parentID *string
+	if req.ParentID != "" {
+		parent, err := h.todos.GetTodo(r.Context(), req.ParentID)
+		if err != nil {
+			httputil.BadRequest(w, "parent todo not found")
+			return
+		}
+		if !parent.IsDefaultTask {
+			httputil.BadRequest(w, "parent is not a default task")
+			return
+		}
+		parentID = &parent.ID
+		if len(groups) == 0 {
+			groups = parent.Groups
+		}
+		if !req.Draft && req.PublishAt == nil && req.ExpiresAt == nil {
+			req.Draft, req.PublishAt, req.ExpiresAt = parent.Draft, parent.PublishAt, parent.ExpiresAt
+		}
 	}
 
+	// Insert admin todo (default task), placed at the top of the default tasks
 	createdByUserID := userID
-	httputil.WriteJSON(w, models.Todo{
+	t := models.Todo{
 		ID:              id,
 		Text:            req.Text,
+		Description:     req.Description,
 		Status:          status,
 		Created:         created,
-		Position:        position,
 		CreatedByUserID: &createdByUserID,
 		IsDefaultTask:   true,
-		SharedWithAdmin: false,
-	}, http.StatusCreated)
+		SharedWithAdmin: false, // SharedWithAdmin is irrelevant for default tasks but defaulting to false
+		DueAt:           req.DueAt,
+		ParentID:        parentID,
+		Tags:            tags,
+		Priority:        string(priority),
+		Recurrence:      recurrence,
+		BlockedBy:       blockedBy,
+		Groups:          groups,
+		Draft:           req.Draft,
+		PublishAt:       req.PublishAt,
+		ExpiresAt:       req.ExpiresAt,
+	}
+	if !h.checkBlockers(w, r, t, blockedBy) {
+		return
+	}
+	if err := h.todos.CreateTodo(r.Context(), &t); err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	h.audit.Record(r.Context(), audit.Event{ActorID: userID, Action: models.AuditTodoCreate, TodoID: t.ID, After: t})
+
+	httputil.WriteJSON(w, t, http.StatusCreated)
 }
 
 // UpdateAdminTodo updates an admin todo's text (admin only)
-// UpdateAdminTodo updates a global default task's text.
+// UpdateAdminTodo updates a global default task's text, due date, priority,
+// recurrence, tags or target groups.
 // @Summary Update global default task
-// @Description Update a global default task's text.
+// @Description Update a global default task's text, markdown description, due date, priority, recurrence, dependencies or tags, which replace the task's tags. An empty recurrence stops the task from recurring; users keep the occurrence they are on. blocked_by replaces the default tasks this one depends on, which must be meant for every user it is, and groups the groups whose members see it; an empty list shows it to every user. Users who set their own due date for the task keep it. draft, publish_at and expires_at change when users see the task; clear_publish_at and clear_expires_at remove either date. When the text or description changes, propagate says what happens to users' progress: keep, the default, leaves it; reset_all moves every user back to pending and reset_done only those who are done. Users reset from done see the task flagged updated_since_done until they change its status. With If-Match, the update fails with 412 if the task has changed since it was read.
 // @Tags admin
 // @Accept json
 // @Produce json
 // @Param id path string true "Todo ID"
-// @Param todo body object true "New text"
+// @Param If-Match header string false "ETag of the task as last read"
+// @Param todo body object true "New text or due date"
 // @Success 200 {object} models.Todo
+// @Header 200 {string} ETag "Version of the updated task"
 // @Failure 400 {object} httputil.APIError
 // @Failure 401 {object} httputil.APIError
 // @Failure 403 {object} httputil.APIError
 // @Failure 404 {object} httputil.APIError
+// @Failure 412 {object} httputil.APIError
 // @Failure 500 {object} httputil.APIError
 // @Router /api/admin/todos/{id} [put]
 func (h *Handler) UpdateAdminTodo(w http.ResponseWriter, r *http.Request) {
@@ -218,63 +414,279 @@ func (h *Handler) UpdateAdminTodo(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Text string `json:"text"`
+		Text        string     `json:"text"`
+		Description *string    `json:"description,omitempty"`
+		DueAt       *time.Time `json:"due_at,omitempty"`
+		ClearDueAt  bool       `json:"clear_due_at,omitempty"`
+		Tags        *[]string  `json:"tags,omitempty"`
+		Priority    string     `json:"priority"`
+		Recurrence  *string    `json:"recurrence,omitempty"`
+		BlockedBy   *[]string  `json:"blocked_by,omitempty"`
+		Groups      *[]string  `json:"groups,omitempty"`
+		Propagate   string     `json:"propagate"`
+
+		Draft          *bool      `json:"draft,omitempty"`
+		PublishAt      *time.Time `json:"publish_at,omitempty"`
+		ClearPublishAt bool       `json:"clear_publish_at,omitempty"`
+		ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+		ClearExpiresAt bool       `json:"clear_expires_at,omitempty"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		httputil.BadRequest(w, "invalid json")
 		return
 	}
 
+	var tags *[]string
+	if req.Tags != nil {
+		normalized, err := models.NormalizeTags(*req.Tags)
+		if err != nil {
+			httputil.BadRequest(w, err.Error())
+			return
+		}
+		tags = &normalized
+	}
+	propagate, ok := models.ParsePropagation(req.Propagate)
+	if !ok {
+		httputil.BadRequest(w, "propagate must be keep, reset_all or reset_done")
+		return
+	}
+	if propagate != models.PropagateKeep && req.Text == "" && req.Description == nil {
+		httputil.BadRequest(w, "propagate requires a change to the text or description")
+		return
+	}
+	var blockedBy *[]string
+	if req.BlockedBy != nil {
+		normalized, err := models.NormalizeBlockers(*req.BlockedBy)
+		if err != nil {
+			httputil.BadRequest(w, err.Error())
+			return
+		}
+		blockedBy = &normalized
+	}
+	var groups *[]string
+	if req.Groups != nil {
+		normalized, err := models.NormalizeGroups(*req.Groups)
+		if err != nil {
+			httputil.BadRequest(w, err.Error())
+			return
+		}
+		groups = &normalized
+	}
+
 	if req.Text != "" && !models.ValidateText(req.Text) {
 		httputil.BadRequest(w, fmt.Sprintf("text limit of %d characters exceeded", models.MaxTextLength))
 		return
 	}
+	if req.Description != nil && !models.ValidateDescription(*req.Description) {
+		httputil.BadRequest(w, fmt.Sprintf("description limit of %d characters exceeded", models.MaxDescriptionLength))
+		return
+	}
+	if req.Priority != "" && !models.TodoPriority(req.Priority).IsValid() {
+		httputil.BadRequest(w, "invalid priority")
+		return
+	}
+	if req.Recurrence != nil {
+		recurrence, err := models.NormalizeRecurrence(*req.Recurrence)
+		if err != nil {
+			httputil.BadRequest(w, err.Error())
+			return
+		}
+		req.Recurrence = &recurrence
+	}
+
+	// A zero due date clears it
+	if req.ClearDueAt {
+		if req.DueAt != nil {
+			httputil.BadRequest(w, "due_at and clear_due_at cannot be combined")
+			return
+		}
+		req.DueAt = &time.Time{}
+	}
+	if req.ClearPublishAt {
+		if req.PublishAt != nil {
+			httputil.BadRequest(w, "publish_at and clear_publish_at cannot be combined")
+			return
+		}
+		req.PublishAt = &time.Time{}
+	}
+	if req.ClearExpiresAt {
+		if req.ExpiresAt != nil {
+			httputil.BadRequest(w, "expires_at and clear_expires_at cannot be combined")
+			return
+		}
+		req.ExpiresAt = &time.Time{}
+	}
 
 	// Verify it's a default task
-	var isDefaultTask bool
-	err := h.db.QueryRow(r.Context(), `SELECT is_default_task FROM todos WHERE id=$1`, id).Scan(&isDefaultTask)
+	existing, err := h.todos.GetTodo(r.Context(), id)
 	if err != nil {
 		http.Error(w, "todo not found", http.StatusNotFound)
 		return
 	}
-	if !isDefaultTask {
+	if !existing.IsDefaultTask {
 		http.Error(w, "not a default task", http.StatusBadRequest)
 		return
 	}
 
-	// Update text only
-	_, err = h.db.Exec(r.Context(), `UPDATE todos SET text = $1 WHERE id = $2`, req.Text, id)
-	if err != nil {
+	// Refuse to overwrite changes the client hasn't seen
+	if !httputil.IfMatch(r, existing.ETag()) {
+		httputil.PreconditionFailed(w, "todo has been changed by someone else")
+		return
+	}
+
+	if groups != nil && !h.checkGroups(w, r, *groups) {
+		return
+	}
+	if blockedBy != nil {
+		// Blockers must reach the users the task is about to reach
+		dependent := existing
+		if groups != nil {
+			dependent.Groups = *groups
+		}
+		if !h.checkBlockers(w, r, dependent, *blockedBy) {
+			return
+		}
+	}
+	publishAt, expiresAt := existing.PublishAt, existing.ExpiresAt
+	if req.PublishAt != nil {
+		publishAt = req.PublishAt
+	}
+	if req.ExpiresAt != nil {
+		expiresAt = req.ExpiresAt
+	}
+	if !models.ValidateSchedule(publishAt, expiresAt) {
+		httputil.BadRequest(w, "expires_at must be after publish_at")
+		return
+	}
+
+	// Update text, description, due date, tags, priority, recurrence,
+	// dependencies, target groups and schedule only
+	adminID, _ := auth.GetUserID(r.Context())
+	update := store.TodoUpdate{Description: req.Description, DueAt: req.DueAt, Tags: tags, Recurrence: req.Recurrence, BlockedBy: blockedBy, Groups: groups, Draft: req.Draft, PublishAt: req.PublishAt, ExpiresAt: req.ExpiresAt, Propagate: propagate, ActorID: adminID}
+	if req.Text != "" {
+		update.Text = &req.Text
+	}
+	if req.Priority != "" {
+		update.Priority = &req.Priority
+	}
+	if httputil.IsConditional(r) {
+		update.IfVersion = &existing.Version
+	}
+	if err := h.todos.UpdateTodo(r.Context(), id, update); err != nil {
+		if errors.Is(err, store.ErrConflict) {
+			httputil.PreconditionFailed(w, "todo has been changed by someone else")
+			return
+		}
+		if errors.Is(err, store.ErrDependencyCycle) {
+			httputil.BadRequest(w, "blocked_by would create a dependency cycle")
+			return
+		}
 		httputil.InternalError(w, err.Error())
 		return
 	}
 
 	// Fetch and return updated todo
-	var t models.Todo
-	if err := h.db.QueryRow(r.Context(), `
-		SELECT id, text, status, created, position, created_by_user_id, is_default_task, shared_with_admin
-		FROM todos 
-		WHERE id=$1
-	`, id).Scan(&t.ID, &t.Text, &t.Status, &t.Created, &t.Position, &t.CreatedByUserID, &t.IsDefaultTask, &t.SharedWithAdmin); err != nil {
+	t, err := h.todos.GetTodo(r.Context(), id)
+	if err != nil {
 		httputil.InternalError(w, err.Error())
 		return
 	}
+	h.audit.Record(r.Context(), audit.Event{ActorID: adminID, Action: models.AuditTodoUpdate, TodoID: id, Before: existing, After: t})
 
+	httputil.SetETag(w, t.ETag())
 	httputil.WriteJSON(w, t, http.StatusOK)
 }
 
+// ReorderAdminTodos sets the canonical order of global default tasks.
+// @Summary Reorder global default tasks
+// @Description Set the order users see default tasks in, unless they have reordered them themselves. With reset_users, users' own order of these tasks is reset to it too. Subtasks are ordered within their parent, so the IDs must be those of every default task with the same parent, or of every top-level one, each listed once.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param ids body object true "List of default task IDs in new order, and reset_users"
+// @Success 200 {object} map[string]bool
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/todos/reorder [put]
+func (h *Handler) ReorderAdminTodos(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		IDs        []string `json:"ids"`
+		ResetUsers bool     `json:"reset_users"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.BadRequest(w, "invalid json")
+		return
+	}
+	if len(req.IDs) == 0 {
+		httputil.BadRequest(w, "ids required")
+		return
+	}
+
+	// Subtasks are ordered within their parent, so the IDs must be those of
+	// every task the admin lists under the first task's parent
+	first, err := h.todos.GetTodo(r.Context(), req.IDs[0])
+	if err != nil || !first.IsDefaultTask {
+		httputil.BadRequest(w, "unknown default task "+req.IDs[0])
+		return
+	}
+	var filter store.TodoFilter
+	if first.ParentID != nil {
+		filter.ParentID = *first.ParentID
+	}
+	siblings, err := h.todos.ListDefaultTodos(r.Context(), filter)
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	listed := map[string]bool{}
+	for _, t := range siblings {
+		if (t.ParentID == nil) == (first.ParentID == nil) {
+			listed[t.ID] = true
+		}
+	}
+	for _, id := range req.IDs {
+		if !listed[id] {
+			httputil.BadRequest(w, "unexpected or repeated default task "+id)
+			return
+		}
+		delete(listed, id)
+	}
+	if len(listed) > 0 {
+		httputil.BadRequest(w, "ids must list every default task with the same parent")
+		return
+	}
+
+	if err := h.todos.ReorderDefaultTodos(r.Context(), req.IDs, req.ResetUsers); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			httputil.NotFound(w, "default task not found")
+			return
+		}
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	adminID, _ := auth.GetUserID(r.Context())
+	h.audit.Record(r.Context(), audit.Event{ActorID: adminID, Action: models.AuditTodoReorder, After: req})
+
+	httputil.WriteSuccess(w)
+}
+
 // DeleteAdminTodo deletes an admin todo (admin only)
 // DeleteAdminTodo deletes a global default task.
 // @Summary Delete global default task
-// @Description Delete a global default task.
+// @Description Move a global default task, with its subtasks, to the trash. Users' progress on it is kept until it is purged. With If-Match, the delete fails with 412 if the task has changed since it was read.
 // @Tags admin
 // @Produce json
 // @Param id path string true "Todo ID"
+// @Param If-Match header string false "ETag of the task as last read"
 // @Success 200 {object} map[string]bool
 // @Failure 400 {object} httputil.APIError
 // @Failure 401 {object} httputil.APIError
 // @Failure 403 {object} httputil.APIError
 // @Failure 404 {object} httputil.APIError
+// @Failure 412 {object} httputil.APIError
 // @Failure 500 {object} httputil.APIError
 // @Router /api/admin/todos/{id} [delete]
 func (h *Handler) DeleteAdminTodo(w http.ResponseWriter, r *http.Request) {
@@ -285,27 +697,39 @@ func (h *Handler) DeleteAdminTodo(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Verify it's a default task
-	var isDefaultTask bool
-	err := h.db.QueryRow(r.Context(), `SELECT is_default_task FROM todos WHERE id=$1`, id).Scan(&isDefaultTask)
+	t, err := h.todos.GetTodo(r.Context(), id)
 	if err != nil {
 		httputil.NotFound(w, "todo not found")
 		return
 	}
-	if !isDefaultTask {
+	if !t.IsDefaultTask {
 		httputil.BadRequest(w, "not a default task")
 		return
 	}
 
-	// Delete the todo
-	cmd, err := h.db.Exec(r.Context(), `DELETE FROM todos WHERE id=$1`, id)
-	if err != nil {
-		httputil.InternalError(w, err.Error())
+	// Delete the todo, unless it changed since the client read it
+	if !httputil.IfMatch(r, t.ETag()) {
+		httputil.PreconditionFailed(w, "todo has been changed by someone else")
 		return
 	}
-	if cmd.RowsAffected() == 0 {
-		httputil.NotFound(w, "todo not found")
+	var ifVersion *int
+	if httputil.IsConditional(r) {
+		ifVersion = &t.Version
+	}
+	if err := h.todos.DeleteTodo(r.Context(), id, ifVersion); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			httputil.NotFound(w, "todo not found")
+			return
+		}
+		if errors.Is(err, store.ErrConflict) {
+			httputil.PreconditionFailed(w, "todo has been changed by someone else")
+			return
+		}
+		httputil.InternalError(w, err.Error())
 		return
 	}
+	adminID, _ := auth.GetUserID(r.Context())
+	h.audit.Record(r.Context(), audit.Event{ActorID: adminID, Action: models.AuditTodoDelete, TodoID: id, Before: t})
 
 	httputil.WriteSuccess(w)
 }
@@ -313,11 +737,25 @@ func (h *Handler) DeleteAdminTodo(w http.ResponseWriter, r *http.Request) {
 // ListUserTodos returns all todos for a specific user (admin only)
 // ListUserTodos returns all todos for a specific user.
 // @Summary List user's todos
-// @Description Get all personal (if shared) and default tasks for a specific user.
+// @Description Get all personal (if shared) and default tasks for a specific user. Default tasks targeted to groups are only included if the user is a member of one. Status filters match the user's own status of default tasks. Results are paged; pass next_cursor back as cursor to get the next page.
 // @Tags admin
 // @Produce json
 // @Param userId path string true "User ID"
+// @Param status query string false "Only todos with this status, one of the workflow's"
+// @Param source query string false "Only todos from this source" Enums(default, admin, personal)
+// @Param parent_id query string false "Only the subtasks of this todo"
+// @Param tag query []string false "Only todos with every one of these tags" collectionFormat(multi)
+// @Param created_from query string false "Only todos created at or after this time (RFC 3339)"
+// @Param created_to query string false "Only todos created before this time (RFC 3339)"
+// @Param due_from query string false "Only todos due at or after this time (RFC 3339)"
+// @Param due_to query string false "Only todos due before this time (RFC 3339)"
+// @Param overdue query bool false "Only todos past their due date and not done"
+// @Param sort query string false "Order by position (default), due date with undated todos last, or priority with the most urgent first" Enums(position, due, priority)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param limit query int false "Maximum number of todos (default 100, max 500)"
+// @Param render query string false "With html, also render descriptions to sanitized HTML in description_html" Enums(markdown, html)
 // @Success 200 {object} map[string][]models.Todo
+// @Failure 400 {object} httputil.APIError
 // @Failure 401 {object} httputil.APIError
 // @Failure 403 {object} httputil.APIError
 // @Failure 404 {object} httputil.APIError
@@ -331,68 +769,107 @@ func (h *Handler) ListUserTodos(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Verify user exists
-	var exists bool
-	err := h.db.QueryRow(r.Context(), `SELECT EXISTS(SELECT 1 FROM users WHERE id=$1)`, userID).Scan(&exists)
-	if err != nil || !exists {
+	if _, err := h.users.GetUser(r.Context(), userID); err != nil {
 		http.Error(w, "user not found", http.StatusNotFound)
 		return
 	}
 
+	workflow, err := h.workflow.GetWorkflow(r.Context())
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	filter, err := httputil.ParseTodoFilter(r.URL.Query(), workflow)
+	if err != nil {
+		httputil.BadRequest(w, err.Error())
+		return
+	}
+	render, err := httputil.ParseRender(r.URL.Query())
+	if err != nil {
+		httputil.BadRequest(w, err.Error())
+		return
+	}
+	limit := filter.Limit
+	filter.Limit++ // One extra todo tells whether another page follows
+
 	// Get user's todos (personal + default with their specific status)
 	// IMPORTANT: For personal todos, ONLY show if shared_with_admin = true
-	rows, err := h.db.Query(r.Context(), `
-		SELECT 
-			t.id, 
-			t.text, 
-			CASE 
-				WHEN t.is_default_task THEN COALESCE(uts.status, t.status)
-				ELSE t.status
-			END as status,
-			t.created, 
-			CASE 
-				WHEN t.is_default_task THEN COALESCE(uts.position, t.position)
-				ELSE t.position
-			END as position,
-			t.created_by_user_id, 
-			t.is_default_task,
-			t.shared_with_admin,
-			t.user_id,
-			t.hidden_from_user
-		FROM todos t
-		LEFT JOIN user_todo_state uts ON t.id = uts.todo_id AND uts.user_id = $1 AND t.is_default_task = true
-		WHERE 
-			(t.user_id = $1 AND t.shared_with_admin = true) -- Show personal only if shared
-			OR 
-			(t.is_default_task = true) -- Always show default tasks
-		ORDER BY position ASC, created DESC
-	`, userID)
+	todos, err := h.todos.ListSharedTodos(r.Context(), userID, filter)
 	if err != nil {
 		httputil.InternalError(w, err.Error())
 		return
 	}
-	defer rows.Close()
 
-	var todos []models.Todo
-	for rows.Next() {
-		var t models.Todo
-		if err := rows.Scan(&t.ID, &t.Text, &t.Status, &t.Created, &t.Position, &t.CreatedByUserID, &t.IsDefaultTask, &t.SharedWithAdmin, &t.UserID, &t.HiddenFromUser); err != nil {
-			httputil.InternalError(w, err.Error())
+	todos, next := store.Paginate(todos, limit, store.TodoCursor)
+	if render {
+		markdown.RenderDescriptions(todos)
+	}
+	httputil.WritePage(w, "todos", todos, next)
+}
+
+// GetUserTodo returns a user's todo as the user sees it.
+// @Summary Get user's todo
+// @Description Get a user's shared personal todo, or a default task with the user's status. Send the returned ETag back in If-Match to update the todo only if nobody has changed it since.
+// @Tags admin
+// @Produce json
+// @Param userId path string true "User ID"
+// @Param todoId path string true "Todo ID"
+// @Param render query string false "With html, also render descriptions to sanitized HTML in description_html" Enums(markdown, html)
+// @Success 200 {object} models.Todo
+// @Header 200 {string} ETag "Version of the todo"
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Router /api/admin/users/{userId}/todos/{todoId} [get]
+func (h *Handler) GetUserTodo(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("userId")
+	todoID := r.PathValue("todoId")
+	if userID == "" || todoID == "" {
+		httputil.BadRequest(w, "userId and todoId required")
+		return
+	}
+	render, err := httputil.ParseRender(r.URL.Query())
+	if err != nil {
+		httputil.BadRequest(w, err.Error())
+		return
+	}
+
+	// Verify user exists
+	if _, err := h.users.GetUser(r.Context(), userID); err != nil {
+		httputil.NotFound(w, "user not found")
+		return
+	}
+
+	t, err := h.todos.GetUserTodo(r.Context(), todoID, userID)
+	if err != nil {
+		httputil.NotFound(w, "todo not found")
+		return
+	}
+
+	// Personal todos must belong to the user and be visible to admins
+	if !t.IsDefaultTask {
+		if t.UserID == nil || *t.UserID != userID {
+			httputil.Forbidden(w, "todo does not belong to this user")
+			return
+		}
+		if !t.SharedWithAdmin {
+			httputil.Forbidden(w, "todo is not shared with admin")
 			return
 		}
-		todos = append(todos, t)
 	}
 
-	if todos == nil {
-		todos = []models.Todo{}
+	if render {
+		markdown.RenderDescription(&t)
 	}
-
-	httputil.WriteJSON(w, map[string]any{"todos": todos}, http.StatusOK)
+	httputil.SetETag(w, t.ETag())
+	httputil.WriteJSON(w, t, http.StatusOK)
 }
 
 // CreateUserTodo creates a new todo for a specific user (admin only)
 // CreateUserTodo creates a new todo for a specific user.
 // @Summary Create todo for user
-// @Description Create a new personal todo for a specific user (admin created).
+// @Description Create a new personal todo for a specific user (admin created), with an optional markdown description. With parent_id, the todo is added as a subtask of one of the user's todos shared with admins. With blocked_by, the todo cannot be marked done until those todos in the user's list are.
 // @Tags admin
 // @Accept json
 // @Produce json
@@ -418,8 +895,15 @@ func (h *Handler) CreateUserTodo(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Text           string `json:"text"`
-		HiddenFromUser bool   `json:"hidden_from_user"`
+		Text           string     `json:"text"`
+		Description    string     `json:"description"`
+		HiddenFromUser bool       `json:"hidden_from_user"`
+		DueAt          *time.Time `json:"due_at"`
+		ParentID       string     `json:"parent_id"`
+		Tags           []string   `json:"tags"`
+		Priority       string     `json:"priority"`
+		Recurrence     string     `json:"recurrence"`
+		BlockedBy      []string   `json:"blocked_by"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		httputil.BadRequest(w, "invalid json")
@@ -430,57 +914,104 @@ func (h *Handler) CreateUserTodo(w http.ResponseWriter, r *http.Request) {
 		httputil.BadRequest(w, fmt.Sprintf("text cannot be empty or exceed %d characters", models.MaxTextLength))
 		return
 	}
+	if !models.ValidateDescription(req.Description) {
+		httputil.BadRequest(w, fmt.Sprintf("description limit of %d characters exceeded", models.MaxDescriptionLength))
+		return
+	}
+	tags, err := models.NormalizeTags(req.Tags)
+	if err != nil {
+		httputil.BadRequest(w, err.Error())
+		return
+	}
+	priority, ok := models.ParsePriority(req.Priority)
+	if !ok {
+		httputil.BadRequest(w, "invalid priority")
+		return
+	}
+	recurrence, err := models.NormalizeRecurrence(req.Recurrence)
+	if err != nil {
+		httputil.BadRequest(w, err.Error())
+		return
+	}
+	blockedBy, err := models.NormalizeBlockers(req.BlockedBy)
+	if err != nil {
+		httputil.BadRequest(w, err.Error())
+		return
+	}
 
-	id := uuid.NewString()
-	status := string(models.StatusPending)
-	created := time.Now()
-
-	// Get min position for this user to put at top
-	var minPos float64
-	_ = h.db.QueryRow(r.Context(), `SELECT COALESCE(MIN(position), 0) FROM todos WHERE user_id=$1`, userId).Scan(&minPos)
-	position := minPos - models.PositionIncrement
-
-	// Insert todo for user, created by admin
-	_, err := h.db.Exec(r.Context(), `
-		INSERT INTO todos(id, text, status, created, position, user_id, created_by_user_id, is_default_task, shared_with_admin, hidden_from_user) 
-		VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
-	`, id, req.Text, status, created, position, userId, adminID, false, true, req.HiddenFromUser)
+	// Subtasks stay in their parent's list, which admins must be able to see
+	var parentID *string
+	if req.ParentID != "" {
+		parent, err := h.todos.GetTodo(r.Context(), req.ParentID)
+		if err != nil {
+			httputil.BadRequest(w, "parent todo not found")
+			return
+		}
+		if parent.IsDefaultTask || parent.UserID == nil || *parent.UserID != userId || !parent.SharedWithAdmin {
+			httputil.Forbidden(w, "parent todo does not belong to this user")
+			return
+		}
+		parentID = &parent.ID
+	}
 
-	if err != nil {
+	t := models.Todo{
+		Text:           req.Text,
+		Description:    req.Description,
+		HiddenFromUser: req.HiddenFromUser,
+		UserID:         &userId,
+		DueAt:          req.DueAt,
+		ParentID:       parentID,
+		Tags:           tags,
+		Priority:       string(priority),
+		Recurrence:     recurrence,
+		BlockedBy:      blockedBy,
+	}
+	if !h.checkBlockers(w, r, t, blockedBy) {
+		return
+	}
+	if err := h.createUserTodo(r.Context(), adminID, userId, &t); err != nil {
 		httputil.InternalError(w, err.Error())
 		return
 	}
 
-	createdByUserID := adminID
-	httputil.WriteJSON(w, models.Todo{
-		ID:              id,
-		Text:            req.Text,
-		Status:          status,
-		Created:         created,
-		Position:        position,
-		CreatedByUserID: &createdByUserID,
-		IsDefaultTask:   false,
-		SharedWithAdmin: true,
-		HiddenFromUser:  req.HiddenFromUser,
-		UserID:          &userId,
-	}, http.StatusCreated)
+	httputil.WriteJSON(w, t, http.StatusCreated)
+}
+
+// createUserTodo inserts t as a pending todo the admin created for the user
+// and shares it with admins. It is placed at the top of the user's list.
+func (h *Handler) createUserTodo(ctx context.Context, adminID, userID string, t *models.Todo) error {
+	t.ID = uuid.NewString()
+	t.Status = string(models.StatusPending)
+	t.Created = time.Now()
+	t.CreatedByUserID = &adminID
+	t.IsDefaultTask = false
+	t.SharedWithAdmin = true
+	t.UserID = &userID
+	if err := h.todos.CreateTodo(ctx, t); err != nil {
+		return err
+	}
+	h.audit.Record(ctx, audit.Event{ActorID: adminID, Action: models.AuditTodoCreate, UserID: userID, TodoID: t.ID, After: *t})
+	return nil
 }
 
 // UpdateUserTodo updates a specific user's todo status (admin only)
 // UpdateUserTodo updates a specific user's todo status or text.
 // @Summary Update user's todo
-// @Description Update a specific user's personal or default todo status/text.
+// @Description Update a specific user's personal or default todo status/text/description/due date/tags/priority/recurrence/dependencies. A todo cannot be marked done while any todo it is blocked by is unfinished. Marking a recurring todo done adds its next occurrence, or for a default task moves this user on to it. The due date of a default task is only changed for this user, and clearing it restores the task's own. With If-Match, the update fails with 412 if the todo has changed since it was read.
 // @Tags admin
 // @Accept json
 // @Produce json
 // @Param userId path string true "User ID"
 // @Param todoId path string true "Todo ID"
+// @Param If-Match header string false "ETag of the todo as last read"
 // @Param todo body object true "Update content"
 // @Success 200 {object} map[string]bool
+// @Header 200 {string} ETag "Version of the updated todo"
 // @Failure 400 {object} httputil.APIError
 // @Failure 401 {object} httputil.APIError
 // @Failure 403 {object} httputil.APIError
 // @Failure 404 {object} httputil.APIError
+// @Failure 412 {object} httputil.APIError
 // @Failure 500 {object} httputil.APIError
 // @Router /api/admin/users/{userId}/todos/{todoId} [put]
 func (h *Handler) UpdateUserTodo(w http.ResponseWriter, r *http.Request) {
@@ -492,117 +1023,274 @@ func (h *Handler) UpdateUserTodo(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Text           *string `json:"text,omitempty"`
-		Status         *string `json:"status,omitempty"`
-		HiddenFromUser *bool   `json:"hidden_from_user,omitempty"`
+		Text           *string    `json:"text,omitempty"`
+		Description    *string    `json:"description,omitempty"`
+		Status         *string    `json:"status,omitempty"`
+		HiddenFromUser *bool      `json:"hidden_from_user,omitempty"`
+		DueAt          *time.Time `json:"due_at,omitempty"`
+		ClearDueAt     bool       `json:"clear_due_at,omitempty"`
+		Tags           *[]string  `json:"tags,omitempty"`
+		Priority       *string    `json:"priority,omitempty"`
+		Recurrence     *string    `json:"recurrence,omitempty"`
+		BlockedBy      *[]string  `json:"blocked_by,omitempty"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		httputil.BadRequest(w, "invalid json")
 		return
 	}
 
+	var tags *[]string
+	if req.Tags != nil {
+		normalized, err := models.NormalizeTags(*req.Tags)
+		if err != nil {
+			httputil.BadRequest(w, err.Error())
+			return
+		}
+		tags = &normalized
+	}
+	var blockedBy *[]string
+	if req.BlockedBy != nil {
+		normalized, err := models.NormalizeBlockers(*req.BlockedBy)
+		if err != nil {
+			httputil.BadRequest(w, err.Error())
+			return
+		}
+		blockedBy = &normalized
+	}
+
+	// A zero due date clears it
+	if req.ClearDueAt {
+		if req.DueAt != nil {
+			httputil.BadRequest(w, "due_at and clear_due_at cannot be combined")
+			return
+		}
+		req.DueAt = &time.Time{}
+	}
+
+	if req.Description != nil && !models.ValidateDescription(*req.Description) {
+		httputil.BadRequest(w, fmt.Sprintf("description limit of %d characters exceeded", models.MaxDescriptionLength))
+		return
+	}
+	if req.Priority != nil && !models.TodoPriority(*req.Priority).IsValid() {
+		httputil.BadRequest(w, "invalid priority")
+		return
+	}
+	if req.Recurrence != nil {
+		recurrence, err := models.NormalizeRecurrence(*req.Recurrence)
+		if err != nil {
+			httputil.BadRequest(w, err.Error())
+			return
+		}
+		req.Recurrence = &recurrence
+	}
+
 	// Verify user exists
-	var exists bool
-	err := h.db.QueryRow(r.Context(), `SELECT EXISTS(SELECT 1 FROM users WHERE id=$1)`, userID).Scan(&exists)
-	if err != nil || !exists {
+	if _, err := h.users.GetUser(r.Context(), userID); err != nil {
 		httputil.NotFound(w, "user not found")
 		return
 	}
 
-	// Check if todo is a default task
-	var isDefaultTask bool
-	var createdByUserID *string
-	err = h.db.QueryRow(r.Context(), `SELECT is_default_task, created_by_user_id FROM todos WHERE id=$1`, todoID).Scan(&isDefaultTask, &createdByUserID)
+	// Check if todo is a default task (fetched as the user sees it, for the audit log)
+	t, err := h.todos.GetUserTodo(r.Context(), todoID, userID)
 	if err != nil {
 		httputil.NotFound(w, "todo not found")
 		return
 	}
 
-	if isDefaultTask {
+	// Refuse to overwrite changes the client hasn't seen
+	if !httputil.IfMatch(r, t.ETag()) {
+		httputil.PreconditionFailed(w, "todo has been changed by someone else")
+		return
+	}
+	conditional := httputil.IsConditional(r)
+
+	if req.Status != nil && !httputil.CheckTransition(w, r, h.workflow, t, *req.Status) {
+		return
+	}
+
+	if t.IsDefaultTask {
+		// Descriptions, tags, priority, recurrence and dependencies are
+		// shared by every user of the task
+		if req.Description != nil {
+			httputil.BadRequest(w, "use the default task endpoint to edit the description of default tasks")
+			return
+		}
+		if tags != nil {
+			httputil.BadRequest(w, "use the default task endpoint to tag default tasks")
+			return
+		}
+		if req.Priority != nil {
+			httputil.BadRequest(w, "use the default task endpoint to set the priority of default tasks")
+			return
+		}
+		if req.Recurrence != nil {
+			httputil.BadRequest(w, "use the default task endpoint to set the recurrence of default tasks")
+			return
+		}
+		if blockedBy != nil {
+			httputil.BadRequest(w, "use the default task endpoint to set the dependencies of default tasks")
+			return
+		}
 		if req.HiddenFromUser != nil {
 			// Admins shouldn't be making default tasks hidden locally for a user (not requested, complicates logic)
 			// But if they want to change status, they can.
 		}
 
 		// For default tasks, UPSERT into user_todo_state
-		// We only update status, position remains checked/default
-		if req.Status != nil {
-			_, err = h.db.Exec(r.Context(), `
-				INSERT INTO user_todo_state (user_id, todo_id, status, position, updated_at)
-				VALUES ($1, $2, $3, 
-					(SELECT COALESCE(
-						(SELECT position FROM user_todo_state WHERE user_id=$1 AND todo_id=$2),
-						(SELECT position FROM todos WHERE id=$2)
-					)),
-					NOW()
-				)
-				ON CONFLICT (user_id, todo_id) 
-				DO UPDATE SET status = EXCLUDED.status, updated_at = NOW()
-			`, userID, todoID, *req.Status)
-
-			if err != nil {
-				httputil.InternalError(w, err.Error())
+		// We only update status and due date, position remains checked/default
+		var ifVersion, ifStateVersion *int
+		if conditional {
+			ifVersion, ifStateVersion = &t.Version, &t.StateVersion
+		}
+		if req.Status != nil || req.DueAt != nil {
+			update := store.StateUpdate{Status: req.Status, DueAt: req.DueAt}
+			err = h.todos.UpdateDefaultTodoState(r.Context(), userID, todoID, update, ifVersion, ifStateVersion)
+		}
+		if err != nil {
+			if errors.Is(err, store.ErrConflict) {
+				httputil.PreconditionFailed(w, "todo has been changed by someone else")
 				return
 			}
+			httputil.InternalError(w, err.Error())
+			return
 		}
 	} else {
 		// Personal / Admin-Assigned User Task
-		// Allow updating hidden_from_user status and text (for admin-created tasks only)
-		if req.HiddenFromUser != nil {
-			_, err = h.db.Exec(r.Context(), `UPDATE todos SET hidden_from_user = $1 WHERE id = $2`, *req.HiddenFromUser, todoID)
-			if err != nil {
-				httputil.InternalError(w, err.Error())
-				return
-			}
+		// The todo must belong to this user and be visible to admins
+		if t.UserID == nil || *t.UserID != userID {
+			httputil.Forbidden(w, "todo does not belong to this user")
+			return
 		}
+		if !t.SharedWithAdmin {
+			httputil.Forbidden(w, "todo is not shared with admin")
+			return
+		}
+
+		// Allow updating hidden_from_user status and text (for admin-created tasks only)
+		adminID, _ := auth.GetUserID(r.Context())
+		update := store.TodoUpdate{HiddenFromUser: req.HiddenFromUser, ActorID: adminID}
 
 		// Allow text update only for admin-created tasks (where created_by != user_id)
 		if req.Text != nil {
 			// Check if admin created this task (created_by_user_id != user_id means admin created it)
-			if createdByUserID != nil && *createdByUserID != userID {
+			if t.CreatedByUserID != nil && *t.CreatedByUserID != userID {
 				if !models.ValidateText(*req.Text) {
 					httputil.BadRequest(w, fmt.Sprintf("text cannot be empty or exceed %d characters", models.MaxTextLength))
 					return
 				}
-				_, err = h.db.Exec(r.Context(), `UPDATE todos SET text = $1 WHERE id = $2`, *req.Text, todoID)
-				if err != nil {
-					httputil.InternalError(w, err.Error())
-					return
-				}
+				update.Text = req.Text
 			} else {
 				httputil.Forbidden(w, "cannot edit text of user-created tasks")
 				return
 			}
 		}
 
+		// Descriptions are only editable on admin-created tasks too
+		if req.Description != nil {
+			if t.CreatedByUserID == nil || *t.CreatedByUserID == userID {
+				httputil.Forbidden(w, "cannot edit description of user-created tasks")
+				return
+			}
+			update.Description = req.Description
+		}
+
+		// Due dates, like text, belong to whoever created the task
+		if req.DueAt != nil {
+			if t.CreatedByUserID == nil || *t.CreatedByUserID == userID {
+				httputil.Forbidden(w, "cannot change due date of user-created tasks")
+				return
+			}
+			update.DueAt = req.DueAt
+		}
+
+		// So do tags
+		if tags != nil {
+			if t.CreatedByUserID == nil || *t.CreatedByUserID == userID {
+				httputil.Forbidden(w, "cannot change tags of user-created tasks")
+				return
+			}
+			update.Tags = tags
+		}
+
+		// So does priority
+		if req.Priority != nil {
+			if t.CreatedByUserID == nil || *t.CreatedByUserID == userID {
+				httputil.Forbidden(w, "cannot change priority of user-created tasks")
+				return
+			}
+			update.Priority = req.Priority
+		}
+
+		// And recurrence
+		if req.Recurrence != nil {
+			if t.CreatedByUserID == nil || *t.CreatedByUserID == userID {
+				httputil.Forbidden(w, "cannot change recurrence of user-created tasks")
+				return
+			}
+			update.Recurrence = req.Recurrence
+		}
+
+		// And dependencies
+		if blockedBy != nil {
+			if t.CreatedByUserID == nil || *t.CreatedByUserID == userID {
+				httputil.Forbidden(w, "cannot change dependencies of user-created tasks")
+				return
+			}
+			if !h.checkBlockers(w, r, t, *blockedBy) {
+				return
+			}
+			update.BlockedBy = blockedBy
+		}
+
 		// Allow status update for any user task (Shared Responsibility)
 		// Both Admin and User can update status of shared tasks.
-		if req.Status != nil {
-			// Update status in todos table
-			_, err = h.db.Exec(r.Context(), `UPDATE todos SET status = $1 WHERE id = $2`, *req.Status, todoID)
-			if err != nil {
-				httputil.InternalError(w, err.Error())
+		update.Status = req.Status
+		if conditional {
+			update.IfVersion = &t.Version
+		}
+
+		if err := h.todos.UpdateTodo(r.Context(), todoID, update); err != nil {
+			if errors.Is(err, store.ErrConflict) {
+				httputil.PreconditionFailed(w, "todo has been changed by someone else")
+				return
+			}
+			if errors.Is(err, store.ErrDependencyCycle) {
+				httputil.BadRequest(w, "blocked_by would create a dependency cycle")
 				return
 			}
+			httputil.InternalError(w, err.Error())
+			return
 		}
 	}
 
+	// Record whether it was the admin who changed the status
+	updated, err := h.todos.GetUserTodo(r.Context(), todoID, userID)
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	adminID, _ := auth.GetUserID(r.Context())
+	h.audit.Record(r.Context(), audit.Event{ActorID: adminID, Action: models.AuditTodoUpdate, UserID: userID, TodoID: todoID, Before: t, After: updated})
+
+	httputil.SetETag(w, updated.ETag())
 	httputil.WriteSuccess(w)
 }
 
 // DeleteUserTodo deletes a user-specific todo that was created by an admin (admin only)
 // DeleteUserTodo deletes a user-specific todo created by an admin.
 // @Summary Delete user's admin-created todo
-// @Description Delete a personal todo that was created for the user by an admin.
+// @Description Delete a personal todo that was created for the user by an admin. With If-Match, the delete fails with 412 if the todo has changed since it was read.
 // @Tags admin
 // @Produce json
 // @Param userId path string true "User ID"
 // @Param todoId path string true "Todo ID"
+// @Param If-Match header string false "ETag of the todo as last read"
 // @Success 200 {object} map[string]bool
 // @Failure 400 {object} httputil.APIError
 // @Failure 401 {object} httputil.APIError
 // @Failure 403 {object} httputil.APIError
 // @Failure 404 {object} httputil.APIError
+// @Failure 412 {object} httputil.APIError
 // @Failure 500 {object} httputil.APIError
 // @Router /api/admin/users/{userId}/todos/{todoId} [delete]
 func (h *Handler) DeleteUserTodo(w http.ResponseWriter, r *http.Request) {
@@ -614,48 +1302,53 @@ func (h *Handler) DeleteUserTodo(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if todo exists and verify it's an admin-created task for this user
-	var isDefaultTask bool
-	var todoUserID *string
-	var createdByUserID *string
-	err := h.db.QueryRow(r.Context(), `
-		SELECT is_default_task, user_id, created_by_user_id 
-		FROM todos 
-		WHERE id=$1
-	`, todoID).Scan(&isDefaultTask, &todoUserID, &createdByUserID)
-
+	t, err := h.todos.GetTodo(r.Context(), todoID)
 	if err != nil {
 		httputil.NotFound(w, "todo not found")
 		return
 	}
 
 	// Cannot delete default tasks through this endpoint
-	if isDefaultTask {
+	if t.IsDefaultTask {
 		httputil.BadRequest(w, "use the default task endpoint to delete default tasks")
 		return
 	}
 
 	// Verify the todo belongs to the specified user
-	if todoUserID == nil || *todoUserID != userID {
+	if t.UserID == nil || *t.UserID != userID {
 		httputil.Forbidden(w, "todo does not belong to this user")
 		return
 	}
 
 	// Only allow deleting admin-created tasks (created_by != user_id)
-	if createdByUserID == nil || *createdByUserID == userID {
+	if t.CreatedByUserID == nil || *t.CreatedByUserID == userID {
 		httputil.Forbidden(w, "can only delete admin-created tasks")
 		return
 	}
 
-	// Delete the todo
-	cmd, err := h.db.Exec(r.Context(), `DELETE FROM todos WHERE id=$1`, todoID)
-	if err != nil {
-		httputil.InternalError(w, err.Error())
+	// Delete the todo, unless it changed since the client read it
+	if !httputil.IfMatch(r, t.ETag()) {
+		httputil.PreconditionFailed(w, "todo has been changed by someone else")
 		return
 	}
-	if cmd.RowsAffected() == 0 {
-		httputil.NotFound(w, "todo not found")
+	var ifVersion *int
+	if httputil.IsConditional(r) {
+		ifVersion = &t.Version
+	}
+	if err := h.todos.DeleteTodo(r.Context(), todoID, ifVersion); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			httputil.NotFound(w, "todo not found")
+			return
+		}
+		if errors.Is(err, store.ErrConflict) {
+			httputil.PreconditionFailed(w, "todo has been changed by someone else")
+			return
+		}
+		httputil.InternalError(w, err.Error())
 		return
 	}
+	adminID, _ := auth.GetUserID(r.Context())
+	h.audit.Record(r.Context(), audit.Event{ActorID: adminID, Action: models.AuditTodoDelete, UserID: userID, TodoID: todoID, Before: t})
 
 	httputil.WriteSuccess(w)
 }
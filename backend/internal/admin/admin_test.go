@@ -4,13 +4,19 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/akhilmk/packup/internal/auth"
 	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+	"github.com/akhilmk/packup/internal/store/memory"
+	"github.com/akhilmk/packup/internal/todo"
 )
 
 // Test structs
@@ -56,7 +62,7 @@ func TestTodoStruct(t *testing.T) {
 
 // TestRequireAdminMiddleware tests the admin middleware
 func TestRequireAdminMiddleware(t *testing.T) {
-	handler := &Handler{db: nil}
+	handler := &Handler{}
 
 	t.Run("Non-admin user is forbidden", func(t *testing.T) {
 		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -113,7 +119,7 @@ func TestRequireAdminMiddleware(t *testing.T) {
 
 // TestCreateAdminTodoUnauthorized tests that CreateAdminTodo fails without auth
 func TestCreateAdminTodoUnauthorized(t *testing.T) {
-	handler := &Handler{db: nil}
+	handler := &Handler{}
 
 	body := bytes.NewBufferString(`{"text":"Default task"}`)
 	req := httptest.NewRequest("POST", "/api/admin/todos", body)
@@ -130,7 +136,7 @@ func TestCreateAdminTodoUnauthorized(t *testing.T) {
 // TestCreateAdminTodoTextValidation tests text validation
 func TestCreateAdminTodoTextValidation(t *testing.T) {
 	t.Run("Empty text", func(t *testing.T) {
-		handler := &Handler{db: nil}
+		handler := &Handler{}
 
 		body := bytes.NewBufferString(`{"text":""}`)
 		req := httptest.NewRequest("POST", "/api/admin/todos", body)
@@ -148,7 +154,7 @@ func TestCreateAdminTodoTextValidation(t *testing.T) {
 	})
 
 	t.Run("Text over 200 characters", func(t *testing.T) {
-		handler := &Handler{db: nil}
+		handler := &Handler{}
 
 		longText := make([]byte, 201)
 		for i := range longText {
@@ -175,7 +181,7 @@ func TestCreateAdminTodoTextValidation(t *testing.T) {
 // TestCreateUserTodoValidation tests CreateUserTodo validation
 func TestCreateUserTodoValidation(t *testing.T) {
 	t.Run("Missing userId", func(t *testing.T) {
-		handler := &Handler{db: nil}
+		handler := &Handler{}
 
 		body := bytes.NewBufferString(`{"text":"Task for user"}`)
 		req := httptest.NewRequest("POST", "/api/admin/users//todos", body)
@@ -192,7 +198,7 @@ func TestCreateUserTodoValidation(t *testing.T) {
 	})
 
 	t.Run("Unauthorized", func(t *testing.T) {
-		handler := &Handler{db: nil}
+		handler := &Handler{}
 
 		body := bytes.NewBufferString(`{"text":"Task for user"}`)
 		req := httptest.NewRequest("POST", "/api/admin/users/user-123/todos", body)
@@ -248,7 +254,7 @@ func TestHiddenFromUserRequest(t *testing.T) {
 // TestUpdateUserTodoValidation tests UpdateUserTodo validation
 func TestUpdateUserTodoValidation(t *testing.T) {
 	t.Run("Missing userId and todoId", func(t *testing.T) {
-		handler := &Handler{db: nil}
+		handler := &Handler{}
 
 		body := bytes.NewBufferString(`{"status":"done"}`)
 		req := httptest.NewRequest("PUT", "/api/admin/users//todos/", body)
@@ -266,7 +272,7 @@ func TestUpdateUserTodoValidation(t *testing.T) {
 // TestUpdateAdminTodoValidation tests UpdateAdminTodo validation
 func TestUpdateAdminTodoValidation(t *testing.T) {
 	t.Run("Missing id", func(t *testing.T) {
-		handler := &Handler{db: nil}
+		handler := &Handler{}
 
 		body := bytes.NewBufferString(`{"text":"Updated text"}`)
 		req := httptest.NewRequest("PUT", "/api/admin/todos/", body)
@@ -284,7 +290,7 @@ func TestUpdateAdminTodoValidation(t *testing.T) {
 // TestDeleteAdminTodoValidation tests DeleteAdminTodo validation
 func TestDeleteAdminTodoValidation(t *testing.T) {
 	t.Run("Missing id", func(t *testing.T) {
-		handler := &Handler{db: nil}
+		handler := &Handler{}
 
 		req := httptest.NewRequest("DELETE", "/api/admin/todos/", nil)
 		w := httptest.NewRecorder()
@@ -300,7 +306,7 @@ func TestDeleteAdminTodoValidation(t *testing.T) {
 // TestListUserTodosValidation tests ListUserTodos validation
 func TestListUserTodosValidation(t *testing.T) {
 	t.Run("Missing userId", func(t *testing.T) {
-		handler := &Handler{db: nil}
+		handler := &Handler{}
 
 		req := httptest.NewRequest("GET", "/api/admin/users//todos", nil)
 		w := httptest.NewRecorder()
@@ -312,3 +318,1645 @@ func TestListUserTodosValidation(t *testing.T) {
 		}
 	})
 }
+
+// newTestServer returns a mux serving the admin routes backed by an in-memory
+// store seeded with a regular user "user-1".
+func newTestServer(t *testing.T) (*http.ServeMux, *memory.Store) {
+	t.Helper()
+	db := memory.New()
+	if err := db.CreateUser(context.Background(), models.User{ID: "user-1", GoogleID: "g-1", Email: "user1@example.com", Role: "user"}); err != nil {
+		t.Fatalf("Failed to seed user: %v", err)
+	}
+	mux := http.NewServeMux()
+	h := NewHandler(db, db)
+	h.RegisterRoutes(mux, h.RequireAdmin)
+	return mux, db
+}
+
+// do performs a request as admin "admin-1" and returns the recorder.
+func do(mux *http.ServeMux, method, path, body string) *httptest.ResponseRecorder {
+	return doIfMatch(mux, method, path, body, "")
+}
+
+// doIfMatch is like do, adding an If-Match header when etag is set.
+func doIfMatch(mux *http.ServeMux, method, path, body, etag string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	if etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
+	req = req.WithContext(auth.SetUserContext(req.Context(), "admin-1", "admin"))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+// seedTodo inserts a todo directly into the store.
+func seedTodo(t *testing.T, db *memory.Store, todo models.Todo) {
+	t.Helper()
+	todo.Status = string(models.StatusPending)
+	todo.Created = time.Now()
+	if err := db.CreateTodo(context.Background(), &todo); err != nil {
+		t.Fatalf("Failed to seed todo: %v", err)
+	}
+}
+
+func strPtr(s string) *string { return &s }
+
+// TestListUserTodosVisibility tests that admins only see shared and default todos
+func TestListUserTodosVisibility(t *testing.T) {
+	mux, db := newTestServer(t)
+
+	seedTodo(t, db, models.Todo{ID: "shared", Text: "Shared", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1"), SharedWithAdmin: true})
+	seedTodo(t, db, models.Todo{ID: "private", Text: "Private", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Everyone", IsDefaultTask: true})
+	db.SetDefaultTodoStatus(context.Background(), "user-1", "default", "done", nil)
+
+	w := do(mux, "GET", "/api/admin/users/user-1/todos", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var resp struct {
+		Todos []models.Todo `json:"todos"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+
+	byID := map[string]models.Todo{}
+	for _, todo := range resp.Todos {
+		byID[todo.ID] = todo
+	}
+	if _, ok := byID["private"]; ok {
+		t.Error("Expected private todo to be hidden from admin")
+	}
+	if _, ok := byID["shared"]; !ok {
+		t.Error("Expected shared todo to be visible to admin")
+	}
+	if byID["default"].Status != "done" {
+		t.Errorf("Expected default task with user's status 'done', got '%s'", byID["default"].Status)
+	}
+
+	if w := do(mux, "GET", "/api/admin/users/missing/todos", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for unknown user, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestUpdateUserTodoPermissions tests what admins may change on a user's todos
+func TestUpdateUserTodoPermissions(t *testing.T) {
+	mux, db := newTestServer(t)
+
+	seedTodo(t, db, models.Todo{ID: "shared", Text: "Shared", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1"), SharedWithAdmin: true})
+	seedTodo(t, db, models.Todo{ID: "private", Text: "Private", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+	seedTodo(t, db, models.Todo{ID: "assigned", Text: "Assigned", UserID: strPtr("user-1"), CreatedByUserID: strPtr("admin-1"), SharedWithAdmin: true})
+	seedTodo(t, db, models.Todo{ID: "other", Text: "Other", UserID: strPtr("user-2"), CreatedByUserID: strPtr("admin-1"), SharedWithAdmin: true})
+
+	tests := []struct {
+		name     string
+		todoID   string
+		body     string
+		expected int
+	}{
+		{"Status of shared user task", "shared", `{"status":"done"}`, http.StatusOK},
+		{"Text of user-created task", "shared", `{"text":"Changed"}`, http.StatusForbidden},
+		{"Private user task", "private", `{"status":"done"}`, http.StatusForbidden},
+		{"Text of admin-created task", "assigned", `{"text":"Changed","hidden_from_user":true}`, http.StatusOK},
+		{"Empty text of admin-created task", "assigned", `{"text":""}`, http.StatusBadRequest},
+		{"Todo of another user", "other", `{"status":"done"}`, http.StatusForbidden},
+		{"Missing todo", "missing", `{"status":"done"}`, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "PUT", "/api/admin/users/user-1/todos/"+tt.todoID, tt.body)
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	assigned, _ := db.GetTodo(context.Background(), "assigned")
+	if assigned.Text != "Changed" || !assigned.HiddenFromUser {
+		t.Errorf("Expected admin-created task to be updated, got %+v", assigned)
+	}
+	private, _ := db.GetTodo(context.Background(), "private")
+	if private.Status != "pending" {
+		t.Errorf("Expected private task to be untouched, got status '%s'", private.Status)
+	}
+}
+
+// TestDeleteUserTodoPermissions tests that admins only delete their own assignments
+func TestDeleteUserTodoPermissions(t *testing.T) {
+	mux, db := newTestServer(t)
+
+	seedTodo(t, db, models.Todo{ID: "shared", Text: "Shared", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1"), SharedWithAdmin: true})
+	seedTodo(t, db, models.Todo{ID: "assigned", Text: "Assigned", UserID: strPtr("user-1"), CreatedByUserID: strPtr("admin-1"), SharedWithAdmin: true})
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Everyone", IsDefaultTask: true})
+
+	tests := []struct {
+		name     string
+		todoID   string
+		expected int
+	}{
+		{"User-created task", "shared", http.StatusForbidden},
+		{"Default task", "default", http.StatusBadRequest},
+		{"Admin-created task", "assigned", http.StatusOK},
+		{"Already deleted", "assigned", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "DELETE", "/api/admin/users/user-1/todos/"+tt.todoID, "")
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestDefaultTaskLifecycle tests creating, editing and deleting default tasks
+func TestDefaultTaskLifecycle(t *testing.T) {
+	mux, db := newTestServer(t)
+
+	w := do(mux, "POST", "/api/admin/todos", `{"text":"Submit ID"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created models.Todo
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if !created.IsDefaultTask {
+		t.Error("Expected created todo to be a default task")
+	}
+
+	db.SetDefaultTodoStatus(context.Background(), "user-1", created.ID, "done", nil)
+
+	w = do(mux, "PUT", "/api/admin/todos/"+created.ID, `{"text":"Submit photo ID"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	got, _ := db.GetUserTodo(context.Background(), created.ID, "user-1")
+	if got.Text != "Submit photo ID" || got.Status != "done" {
+		t.Errorf("Expected new text with user's progress kept, got %+v", got)
+	}
+
+	w = do(mux, "DELETE", "/api/admin/todos/"+created.ID, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if _, err := db.GetTodo(context.Background(), created.ID); err == nil {
+		t.Error("Expected default task to be deleted")
+	}
+
+	w = do(mux, "GET", "/api/admin/trash", "")
+	var trash struct {
+		Todos []models.Todo `json:"todos"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &trash)
+	if len(trash.Todos) != 1 || trash.Todos[0].ID != created.ID {
+		t.Errorf("Expected deleted default task in the trash, got %+v", trash.Todos)
+	}
+
+	w = do(mux, "POST", "/api/admin/todos/"+created.ID+"/restore", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	got, _ = db.GetUserTodo(context.Background(), created.ID, "user-1")
+	if got.Status != "done" {
+		t.Errorf("Expected user's progress to survive the trash, got '%s'", got.Status)
+	}
+}
+
+// TestUserTrash tests listing and restoring a user's deleted todos
+func TestUserTrash(t *testing.T) {
+	mux, db := newTestServer(t)
+
+	seedTodo(t, db, models.Todo{ID: "private", Text: "Private", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+	seedTodo(t, db, models.Todo{ID: "shared", Text: "Shared", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1"), SharedWithAdmin: true})
+	seedTodo(t, db, models.Todo{ID: "assigned", Text: "Assigned", UserID: strPtr("user-1"), CreatedByUserID: strPtr("admin-1"), SharedWithAdmin: true})
+	for _, id := range []string{"private", "shared", "assigned"} {
+		db.DeleteTodo(context.Background(), id, nil)
+	}
+
+	w := do(mux, "GET", "/api/admin/users/user-1/trash", "")
+	var resp struct {
+		Todos []models.Todo `json:"todos"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	ids := map[string]bool{}
+	for _, todo := range resp.Todos {
+		ids[todo.ID] = true
+	}
+	if len(ids) != 2 || !ids["shared"] || !ids["assigned"] {
+		t.Errorf("Expected only shared todos in the trash, got %v", ids)
+	}
+
+	if w := do(mux, "GET", "/api/admin/users/missing/trash", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for missing user, got %d", http.StatusNotFound, w.Code)
+	}
+
+	tests := []struct {
+		name     string
+		todoID   string
+		expected int
+	}{
+		{"User-created task", "shared", http.StatusForbidden},
+		{"Admin-created task", "assigned", http.StatusOK},
+		{"Not in trash", "assigned", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "POST", "/api/admin/users/user-1/todos/"+tt.todoID+"/restore", "")
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestAuditLog tests that changes are attributed to the right actor and filterable
+func TestAuditLog(t *testing.T) {
+	mux, db := newTestServer(t)
+	todos := todo.NewHandler(db, db)
+	todoMux := http.NewServeMux()
+	todos.RegisterRoutes(todoMux, func(next http.HandlerFunc) http.HandlerFunc { return next })
+
+	seedTodo(t, db, models.Todo{ID: "shared", Text: "Shared", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1"), SharedWithAdmin: true})
+
+	// The admin marks the task in progress, then the user marks it done
+	if w := do(mux, "PUT", "/api/admin/users/user-1/todos/shared", `{"status":"in-progress"}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	req := httptest.NewRequest("PUT", "/api/todos/shared", bytes.NewBufferString(`{"status":"done"}`))
+	req = req.WithContext(auth.SetUserContext(req.Context(), "user-1", "user"))
+	todoMux.ServeHTTP(httptest.NewRecorder(), req)
+
+	listEvents := func(query string) []models.AuditEvent {
+		t.Helper()
+		w := do(mux, "GET", "/api/admin/audit"+query, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var resp struct {
+			Events []models.AuditEvent `json:"events"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Events
+	}
+
+	events := listEvents("?todo_id=shared")
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %+v", events)
+	}
+	if *events[0].ActorID != "user-1" || *events[1].ActorID != "admin-1" {
+		t.Errorf("Expected newest first with user-1 then admin-1 as actors, got %+v", events)
+	}
+	var before, after models.Todo
+	json.Unmarshal(events[0].Before, &before)
+	json.Unmarshal(events[0].After, &after)
+	if before.Status != "in-progress" || after.Status != "done" {
+		t.Errorf("Expected in-progress -> done, got %s -> %s", before.Status, after.Status)
+	}
+
+	if events := listEvents("?actor_id=admin-1&user_id=user-1"); len(events) != 1 || events[0].Action != models.AuditTodoUpdate {
+		t.Errorf("Expected the admin's update only, got %+v", events)
+	}
+	if events := listEvents("?to=2000-01-01T00:00:00Z"); len(events) != 0 {
+		t.Errorf("Expected no events before 2000, got %+v", events)
+	}
+	if events := listEvents("?limit=1"); len(events) != 1 {
+		t.Errorf("Expected limit to cap the events, got %d", len(events))
+	}
+
+	for _, query := range []string{"?from=yesterday", "?limit=0", "?limit=1000"} {
+		if w := do(mux, "GET", "/api/admin/audit"+query, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, query, w.Code)
+		}
+	}
+}
+
+// TestUserTodoHistoryPermissions tests that admins only see the history of shared todos
+func TestUserTodoHistoryPermissions(t *testing.T) {
+	mux, db := newTestServer(t)
+
+	seedTodo(t, db, models.Todo{ID: "private", Text: "Private", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+	seedTodo(t, db, models.Todo{ID: "shared", Text: "Shared", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1"), SharedWithAdmin: true})
+	seedTodo(t, db, models.Todo{ID: "other", Text: "Other", UserID: strPtr("user-2"), CreatedByUserID: strPtr("user-2"), SharedWithAdmin: true})
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Default", IsDefaultTask: true})
+
+	tests := []struct {
+		name     string
+		path     string
+		expected int
+	}{
+		{"Shared todo", "/api/admin/users/user-1/todos/shared/history", http.StatusOK},
+		{"Default task", "/api/admin/users/user-1/todos/default/history", http.StatusOK},
+		{"Private todo", "/api/admin/users/user-1/todos/private/history", http.StatusForbidden},
+		{"Other user's todo", "/api/admin/users/user-1/todos/other/history", http.StatusForbidden},
+		{"Unknown user", "/api/admin/users/missing/todos/shared/history", http.StatusNotFound},
+		{"Default task history", "/api/admin/todos/default/history", http.StatusOK},
+		{"Personal todo as default task", "/api/admin/todos/shared/history", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "GET", tt.path, "")
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestRevertDefaultTask tests restoring a default task's text from its history
+func TestRevertDefaultTask(t *testing.T) {
+	mux, db := newTestServer(t)
+
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Submit ID", IsDefaultTask: true})
+	seedTodo(t, db, models.Todo{ID: "personal", Text: "Personal", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1"), SharedWithAdmin: true})
+	db.SetDefaultTodoStatus(context.Background(), "user-1", "default", "done", nil)
+
+	if w := do(mux, "PUT", "/api/admin/todos/default", `{"text":"Submit passport"}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		name     string
+		todoID   string
+		body     string
+		expected int
+	}{
+		{"Invalid json", "default", `{`, http.StatusBadRequest},
+		{"Personal todo", "personal", `{"revision":1}`, http.StatusBadRequest},
+		{"Missing revision", "default", `{"revision":9}`, http.StatusNotFound},
+		{"Valid revision", "default", `{"revision":1}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "POST", "/api/admin/todos/"+tt.todoID+"/revert", tt.body)
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	got, _ := db.GetUserTodo(context.Background(), "default", "user-1")
+	if got.Text != "Submit ID" || got.Status != "done" {
+		t.Errorf("Expected original text with user's progress kept, got %+v", got)
+	}
+
+	revs, _ := db.ListTodoRevisions(context.Background(), "default")
+	if len(revs) != 3 || revs[2].ChangedBy == nil || *revs[2].ChangedBy != "admin-1" {
+		t.Errorf("Expected the revert to be recorded as a new revision by admin-1, got %+v", revs)
+	}
+}
+
+// TestConditionalUpdates tests that admins can't overwrite changes they haven't seen
+func TestConditionalUpdates(t *testing.T) {
+	mux, db := newTestServer(t)
+
+	seedTodo(t, db, models.Todo{ID: "shared", Text: "Shared", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1"), SharedWithAdmin: true})
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Default", IsDefaultTask: true})
+
+	w := do(mux, "GET", "/api/admin/users/user-1/todos/shared", "")
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"1"` {
+		t.Fatalf("Expected status %d with ETag \"1\", got %d with %q", http.StatusOK, w.Code, w.Header().Get("ETag"))
+	}
+	w = do(mux, "GET", "/api/admin/users/user-1/todos/default", "")
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"1"` {
+		t.Fatalf("Expected status %d with ETag \"1\", got %d with %q", http.StatusOK, w.Code, w.Header().Get("ETag"))
+	}
+
+	// The user changes both tasks in the meantime
+	done := "done"
+	db.UpdateTodo(context.Background(), "shared", store.TodoUpdate{Status: &done, ActorID: "user-1"})
+	db.SetDefaultTodoStatus(context.Background(), "user-1", "default", done, nil)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		etag     string
+		expected int
+	}{
+		{"Stale user todo", "PUT", "/api/admin/users/user-1/todos/shared", `{"status":"pending"}`, `"1"`, http.StatusPreconditionFailed},
+		{"Current user todo", "PUT", "/api/admin/users/user-1/todos/shared", `{"status":"in-progress"}`, `"2"`, http.StatusOK},
+		{"Stale user default task", "PUT", "/api/admin/users/user-1/todos/default", `{"status":"pending"}`, `"1"`, http.StatusPreconditionFailed},
+		{"Current user default task", "PUT", "/api/admin/users/user-1/todos/default", `{"status":"in-progress"}`, `"1.1"`, http.StatusOK},
+		{"Default task text", "PUT", "/api/admin/todos/default", `{"text":"Renamed"}`, `"1"`, http.StatusOK},
+		{"Stale default task text", "PUT", "/api/admin/todos/default", `{"text":"Renamed again"}`, `"1"`, http.StatusPreconditionFailed},
+		{"Stale default task delete", "DELETE", "/api/admin/todos/default", "", `"1"`, http.StatusPreconditionFailed},
+		{"Default task delete", "DELETE", "/api/admin/todos/default", "", `"2"`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doIfMatch(mux, tt.method, tt.path, tt.body, tt.etag)
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	got, _ := db.GetTodo(context.Background(), "shared")
+	if got.Status != "in-progress" {
+		t.Errorf("Expected only the current update to apply, got status '%s'", got.Status)
+	}
+}
+
+// TestSearchTodos tests that admin search only finds todos admins can see
+func TestSearchTodos(t *testing.T) {
+	mux, db := newTestServer(t)
+
+	seedTodo(t, db, models.Todo{ID: "private", Text: "Private passport", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+	seedTodo(t, db, models.Todo{ID: "shared", Text: "Shared passport", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1"), SharedWithAdmin: true})
+	seedTodo(t, db, models.Todo{ID: "assigned", Text: "Assigned passport", UserID: strPtr("user-2"), CreatedByUserID: strPtr("admin-1"), SharedWithAdmin: true, HiddenFromUser: true})
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Bring passport", IsDefaultTask: true})
+
+	tests := []struct {
+		name     string
+		query    string
+		expected int
+		results  []string
+	}{
+		{"All users", "?q=passport", http.StatusOK, []string{"assigned", "default", "shared"}},
+		{"One user", "?q=passport&user_id=user-1", http.StatusOK, []string{"default", "shared"}},
+		{"Unknown user", "?q=passport&user_id=missing", http.StatusNotFound, nil},
+		{"Missing query", "", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "GET", "/api/admin/search"+tt.query, "")
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+			if tt.results == nil {
+				return
+			}
+			var resp struct {
+				Todos []models.Todo `json:"todos"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			var got []string
+			for _, todo := range resp.Todos {
+				got = append(got, todo.ID)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.results) {
+				t.Errorf("Expected %v, got %v", tt.results, got)
+			}
+		})
+	}
+}
+
+// TestListFilters tests filtering and paging the admin listings
+func TestListFilters(t *testing.T) {
+	mux, db := newTestServer(t)
+	for _, id := range []string{"user-2", "user-3"} {
+		if err := db.CreateUser(context.Background(), models.User{ID: id, GoogleID: "g-" + id, Email: id + "@example.com", Role: "user", CreatedAt: time.Now()}); err != nil {
+			t.Fatalf("Failed to seed user: %v", err)
+		}
+	}
+
+	seedTodo(t, db, models.Todo{ID: "shared", Text: "Shared", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1"), SharedWithAdmin: true})
+	seedTodo(t, db, models.Todo{ID: "added", Text: "Added", UserID: strPtr("user-1"), CreatedByUserID: strPtr("admin-1"), SharedWithAdmin: true})
+	seedTodo(t, db, models.Todo{ID: "default-1", Text: "Default 1", IsDefaultTask: true})
+	seedTodo(t, db, models.Todo{ID: "default-2", Text: "Default 2", IsDefaultTask: true})
+	if err := db.SetDefaultTodoStatus(context.Background(), "user-1", "default-1", string(models.StatusDone), nil); err != nil {
+		t.Fatalf("SetDefaultTodoStatus failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		key      string
+		expected int
+		items    int
+		next     bool
+	}{
+		{"Users page", "/api/admin/users?limit=2", "users", http.StatusOK, 2, true},
+		{"Last users page", "/api/admin/users?limit=3", "users", http.StatusOK, 3, false},
+		{"Users created later", "/api/admin/users?created_from=2100-01-01T00:00:00Z", "users", http.StatusOK, 0, false},
+		{"Default tasks page", "/api/admin/todos?limit=1", "todos", http.StatusOK, 1, true},
+		{"User's status", "/api/admin/users/user-1/todos?status=done", "todos", http.StatusOK, 1, false},
+		{"User's admin todos", "/api/admin/users/user-1/todos?source=admin", "todos", http.StatusOK, 1, false},
+		{"User's default tasks", "/api/admin/users/user-1/todos?source=default&status=pending", "todos", http.StatusOK, 1, false},
+		{"Invalid source", "/api/admin/users/user-1/todos?source=shared", "todos", http.StatusBadRequest, 0, false},
+		{"Invalid user cursor", "/api/admin/users?cursor=nope", "users", http.StatusBadRequest, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "GET", tt.path, "")
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+			var resp map[string]json.RawMessage
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			var items []any
+			json.Unmarshal(resp[tt.key], &items)
+			if len(items) != tt.items {
+				t.Errorf("Expected %d items, got %s", tt.items, resp[tt.key])
+			}
+			if _, ok := resp["next_cursor"]; ok != tt.next {
+				t.Errorf("Expected next_cursor %v, got %s", tt.next, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestListOverdue tests listing overdue todos across users
+func TestListOverdue(t *testing.T) {
+	mux, db := newTestServer(t)
+	if err := db.CreateUser(context.Background(), models.User{ID: "user-2", GoogleID: "g-2", Email: "user2@example.com", Role: "user"}); err != nil {
+		t.Fatalf("Failed to seed user: %v", err)
+	}
+
+	past := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	future := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Default", IsDefaultTask: true, DueAt: &past})
+	seedTodo(t, db, models.Todo{ID: "shared", Text: "Shared", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1"), SharedWithAdmin: true, DueAt: &past})
+	seedTodo(t, db, models.Todo{ID: "private", Text: "Private", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1"), DueAt: &past})
+
+	// Give user-2 more time on the default task
+	w := do(mux, "PUT", "/api/admin/users/user-2/todos/default", `{"due_at":"`+future.Format(time.RFC3339)+`"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	// Admins can't set due dates on users' own todos
+	w = do(mux, "PUT", "/api/admin/users/user-1/todos/shared", `{"due_at":"`+future.Format(time.RFC3339)+`"}`)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status 403, got %d: %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected int
+		todos    []string
+	}{
+		{"All users", "", http.StatusOK, []string{"user-1/default", "user-1/shared"}},
+		{"One user", "?user_id=user-2", http.StatusOK, []string{}},
+		{"Limit", "?limit=1", http.StatusOK, []string{"user-1/default"}},
+		{"Unknown user", "?user_id=missing", http.StatusNotFound, nil},
+		{"Invalid limit", "?limit=0", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "GET", "/api/admin/overdue"+tt.query, "")
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+			if tt.todos == nil {
+				return
+			}
+			var resp struct {
+				Todos []models.OverdueTodo `json:"todos"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			got := []string{}
+			for _, o := range resp.Todos {
+				if !o.Todo.Overdue {
+					t.Errorf("Expected %s to be marked overdue", o.Todo.ID)
+				}
+				got = append(got, o.UserID+"/"+o.Todo.ID)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.todos) {
+				t.Errorf("Expected %v, got %v", tt.todos, got)
+			}
+		})
+	}
+}
+
+// TestSubtasks tests adding subtasks to default tasks and users' todos
+func TestSubtasks(t *testing.T) {
+	mux, db := newTestServer(t)
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Default", IsDefaultTask: true})
+	seedTodo(t, db, models.Todo{ID: "shared", Text: "Shared", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1"), SharedWithAdmin: true})
+	seedTodo(t, db, models.Todo{ID: "private", Text: "Private", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+
+	tests := []struct {
+		name     string
+		path     string
+		parentID string
+		expected int
+	}{
+		{"Default subtask", "/api/admin/todos", "default", http.StatusCreated},
+		{"Default subtask of a personal todo", "/api/admin/todos", "shared", http.StatusBadRequest},
+		{"User's subtask", "/api/admin/users/user-1/todos", "shared", http.StatusCreated},
+		{"User's subtask of a private todo", "/api/admin/users/user-1/todos", "private", http.StatusForbidden},
+		{"User's subtask of a default task", "/api/admin/users/user-1/todos", "default", http.StatusForbidden},
+		{"Missing parent", "/api/admin/users/user-1/todos", "nope", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "POST", tt.path, `{"text":"Subtask","parent_id":"`+tt.parentID+`"}`)
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// Each user's progress on default subtasks rolls up separately
+	children, _ := db.ListDefaultTodos(context.Background(), store.TodoFilter{ParentID: "default"})
+	if len(children) != 1 {
+		t.Fatalf("Expected 1 default subtask, got %+v", children)
+	}
+	w := do(mux, "PUT", "/api/admin/users/user-1/todos/"+children[0].ID, `{"status":"done"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	w = do(mux, "GET", "/api/admin/users/user-1/todos/default", "")
+	var todo models.Todo
+	json.Unmarshal(w.Body.Bytes(), &todo)
+	if todo.Subtasks == nil || todo.Subtasks.Status != models.StatusDone {
+		t.Errorf("Expected user-1's subtasks done, got %+v", todo.Subtasks)
+	}
+	w = do(mux, "GET", "/api/admin/todos/default", "")
+	json.Unmarshal(w.Body.Bytes(), &todo)
+	if todo.Subtasks == nil || todo.Subtasks.Status != models.StatusPending {
+		t.Errorf("Expected the task's own subtasks pending, got %+v", todo.Subtasks)
+	}
+
+	// Subtasks go to the trash and come back with their parent
+	if w := do(mux, "DELETE", "/api/admin/todos/default", ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 deleting, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(mux, "POST", "/api/admin/todos/"+children[0].ID+"/restore", ""); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 restoring a subtask of a trashed task, got %d", w.Code)
+	}
+	if w := do(mux, "POST", "/api/admin/todos/default/restore", ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 restoring, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := db.GetTodo(context.Background(), children[0].ID); err != nil {
+		t.Errorf("Expected the subtask restored with its parent, got %v", err)
+	}
+}
+
+// TestTags tests tagging todos as an admin and managing the tag palette
+func TestTags(t *testing.T) {
+	mux, db := newTestServer(t)
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Default", IsDefaultTask: true})
+	seedTodo(t, db, models.Todo{ID: "added", Text: "Added", UserID: strPtr("user-1"), CreatedByUserID: strPtr("admin-1"), SharedWithAdmin: true})
+	seedTodo(t, db, models.Todo{ID: "own", Text: "Own", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1"), SharedWithAdmin: true, Tags: []string{"mine"}})
+
+	tests := []struct {
+		name     string
+		path     string
+		id       string
+		expected int
+		tags     []string
+	}{
+		{"Default task", "/api/admin/todos/default", "default", http.StatusOK, []string{"packing"}},
+		{"Default task as user's", "/api/admin/users/user-1/todos/default", "default", http.StatusBadRequest, []string{"packing"}},
+		{"Admin-created task", "/api/admin/users/user-1/todos/added", "added", http.StatusOK, []string{"packing"}},
+		{"User-created task", "/api/admin/users/user-1/todos/own", "own", http.StatusForbidden, []string{"mine"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "PUT", tt.path, `{"tags":["Packing"]}`)
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+			todo, _ := db.GetTodo(context.Background(), tt.id)
+			if !slices.Equal(todo.Tags, tt.tags) {
+				t.Errorf("Expected tags %v, got %v", tt.tags, todo.Tags)
+			}
+		})
+	}
+
+	// Curating the palette
+	palette := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		expected int
+	}{
+		{"Add", "PUT", "/api/admin/tags/Urgent", `{"color":"#ff0000"}`, http.StatusOK},
+		{"Add without color", "PUT", "/api/admin/tags/later", "", http.StatusOK},
+		{"Invalid color", "PUT", "/api/admin/tags/later", `{"color":"red"}`, http.StatusBadRequest},
+		{"Invalid name", "PUT", "/api/admin/tags/a.b", "", http.StatusBadRequest},
+		{"Remove", "DELETE", "/api/admin/tags/later", "", http.StatusOK},
+		{"Remove again", "DELETE", "/api/admin/tags/later", "", http.StatusNotFound},
+	}
+	for _, tt := range palette {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := do(mux, tt.method, tt.path, tt.body); w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	var resp struct {
+		Tags []models.Tag `json:"tags"`
+	}
+	w := do(mux, "GET", "/api/admin/tags", "")
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Tags) != 1 || resp.Tags[0] != (models.Tag{Name: "urgent", Color: "#ff0000", Curated: true}) {
+		t.Errorf("Expected the palette [urgent], got %+v", resp.Tags)
+	}
+	w = do(mux, "GET", "/api/admin/tags?all=true", "")
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Tags) != 3 {
+		t.Errorf("Expected mine, packing and urgent, got %+v", resp.Tags)
+	}
+
+	w = do(mux, "GET", "/api/admin/users/user-1/todos?tag=packing", "")
+	var todos struct {
+		Todos []models.Todo `json:"todos"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &todos)
+	if len(todos.Todos) != 2 {
+		t.Errorf("Expected 2 of user-1's todos tagged packing, got %+v", todos.Todos)
+	}
+}
+
+func TestPriorities(t *testing.T) {
+	mux, db := newTestServer(t)
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Default", IsDefaultTask: true})
+	seedTodo(t, db, models.Todo{ID: "added", Text: "Added", UserID: strPtr("user-1"), CreatedByUserID: strPtr("admin-1"), SharedWithAdmin: true})
+	seedTodo(t, db, models.Todo{ID: "own", Text: "Own", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1"), SharedWithAdmin: true})
+
+	tests := []struct {
+		name     string
+		path     string
+		id       string
+		body     string
+		expected int
+		priority string
+	}{
+		{"Default task", "/api/admin/todos/default", "default", `{"priority":"urgent"}`, http.StatusOK, "urgent"},
+		{"Invalid priority", "/api/admin/todos/default", "default", `{"priority":"asap"}`, http.StatusBadRequest, "urgent"},
+		{"Default task as user's", "/api/admin/users/user-1/todos/default", "default", `{"priority":"low"}`, http.StatusBadRequest, "urgent"},
+		{"Admin-created task", "/api/admin/users/user-1/todos/added", "added", `{"priority":"high"}`, http.StatusOK, "high"},
+		{"User-created task", "/api/admin/users/user-1/todos/own", "own", `{"priority":"high"}`, http.StatusForbidden, "normal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "PUT", tt.path, tt.body)
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+			if todo, _ := db.GetTodo(context.Background(), tt.id); todo.Priority != tt.priority {
+				t.Errorf("Expected priority %s, got %s", tt.priority, todo.Priority)
+			}
+		})
+	}
+
+	w := do(mux, "POST", "/api/admin/users/user-1/todos", `{"text":"Low","priority":"low"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = do(mux, "GET", "/api/admin/users/user-1/todos?sort=priority", "")
+	var resp struct {
+		Todos []models.Todo `json:"todos"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	var got []string
+	for _, todo := range resp.Todos {
+		got = append(got, todo.Priority)
+	}
+	if !slices.Equal(got, []string{"urgent", "high", "normal", "low"}) {
+		t.Errorf("Expected todos by priority, got %v", got)
+	}
+}
+
+func TestComments(t *testing.T) {
+	mux, db := newTestServer(t)
+	ctx := context.Background()
+	seedTodo(t, db, models.Todo{ID: "shared", Text: "Shared", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1"), SharedWithAdmin: true})
+	seedTodo(t, db, models.Todo{ID: "private", Text: "Private", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Default", IsDefaultTask: true})
+	if err := db.CreateUser(ctx, models.User{ID: "user-2", GoogleID: "g-2", Email: "user2@example.com", Role: "user"}); err != nil {
+		t.Fatalf("Failed to seed user: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected int
+	}{
+		{"Shared todo", "/api/admin/users/user-1/todos/shared/comments", http.StatusCreated},
+		{"Default task", "/api/admin/users/user-1/todos/default/comments", http.StatusCreated},
+		{"Private todo", "/api/admin/users/user-1/todos/private/comments", http.StatusForbidden},
+		{"Other user's todo", "/api/admin/users/user-2/todos/shared/comments", http.StatusForbidden},
+		{"Missing user", "/api/admin/users/missing/todos/default/comments", http.StatusNotFound},
+		{"Missing todo", "/api/admin/users/user-1/todos/missing/comments", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "POST", tt.path, `{"body":"Please attach the receipt"}`)
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// Private todos keep their comments from admins
+	if w := do(mux, "GET", "/api/admin/users/user-1/todos/private/comments", ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 listing comments on a private todo, got %d", w.Code)
+	}
+
+	question := models.Comment{ID: "question", TodoID: "shared", UserID: "user-1", AuthorID: strPtr("user-1"), Body: "Which receipt?", CreatedAt: time.Now().Add(time.Minute)}
+	if err := db.CreateComment(ctx, question); err != nil {
+		t.Fatalf("Failed to seed comment: %v", err)
+	}
+	w := do(mux, "GET", "/api/admin/users/user-1/todos/shared/comments", "")
+	var resp struct {
+		Comments []models.Comment `json:"comments"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Comments) != 2 || *resp.Comments[0].AuthorID != "admin-1" || resp.Comments[1].ID != "question" {
+		t.Fatalf("Expected the admin's comment then the user's, got %+v", resp.Comments)
+	}
+
+	w = do(mux, "GET", "/api/admin/users/user-1/todos", "")
+	var list struct {
+		Todos []models.Todo `json:"todos"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &list)
+	for _, todo := range list.Todos {
+		if todo.CommentCount != map[string]int{"shared": 2, "default": 1}[todo.ID] {
+			t.Errorf("Expected comment count of %s listed, got %d", todo.ID, todo.CommentCount)
+		}
+	}
+
+	id := resp.Comments[0].ID
+	changes := []struct {
+		name     string
+		method   string
+		path     string
+		expected int
+	}{
+		{"Edit own comment", "PUT", "/api/admin/users/user-1/todos/shared/comments/" + id, http.StatusOK},
+		{"Edit user's comment", "PUT", "/api/admin/users/user-1/todos/shared/comments/question", http.StatusForbidden},
+		{"Delete user's comment", "DELETE", "/api/admin/users/user-1/todos/shared/comments/question", http.StatusForbidden},
+		{"Comment on another todo", "DELETE", "/api/admin/users/user-1/todos/default/comments/" + id, http.StatusNotFound},
+		{"Delete own comment", "DELETE", "/api/admin/users/user-1/todos/shared/comments/" + id, http.StatusOK},
+	}
+	for _, tt := range changes {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, tt.method, tt.path, `{"body":"Edited"}`)
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	events, _ := db.ListAuditEvents(ctx, store.AuditFilter{TodoID: "shared"})
+	var actions []models.AuditAction
+	for _, e := range events {
+		actions = append(actions, e.Action)
+	}
+	expected := []models.AuditAction{models.AuditCommentDelete, models.AuditCommentUpdate, models.AuditCommentCreate}
+	if !slices.Equal(actions, expected) {
+		t.Errorf("Expected audit actions %v, got %v", expected, actions)
+	}
+}
+
+func TestAttachments(t *testing.T) {
+	mux, db := newTestServer(t)
+	ctx := context.Background()
+	seedTodo(t, db, models.Todo{ID: "shared", Text: "Shared", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1"), SharedWithAdmin: true})
+	seedTodo(t, db, models.Todo{ID: "private", Text: "Private", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Submit ID", IsDefaultTask: true})
+
+	upload := func(path string, data []byte) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, _ := form.CreateFormFile("file", "form.pdf")
+		part.Write(data)
+		form.Close()
+
+		req := httptest.NewRequest("POST", path, &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		req = req.WithContext(auth.SetUserContext(req.Context(), "admin-1", "admin"))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	pdf := []byte("%PDF-1.4\nvisa form")
+	tests := []struct {
+		name     string
+		path     string
+		expected int
+	}{
+		{"Shared todo", "/api/admin/users/user-1/todos/shared/attachments", http.StatusCreated},
+		{"Default task", "/api/admin/users/user-1/todos/default/attachments", http.StatusCreated},
+		{"Private todo", "/api/admin/users/user-1/todos/private/attachments", http.StatusForbidden},
+		{"Missing user", "/api/admin/users/missing/todos/default/attachments", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := upload(tt.path, pdf)
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// The user's own upload on their copy of a default task
+	scan := []byte("%PDF-1.4\nscanned passport")
+	passport := models.Attachment{ID: "passport", TodoID: "default", UserID: "user-1", UploadedBy: strPtr("user-1"), Filename: "passport.pdf", ContentType: "application/pdf", Size: int64(len(scan)), CreatedAt: time.Now().Add(time.Minute)}
+	if err := db.CreateAttachment(ctx, passport); err != nil {
+		t.Fatalf("Failed to seed attachment: %v", err)
+	}
+	if err := db.PutBlob(ctx, "passport", bytes.NewReader(scan)); err != nil {
+		t.Fatalf("Failed to seed attachment contents: %v", err)
+	}
+
+	w := do(mux, "GET", "/api/admin/users/user-1/todos/default/attachments", "")
+	var resp struct {
+		Attachments []models.Attachment `json:"attachments"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Attachments) != 2 || *resp.Attachments[0].UploadedBy != "admin-1" || resp.Attachments[1].ID != "passport" {
+		t.Fatalf("Expected the admin's attachment then the user's, got %+v", resp.Attachments)
+	}
+	if w := do(mux, "GET", "/api/admin/users/user-1/todos/private/attachments", ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 listing attachments on a private todo, got %d", w.Code)
+	}
+
+	w = do(mux, "GET", "/api/admin/users/user-1/todos/default/attachments/passport", "")
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), scan) {
+		t.Fatalf("Expected the user's scan downloaded, got %d: %q", w.Code, w.Body.String())
+	}
+
+	id := resp.Attachments[0].ID
+	changes := []struct {
+		name     string
+		method   string
+		path     string
+		expected int
+	}{
+		{"Delete user's attachment", "DELETE", "/api/admin/users/user-1/todos/default/attachments/passport", http.StatusForbidden},
+		{"Attachment on another todo", "GET", "/api/admin/users/user-1/todos/shared/attachments/passport", http.StatusNotFound},
+		{"Delete own attachment", "DELETE", "/api/admin/users/user-1/todos/default/attachments/" + id, http.StatusOK},
+	}
+	for _, tt := range changes {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, tt.method, tt.path, "")
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	events, _ := db.ListAuditEvents(ctx, store.AuditFilter{TodoID: "default"})
+	var actions []models.AuditAction
+	for _, e := range events {
+		actions = append(actions, e.Action)
+	}
+	expected := []models.AuditAction{models.AuditAttachmentDelete, models.AuditAttachmentAdd}
+	if !slices.Equal(actions, expected) {
+		t.Errorf("Expected audit actions %v, got %v", expected, actions)
+	}
+}
+
+func TestRecurrence(t *testing.T) {
+	mux, db := newTestServer(t)
+	ctx := context.Background()
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Default", IsDefaultTask: true})
+	seedTodo(t, db, models.Todo{ID: "added", Text: "Added", UserID: strPtr("user-1"), CreatedByUserID: strPtr("admin-1"), SharedWithAdmin: true})
+	seedTodo(t, db, models.Todo{ID: "own", Text: "Own", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1"), SharedWithAdmin: true})
+
+	tests := []struct {
+		name       string
+		path       string
+		id         string
+		body       string
+		expected   int
+		recurrence string
+	}{
+		{"Default task", "/api/admin/todos/default", "default", `{"recurrence":"FREQ=WEEKLY"}`, http.StatusOK, "FREQ=WEEKLY"},
+		{"Invalid rule", "/api/admin/todos/default", "default", `{"recurrence":"FREQ=WEEKLY;INTERVAL=0"}`, http.StatusBadRequest, "FREQ=WEEKLY"},
+		{"Default task as user's", "/api/admin/users/user-1/todos/default", "default", `{"recurrence":""}`, http.StatusBadRequest, "FREQ=WEEKLY"},
+		{"Admin-created task", "/api/admin/users/user-1/todos/added", "added", `{"recurrence":"FREQ=MONTHLY;INTERVAL=3"}`, http.StatusOK, "FREQ=MONTHLY;INTERVAL=3"},
+		{"User-created task", "/api/admin/users/user-1/todos/own", "own", `{"recurrence":"FREQ=DAILY"}`, http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "PUT", tt.path, tt.body)
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+			if todo, _ := db.GetTodo(ctx, tt.id); todo.Recurrence != tt.recurrence {
+				t.Errorf("Expected recurrence %q, got %q", tt.recurrence, todo.Recurrence)
+			}
+		})
+	}
+
+	// Marking a recurring default task done for a user moves them on
+	if w := do(mux, "PUT", "/api/admin/users/user-1/todos/default", `{"status":"done"}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if todo, _ := db.GetUserTodo(ctx, "default", "user-1"); todo.Status != "pending" || todo.Occurrence != 1 {
+		t.Errorf("Expected user-1 on the next occurrence, got %s, occurrence %d", todo.Status, todo.Occurrence)
+	}
+
+	w := do(mux, "POST", "/api/admin/todos", `{"text":"Daily","recurrence":"FREQ=DAILY"}`)
+	var created models.Todo
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if w.Code != http.StatusCreated || created.Recurrence != "FREQ=DAILY" {
+		t.Errorf("Expected a recurring default task to be created, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(mux, "POST", "/api/admin/users/user-1/todos", `{"text":"New","recurrence":"FREQ=YEARLY"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unsupported rule, got %d", w.Code)
+	}
+}
+
+func TestWorkflow(t *testing.T) {
+	mux, db := newTestServer(t)
+	ctx := context.Background()
+	seedTodo(t, db, models.Todo{ID: "shared", Text: "Shared", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1"), SharedWithAdmin: true})
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Default", IsDefaultTask: true})
+
+	saves := []struct {
+		name     string
+		body     string
+		expected int
+	}{
+		{"Invalid name", `{"statuses":["On Hold"]}`, http.StatusBadRequest},
+		{"Unknown transition target", `{"statuses":["blocked"],"transitions":{"pending":["archived"]}}`, http.StatusBadRequest},
+		{"Unknown transition source", `{"statuses":["blocked"],"transitions":{"archived":["pending"]}}`, http.StatusBadRequest},
+		{"Invalid json", `{`, http.StatusBadRequest},
+		{"Done unreachable", `{"statuses":["needs-review"],"transitions":{"pending":["in-progress"],"in-progress":["needs-review"],"needs-review":["in-progress"],"done":["pending"]}}`, http.StatusBadRequest},
+		{"Valid", `{"statuses":["needs-review"],"transitions":{"pending":["in-progress"],"in-progress":["needs-review"],"needs-review":["done","in-progress"]}}`, http.StatusOK},
+	}
+	for _, tt := range saves {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := do(mux, "PUT", "/api/admin/workflow", tt.body); w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	w := do(mux, "GET", "/api/admin/workflow", "")
+	var workflow models.Workflow
+	json.Unmarshal(w.Body.Bytes(), &workflow)
+	if !slices.Equal(workflow.Statuses, []string{"pending", "in-progress", "done", "needs-review"}) || !slices.Equal(workflow.Transitions["needs-review"], []string{"in-progress", "done"}) {
+		t.Errorf("Expected the saved workflow, got %s", w.Body.String())
+	}
+	if events, _ := db.ListAuditEvents(ctx, store.AuditFilter{Action: models.AuditWorkflowUpdate}); len(events) != 1 {
+		t.Errorf("Expected one workflow.update event, got %d", len(events))
+	}
+
+	updates := []struct {
+		name     string
+		id       string
+		status   string
+		expected int
+	}{
+		{"Skipping review", "shared", "done", http.StatusConflict},
+		{"Unknown status", "shared", "blocked", http.StatusBadRequest},
+		{"Allowed move", "shared", "in-progress", http.StatusOK},
+		{"Custom status", "shared", "needs-review", http.StatusOK},
+		{"Default task disallowed move", "default", "needs-review", http.StatusConflict},
+		{"Default task allowed move", "default", "in-progress", http.StatusOK},
+	}
+	for _, tt := range updates {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "PUT", "/api/admin/users/user-1/todos/"+tt.id, `{"status":"`+tt.status+`"}`)
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+	if todo, _ := db.GetTodo(ctx, "shared"); todo.Status != "needs-review" {
+		t.Errorf("Expected the todo to need review, got %s", todo.Status)
+	}
+
+	w = do(mux, "GET", "/api/admin/users/user-1/todos?status=needs-review", "")
+	var resp struct {
+		Todos []models.Todo `json:"todos"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || len(resp.Todos) != 1 {
+		t.Errorf("Expected to filter by a custom status, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestDependencies(t *testing.T) {
+	mux, db := newTestServer(t)
+	ctx := context.Background()
+	seedTodo(t, db, models.Todo{ID: "book", Text: "Book", IsDefaultTask: true})
+	seedTodo(t, db, models.Todo{ID: "go", Text: "Go", IsDefaultTask: true})
+	seedTodo(t, db, models.Todo{ID: "added", Text: "Added", UserID: strPtr("user-1"), CreatedByUserID: strPtr("admin-1"), SharedWithAdmin: true})
+	seedTodo(t, db, models.Todo{ID: "own", Text: "Own", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1"), SharedWithAdmin: true})
+	seedTodo(t, db, models.Todo{ID: "theirs", Text: "Theirs", UserID: strPtr("user-2"), CreatedByUserID: strPtr("admin-1")})
+	if err := db.SaveGroup(ctx, models.Group{ID: "pro", Name: "Pro", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to save group: %v", err)
+	}
+	seedTodo(t, db, models.Todo{ID: "pro-only", Text: "Pro only", IsDefaultTask: true, Groups: []string{"pro"}})
+	seedTodo(t, db, models.Todo{ID: "later", Text: "Later", IsDefaultTask: true})
+
+	tests := []struct {
+		name      string
+		path      string
+		id        string
+		body      string
+		expected  int
+		blockedBy []string
+	}{
+		{"Default task", "/api/admin/todos/go", "go", `{"blocked_by":["book"]}`, http.StatusOK, []string{"book"}},
+		{"Cycle", "/api/admin/todos/book", "book", `{"blocked_by":["go"]}`, http.StatusBadRequest, nil},
+		{"Default task on personal todo", "/api/admin/todos/book", "book", `{"blocked_by":["own"]}`, http.StatusBadRequest, nil},
+		{"Default task as user's", "/api/admin/users/user-1/todos/go", "go", `{"blocked_by":[]}`, http.StatusBadRequest, []string{"book"}},
+		{"Admin-created task", "/api/admin/users/user-1/todos/added", "added", `{"blocked_by":["own","go"]}`, http.StatusOK, []string{"go", "own"}},
+		{"Other user's todo", "/api/admin/users/user-1/todos/added", "added", `{"blocked_by":["theirs"]}`, http.StatusBadRequest, []string{"go", "own"}},
+		{"Task of another group", "/api/admin/users/user-1/todos/added", "added", `{"blocked_by":["pro-only"]}`, http.StatusBadRequest, []string{"go", "own"}},
+		{"Default task on a narrower task", "/api/admin/todos/later", "later", `{"blocked_by":["pro-only"]}`, http.StatusBadRequest, nil},
+		{"Default task for the blocker's groups", "/api/admin/todos/later", "later", `{"groups":["pro"],"blocked_by":["pro-only"]}`, http.StatusOK, []string{"pro-only"}},
+		{"User-created task", "/api/admin/users/user-1/todos/own", "own", `{"blocked_by":["added"]}`, http.StatusForbidden, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "PUT", tt.path, tt.body)
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+			if todo, _ := db.GetTodo(ctx, tt.id); !slices.Equal(todo.BlockedBy, tt.blockedBy) {
+				t.Errorf("Expected blocked_by %v, got %v", tt.blockedBy, todo.BlockedBy)
+			}
+		})
+	}
+
+	// Admins cannot finish a user's blocked todo for them either
+	if w := do(mux, "PUT", "/api/admin/users/user-1/todos/go", `{"status":"done"}`); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 finishing a blocked default task, got %d", w.Code)
+	}
+	do(mux, "PUT", "/api/admin/users/user-1/todos/book", `{"status":"done"}`)
+	if w := do(mux, "PUT", "/api/admin/users/user-1/todos/go", `{"status":"done"}`); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 once the blocker is done, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := do(mux, "POST", "/api/admin/todos", `{"text":"Leave","blocked_by":["go"]}`)
+	var created models.Todo
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if w.Code != http.StatusCreated || !slices.Equal(created.BlockedBy, []string{"go"}) {
+		t.Errorf("Expected a blocked default task to be created, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(mux, "POST", "/api/admin/users/user-1/todos", `{"text":"New","blocked_by":["theirs"]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for another user's blocker, got %d", w.Code)
+	}
+}
+
+func TestDescriptions(t *testing.T) {
+	mux, db := newTestServer(t)
+	ctx := context.Background()
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Default", IsDefaultTask: true})
+	seedTodo(t, db, models.Todo{ID: "added", Text: "Added", UserID: strPtr("user-1"), CreatedByUserID: strPtr("admin-1"), SharedWithAdmin: true})
+	seedTodo(t, db, models.Todo{ID: "own", Text: "Own", Description: "Mine", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1"), SharedWithAdmin: true})
+
+	tests := []struct {
+		name        string
+		path        string
+		id          string
+		body        string
+		expected    int
+		description string
+	}{
+		{"Default task", "/api/admin/todos/default", "default", `{"description":"1. Book\n2. Pack"}`, http.StatusOK, "1. Book\n2. Pack"},
+		{"Too long", "/api/admin/todos/default", "default", `{"description":"` + strings.Repeat("a", models.MaxDescriptionLength+1) + `"}`, http.StatusBadRequest, "1. Book\n2. Pack"},
+		{"Default task as user's", "/api/admin/users/user-1/todos/default", "default", `{"description":""}`, http.StatusBadRequest, "1. Book\n2. Pack"},
+		{"Admin-created task", "/api/admin/users/user-1/todos/added", "added", `{"description":"See [the list](https://example.com)"}`, http.StatusOK, "See [the list](https://example.com)"},
+		{"User-created task", "/api/admin/users/user-1/todos/own", "own", `{"description":"Theirs"}`, http.StatusForbidden, "Mine"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "PUT", tt.path, tt.body)
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+			if todo, _ := db.GetTodo(ctx, tt.id); todo.Description != tt.description {
+				t.Errorf("Expected description %q, got %q", tt.description, todo.Description)
+			}
+		})
+	}
+
+	w := do(mux, "GET", "/api/admin/todos/default?render=html", "")
+	var got models.Todo
+	json.Unmarshal(w.Body.Bytes(), &got)
+	if w.Code != http.StatusOK || got.DescriptionHTML != "<ol>\n<li>Book</li>\n<li>Pack</li>\n</ol>\n" {
+		t.Errorf("Expected the description rendered, got %d: %s", w.Code, w.Body.String())
+	}
+	w = do(mux, "GET", "/api/admin/users/user-1/todos/added?render=html", "")
+	json.Unmarshal(w.Body.Bytes(), &got)
+	if w.Code != http.StatusOK || !strings.Contains(got.DescriptionHTML, `<a href="https://example.com"`) {
+		t.Errorf("Expected the link rendered, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = do(mux, "POST", "/api/admin/users/user-1/todos", `{"text":"New","description":"**Bring** ID"}`)
+	var created models.Todo
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if w.Code != http.StatusCreated || created.Description != "**Bring** ID" {
+		t.Errorf("Expected a todo with a description to be created, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	mux, db := newTestServer(t)
+	ctx := context.Background()
+	seedTodo(t, db, models.Todo{ID: "own", Text: "Own", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1"), SharedWithAdmin: true})
+
+	for _, body := range []string{`{"name":" ","tasks":[]}`, `{"name":"Trip","tasks":[{"text":""}]}`, `{"name":"Trip","tasks":[{"text":"Pack","priority":"someday"}]}`} {
+		if w := do(mux, "POST", "/api/admin/templates", body); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", body, w.Code)
+		}
+	}
+
+	w := do(mux, "POST", "/api/admin/templates", `{"name":"Trip","tasks":[{"text":"Book"},{"text":"Pack","priority":"high"}]}`)
+	var tmpl models.Template
+	json.Unmarshal(w.Body.Bytes(), &tmpl)
+	if w.Code != http.StatusCreated || len(tmpl.Tasks) != 2 || tmpl.Tasks[0].Priority != "normal" {
+		t.Fatalf("Expected the template to be created, got %d: %s", w.Code, w.Body.String())
+	}
+	path := "/api/admin/templates/" + tmpl.ID
+
+	if w := do(mux, "POST", path+"/apply", `{"user_ids":["user-1","nobody"]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown user, got %d", w.Code)
+	}
+	w = do(mux, "POST", path+"/apply", `{"user_ids":["user-1"]}`)
+	if w.Code != http.StatusOK || w.Body.String() != "{\"added\":2,\"updated\":0}\n" {
+		t.Fatalf("Expected two todos added, got %d: %s", w.Code, w.Body.String())
+	}
+	w = do(mux, "GET", "/api/admin/users/user-1/todos", "")
+	var list struct {
+		Todos []models.Todo `json:"todos"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &list)
+	var texts []string
+	for _, todo := range list.Todos {
+		texts = append(texts, todo.Text)
+		if todo.Text != "Own" && (todo.CreatedByUserID == nil || *todo.CreatedByUserID != "admin-1" || !todo.SharedWithAdmin) {
+			t.Errorf("Expected %q to be created by the admin and shared, got %+v", todo.Text, todo)
+		}
+	}
+	if !slices.Equal(texts, []string{"Book", "Pack", "Own"}) {
+		t.Errorf("Expected the template's tasks on top in order, got %v", texts)
+	}
+
+	// Applying again adds nothing
+	if w := do(mux, "POST", path+"/apply", `{"user_ids":["user-1"]}`); w.Body.String() != "{\"added\":0,\"updated\":0}\n" {
+		t.Errorf("Expected nothing to change applying again, got %s", w.Body.String())
+	}
+
+	body := `{"name":"Trip","tasks":[{"id":"` + tmpl.Tasks[1].ID + `","text":"Pack bags","priority":"high"},{"text":"Lock up"}]}`
+	if w := do(mux, "PUT", path, `{"name":"Trip","tasks":[{"id":"other","text":"Book"}]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a task of another template, got %d", w.Code)
+	}
+	if w := do(mux, "PUT", path, body); w.Code != http.StatusOK {
+		t.Fatalf("Expected the template to be updated, got %d: %s", w.Code, w.Body.String())
+	}
+	w = do(mux, "POST", path+"/sync", "")
+	if w.Code != http.StatusOK || w.Body.String() != "{\"added\":1,\"updated\":1}\n" {
+		t.Fatalf("Expected one todo added and one updated, got %d: %s", w.Code, w.Body.String())
+	}
+	todos, _ := db.ListTemplateTodos(ctx, tmpl.ID, "user-1")
+	if len(todos) != 2 || todos[0].Text != "Pack bags" || todos[1].Text != "Lock up" {
+		t.Errorf("Expected the user's todos to follow the template, got %+v", todos)
+	}
+
+	if w := do(mux, "DELETE", path, ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected the template to be deleted, got %d", w.Code)
+	}
+	if w := do(mux, "GET", path, ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 after delete, got %d", w.Code)
+	}
+	if todo, err := db.GetTodo(ctx, todos[0].ID); err != nil || todo.TemplateTaskID != nil {
+		t.Errorf("Expected the todo to stay, unlinked, got %+v, %v", todo, err)
+	}
+}
+
+func TestGroups(t *testing.T) {
+	mux, db := newTestServer(t)
+	if err := db.CreateUser(context.Background(), models.User{ID: "user-2", GoogleID: "g-2", Email: "user2@example.com", Role: "user"}); err != nil {
+		t.Fatalf("Failed to seed user: %v", err)
+	}
+
+	if w := do(mux, "POST", "/api/admin/groups", `{"name":"  "}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an empty name, got %d", w.Code)
+	}
+	w := do(mux, "POST", "/api/admin/groups", `{"name":" Pro "}`)
+	var group models.Group
+	json.Unmarshal(w.Body.Bytes(), &group)
+	if w.Code != http.StatusCreated || group.Name != "Pro" {
+		t.Fatalf("Expected the group to be created, got %d: %s", w.Code, w.Body.String())
+	}
+	path := "/api/admin/groups/" + group.ID
+
+	if w := do(mux, "PUT", path+"/members/user-1", ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected user-1 to be added, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(mux, "PUT", path+"/members/nobody", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 adding an unknown user, got %d", w.Code)
+	}
+	w = do(mux, "GET", path+"/members", "")
+	var members struct {
+		Users []models.User `json:"users"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &members)
+	if len(members.Users) != 1 || members.Users[0].ID != "user-1" {
+		t.Errorf("Expected user-1 to be the only member, got %s", w.Body.String())
+	}
+
+	if w := do(mux, "POST", "/api/admin/todos", `{"text":"Upgrade","groups":["missing"]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown group, got %d", w.Code)
+	}
+	w = do(mux, "POST", "/api/admin/todos", `{"text":"Upgrade","groups":["`+group.ID+`"]}`)
+	var upgrade models.Todo
+	json.Unmarshal(w.Body.Bytes(), &upgrade)
+	if w.Code != http.StatusCreated || !slices.Equal(upgrade.Groups, []string{group.ID}) {
+		t.Fatalf("Expected a targeted default task to be created, got %d: %s", w.Code, w.Body.String())
+	}
+	w = do(mux, "POST", "/api/admin/todos", `{"text":"Pay","parent_id":"`+upgrade.ID+`"}`)
+	var sub models.Todo
+	json.Unmarshal(w.Body.Bytes(), &sub)
+	if w.Code != http.StatusCreated || !slices.Equal(sub.Groups, []string{group.ID}) {
+		t.Errorf("Expected the subtask to target its parent's groups, got %d: %s", w.Code, w.Body.String())
+	}
+	seedTodo(t, db, models.Todo{ID: "everyone", Text: "Everyone", IsDefaultTask: true})
+
+	listed := func(userID string) []string {
+		t.Helper()
+		w := do(mux, "GET", "/api/admin/users/"+userID+"/todos", "")
+		var list struct {
+			Todos []models.Todo `json:"todos"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &list)
+		var texts []string
+		for _, todo := range list.Todos {
+			texts = append(texts, todo.Text)
+		}
+		return texts
+	}
+	if got := listed("user-1"); !slices.Equal(got, []string{"Everyone", "Pay", "Upgrade"}) {
+		t.Errorf("Expected user-1 to get the targeted tasks, got %v", got)
+	}
+	if got := listed("user-2"); !slices.Equal(got, []string{"Everyone"}) {
+		t.Errorf("Expected user-2 to get only the untargeted task, got %v", got)
+	}
+	for _, suffix := range []string{"", "/comments", "/attachments"} {
+		if w := do(mux, "GET", "/api/admin/users/user-2/todos/"+upgrade.ID+suffix, ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for %q on a task not meant for the user, got %d", suffix, w.Code)
+		}
+	}
+	if w := do(mux, "POST", "/api/admin/users/user-2/todos/"+upgrade.ID+"/comments", `{"body":"Hi"}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 commenting on a task not meant for the user, got %d", w.Code)
+	}
+
+	if w := do(mux, "DELETE", path, ""); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 deleting a targeted group, got %d", w.Code)
+	}
+	for _, id := range []string{upgrade.ID, sub.ID} {
+		if w := do(mux, "PUT", "/api/admin/todos/"+id, `{"groups":[]}`); w.Code != http.StatusOK {
+			t.Fatalf("Expected the groups to be cleared, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+	if got := listed("user-2"); len(got) != 3 {
+		t.Errorf("Expected user-2 to get every task once untargeted, got %v", got)
+	}
+	if w := do(mux, "PUT", path, `{"name":"Premium"}`); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"member_count":1`) {
+		t.Errorf("Expected the group to be renamed, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(mux, "DELETE", path, ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected the group to be deleted, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(mux, "GET", path, ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 after delete, got %d", w.Code)
+	}
+}
+
+func TestPropagation(t *testing.T) {
+	mux, db := newTestServer(t)
+	if err := db.CreateUser(context.Background(), models.User{ID: "user-2", GoogleID: "g-2", Email: "user2@example.com", Role: "user"}); err != nil {
+		t.Fatalf("Failed to seed user: %v", err)
+	}
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Pack", IsDefaultTask: true})
+	db.SetDefaultTodoStatus(context.Background(), "user-1", "default", "done", nil)
+	db.SetDefaultTodoStatus(context.Background(), "user-2", "default", "in-progress", nil)
+
+	if w := do(mux, "PUT", "/api/admin/todos/default", `{"text":"Pack light","propagate":"reset_some"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown policy, got %d", w.Code)
+	}
+	if w := do(mux, "PUT", "/api/admin/todos/default", `{"priority":"high","propagate":"reset_all"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 resetting without a text change, got %d", w.Code)
+	}
+
+	userTodo := func(userID string) models.Todo {
+		t.Helper()
+		w := do(mux, "GET", "/api/admin/users/"+userID+"/todos/default", "")
+		var todo models.Todo
+		json.Unmarshal(w.Body.Bytes(), &todo)
+		return todo
+	}
+	if w := do(mux, "PUT", "/api/admin/todos/default", `{"text":"Pack light","propagate":"reset_done"}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected the task to be updated, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := userTodo("user-1"); got.Text != "Pack light" || got.Status != "pending" || !got.UpdatedSinceDone {
+		t.Errorf("Expected user-1 to be reset and flagged, got %+v", got)
+	}
+	if got := userTodo("user-2"); got.Status != "in-progress" || got.UpdatedSinceDone {
+		t.Errorf("Expected user-2 to keep their progress, got %+v", got)
+	}
+
+	if w := do(mux, "PUT", "/api/admin/todos/default", `{"description":"Carry-on only","propagate":"reset_all"}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected the task to be updated, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := userTodo("user-2"); got.Status != "pending" || got.UpdatedSinceDone {
+		t.Errorf("Expected user-2 to be reset without a flag, got %+v", got)
+	}
+
+	db.SetDefaultTodoStatus(context.Background(), "user-1", "default", "in-progress", nil)
+	if got := userTodo("user-1"); got.UpdatedSinceDone {
+		t.Errorf("Expected the flag to clear once user-1 changes status, got %+v", got)
+	}
+}
+
+func TestPublication(t *testing.T) {
+	mux, _ := newTestServer(t)
+	past, future := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339), time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		expected int
+	}{
+		{"Expiry before publication", "POST", "/api/admin/todos", `{"text":"Backwards","publish_at":"` + future + `","expires_at":"` + past + `"}`, http.StatusBadRequest},
+		{"Draft", "POST", "/api/admin/todos", `{"text":"Draft","draft":true}`, http.StatusCreated},
+		{"Scheduled", "POST", "/api/admin/todos", `{"text":"Scheduled","publish_at":"` + future + `"}`, http.StatusCreated},
+		{"Expired", "POST", "/api/admin/todos", `{"text":"Expired","expires_at":"` + past + `"}`, http.StatusCreated},
+		{"Live", "POST", "/api/admin/todos", `{"text":"Live","publish_at":"` + past + `","expires_at":"` + future + `"}`, http.StatusCreated},
+		{"Unknown state", "GET", "/api/admin/todos?publication=hidden", "", http.StatusBadRequest},
+	}
+	created := map[string]models.Todo{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, tt.method, tt.path, tt.body)
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+			var todo models.Todo
+			json.Unmarshal(w.Body.Bytes(), &todo)
+			created[todo.Text] = todo
+		})
+	}
+
+	listed := func(query string) []string {
+		t.Helper()
+		w := do(mux, "GET", "/api/admin/todos"+query, "")
+		var list struct {
+			Todos []models.Todo `json:"todos"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &list)
+		var got []string
+		for _, todo := range list.Todos {
+			got = append(got, todo.Text+":"+string(todo.Publication))
+		}
+		return got
+	}
+	userList := func(userID string) []string {
+		t.Helper()
+		w := do(mux, "GET", "/api/admin/users/"+userID+"/todos", "")
+		var list struct {
+			Todos []models.Todo `json:"todos"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &list)
+		var texts []string
+		for _, todo := range list.Todos {
+			texts = append(texts, todo.Text)
+		}
+		return texts
+	}
+	if got := listed(""); !slices.Equal(got, []string{"Live:live", "Expired:expired", "Scheduled:scheduled", "Draft:draft"}) {
+		t.Errorf("Expected every task with its state, got %v", got)
+	}
+	if got := listed("?publication=draft"); !slices.Equal(got, []string{"Draft:draft"}) {
+		t.Errorf("Expected only the draft, got %v", got)
+	}
+	if got := userList("user-1"); !slices.Equal(got, []string{"Live"}) {
+		t.Errorf("Expected users to see only the live task, got %v", got)
+	}
+	if w := do(mux, "GET", "/api/admin/users/user-1/todos/"+created["Scheduled"].ID+"/comments", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for comments on a task users cannot see yet, got %d", w.Code)
+	}
+
+	// Subtasks go out with their parent
+	draft := created["Draft"]
+	if w := do(mux, "POST", "/api/admin/todos", `{"text":"Draft step","parent_id":"`+draft.ID+`"}`); w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"draft":true`) {
+		t.Fatalf("Expected the subtask to be a draft too, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doIfMatch(mux, "POST", "/api/admin/todos/"+draft.ID+"/publish", "", `"0"`); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected status 412 publishing a stale task, got %d", w.Code)
+	}
+	w := do(mux, "POST", "/api/admin/todos/"+draft.ID+"/publish", "")
+	var published models.Todo
+	json.Unmarshal(w.Body.Bytes(), &published)
+	if w.Code != http.StatusOK || published.Publication != models.PublicationLive || published.Draft {
+		t.Fatalf("Expected the draft to be published, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := userList("user-1"); !slices.Equal(got, []string{"Draft step", "Live", "Draft"}) {
+		t.Errorf("Expected the published task and its subtask to be shown, got %v", got)
+	}
+	if w := do(mux, "POST", "/api/admin/todos/missing/publish", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 publishing a missing task, got %d", w.Code)
+	}
+
+	// Updates change and clear the dates
+	scheduled := created["Scheduled"]
+	if w := do(mux, "PUT", "/api/admin/todos/"+scheduled.ID, `{"expires_at":"`+past+`"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 expiring before publication, got %d", w.Code)
+	}
+	if w := do(mux, "PUT", "/api/admin/todos/"+scheduled.ID, `{"publish_at":"`+past+`","clear_publish_at":true}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 combining publish_at and clear_publish_at, got %d", w.Code)
+	}
+	w = do(mux, "PUT", "/api/admin/todos/"+scheduled.ID, `{"clear_publish_at":true}`)
+	var updated models.Todo
+	json.Unmarshal(w.Body.Bytes(), &updated)
+	if w.Code != http.StatusOK || updated.PublishAt != nil || updated.Publication != models.PublicationLive {
+		t.Errorf("Expected the task to go live without a publish date, got %d: %s", w.Code, w.Body.String())
+	}
+	w = do(mux, "PUT", "/api/admin/todos/"+scheduled.ID, `{"draft":true}`)
+	json.Unmarshal(w.Body.Bytes(), &updated)
+	if w.Code != http.StatusOK || updated.Publication != models.PublicationDraft {
+		t.Errorf("Expected the task to become a draft, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestReorderAdminTodos(t *testing.T) {
+	mux, db := newTestServer(t)
+	for _, id := range []string{"a", "b", "c"} {
+		seedTodo(t, db, models.Todo{ID: id, Text: id, IsDefaultTask: true})
+	}
+	seedTodo(t, db, models.Todo{ID: "a-1", Text: "a-1", IsDefaultTask: true, ParentID: strPtr("a")})
+	seedTodo(t, db, models.Todo{ID: "own", Text: "own", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+	if err := db.ReorderTodos(context.Background(), "user-1", []string{"a", "b", "c"}); err != nil {
+		t.Fatalf("Failed to reorder user-1's tasks: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		expected int
+	}{
+		{"No IDs", `{"ids":[]}`, http.StatusBadRequest},
+		{"Unknown task", `{"ids":["a","b","c","missing"]}`, http.StatusBadRequest},
+		{"Unknown first task", `{"ids":["missing","a","b","c"]}`, http.StatusBadRequest},
+		{"Personal todo", `{"ids":["a","b","c","own"]}`, http.StatusBadRequest},
+		{"Different parents", `{"ids":["a","b","c","a-1"]}`, http.StatusBadRequest},
+		{"Missing task", `{"ids":["c","a"]}`, http.StatusBadRequest},
+		{"Duplicate task", `{"ids":["c","a","a","b"]}`, http.StatusBadRequest},
+		{"Subtasks", `{"ids":["a-1"]}`, http.StatusOK},
+		{"Canonical order", `{"ids":["c","a","b"]}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := do(mux, "PUT", "/api/admin/todos/reorder", tt.body); w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	listed := func(path string) []string {
+		t.Helper()
+		w := do(mux, "GET", path+"?source=default", "")
+		var list struct {
+			Todos []models.Todo `json:"todos"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &list)
+		var got []string
+		for _, todo := range list.Todos {
+			if todo.ParentID == nil {
+				got = append(got, todo.ID)
+			}
+		}
+		return got
+	}
+	if got := listed("/api/admin/todos"); !slices.Equal(got, []string{"c", "a", "b"}) {
+		t.Errorf("Expected the canonical order, got %v", got)
+	}
+	if got := listed("/api/admin/users/user-1/todos"); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("Expected user-1 to keep their own order, got %v", got)
+	}
+
+	if w := do(mux, "PUT", "/api/admin/todos/reorder", `{"ids":["c","a","b"],"reset_users":true}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected the tasks to be reordered, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := listed("/api/admin/users/user-1/todos"); !slices.Equal(got, []string{"c", "a", "b"}) {
+		t.Errorf("Expected user-1's order to be reset, got %v", got)
+	}
+}
@@ -0,0 +1,172 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/akhilmk/packup/internal/attachment"
+	"github.com/akhilmk/packup/internal/audit"
+	"github.com/akhilmk/packup/internal/auth"
+	"github.com/akhilmk/packup/internal/httputil"
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+	"github.com/google/uuid"
+)
+
+// ListAttachments lists the files attached to a user's todo.
+// @Summary List attachments on user's todo
+// @Description Get the files attached to one of a user's shared personal todos, or to the user's copy of a default task, oldest first.
+// @Tags admin
+// @Produce json
+// @Param userId path string true "User ID"
+// @Param todoId path string true "Todo ID"
+// @Success 200 {object} map[string][]models.Attachment
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/users/{userId}/todos/{todoId}/attachments [get]
+func (h *Handler) ListAttachments(w http.ResponseWriter, r *http.Request) {
+	userID, todoID, ok := h.sharedTodo(w, r)
+	if !ok {
+		return
+	}
+
+	attachments, err := h.attachments.ListAttachments(r.Context(), todoID, userID)
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+
+	httputil.WriteJSON(w, map[string]any{"attachments": attachments}, http.StatusOK)
+}
+
+// UploadAttachment attaches a file to a user's todo.
+// @Summary Upload attachment to user's todo
+// @Description Attach a file to one of a user's shared personal todos, or to the user's copy of a default task, as multipart/form-data in the file field. Files may be PDF documents, plain text or GIF, JPEG, PNG or WebP images of up to 10 MiB; the type is detected from the contents.
+// @Tags admin
+// @Accept multipart/form-data
+// @Produce json
+// @Param userId path string true "User ID"
+// @Param todoId path string true "Todo ID"
+// @Param file formData file true "File to attach"
+// @Success 201 {object} models.Attachment
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 413 {object} httputil.APIError
+// @Failure 415 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/users/{userId}/todos/{todoId}/attachments [post]
+func (h *Handler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	userID, todoID, ok := h.sharedTodo(w, r)
+	if !ok {
+		return
+	}
+
+	adminID, _ := auth.GetUserID(r.Context())
+	a := models.Attachment{
+		ID:         uuid.New().String(),
+		TodoID:     todoID,
+		UserID:     userID,
+		UploadedBy: &adminID,
+		CreatedAt:  time.Now(),
+	}
+	if !attachment.Receive(w, r, h.blobs, &a) {
+		return
+	}
+	if err := h.attachments.CreateAttachment(r.Context(), a); err != nil {
+		attachment.Delete(r.Context(), h.blobs, a.ID)
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	h.audit.Record(r.Context(), audit.Event{ActorID: adminID, Action: models.AuditAttachmentAdd, UserID: userID, TodoID: todoID, After: a})
+
+	httputil.WriteJSON(w, a, http.StatusCreated)
+}
+
+// DownloadAttachment sends the contents of an attachment on a user's todo.
+// @Summary Download attachment on user's todo
+// @Description Download a file attached to one of a user's shared personal todos, or to the user's copy of a default task.
+// @Tags admin
+// @Produce application/octet-stream
+// @Param userId path string true "User ID"
+// @Param todoId path string true "Todo ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Success 200 {file} file
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/users/{userId}/todos/{todoId}/attachments/{attachmentId} [get]
+func (h *Handler) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	userID, todoID, ok := h.sharedTodo(w, r)
+	if !ok {
+		return
+	}
+	a, ok := h.todoAttachment(w, r, todoID, userID)
+	if !ok {
+		return
+	}
+
+	attachment.Serve(w, r, h.blobs, a)
+}
+
+// DeleteAttachment removes an attachment from a user's todo.
+// @Summary Delete attachment on user's todo
+// @Description Remove a file the authenticated admin attached to a user's todo.
+// @Tags admin
+// @Produce json
+// @Param userId path string true "User ID"
+// @Param todoId path string true "Todo ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Success 200 {object} map[string]bool
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/users/{userId}/todos/{todoId}/attachments/{attachmentId} [delete]
+func (h *Handler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	userID, todoID, ok := h.sharedTodo(w, r)
+	if !ok {
+		return
+	}
+	a, ok := h.todoAttachment(w, r, todoID, userID)
+	if !ok {
+		return
+	}
+	adminID, _ := auth.GetUserID(r.Context())
+	if a.UploadedBy == nil || *a.UploadedBy != adminID {
+		httputil.Forbidden(w, "forbidden: only the uploader can delete an attachment")
+		return
+	}
+
+	if err := h.attachments.DeleteAttachment(r.Context(), a.ID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			httputil.NotFound(w, "attachment not found")
+			return
+		}
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	attachment.Delete(r.Context(), h.blobs, a.ID)
+	h.audit.Record(r.Context(), audit.Event{ActorID: adminID, Action: models.AuditAttachmentDelete, UserID: userID, TodoID: todoID, Before: a})
+
+	httputil.WriteSuccess(w)
+}
+
+// todoAttachment returns the attachment in the path if it is on userID's
+// todoID. It writes the error response and returns false otherwise.
+func (h *Handler) todoAttachment(w http.ResponseWriter, r *http.Request, todoID, userID string) (models.Attachment, bool) {
+	a, err := h.attachments.GetAttachment(r.Context(), r.PathValue("attachmentId"))
+	if err != nil || a.TodoID != todoID || a.UserID != userID {
+		httputil.NotFound(w, "attachment not found")
+		return models.Attachment{}, false
+	}
+	return a, true
+}
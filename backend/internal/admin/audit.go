@@ -0,0 +1,75 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/akhilmk/packup/internal/httputil"
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// Audit log page sizes.
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 500
+)
+
+// ListAudit returns audit log events, newest first.
+// @Summary List audit events
+// @Description Get the audit log of todo and user changes, newest first. Every filter is optional.
+// @Tags admin
+// @Produce json
+// @Param user_id query string false "Only changes to this user's data"
+// @Param todo_id query string false "Only changes to this todo"
+// @Param actor_id query string false "Only changes made by this user"
+// @Param action query string false "Only this action, e.g. todo.update"
+// @Param from query string false "Only events at or after this time (RFC 3339)"
+// @Param to query string false "Only events before this time (RFC 3339)"
+// @Param limit query int false "Maximum number of events (default 100, max 500)"
+// @Success 200 {object} map[string][]models.AuditEvent
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/audit [get]
+func (h *Handler) ListAudit(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := store.AuditFilter{
+		UserID:  q.Get("user_id"),
+		TodoID:  q.Get("todo_id"),
+		ActorID: q.Get("actor_id"),
+		Action:  models.AuditAction(q.Get("action")),
+		Limit:   defaultAuditLimit,
+	}
+
+	var err error
+	if v := q.Get("from"); v != "" {
+		if filter.From, err = time.Parse(time.RFC3339, v); err != nil {
+			httputil.BadRequest(w, "invalid from time, expected RFC 3339")
+			return
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if filter.To, err = time.Parse(time.RFC3339, v); err != nil {
+			httputil.BadRequest(w, "invalid to time, expected RFC 3339")
+			return
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		filter.Limit, err = strconv.Atoi(v)
+		if err != nil || filter.Limit < 1 || filter.Limit > maxAuditLimit {
+			httputil.BadRequest(w, "limit must be between 1 and 500")
+			return
+		}
+	}
+
+	events, err := h.events.ListAuditEvents(r.Context(), filter)
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+
+	httputil.WriteJSON(w, map[string]any{"events": events}, http.StatusOK)
+}
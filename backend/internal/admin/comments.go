@@ -0,0 +1,251 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/akhilmk/packup/internal/audit"
+	"github.com/akhilmk/packup/internal/auth"
+	"github.com/akhilmk/packup/internal/httputil"
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+	"github.com/google/uuid"
+)
+
+// ListComments lists the comments in a user's thread on a todo.
+// @Summary List comments on user's todo
+// @Description Get the comment thread between a user and the admins on one of the user's shared personal todos or on a default task, oldest first.
+// @Tags admin
+// @Produce json
+// @Param userId path string true "User ID"
+// @Param todoId path string true "Todo ID"
+// @Success 200 {object} map[string][]models.Comment
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/users/{userId}/todos/{todoId}/comments [get]
+func (h *Handler) ListComments(w http.ResponseWriter, r *http.Request) {
+	userID, todoID, ok := h.sharedTodo(w, r)
+	if !ok {
+		return
+	}
+
+	comments, err := h.comments.ListComments(r.Context(), todoID, userID)
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+
+	httputil.WriteJSON(w, map[string]any{"comments": comments}, http.StatusOK)
+}
+
+// CreateComment adds a comment to a user's thread on a todo.
+// @Summary Add comment to user's todo
+// @Description Add a comment to the thread between a user and the admins on one of the user's shared personal todos or on a default task.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param userId path string true "User ID"
+// @Param todoId path string true "Todo ID"
+// @Param comment body object true "Comment body"
+// @Success 201 {object} models.Comment
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/users/{userId}/todos/{todoId}/comments [post]
+func (h *Handler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	userID, todoID, ok := h.sharedTodo(w, r)
+	if !ok {
+		return
+	}
+
+	body, ok := decodeComment(w, r)
+	if !ok {
+		return
+	}
+
+	adminID, _ := auth.GetUserID(r.Context())
+	c := models.Comment{
+		ID:        uuid.New().String(),
+		TodoID:    todoID,
+		UserID:    userID,
+		AuthorID:  &adminID,
+		Body:      body,
+		CreatedAt: time.Now(),
+	}
+	if err := h.comments.CreateComment(r.Context(), c); err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	h.audit.Record(r.Context(), audit.Event{ActorID: adminID, Action: models.AuditCommentCreate, UserID: userID, TodoID: todoID, After: c})
+
+	httputil.WriteJSON(w, c, http.StatusCreated)
+}
+
+// UpdateComment edits an admin's own comment on a user's todo.
+// @Summary Edit comment on user's todo
+// @Description Replace the body of one of the authenticated admin's own comments in a user's thread.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param userId path string true "User ID"
+// @Param todoId path string true "Todo ID"
+// @Param commentId path string true "Comment ID"
+// @Param comment body object true "Comment body"
+// @Success 200 {object} models.Comment
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/users/{userId}/todos/{todoId}/comments/{commentId} [put]
+func (h *Handler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	userID, todoID, ok := h.sharedTodo(w, r)
+	if !ok {
+		return
+	}
+	adminID, _ := auth.GetUserID(r.Context())
+	existing, ok := h.ownComment(w, r, todoID, userID, adminID)
+	if !ok {
+		return
+	}
+
+	body, ok := decodeComment(w, r)
+	if !ok {
+		return
+	}
+
+	c := existing
+	c.Body = body
+	now := time.Now()
+	c.EditedAt = &now
+	if err := h.comments.UpdateComment(r.Context(), c.ID, c.Body, now); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			httputil.NotFound(w, "comment not found")
+			return
+		}
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	h.audit.Record(r.Context(), audit.Event{ActorID: adminID, Action: models.AuditCommentUpdate, UserID: userID, TodoID: todoID, Before: existing, After: c})
+
+	httputil.WriteJSON(w, c, http.StatusOK)
+}
+
+// DeleteComment deletes an admin's own comment on a user's todo.
+// @Summary Delete comment on user's todo
+// @Description Delete one of the authenticated admin's own comments in a user's thread.
+// @Tags admin
+// @Produce json
+// @Param userId path string true "User ID"
+// @Param todoId path string true "Todo ID"
+// @Param commentId path string true "Comment ID"
+// @Success 200 {object} map[string]bool
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/users/{userId}/todos/{todoId}/comments/{commentId} [delete]
+func (h *Handler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	userID, todoID, ok := h.sharedTodo(w, r)
+	if !ok {
+		return
+	}
+	adminID, _ := auth.GetUserID(r.Context())
+	existing, ok := h.ownComment(w, r, todoID, userID, adminID)
+	if !ok {
+		return
+	}
+
+	if err := h.comments.DeleteComment(r.Context(), existing.ID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			httputil.NotFound(w, "comment not found")
+			return
+		}
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	h.audit.Record(r.Context(), audit.Event{ActorID: adminID, Action: models.AuditCommentDelete, UserID: userID, TodoID: todoID, Before: existing})
+
+	httputil.WriteSuccess(w)
+}
+
+// sharedTodo identifies the user and todo in the path, whose comments and
+// attachments the request is about. Admins must be able to see the todo as
+// with GetUserTodo. It writes the error response and returns false
+// otherwise.
+func (h *Handler) sharedTodo(w http.ResponseWriter, r *http.Request) (userID, todoID string, ok bool) {
+	userID = r.PathValue("userId")
+	todoID = r.PathValue("todoId")
+	if userID == "" || todoID == "" {
+		httputil.BadRequest(w, "userId and todoId required")
+		return "", "", false
+	}
+
+	// Verify user exists
+	if _, err := h.users.GetUser(r.Context(), userID); err != nil {
+		httputil.NotFound(w, "user not found")
+		return "", "", false
+	}
+
+	// Default tasks must be meant for the user and live
+	t, err := h.todos.GetUserTodo(r.Context(), todoID, userID)
+	if err != nil {
+		httputil.NotFound(w, "todo not found")
+		return "", "", false
+	}
+
+	// Personal todos must belong to the user and be visible to admins
+	if !t.IsDefaultTask {
+		if t.UserID == nil || *t.UserID != userID {
+			httputil.Forbidden(w, "todo does not belong to this user")
+			return "", "", false
+		}
+		if !t.SharedWithAdmin {
+			httputil.Forbidden(w, "todo is not shared with admin")
+			return "", "", false
+		}
+	}
+	return userID, todoID, true
+}
+
+// ownComment returns the comment in the path if it is in userID's thread on
+// todoID and written by adminID. It writes the error response and returns
+// false otherwise.
+func (h *Handler) ownComment(w http.ResponseWriter, r *http.Request, todoID, userID, adminID string) (models.Comment, bool) {
+	c, err := h.comments.GetComment(r.Context(), r.PathValue("commentId"))
+	if err != nil || c.TodoID != todoID || c.UserID != userID {
+		httputil.NotFound(w, "comment not found")
+		return models.Comment{}, false
+	}
+	if c.AuthorID == nil || *c.AuthorID != adminID {
+		httputil.Forbidden(w, "forbidden: only the author can change a comment")
+		return models.Comment{}, false
+	}
+	return c, true
+}
+
+// decodeComment reads and normalizes the body of a comment from the request.
+// It writes the error response and returns false if it is invalid.
+func decodeComment(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req struct {
+		Body string `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.BadRequest(w, "invalid json")
+		return "", false
+	}
+	body, err := models.NormalizeComment(req.Body)
+	if err != nil {
+		httputil.BadRequest(w, err.Error())
+		return "", false
+	}
+	return body, true
+}
@@ -0,0 +1,33 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/akhilmk/packup/internal/httputil"
+	"github.com/akhilmk/packup/internal/models"
+)
+
+// checkBlockers checks that t can depend on each of ids: default tasks on
+// default tasks that reach all their users, and a user's todos on default
+// tasks meant for that user or that user's todos. Otherwise it writes an
+// error and returns false.
+func (h *Handler) checkBlockers(w http.ResponseWriter, r *http.Request, t models.Todo, ids []string) bool {
+	for _, id := range ids {
+		var blocker models.Todo
+		var err error
+		if t.UserID != nil {
+			blocker, err = h.todos.GetUserTodo(r.Context(), id, *t.UserID)
+		} else {
+			blocker, err = h.todos.GetTodo(r.Context(), id)
+		}
+		if err != nil {
+			httputil.BadRequest(w, "blocker "+id+" not found")
+			return false
+		}
+		if !t.CanDependOn(blocker) {
+			httputil.BadRequest(w, "todo cannot depend on "+id)
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,307 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/akhilmk/packup/internal/audit"
+	"github.com/akhilmk/packup/internal/auth"
+	"github.com/akhilmk/packup/internal/httputil"
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+	"github.com/google/uuid"
+)
+
+// ListGroups returns every user group.
+// @Summary List user groups
+// @Description Get every user group by name, with its member count.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} map[string][]models.Group
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/groups [get]
+func (h *Handler) ListGroups(w http.ResponseWriter, r *http.Request) {
+	groups, err := h.groups.ListGroups(r.Context())
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+
+	httputil.WriteJSON(w, map[string][]models.Group{"groups": groups}, http.StatusOK)
+}
+
+// GetGroup returns a user group.
+// @Summary Get user group
+// @Description Get a user group with its member count.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Group ID"
+// @Success 200 {object} models.Group
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/groups/{id} [get]
+func (h *Handler) GetGroup(w http.ResponseWriter, r *http.Request) {
+	g, ok := h.getGroup(w, r)
+	if !ok {
+		return
+	}
+
+	httputil.WriteJSON(w, g, http.StatusOK)
+}
+
+// CreateGroup creates a user group.
+// @Summary Create user group
+// @Description Create a group of users, such as the customers on a plan or in a region, that default tasks can be targeted to.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param group body object true "Group name"
+// @Success 201 {object} models.Group
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/groups [post]
+func (h *Handler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.BadRequest(w, "invalid json")
+		return
+	}
+	name, ok := models.NormalizeGroupName(req.Name)
+	if !ok {
+		httputil.BadRequest(w, fmt.Sprintf("name cannot be empty or exceed %d characters", models.MaxGroupNameLength))
+		return
+	}
+
+	g := models.Group{ID: uuid.NewString(), Name: name, CreatedAt: time.Now()}
+	if err := h.groups.SaveGroup(r.Context(), g); err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	adminID, _ := auth.GetUserID(r.Context())
+	h.audit.Record(r.Context(), audit.Event{ActorID: adminID, Action: models.AuditGroupSave, After: g})
+
+	httputil.WriteJSON(w, g, http.StatusCreated)
+}
+
+// UpdateGroup renames a user group.
+// @Summary Rename user group
+// @Description Change the name of a user group. Its members and the default tasks targeted to it are unchanged.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param group body object true "Group name"
+// @Success 200 {object} models.Group
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/groups/{id} [put]
+func (h *Handler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
+	before, ok := h.getGroup(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.BadRequest(w, "invalid json")
+		return
+	}
+	name, ok := models.NormalizeGroupName(req.Name)
+	if !ok {
+		httputil.BadRequest(w, fmt.Sprintf("name cannot be empty or exceed %d characters", models.MaxGroupNameLength))
+		return
+	}
+
+	g := before
+	g.Name = name
+	if err := h.groups.SaveGroup(r.Context(), g); err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	adminID, _ := auth.GetUserID(r.Context())
+	h.audit.Record(r.Context(), audit.Event{ActorID: adminID, Action: models.AuditGroupSave, Before: before, After: g})
+
+	httputil.WriteJSON(w, g, http.StatusOK)
+}
+
+// DeleteGroup deletes a user group.
+// @Summary Delete user group
+// @Description Delete a user group and its memberships. Fails with 409 while default tasks, including those in the trash, are targeted to it.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Group ID"
+// @Success 200 {object} map[string]bool
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 409 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/groups/{id} [delete]
+func (h *Handler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	before, ok := h.getGroup(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.groups.DeleteGroup(r.Context(), before.ID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			httputil.NotFound(w, "group not found")
+		case errors.Is(err, store.ErrConflict):
+			httputil.Conflict(w, "group is targeted by default tasks")
+		default:
+			httputil.InternalError(w, err.Error())
+		}
+		return
+	}
+	adminID, _ := auth.GetUserID(r.Context())
+	h.audit.Record(r.Context(), audit.Event{ActorID: adminID, Action: models.AuditGroupDelete, Before: before})
+
+	httputil.WriteSuccess(w)
+}
+
+// ListGroupMembers returns the members of a user group.
+// @Summary List group members
+// @Description Get the members of a user group by email.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Group ID"
+// @Success 200 {object} map[string][]models.User
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/groups/{id}/members [get]
+func (h *Handler) ListGroupMembers(w http.ResponseWriter, r *http.Request) {
+	g, ok := h.getGroup(w, r)
+	if !ok {
+		return
+	}
+
+	users, err := h.groups.ListGroupMembers(r.Context(), g.ID)
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+
+	httputil.WriteJSON(w, map[string][]models.User{"users": users}, http.StatusOK)
+}
+
+// AddGroupMember adds a user to a group.
+// @Summary Add group member
+// @Description Add a user to a group, so that they see the default tasks targeted to it. Adding a member again changes nothing.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param userId path string true "User ID"
+// @Success 200 {object} map[string]bool
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/groups/{id}/members/{userId} [put]
+func (h *Handler) AddGroupMember(w http.ResponseWriter, r *http.Request) {
+	g, ok := h.getGroup(w, r)
+	if !ok {
+		return
+	}
+	userID := r.PathValue("userId")
+	if _, err := h.users.GetUser(r.Context(), userID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			httputil.NotFound(w, "user not found")
+			return
+		}
+		httputil.InternalError(w, err.Error())
+		return
+	}
+
+	if err := h.groups.AddGroupMember(r.Context(), g.ID, userID); err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	adminID, _ := auth.GetUserID(r.Context())
+	h.audit.Record(r.Context(), audit.Event{ActorID: adminID, Action: models.AuditGroupAddMember, UserID: userID, After: g})
+
+	httputil.WriteSuccess(w)
+}
+
+// RemoveGroupMember removes a user from a group.
+// @Summary Remove group member
+// @Description Remove a user from a group. The default tasks targeted only to groups they are no longer in disappear from their list; their progress is kept.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param userId path string true "User ID"
+// @Success 200 {object} map[string]bool
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/groups/{id}/members/{userId} [delete]
+func (h *Handler) RemoveGroupMember(w http.ResponseWriter, r *http.Request) {
+	g, ok := h.getGroup(w, r)
+	if !ok {
+		return
+	}
+	userID := r.PathValue("userId")
+
+	if err := h.groups.RemoveGroupMember(r.Context(), g.ID, userID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			httputil.NotFound(w, "user is not a member of the group")
+			return
+		}
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	adminID, _ := auth.GetUserID(r.Context())
+	h.audit.Record(r.Context(), audit.Event{ActorID: adminID, Action: models.AuditGroupRemoveMember, UserID: userID, Before: g})
+
+	httputil.WriteSuccess(w)
+}
+
+// getGroup loads the group named by the id path value. If there is none, it
+// writes an error and returns false.
+func (h *Handler) getGroup(w http.ResponseWriter, r *http.Request) (models.Group, bool) {
+	g, err := h.groups.GetGroup(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			httputil.NotFound(w, "group not found")
+			return g, false
+		}
+		httputil.InternalError(w, err.Error())
+		return g, false
+	}
+	return g, true
+}
+
+// checkGroups checks that the groups a default task is targeted to exist. If
+// one does not, it writes an error and returns false.
+func (h *Handler) checkGroups(w http.ResponseWriter, r *http.Request, ids []string) bool {
+	for _, id := range ids {
+		if _, err := h.groups.GetGroup(r.Context(), id); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				httputil.BadRequest(w, "group "+id+" not found")
+				return false
+			}
+			httputil.InternalError(w, err.Error())
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,175 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/akhilmk/packup/internal/audit"
+	"github.com/akhilmk/packup/internal/auth"
+	"github.com/akhilmk/packup/internal/httputil"
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// AdminTodoHistory returns the revision history of a global default task.
+// @Summary Default task revision history
+// @Description Get every revision of a global default task, oldest first, each with the fields changed since the previous revision.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Todo ID"
+// @Success 200 {object} map[string][]models.TodoRevision
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/todos/{id}/history [get]
+func (h *Handler) AdminTodoHistory(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		httputil.BadRequest(w, "id required")
+		return
+	}
+
+	// Verify it's a default task
+	t, err := h.todos.GetTodo(r.Context(), id)
+	if err != nil {
+		httputil.NotFound(w, "todo not found")
+		return
+	}
+	if !t.IsDefaultTask {
+		httputil.BadRequest(w, "not a default task")
+		return
+	}
+
+	h.writeHistory(w, r, id)
+}
+
+// UserTodoHistory returns the revision history of a user's todo.
+// @Summary User's todo revision history
+// @Description Get every revision of a user's shared personal todo or of a default task, oldest first, each with the fields changed since the previous revision.
+// @Tags admin
+// @Produce json
+// @Param userId path string true "User ID"
+// @Param todoId path string true "Todo ID"
+// @Success 200 {object} map[string][]models.TodoRevision
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/users/{userId}/todos/{todoId}/history [get]
+func (h *Handler) UserTodoHistory(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("userId")
+	todoID := r.PathValue("todoId")
+	if userID == "" || todoID == "" {
+		httputil.BadRequest(w, "userId and todoId required")
+		return
+	}
+
+	// Verify user exists
+	if _, err := h.users.GetUser(r.Context(), userID); err != nil {
+		httputil.NotFound(w, "user not found")
+		return
+	}
+
+	t, err := h.todos.GetTodo(r.Context(), todoID)
+	if err != nil {
+		httputil.NotFound(w, "todo not found")
+		return
+	}
+
+	// Personal todos must belong to the user and be visible to admins
+	if !t.IsDefaultTask {
+		if t.UserID == nil || *t.UserID != userID {
+			httputil.Forbidden(w, "todo does not belong to this user")
+			return
+		}
+		if !t.SharedWithAdmin {
+			httputil.Forbidden(w, "todo is not shared with admin")
+			return
+		}
+	}
+
+	h.writeHistory(w, r, todoID)
+}
+
+// RevertAdminTodo restores a global default task's text from an earlier revision.
+// @Summary Revert global default task
+// @Description Set a global default task's text back to the text of an earlier revision. The revert is recorded as a new revision; users' progress is kept.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Todo ID"
+// @Param revision body object true "Revision number to revert to"
+// @Success 200 {object} models.Todo
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/todos/{id}/revert [post]
+func (h *Handler) RevertAdminTodo(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		httputil.BadRequest(w, "id required")
+		return
+	}
+
+	var req struct {
+		Revision int `json:"revision"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.BadRequest(w, "invalid json")
+		return
+	}
+
+	// Verify it's a default task
+	existing, err := h.todos.GetTodo(r.Context(), id)
+	if err != nil {
+		httputil.NotFound(w, "todo not found")
+		return
+	}
+	if !existing.IsDefaultTask {
+		httputil.BadRequest(w, "not a default task")
+		return
+	}
+
+	rev, err := h.todos.GetTodoRevision(r.Context(), id, req.Revision)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			httputil.NotFound(w, "revision not found")
+			return
+		}
+		httputil.InternalError(w, err.Error())
+		return
+	}
+
+	adminID, _ := auth.GetUserID(r.Context())
+	if err := h.todos.UpdateTodo(r.Context(), id, store.TodoUpdate{Text: &rev.Text, ActorID: adminID}); err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+
+	t, err := h.todos.GetTodo(r.Context(), id)
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	h.audit.Record(r.Context(), audit.Event{ActorID: adminID, Action: models.AuditTodoUpdate, TodoID: id, Before: existing, After: t})
+
+	httputil.WriteJSON(w, t, http.StatusOK)
+}
+
+// writeHistory writes a todo's revisions with their changes.
+func (h *Handler) writeHistory(w http.ResponseWriter, r *http.Request, todoID string) {
+	revs, err := h.todos.ListTodoRevisions(r.Context(), todoID)
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	models.DiffRevisions(revs)
+
+	httputil.WriteJSON(w, map[string]any{"revisions": revs}, http.StatusOK)
+}
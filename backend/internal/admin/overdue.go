@@ -0,0 +1,60 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/akhilmk/packup/internal/httputil"
+)
+
+// Overdue list sizes.
+const (
+	defaultOverdueLimit = 100
+	maxOverdueLimit     = 500
+)
+
+// ListOverdue returns the overdue todos of every user.
+// @Summary List overdue todos
+// @Description Get the todos past their due date and not done, most overdue first, across all users: default tasks by each user's own status and due date, plus users' todos shared with admins. Each entry names the user it is overdue for.
+// @Tags admin
+// @Produce json
+// @Param user_id query string false "Only this user's overdue todos"
+// @Param limit query int false "Maximum number of todos (default 100, max 500)"
+// @Success 200 {object} map[string][]models.OverdueTodo
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/overdue [get]
+func (h *Handler) ListOverdue(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	limit := defaultOverdueLimit
+	if v := q.Get("limit"); v != "" {
+		var err error
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxOverdueLimit {
+			httputil.BadRequest(w, "limit must be between 1 and 500")
+			return
+		}
+	}
+
+	userID := q.Get("user_id")
+	if userID != "" {
+		// Verify user exists
+		if _, err := h.users.GetUser(r.Context(), userID); err != nil {
+			httputil.NotFound(w, "user not found")
+			return
+		}
+	}
+
+	todos, err := h.todos.ListOverdueTodos(r.Context(), userID, time.Now(), limit)
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+
+	httputil.WriteJSON(w, map[string]any{"todos": todos}, http.StatusOK)
+}
@@ -0,0 +1,76 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/akhilmk/packup/internal/audit"
+	"github.com/akhilmk/packup/internal/auth"
+	"github.com/akhilmk/packup/internal/httputil"
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// PublishAdminTodo makes a global default task live now.
+// @Summary Publish global default task
+// @Description Show a draft or scheduled default task to users right away, along with its subtasks. An expiry date that has passed is cleared; a later one is kept. With If-Match, publishing fails with 412 if the task has changed since it was read.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Todo ID"
+// @Param If-Match header string false "ETag of the task as last read"
+// @Success 200 {object} models.Todo
+// @Header 200 {string} ETag "Version of the published task"
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 412 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/todos/{id}/publish [post]
+func (h *Handler) PublishAdminTodo(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	before, err := h.todos.GetTodo(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			httputil.NotFound(w, "todo not found")
+			return
+		}
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	if !before.IsDefaultTask {
+		httputil.BadRequest(w, "not a default task")
+		return
+	}
+	if !httputil.IfMatch(r, before.ETag()) {
+		httputil.PreconditionFailed(w, "todo has been changed by someone else")
+		return
+	}
+
+	var ifVersion *int
+	if httputil.IsConditional(r) {
+		ifVersion = &before.Version
+	}
+	if err := h.todos.PublishTodo(r.Context(), id, time.Now(), ifVersion); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			httputil.NotFound(w, "todo not found")
+		case errors.Is(err, store.ErrConflict):
+			httputil.PreconditionFailed(w, "todo has been changed by someone else")
+		default:
+			httputil.InternalError(w, err.Error())
+		}
+		return
+	}
+	t, err := h.todos.GetTodo(r.Context(), id)
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	adminID, _ := auth.GetUserID(r.Context())
+	h.audit.Record(r.Context(), audit.Event{ActorID: adminID, Action: models.AuditTodoPublish, TodoID: id, Before: before, After: t})
+
+	httputil.SetETag(w, t.ETag())
+	httputil.WriteJSON(w, t, http.StatusOK)
+}
@@ -0,0 +1,66 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/akhilmk/packup/internal/httputil"
+)
+
+// Search result sizes.
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// SearchTodos searches the todos admins can see.
+// @Summary Search todos
+// @Description Full-text search over global default tasks and users' todos that are shared with admins (including admin-assigned tasks), best matches first. Every word of the query must match. Private todos are never returned.
+// @Tags admin
+// @Produce json
+// @Param q query string true "Search text"
+// @Param user_id query string false "Only this user's todos, with their status for default tasks"
+// @Param limit query int false "Maximum number of results (default 20, max 100)"
+// @Success 200 {object} map[string][]models.Todo
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/search [get]
+func (h *Handler) SearchTodos(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := strings.TrimSpace(q.Get("q"))
+	if query == "" {
+		httputil.BadRequest(w, "q required")
+		return
+	}
+
+	limit := defaultSearchLimit
+	if v := q.Get("limit"); v != "" {
+		var err error
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxSearchLimit {
+			httputil.BadRequest(w, "limit must be between 1 and 100")
+			return
+		}
+	}
+
+	userID := q.Get("user_id")
+	if userID != "" {
+		// Verify user exists
+		if _, err := h.users.GetUser(r.Context(), userID); err != nil {
+			httputil.NotFound(w, "user not found")
+			return
+		}
+	}
+
+	todos, err := h.todos.SearchSharedTodos(r.Context(), userID, query, limit)
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+
+	httputil.WriteJSON(w, map[string]any{"todos": todos}, http.StatusOK)
+}
@@ -0,0 +1,108 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/akhilmk/packup/internal/audit"
+	"github.com/akhilmk/packup/internal/auth"
+	"github.com/akhilmk/packup/internal/httputil"
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// ListTags returns the tag palette, and optionally every tag in use.
+// @Summary List tags
+// @Description Get the palette of curated tags by name, or with all=true also every other tag on a live todo.
+// @Tags admin
+// @Produce json
+// @Param all query bool false "Include tags that are in use but not curated"
+// @Success 200 {object} map[string][]models.Tag
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/tags [get]
+func (h *Handler) ListTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.tags.ListTags(r.Context(), r.URL.Query().Get("all") == "true")
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+
+	httputil.WriteJSON(w, map[string]any{"tags": tags}, http.StatusOK)
+}
+
+// SaveTag adds a tag to the palette or changes its color.
+// @Summary Save curated tag
+// @Description Add a tag to the palette offered to users, or change its color. The name is normalized to lower case.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param name path string true "Tag name"
+// @Param tag body object false "Display color as #rrggbb"
+// @Success 200 {object} models.Tag
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/tags/{name} [put]
+func (h *Handler) SaveTag(w http.ResponseWriter, r *http.Request) {
+	name, ok := models.NormalizeTag(r.PathValue("name"))
+	if !ok {
+		httputil.BadRequest(w, models.ErrInvalidTags.Error())
+		return
+	}
+
+	var req struct {
+		Color string `json:"color"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			httputil.BadRequest(w, "invalid json")
+			return
+		}
+	}
+	if !models.ValidateColor(req.Color) {
+		httputil.BadRequest(w, "color must be of the form #rrggbb")
+		return
+	}
+
+	tag := models.Tag{Name: name, Color: req.Color, Curated: true}
+	if err := h.tags.SaveCuratedTag(r.Context(), tag); err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	adminID, _ := auth.GetUserID(r.Context())
+	h.audit.Record(r.Context(), audit.Event{ActorID: adminID, Action: models.AuditTagSave, After: tag})
+
+	httputil.WriteJSON(w, tag, http.StatusOK)
+}
+
+// RemoveTag takes a tag out of the palette.
+// @Summary Remove curated tag
+// @Description Take a tag out of the palette offered to users. Todos keep the tag.
+// @Tags admin
+// @Produce json
+// @Param name path string true "Tag name"
+// @Success 200 {object} map[string]bool
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/tags/{name} [delete]
+func (h *Handler) RemoveTag(w http.ResponseWriter, r *http.Request) {
+	name, _ := models.NormalizeTag(r.PathValue("name"))
+	if err := h.tags.RemoveCuratedTag(r.Context(), name); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			httputil.NotFound(w, "tag not in palette")
+			return
+		}
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	adminID, _ := auth.GetUserID(r.Context())
+	h.audit.Record(r.Context(), audit.Event{ActorID: adminID, Action: models.AuditTagRemove, Before: models.Tag{Name: name, Curated: true}})
+
+	httputil.WriteSuccess(w)
+}
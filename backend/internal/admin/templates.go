@@ -0,0 +1,359 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/akhilmk/packup/internal/audit"
+	"github.com/akhilmk/packup/internal/auth"
+	"github.com/akhilmk/packup/internal/httputil"
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+	"github.com/google/uuid"
+)
+
+// templateRequest is the body of the template create and update endpoints.
+type templateRequest struct {
+	Name  string                `json:"name"`
+	Tasks []models.TemplateTask `json:"tasks"`
+}
+
+// ListTemplates returns every checklist template.
+// @Summary List checklist templates
+// @Description Get every checklist template with its tasks, by name.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} map[string][]models.Template
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/templates [get]
+func (h *Handler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := h.templates.ListTemplates(r.Context())
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+
+	httputil.WriteJSON(w, map[string][]models.Template{"templates": templates}, http.StatusOK)
+}
+
+// GetTemplate returns a checklist template.
+// @Summary Get checklist template
+// @Description Get a checklist template with its tasks in order.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Template ID"
+// @Success 200 {object} models.Template
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/templates/{id} [get]
+func (h *Handler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	t, ok := h.getTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	httputil.WriteJSON(w, t, http.StatusOK)
+}
+
+// CreateTemplate creates a checklist template.
+// @Summary Create checklist template
+// @Description Create a named, ordered checklist of tasks that can be applied to users. Each task has a text, an optional markdown description and a priority.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param template body object true "Template name and tasks"
+// @Success 201 {object} models.Template
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/templates [post]
+func (h *Handler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	var req templateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.BadRequest(w, "invalid json")
+		return
+	}
+	for i := range req.Tasks {
+		req.Tasks[i].ID = uuid.NewString()
+	}
+	t, err := models.NormalizeTemplate(models.Template{Name: req.Name, Tasks: req.Tasks})
+	if err != nil {
+		httputil.BadRequest(w, err.Error())
+		return
+	}
+
+	t.ID = uuid.NewString()
+	t.CreatedAt = time.Now()
+	t.UpdatedAt = t.CreatedAt
+	if err := h.templates.SaveTemplate(r.Context(), t); err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	adminID, _ := auth.GetUserID(r.Context())
+	h.audit.Record(r.Context(), audit.Event{ActorID: adminID, Action: models.AuditTemplateSave, After: t})
+
+	httputil.WriteJSON(w, t, http.StatusCreated)
+}
+
+// UpdateTemplate replaces the name and tasks of a checklist template.
+// @Summary Update checklist template
+// @Description Replace the name and tasks of a checklist template. Tasks keep their identity by id; tasks without one are new. Todos of removed tasks stay in users' lists but are no longer synced. Changes reach users already given the template when it is synced.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Template ID"
+// @Param template body object true "Template name and tasks"
+// @Success 200 {object} models.Template
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/templates/{id} [put]
+func (h *Handler) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	before, ok := h.getTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	var req templateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.BadRequest(w, "invalid json")
+		return
+	}
+	for i, task := range req.Tasks {
+		if task.ID == "" {
+			req.Tasks[i].ID = uuid.NewString()
+		} else if !slices.ContainsFunc(before.Tasks, func(old models.TemplateTask) bool { return old.ID == task.ID }) {
+			httputil.BadRequest(w, "task "+task.ID+" is not part of this template")
+			return
+		}
+	}
+	t, err := models.NormalizeTemplate(models.Template{Name: req.Name, Tasks: req.Tasks})
+	if err != nil {
+		httputil.BadRequest(w, err.Error())
+		return
+	}
+
+	t.ID = before.ID
+	t.CreatedAt = before.CreatedAt
+	t.UpdatedAt = time.Now()
+	if err := h.templates.SaveTemplate(r.Context(), t); err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	adminID, _ := auth.GetUserID(r.Context())
+	h.audit.Record(r.Context(), audit.Event{ActorID: adminID, Action: models.AuditTemplateSave, Before: before, After: t})
+
+	httputil.WriteJSON(w, t, http.StatusOK)
+}
+
+// DeleteTemplate deletes a checklist template.
+// @Summary Delete checklist template
+// @Description Delete a checklist template. Todos it added stay in users' lists.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Template ID"
+// @Success 200 {object} map[string]bool
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/templates/{id} [delete]
+func (h *Handler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	before, ok := h.getTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.templates.DeleteTemplate(r.Context(), before.ID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			httputil.NotFound(w, "template not found")
+			return
+		}
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	adminID, _ := auth.GetUserID(r.Context())
+	h.audit.Record(r.Context(), audit.Event{ActorID: adminID, Action: models.AuditTemplateDelete, Before: before})
+
+	httputil.WriteSuccess(w)
+}
+
+// ApplyTemplate applies a checklist template to users.
+// @Summary Apply checklist template
+// @Description Add the template's tasks, in order and on top of each user's list, as todos created by the admin and shared with admins. Tasks a user already has from the template are synced instead of added again, so applying twice is safe.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Template ID"
+// @Param users body object true "IDs of the users to apply the template to, as user_ids"
+// @Success 200 {object} map[string]int
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/templates/{id}/apply [post]
+func (h *Handler) ApplyTemplate(w http.ResponseWriter, r *http.Request) {
+	t, ok := h.getTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		UserIDs []string `json:"user_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.BadRequest(w, "invalid json")
+		return
+	}
+	if len(req.UserIDs) == 0 {
+		httputil.BadRequest(w, "user_ids required")
+		return
+	}
+	for _, id := range req.UserIDs {
+		user, err := h.users.GetUser(r.Context(), id)
+		if err != nil || user.Role == string(models.RoleAdmin) {
+			httputil.BadRequest(w, "user "+id+" not found")
+			return
+		}
+	}
+
+	adminID, _ := auth.GetUserID(r.Context())
+	var added, updated int
+	for _, id := range req.UserIDs {
+		if err := h.templates.AddTemplateUser(r.Context(), t.ID, id); err != nil {
+			httputil.InternalError(w, err.Error())
+			return
+		}
+		a, u, err := h.syncTemplate(r.Context(), adminID, id, t)
+		if err != nil {
+			httputil.InternalError(w, err.Error())
+			return
+		}
+		added += a
+		updated += u
+	}
+
+	httputil.WriteJSON(w, map[string]int{"added": added, "updated": updated}, http.StatusOK)
+}
+
+// SyncTemplate re-syncs the users a checklist template was applied to.
+// @Summary Sync checklist template
+// @Description Bring the todos of every user the template was applied to in line with it: the text, description and priority of changed tasks are updated and new tasks are added. Progress is kept, and tasks a user deleted are not added back.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Template ID"
+// @Success 200 {object} map[string]int
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/templates/{id}/sync [post]
+func (h *Handler) SyncTemplate(w http.ResponseWriter, r *http.Request) {
+	t, ok := h.getTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	users, err := h.templates.ListTemplateUsers(r.Context(), t.ID)
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	adminID, _ := auth.GetUserID(r.Context())
+	var added, updated int
+	for _, id := range users {
+		a, u, err := h.syncTemplate(r.Context(), adminID, id, t)
+		if err != nil {
+			httputil.InternalError(w, err.Error())
+			return
+		}
+		added += a
+		updated += u
+	}
+
+	httputil.WriteJSON(w, map[string]int{"added": added, "updated": updated}, http.StatusOK)
+}
+
+// getTemplate loads the template named by the id path value. If there is
+// none, it writes an error and returns false.
+func (h *Handler) getTemplate(w http.ResponseWriter, r *http.Request) (models.Template, bool) {
+	t, err := h.templates.GetTemplate(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			httputil.NotFound(w, "template not found")
+			return t, false
+		}
+		httputil.InternalError(w, err.Error())
+		return t, false
+	}
+	return t, true
+}
+
+// syncTemplate brings the user's todos from template t in line with it. Todos
+// whose task changed get its text, description and priority; tasks the user
+// has no todo for are added, in order, on top of the list. Trashed todos count
+// as present, so tasks the user deleted are not added back.
+func (h *Handler) syncTemplate(ctx context.Context, adminID, userID string, t models.Template) (added, updated int, err error) {
+	todos, err := h.templates.ListTemplateTodos(ctx, t.ID, userID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	present := map[string]bool{}
+	for _, todo := range todos {
+		present[*todo.TemplateTaskID] = true
+		if todo.DeletedAt != nil {
+			continue
+		}
+		i := slices.IndexFunc(t.Tasks, func(task models.TemplateTask) bool { return task.ID == *todo.TemplateTaskID })
+		if i < 0 {
+			// The task was removed from the template since t was read
+			continue
+		}
+		task := t.Tasks[i]
+		if todo.Text == task.Text && todo.Description == task.Description && todo.Priority == task.Priority {
+			continue
+		}
+		update := store.TodoUpdate{Text: &task.Text, Description: &task.Description, Priority: &task.Priority, ActorID: adminID}
+		if err := h.todos.UpdateTodo(ctx, todo.ID, update); err != nil {
+			return added, updated, err
+		}
+		after, err := h.todos.GetTodo(ctx, todo.ID)
+		if err != nil {
+			return added, updated, err
+		}
+		h.audit.Record(ctx, audit.Event{ActorID: adminID, Action: models.AuditTodoUpdate, UserID: userID, TodoID: todo.ID, Before: todo, After: after})
+		updated++
+	}
+
+	// New todos go on top, so add the last task first to keep them in order
+	for i := len(t.Tasks) - 1; i >= 0; i-- {
+		task := t.Tasks[i]
+		if present[task.ID] {
+			continue
+		}
+		todo := models.Todo{
+			Text:           task.Text,
+			Description:    task.Description,
+			Priority:       task.Priority,
+			TemplateTaskID: &task.ID,
+		}
+		if err := h.createUserTodo(ctx, adminID, userID, &todo); err != nil {
+			return added, updated, err
+		}
+		added++
+	}
+	return added, updated, nil
+}
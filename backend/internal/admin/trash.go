@@ -0,0 +1,188 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/akhilmk/packup/internal/audit"
+	"github.com/akhilmk/packup/internal/auth"
+	"github.com/akhilmk/packup/internal/httputil"
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// ListTrash returns deleted global default tasks.
+// @Summary List deleted default tasks
+// @Description Get the global default tasks in the trash, most recently deleted first.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} map[string][]models.Todo
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/trash [get]
+func (h *Handler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	todos, err := h.todos.ListDeletedTodos(r.Context(), "")
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+
+	httputil.WriteJSON(w, map[string]any{"todos": todos}, http.StatusOK)
+}
+
+// RestoreAdminTodo restores a deleted global default task.
+// @Summary Restore global default task
+// @Description Move a global default task out of the trash, along with the subtasks deleted with it. Every user's status and position for it is restored as it was. A subtask can't be restored while its parent is in the trash.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Todo ID"
+// @Success 200 {object} models.Todo
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 409 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/todos/{id}/restore [post]
+func (h *Handler) RestoreAdminTodo(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		httputil.BadRequest(w, "id required")
+		return
+	}
+
+	// Verify it's a deleted default task
+	t, err := h.todos.GetDeletedTodo(r.Context(), id)
+	if err != nil {
+		httputil.NotFound(w, "todo not found in trash")
+		return
+	}
+	if !t.IsDefaultTask {
+		httputil.BadRequest(w, "not a default task")
+		return
+	}
+
+	h.restore(w, r, t)
+}
+
+// ListUserTrash returns a user's deleted todos that are visible to admins.
+// @Summary List user's deleted todos
+// @Description Get a specific user's deleted personal todos that are shared with admins.
+// @Tags admin
+// @Produce json
+// @Param userId path string true "User ID"
+// @Success 200 {object} map[string][]models.Todo
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/users/{userId}/trash [get]
+func (h *Handler) ListUserTrash(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("userId")
+	if userID == "" {
+		httputil.BadRequest(w, "userId required")
+		return
+	}
+
+	// Verify user exists
+	if _, err := h.users.GetUser(r.Context(), userID); err != nil {
+		httputil.NotFound(w, "user not found")
+		return
+	}
+
+	deleted, err := h.todos.ListDeletedTodos(r.Context(), userID)
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+
+	// Private todos stay private in the trash too
+	todos := []models.Todo{}
+	for _, t := range deleted {
+		if t.SharedWithAdmin {
+			todos = append(todos, t)
+		}
+	}
+
+	httputil.WriteJSON(w, map[string]any{"todos": todos}, http.StatusOK)
+}
+
+// RestoreUserTodo restores a deleted admin-created todo for a user.
+// @Summary Restore todo for user
+// @Description Move a personal todo that an admin created for the user out of the trash, along with the subtasks deleted with it. A subtask can't be restored while its parent is in the trash.
+// @Tags admin
+// @Produce json
+// @Param userId path string true "User ID"
+// @Param todoId path string true "Todo ID"
+// @Success 200 {object} models.Todo
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 409 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/users/{userId}/todos/{todoId}/restore [post]
+func (h *Handler) RestoreUserTodo(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("userId")
+	todoID := r.PathValue("todoId")
+	if userID == "" || todoID == "" {
+		httputil.BadRequest(w, "userId and todoId required")
+		return
+	}
+
+	t, err := h.todos.GetDeletedTodo(r.Context(), todoID)
+	if err != nil {
+		httputil.NotFound(w, "todo not found in trash")
+		return
+	}
+
+	// Cannot restore default tasks through this endpoint
+	if t.IsDefaultTask {
+		httputil.BadRequest(w, "use the default task endpoint to restore default tasks")
+		return
+	}
+
+	// Verify the todo belongs to the specified user
+	if t.UserID == nil || *t.UserID != userID {
+		httputil.Forbidden(w, "todo does not belong to this user")
+		return
+	}
+
+	// Only allow restoring admin-created tasks, matching what admins can delete
+	if t.CreatedByUserID == nil || *t.CreatedByUserID == userID {
+		httputil.Forbidden(w, "can only restore admin-created tasks")
+		return
+	}
+
+	h.restore(w, r, t)
+}
+
+// restore moves deleted out of the trash and writes it to the response.
+func (h *Handler) restore(w http.ResponseWriter, r *http.Request, deleted models.Todo) {
+	if deleted.ParentID != nil {
+		if _, err := h.todos.GetDeletedTodo(r.Context(), *deleted.ParentID); err == nil {
+			httputil.Conflict(w, "restore the parent todo first")
+			return
+		}
+	}
+
+	if err := h.todos.RestoreTodo(r.Context(), deleted.ID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			httputil.NotFound(w, "todo not found in trash")
+			return
+		}
+		httputil.InternalError(w, err.Error())
+		return
+	}
+
+	t, err := h.todos.GetTodo(r.Context(), deleted.ID)
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	adminID, _ := auth.GetUserID(r.Context())
+	h.audit.Record(r.Context(), audit.Event{ActorID: adminID, Action: models.AuditTodoRestore, UserID: audit.TodoOwner(t, ""), TodoID: t.ID, Before: deleted, After: t})
+
+	httputil.WriteJSON(w, t, http.StatusOK)
+}
@@ -0,0 +1,65 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/akhilmk/packup/internal/audit"
+	"github.com/akhilmk/packup/internal/auth"
+	"github.com/akhilmk/packup/internal/httputil"
+	"github.com/akhilmk/packup/internal/models"
+)
+
+// GetWorkflow returns the status workflow.
+// @Summary Get status workflow
+// @Description Get the statuses todos can be in, built-in ones first, and the statuses each can move to. Without transitions, any status can move to any other.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.Workflow
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/workflow [get]
+func (h *Handler) GetWorkflow(w http.ResponseWriter, r *http.Request) {
+	httputil.WriteWorkflow(w, r, h.workflow)
+}
+
+// SaveWorkflow replaces the status workflow.
+// @Summary Save status workflow
+// @Description Replace the statuses todos can be in and the statuses each can move to. Pending, in-progress and done are always included, and done, the status in which a todo counts as finished, must be reachable from pending. Without transitions, any status can move to any other; otherwise a status without transitions cannot be left. Todos in a removed status keep it until they are moved to another.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param workflow body models.Workflow true "Statuses and transitions"
+// @Success 200 {object} models.Workflow
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/admin/workflow [put]
+func (h *Handler) SaveWorkflow(w http.ResponseWriter, r *http.Request) {
+	var req models.Workflow
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.BadRequest(w, "invalid json")
+		return
+	}
+	workflow, err := models.NormalizeWorkflow(req)
+	if err != nil {
+		httputil.BadRequest(w, err.Error())
+		return
+	}
+
+	before, err := h.workflow.GetWorkflow(r.Context())
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	if err := h.workflow.SaveWorkflow(r.Context(), workflow); err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	adminID, _ := auth.GetUserID(r.Context())
+	h.audit.Record(r.Context(), audit.Event{ActorID: adminID, Action: models.AuditWorkflowUpdate, Before: before, After: workflow})
+
+	httputil.WriteJSON(w, workflow, http.StatusOK)
+}
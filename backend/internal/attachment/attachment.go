@@ -0,0 +1,158 @@
+// Package attachment receives and serves the contents of todo attachments
+// for the user and admin handlers.
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/akhilmk/packup/internal/httputil"
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// FormField is the multipart form field carrying the uploaded file.
+const FormField = "file"
+
+// formOverhead is the room left for the multipart framing and any other
+// fields around the file.
+const formOverhead = 64 << 10
+
+// sniffLength is how much of a file http.DetectContentType looks at.
+const sniffLength = 512
+
+// Receive reads the file uploaded in the FormField of a multipart request
+// and stores its contents in blobs under a.ID, filling in a's file name,
+// content type and size. The content type is sniffed from the contents
+// rather than trusted from the client, and must be one of
+// models.AttachmentTypes. It writes the error response and returns false if
+// the upload is rejected.
+func Receive(w http.ResponseWriter, r *http.Request, blobs store.BlobStore, a *models.Attachment) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, models.MaxAttachmentSize+formOverhead)
+	form, err := r.MultipartReader()
+	if err != nil {
+		httputil.BadRequest(w, "expected a multipart/form-data upload")
+		return false
+	}
+
+	var part io.Reader
+	var filename string
+	for {
+		p, err := form.NextPart()
+		if err != nil {
+			if tooLarge(err) {
+				httputil.TooLarge(w, sizeError)
+			} else {
+				httputil.BadRequest(w, fmt.Sprintf("%s field required", FormField))
+			}
+			return false
+		}
+		if p.FormName() == FormField {
+			part, filename = p, p.FileName()
+			break
+		}
+	}
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(part, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		if tooLarge(err) {
+			httputil.TooLarge(w, sizeError)
+		} else {
+			httputil.BadRequest(w, "failed to read upload")
+		}
+		return false
+	}
+	if n == 0 {
+		httputil.BadRequest(w, "file is empty")
+		return false
+	}
+	head = head[:n]
+
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if !slices.Contains(models.AttachmentTypes, contentType) {
+		httputil.UnsupportedMediaType(w, fmt.Sprintf("files of type %s cannot be attached; allowed types are %s", contentType, strings.Join(models.AttachmentTypes, ", ")))
+		return false
+	}
+
+	// Read one byte past the limit to tell a full-sized file from a larger one
+	body := &countingReader{r: io.LimitReader(io.MultiReader(bytes.NewReader(head), part), models.MaxAttachmentSize+1)}
+	if err := blobs.PutBlob(r.Context(), a.ID, body); err != nil {
+		if tooLarge(err) {
+			httputil.TooLarge(w, sizeError)
+			return false
+		}
+		httputil.InternalError(w, err.Error())
+		return false
+	}
+	if body.n > models.MaxAttachmentSize {
+		Delete(r.Context(), blobs, a.ID)
+		httputil.TooLarge(w, sizeError)
+		return false
+	}
+
+	a.Filename = models.CleanFilename(filename)
+	a.ContentType = contentType
+	a.Size = body.n
+	return true
+}
+
+// sizeError is the message for uploads over models.MaxAttachmentSize.
+var sizeError = fmt.Sprintf("files must be at most %d MiB", models.MaxAttachmentSize>>20)
+
+// tooLarge reports whether err comes from reading past http.MaxBytesReader.
+func tooLarge(err error) bool {
+	var maxErr *http.MaxBytesError
+	return errors.As(err, &maxErr)
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Serve writes the contents of an attachment as a download.
+func Serve(w http.ResponseWriter, r *http.Request, blobs store.BlobStore, a models.Attachment) {
+	f, err := blobs.OpenBlob(r.Context(), a.ID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			httputil.NotFound(w, "attachment contents not found")
+			return
+		}
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(a.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if _, err := io.Copy(w, f); err != nil {
+		log.Printf("Failed to send attachment %s: %v", a.ID, err)
+	}
+}
+
+// Delete removes the contents of an attachment. Failures are logged rather
+// than returned because the attachment itself is gone by then.
+func Delete(ctx context.Context, blobs store.BlobStore, id string) {
+	if err := blobs.DeleteBlob(ctx, id); err != nil {
+		log.Printf("Failed to delete attachment contents %s: %v", id, err)
+	}
+}
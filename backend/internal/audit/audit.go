@@ -0,0 +1,87 @@
+// Package audit records who changed which todo or user in the audit log.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// Event describes a change to record. Empty IDs and nil values are omitted.
+type Event struct {
+	// ActorID is the user who made the change; empty for system changes.
+	ActorID string
+	Action  models.AuditAction
+	// UserID is the user whose data changed.
+	UserID string
+	TodoID string
+	// Before and After are snapshots of the changed record, stored as JSON.
+	Before any
+	After  any
+}
+
+// Recorder writes events to an AuditStore. A nil Recorder records nothing.
+type Recorder struct {
+	events store.AuditStore
+}
+
+// NewRecorder returns a Recorder writing to events.
+func NewRecorder(events store.AuditStore) *Recorder {
+	return &Recorder{events: events}
+}
+
+// Record stores e. Failures are logged rather than returned because the
+// change itself has already been made by the time it is recorded.
+func (r *Recorder) Record(ctx context.Context, e Event) {
+	if r == nil || r.events == nil {
+		return
+	}
+
+	event := models.AuditEvent{
+		ActorID:   optional(e.ActorID),
+		UserID:    optional(e.UserID),
+		TodoID:    optional(e.TodoID),
+		Action:    e.Action,
+		CreatedAt: time.Now(),
+	}
+	var err error
+	if event.Before, err = snapshot(e.Before); err == nil {
+		event.After, err = snapshot(e.After)
+	}
+	if err == nil {
+		err = r.events.RecordAuditEvent(ctx, &event)
+	}
+	if err != nil {
+		log.Printf("Failed to record audit event %s for todo %q: %v", e.Action, e.TodoID, err)
+	}
+}
+
+// TodoOwner returns the user whose data a change to t affects: the owner of
+// a personal todo, or userID for a default task (whose state is per user).
+func TodoOwner(t models.Todo, userID string) string {
+	if t.UserID != nil {
+		return *t.UserID
+	}
+	if t.IsDefaultTask {
+		return userID
+	}
+	return ""
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func snapshot(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
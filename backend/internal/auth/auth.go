@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,18 +13,20 @@ import (
 	"os"
 	"time"
 
+	"github.com/akhilmk/packup/internal/audit"
 	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Handler struct {
-	db *pgxpool.Pool
+	users    store.UserStore
+	sessions store.SessionStore
+	audit    *audit.Recorder
 }
 
-func NewHandler(db *pgxpool.Pool) *Handler {
-	return &Handler{db: db}
+func NewHandler(users store.UserStore, sessions store.SessionStore, events store.AuditStore) *Handler {
+	return &Handler{users: users, sessions: sessions, audit: audit.NewRecorder(events)}
 }
 
 // RegisterRoutes registers auth routes interactively
@@ -152,7 +155,7 @@ func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 	cookie, err := r.Cookie("session_token")
 	if err == nil {
-		h.db.Exec(r.Context(), "DELETE FROM sessions WHERE token=$1", cookie.Value)
+		h.sessions.DeleteSession(r.Context(), cookie.Value)
 	}
 
 	http.SetCookie(w, &http.Cookie{
@@ -316,12 +319,10 @@ func (h *Handler) getGoogleUser(token string) (googleUser, error) {
 }
 
 func (h *Handler) getOrCreateUser(ctx context.Context, gu googleUser) (models.User, error) {
-	var user models.User
 	// Check if exists
-	err := h.db.QueryRow(ctx, "SELECT id, google_id, email, name, avatar_url, role, created_at FROM users WHERE google_id=$1", gu.ID).
-		Scan(&user.ID, &user.GoogleID, &user.Email, &user.Name, &user.AvatarURL, &user.Role, &user.CreatedAt)
+	user, err := h.users.GetUserByGoogleID(ctx, gu.ID)
 
-	if err == pgx.ErrNoRows {
+	if errors.Is(err, store.ErrNotFound) {
 		// Determine role based on admin emails
 		role := determineUserRole(gu.Email)
 
@@ -335,14 +336,20 @@ func (h *Handler) getOrCreateUser(ctx context.Context, gu googleUser) (models.Us
 			Role:      role,
 			CreatedAt: time.Now(),
 		}
-		_, err = h.db.Exec(ctx, "INSERT INTO users(id, google_id, email, name, avatar_url, role, created_at) VALUES($1,$2,$3,$4,$5,$6,$7)",
-			user.ID, user.GoogleID, user.Email, user.Name, user.AvatarURL, user.Role, user.CreatedAt)
+		err = h.users.CreateUser(ctx, user)
+		if err == nil {
+			h.audit.Record(ctx, audit.Event{ActorID: user.ID, Action: models.AuditUserCreate, UserID: user.ID, After: user})
+		}
 	} else if err == nil {
 		// Update role if it changed (in case admin emails were updated)
 		newRole := determineUserRole(gu.Email)
 		if newRole != user.Role {
+			before := user
 			user.Role = newRole
-			_, _ = h.db.Exec(ctx, "UPDATE users SET role=$1 WHERE id=$2", user.Role, user.ID)
+			if h.users.UpdateUserRole(ctx, user.ID, user.Role) == nil {
+				// Roles follow ADMIN_EMAILS, so the system is the actor
+				h.audit.Record(ctx, audit.Event{Action: models.AuditUserRoleChange, UserID: user.ID, Before: before, After: user})
+			}
 		}
 	}
 
@@ -355,18 +362,10 @@ func (h *Handler) createSession(ctx context.Context, userID string) (string, err
 	token := base64.URLEncoding.EncodeToString(b)
 	expiresAt := time.Now().Add(24 * time.Hour)
 
-	_, err := h.db.Exec(ctx, "INSERT INTO sessions(token, user_id, expires_at) VALUES($1,$2,$3)", token, userID, expiresAt)
+	err := h.sessions.CreateSession(ctx, token, userID, expiresAt)
 	return token, err
 }
 
 func (h *Handler) getUserBySession(ctx context.Context, token string) (models.User, error) {
-	var user models.User
-	err := h.db.QueryRow(ctx, `
-		SELECT u.id, u.google_id, u.email, u.name, u.avatar_url, u.role, u.created_at 
-		FROM sessions s
-		JOIN users u ON s.user_id = u.id
-		WHERE s.token = $1 AND s.expires_at > now()
-	`, token).Scan(&user.ID, &user.GoogleID, &user.Email, &user.Name, &user.AvatarURL, &user.Role, &user.CreatedAt)
-
-	return user, err
+	return h.sessions.GetSessionUser(ctx, token)
 }
@@ -6,8 +6,11 @@ import (
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+	"github.com/akhilmk/packup/internal/store/memory"
 )
 
 // TestDetermineUserRole tests the role determination logic
@@ -74,7 +77,7 @@ func TestDetermineUserRole(t *testing.T) {
 
 // TestMiddlewareUnauthorized tests the auth middleware without cookie
 func TestMiddlewareUnauthorized(t *testing.T) {
-	handler := &Handler{db: nil}
+	handler := &Handler{}
 
 	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -94,7 +97,7 @@ func TestMiddlewareUnauthorized(t *testing.T) {
 
 // TestMeUnauthorized tests the Me endpoint without authentication
 func TestMeUnauthorized(t *testing.T) {
-	handler := &Handler{db: nil}
+	handler := &Handler{}
 
 	req := httptest.NewRequest("GET", "/api/auth/me", nil)
 	w := httptest.NewRecorder()
@@ -108,7 +111,7 @@ func TestMeUnauthorized(t *testing.T) {
 
 // TestLogout tests the logout endpoint
 func TestLogout(t *testing.T) {
-	handler := &Handler{db: nil}
+	handler := &Handler{}
 
 	t.Run("Logout without cookie", func(t *testing.T) {
 		req := httptest.NewRequest("POST", "/api/auth/logout", nil)
@@ -138,7 +141,7 @@ func TestLogout(t *testing.T) {
 
 // TestGoogleLoginMissingConfig tests login with missing OAuth config
 func TestGoogleLoginMissingConfig(t *testing.T) {
-	handler := &Handler{db: nil}
+	handler := &Handler{}
 
 	// Save and restore original env
 	originalClientID := os.Getenv("GOOGLE_CLIENT_ID")
@@ -163,7 +166,7 @@ func TestGoogleLoginMissingConfig(t *testing.T) {
 
 // TestGoogleCallbackMissingCode tests callback without code
 func TestGoogleCallbackMissingCode(t *testing.T) {
-	handler := &Handler{db: nil}
+	handler := &Handler{}
 
 	req := httptest.NewRequest("GET", "/api/auth/google/callback", nil)
 	w := httptest.NewRecorder()
@@ -219,3 +222,90 @@ func TestContextValues(t *testing.T) {
 		}
 	})
 }
+
+// TestMiddlewareWithSession tests that a valid session populates the context
+func TestMiddlewareWithSession(t *testing.T) {
+	db := memory.New()
+	handler := NewHandler(db, db, db)
+	ctx := context.Background()
+
+	db.CreateUser(ctx, models.User{ID: "user-1", GoogleID: "g-1", Email: "user@example.com", Role: "user"})
+	token, err := handler.createSession(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	db.CreateSession(ctx, "expired", "user-1", time.Now().Add(-time.Minute))
+
+	next := func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := GetUserID(r.Context())
+		w.Write([]byte(userID))
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		expected int
+	}{
+		{"Valid session", token, http.StatusOK},
+		{"Expired session", "expired", http.StatusUnauthorized},
+		{"Unknown session", "unknown", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/todos", nil)
+			req.AddCookie(&http.Cookie{Name: "session_token", Value: tt.token})
+			w := httptest.NewRecorder()
+
+			handler.Middleware(next)(w, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, w.Code)
+			}
+			if tt.expected == http.StatusOK && w.Body.String() != "user-1" {
+				t.Errorf("Expected user-1 in context, got '%s'", w.Body.String())
+			}
+		})
+	}
+}
+
+// TestGetOrCreateUser tests user creation and role refresh on login
+func TestGetOrCreateUser(t *testing.T) {
+	db := memory.New()
+	handler := NewHandler(db, db, db)
+	ctx := context.Background()
+
+	original := os.Getenv("ADMIN_EMAILS")
+	defer os.Setenv("ADMIN_EMAILS", original)
+	os.Setenv("ADMIN_EMAILS", "")
+
+	gu := googleUser{ID: "g-1", Email: "boss@example.com", Name: "Boss"}
+	created, err := handler.getOrCreateUser(ctx, gu)
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	if created.Role != "user" {
+		t.Errorf("Expected role 'user', got '%s'", created.Role)
+	}
+
+	os.Setenv("ADMIN_EMAILS", "boss@example.com")
+	again, err := handler.getOrCreateUser(ctx, gu)
+	if err != nil {
+		t.Fatalf("Failed to get user: %v", err)
+	}
+	if again.ID != created.ID {
+		t.Errorf("Expected same user ID '%s', got '%s'", created.ID, again.ID)
+	}
+	stored, _ := db.GetUser(ctx, created.ID)
+	if stored.Role != "admin" {
+		t.Errorf("Expected stored role to be promoted to 'admin', got '%s'", stored.Role)
+	}
+
+	events, _ := db.ListAuditEvents(ctx, store.AuditFilter{UserID: created.ID})
+	if len(events) != 2 || events[0].Action != models.AuditUserRoleChange || events[1].Action != models.AuditUserCreate {
+		t.Fatalf("Expected role change and create events, got %+v", events)
+	}
+	if events[0].ActorID != nil {
+		t.Errorf("Expected role change to be attributed to the system, got actor %q", *events[0].ActorID)
+	}
+}
@@ -2,17 +2,61 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
-	"log"
+	"net/url"
 	"os"
+	"strings"
 
+	"github.com/akhilmk/packup/internal/store"
+	"github.com/akhilmk/packup/internal/store/postgres"
+	"github.com/akhilmk/packup/internal/store/sqlite"
+	"github.com/akhilmk/packup/migrations"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	_ "modernc.org/sqlite"
 )
 
-func New(ctx context.Context) (*pgxpool.Pool, error) {
+// sqliteScheme selects the SQLite backend, e.g. DATABASE_URL=sqlite:///data/packup.db.
+const sqliteScheme = "sqlite://"
+
+// DB is an open database with the store and migrator for its backend.
+type DB struct {
+	store.Store
+	Migrator *Migrator
+	close    func()
+}
+
+// Close releases the underlying connections.
+func (db *DB) Close() {
+	db.close()
+}
+
+// New connects to the database and applies any pending migrations.
+func New(ctx context.Context) (*DB, error) {
+	db, err := Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Migrator.Up(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Connect opens the database without touching the schema. A DATABASE_URL
+// starting with sqlite:// selects SQLite; anything else is PostgreSQL.
+func Connect(ctx context.Context) (*DB, error) {
 
 	// The application relies on environment variables provided by the OS/Docker runtime.
 	dbURL := os.Getenv("DATABASE_URL")
+	if strings.HasPrefix(dbURL, sqliteScheme) {
+		return OpenSQLite(ctx, strings.TrimPrefix(dbURL, sqliteScheme))
+	}
+
 	if dbURL == "" {
 		user := os.Getenv("DB_USER")
 		pass := os.Getenv("DB_PASS")
@@ -47,47 +91,40 @@ func New(ctx context.Context) (*pgxpool.Pool, error) {
 	if err != nil {
 		return nil, err
 	}
-
-	// Auto-load schema if tables don't exist
-	if err := ensureSchema(ctx, pool); err != nil {
+	migrator, err := NewPostgresMigrator(pool, migrations.Postgres)
+	if err != nil {
 		pool.Close()
 		return nil, err
 	}
-
-	return pool, nil
+	return &DB{Store: postgres.New(pool), Migrator: migrator, close: pool.Close}, nil
 }
 
-func ensureSchema(ctx context.Context, db *pgxpool.Pool) error {
-	// Check if tables exist
-	var exists bool
-	err := db.QueryRow(ctx, "SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_schema = 'public' AND table_name = 'users')").Scan(&exists)
-	if err != nil {
-		return err
-	}
-
-	// If tables already exist, skip schema loading
-	// If tables already exist, we still want to run the schema to ensure new columns/tables are added.
-	// The schema file should be idempotent (using IF NOT EXISTS).
-	if exists {
-		log.Println("Schema loaded previously, but running again to ensure updates...")
-	}
+// OpenSQLite opens the SQLite database file at path, creating it if needed.
+//
+// SQLite allows a single writer, so the handle is limited to one connection
+// and transactions take the write lock up front.
+func OpenSQLite(ctx context.Context, path string) (*DB, error) {
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Set("_time_format", "sqlite")
+	params.Set("_txlock", "immediate")
 
-	schemaPath := "migrations/schema_v1.sql"
-	schemaSQL, err := os.ReadFile(schemaPath)
+	db, err := sql.Open("sqlite", "file:"+path+"?"+params.Encode())
 	if err != nil {
-		return fmt.Errorf("schema file not found at %s: %w", schemaPath, err)
+		return nil, err
 	}
+	db.SetMaxOpenConns(1)
 
-	if schemaSQL == nil {
-		return fmt.Errorf("schema_v1.sql not found in any expected location")
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("open %s: %w", path, err)
 	}
-
-	log.Printf("Loading schema from: %s", schemaPath)
-	_, err = db.Exec(ctx, string(schemaSQL))
+	migrator, err := NewSQLiteMigrator(db, migrations.SQLite)
 	if err != nil {
-		return fmt.Errorf("failed to execute schema: %w", err)
+		db.Close()
+		return nil, err
 	}
-
-	log.Println("Schema loaded successfully")
-	return nil
+	return &DB{Store: sqlite.New(db), Migrator: migrator, close: func() { db.Close() }}, nil
 }
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationLockKey is the pg_advisory_lock key held while migrations run,
+// so that replicas starting at the same time don't migrate concurrently.
+const migrationLockKey int64 = 0x7061636b7570 // "packup"
+
+// NewPostgresMigrator loads the migrations in fsys for a PostgreSQL database.
+func NewPostgresMigrator(db *pgxpool.Pool, fsys fs.FS) (*Migrator, error) {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	withLock := func(ctx context.Context, fn func(conn migrationConn) error) error {
+		conn, err := db.Acquire(ctx)
+		if err != nil {
+			return err
+		}
+		defer conn.Release()
+
+		if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+			return fmt.Errorf("acquire migration lock: %w", err)
+		}
+		defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey)
+
+		_, err = conn.Exec(ctx, `
+			CREATE TABLE IF NOT EXISTS schema_migrations (
+				version BIGINT PRIMARY KEY,
+				name TEXT NOT NULL,
+				applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+			)
+		`)
+		if err != nil {
+			return fmt.Errorf("create schema_migrations: %w", err)
+		}
+
+		return fn(postgresMigrationConn{conn})
+	}
+
+	return &Migrator{withLock: withLock, migrations: migrations}, nil
+}
+
+// postgresMigrationConn runs migrations on a connection holding the advisory lock.
+type postgresMigrationConn struct {
+	conn *pgxpool.Conn
+}
+
+func (c postgresMigrationConn) appliedVersions(ctx context.Context) (map[int64]time.Time, error) {
+	rows, err := c.conn.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]time.Time{}
+	for rows.Next() {
+		var version int64
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+func (c postgresMigrationConn) applyUp(ctx context.Context, mig Migration) error {
+	return c.run(ctx, mig.Up, `INSERT INTO schema_migrations(version, name) VALUES($1, $2)`, mig.Version, mig.Name)
+}
+
+func (c postgresMigrationConn) applyDown(ctx context.Context, mig Migration) error {
+	return c.run(ctx, mig.Down, `DELETE FROM schema_migrations WHERE version = $1`, mig.Version)
+}
+
+// run executes sql and the bookkeeping statement in a single transaction so a
+// failed migration leaves neither a partial schema change nor a version row.
+func (c postgresMigrationConn) run(ctx context.Context, sql, record string, args ...any) error {
+	tx, err := c.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
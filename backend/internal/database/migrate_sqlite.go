@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"time"
+)
+
+// NewSQLiteMigrator loads the migrations in fsys for a SQLite database.
+//
+// SQLite has no advisory locks, so each migration instead runs in an
+// immediate (write-locked) transaction that re-checks whether its version
+// was applied by another process in the meantime.
+func NewSQLiteMigrator(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	withLock := func(ctx context.Context, fn func(conn migrationConn) error) error {
+		_, err := db.ExecContext(ctx, `
+			CREATE TABLE IF NOT EXISTS schema_migrations (
+				version INTEGER PRIMARY KEY,
+				name TEXT NOT NULL,
+				applied_at DATETIME NOT NULL
+			)
+		`)
+		if err != nil {
+			return fmt.Errorf("create schema_migrations: %w", err)
+		}
+		return fn(sqliteMigrationConn{db})
+	}
+
+	return &Migrator{withLock: withLock, migrations: migrations}, nil
+}
+
+type sqliteMigrationConn struct {
+	db *sql.DB
+}
+
+func (c sqliteMigrationConn) appliedVersions(ctx context.Context) (map[int64]time.Time, error) {
+	rows, err := c.db.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]time.Time{}
+	for rows.Next() {
+		var version int64
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+func (c sqliteMigrationConn) applyUp(ctx context.Context, mig Migration) error {
+	return c.run(ctx, mig, false, mig.Up,
+		`INSERT INTO schema_migrations(version, name, applied_at) VALUES($1, $2, $3)`,
+		mig.Version, mig.Name, time.Now().UTC())
+}
+
+func (c sqliteMigrationConn) applyDown(ctx context.Context, mig Migration) error {
+	return c.run(ctx, mig, true, mig.Down,
+		`DELETE FROM schema_migrations WHERE version = $1`, mig.Version)
+}
+
+// run executes script and the bookkeeping statement in a single transaction,
+// skipping the migration if another process got there first.
+func (c sqliteMigrationConn) run(ctx context.Context, mig Migration, wantApplied bool, script, record string, args ...any) error {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var n int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations WHERE version = $1`, mig.Version).Scan(&n); err != nil {
+		return err
+	}
+	if (n > 0) != wantApplied {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
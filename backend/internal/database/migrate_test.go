@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/akhilmk/packup/migrations"
+)
+
+// TestLoadMigrations tests parsing and ordering of migration files
+func TestLoadMigrations(t *testing.T) {
+	t.Run("Sorted by version with up and down", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"000002_add_tags.up.sql":   {Data: []byte("CREATE TABLE tags();")},
+			"000002_add_tags.down.sql": {Data: []byte("DROP TABLE tags;")},
+			"000001_init.up.sql":       {Data: []byte("CREATE TABLE users();")},
+			"README.md":                {Data: []byte("ignored")},
+		}
+
+		migrations, err := LoadMigrations(fsys)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(migrations) != 2 {
+			t.Fatalf("Expected 2 migrations, got %d", len(migrations))
+		}
+		if migrations[0].Version != 1 || migrations[0].Name != "init" {
+			t.Errorf("Expected first migration 1_init, got %d_%s", migrations[0].Version, migrations[0].Name)
+		}
+		if migrations[0].Down != "" {
+			t.Errorf("Expected no down SQL for 1_init, got %q", migrations[0].Down)
+		}
+		if migrations[1].Up != "CREATE TABLE tags();" || migrations[1].Down != "DROP TABLE tags;" {
+			t.Errorf("Unexpected SQL for 2_add_tags: %+v", migrations[1])
+		}
+	})
+
+	t.Run("Down without up is rejected", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"000001_init.down.sql": {Data: []byte("DROP TABLE users;")},
+		}
+		if _, err := LoadMigrations(fsys); err == nil {
+			t.Error("Expected error for migration without up file")
+		}
+	})
+
+	t.Run("Conflicting names are rejected", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"000001_init.up.sql":  {Data: []byte("SELECT 1;")},
+			"000001_other.up.sql": {Data: []byte("SELECT 2;")},
+		}
+		if _, err := LoadMigrations(fsys); err == nil {
+			t.Error("Expected error for conflicting migration names")
+		}
+	})
+}
+
+// TestEmbeddedMigrations tests that the embedded migrations load cleanly
+// and that every backend has the same schema versions
+func TestEmbeddedMigrations(t *testing.T) {
+	backends := map[string]fs.FS{
+		"postgres": migrations.Postgres,
+		"sqlite":   migrations.SQLite,
+	}
+
+	versions := map[string][]string{}
+	for name, fsys := range backends {
+		loaded, err := LoadMigrations(fsys)
+		if err != nil {
+			t.Fatalf("Failed to load %s migrations: %v", name, err)
+		}
+		if len(loaded) == 0 {
+			t.Fatalf("Expected at least one %s migration", name)
+		}
+		for i, m := range loaded {
+			if m.Down == "" {
+				t.Errorf("%s migration %d_%s has no down file", name, m.Version, m.Name)
+			}
+			if i > 0 && loaded[i-1].Version == m.Version {
+				t.Errorf("Duplicate %s migration version %d", name, m.Version)
+			}
+			versions[name] = append(versions[name], fmt.Sprintf("%d_%s", m.Version, m.Name))
+		}
+	}
+
+	if !slices.Equal(versions["postgres"], versions["sqlite"]) {
+		t.Errorf("Expected matching migrations, got postgres %v and sqlite %v", versions["postgres"], versions["sqlite"])
+	}
+}
+
+// TestSQLiteMigrator tests applying and rolling back the SQLite migrations
+func TestSQLiteMigrator(t *testing.T) {
+	ctx := context.Background()
+	db, err := OpenSQLite(ctx, filepath.Join(t.TempDir(), "packup.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	pending := func() int {
+		statuses, err := db.Migrator.Status(ctx)
+		if err != nil {
+			t.Fatalf("Failed to get status: %v", err)
+		}
+		n := 0
+		for _, s := range statuses {
+			if s.AppliedAt == nil {
+				n++
+			}
+		}
+		return n
+	}
+
+	total := pending()
+	if total == 0 {
+		t.Fatal("Expected pending migrations on a new database")
+	}
+
+	if err := db.Migrator.Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	if n := pending(); n != 0 {
+		t.Errorf("Expected no pending migrations after up, got %d", n)
+	}
+
+	// Running up again is a no-op
+	if err := db.Migrator.Up(ctx); err != nil {
+		t.Fatalf("Second up failed: %v", err)
+	}
+
+	if err := db.Migrator.Down(ctx, total); err != nil {
+		t.Fatalf("Down failed: %v", err)
+	}
+	if n := pending(); n != total {
+		t.Errorf("Expected %d pending migrations after down, got %d", total, n)
+	}
+
+	// The schema can be recreated after a full rollback
+	if err := db.Migrator.Up(ctx); err != nil {
+		t.Fatalf("Up after down failed: %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package httputil
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// Page sizes of cursor-paginated list endpoints.
+const (
+	DefaultPageLimit = 100
+	MaxPageLimit     = 500
+)
+
+// ParseTodoFilter reads the status, source, parent_id, tag, created_from,
+// created_to, due_from, due_to, overdue, sort, cursor and limit query
+// parameters of a todo list endpoint. Statuses must be part of workflow.
+// Errors are meant for the client.
+func ParseTodoFilter(q url.Values, workflow models.Workflow) (store.TodoFilter, error) {
+	f := store.TodoFilter{
+		Status:   q.Get("status"),
+		Source:   models.TodoSource(q.Get("source")),
+		ParentID: q.Get("parent_id"),
+	}
+	if f.Status != "" && !workflow.Has(f.Status) {
+		return f, errors.New("invalid status")
+	}
+	if f.Source != "" && !f.Source.IsValid() {
+		return f, errors.New("source must be default, admin or personal")
+	}
+
+	var err error
+	if q.Has("tag") {
+		if f.Tags, err = models.NormalizeTags(q["tag"]); err != nil {
+			return f, err
+		}
+	}
+	if v := q.Get("due_from"); v != "" {
+		if f.DueFrom, err = time.Parse(time.RFC3339, v); err != nil {
+			return f, errors.New("invalid due_from time, expected RFC 3339")
+		}
+	}
+	if v := q.Get("due_to"); v != "" {
+		if f.DueTo, err = time.Parse(time.RFC3339, v); err != nil {
+			return f, errors.New("invalid due_to time, expected RFC 3339")
+		}
+	}
+	switch q.Get("overdue") {
+	case "", "false":
+	case "true":
+		f.OverdueAt = time.Now()
+	default:
+		return f, errors.New("overdue must be true or false")
+	}
+	switch v := q.Get("sort"); v {
+	case "", "position":
+	case "due":
+		f.Sort = store.SortDue
+	case "priority":
+		f.Sort = store.SortPriority
+	default:
+		return f, errors.New("sort must be position, due or priority")
+	}
+
+	f.From, f.To, f.After, f.Limit, err = parsePage(q)
+	return f, err
+}
+
+// ParseRender reads the render query parameter of a todo endpoint, and
+// reports whether descriptions should be rendered to HTML along with their
+// markdown. Errors are meant for the client.
+func ParseRender(q url.Values) (bool, error) {
+	switch q.Get("render") {
+	case "", "markdown":
+		return false, nil
+	case "html":
+		return true, nil
+	}
+	return false, errors.New("render must be markdown or html")
+}
+
+// ParseUserFilter reads the created_from, created_to, cursor and limit query
+// parameters of a user list endpoint. Errors are meant for the client.
+func ParseUserFilter(q url.Values) (store.UserFilter, error) {
+	var f store.UserFilter
+	var err error
+	f.From, f.To, f.After, f.Limit, err = parsePage(q)
+	return f, err
+}
+
+func parsePage(q url.Values) (from, to time.Time, after *store.Cursor, limit int, err error) {
+	if v := q.Get("created_from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			return from, to, nil, 0, errors.New("invalid created_from time, expected RFC 3339")
+		}
+	}
+	if v := q.Get("created_to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			return from, to, nil, 0, errors.New("invalid created_to time, expected RFC 3339")
+		}
+	}
+	if v := q.Get("cursor"); v != "" {
+		c, err := store.ParseCursor(v)
+		if err != nil {
+			return from, to, nil, 0, err
+		}
+		after = &c
+	}
+	limit = DefaultPageLimit
+	if v := q.Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > MaxPageLimit {
+			return from, to, nil, 0, errors.New("limit must be between 1 and 500")
+		}
+	}
+	return from, to, after, limit, nil
+}
+
+// WritePage writes a page of a list endpoint under key, with the cursor of
+// the next page if there is one.
+func WritePage(w http.ResponseWriter, key string, items any, next string) {
+	resp := map[string]any{key: items}
+	if next != "" {
+		resp["next_cursor"] = next
+	}
+	WriteJSON(w, resp, http.StatusOK)
+}
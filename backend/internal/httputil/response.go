@@ -1,9 +1,10 @@
-// Package httputil provides HTTP response utilities.
+// Package httputil provides HTTP request and response utilities.
 package httputil
 
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 )
 
 // APIError represents a standard API error response.
@@ -50,8 +51,57 @@ func NotFound(w http.ResponseWriter, message string) {
 	WriteError(w, message, http.StatusNotFound)
 }
 
+// Conflict writes a 409 Conflict error response.
+func Conflict(w http.ResponseWriter, message string) {
+	WriteError(w, message, http.StatusConflict)
+}
+
+// PreconditionFailed writes a 412 Precondition Failed error response.
+func PreconditionFailed(w http.ResponseWriter, message string) {
+	WriteError(w, message, http.StatusPreconditionFailed)
+}
+
+// TooLarge writes a 413 Content Too Large error response.
+func TooLarge(w http.ResponseWriter, message string) {
+	WriteError(w, message, http.StatusRequestEntityTooLarge)
+}
+
+// UnsupportedMediaType writes a 415 Unsupported Media Type error response.
+func UnsupportedMediaType(w http.ResponseWriter, message string) {
+	WriteError(w, message, http.StatusUnsupportedMediaType)
+}
+
 // InternalError writes a 500 Internal Server Error response.
 // Note: Avoid exposing internal error details to clients in production.
 func InternalError(w http.ResponseWriter, message string) {
 	WriteError(w, message, http.StatusInternalServerError)
 }
+
+// SetETag sets the ETag header of the response.
+func SetETag(w http.ResponseWriter, etag string) {
+	w.Header().Set("ETag", etag)
+}
+
+// IfMatch reports whether the request's If-Match header matches etag.
+// Requests without the header always match.
+func IfMatch(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// IsConditional reports whether the request's If-Match header names
+// specific entity tags, so that writes should only apply to the version
+// the client has seen.
+func IsConditional(r *http.Request) bool {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	return header != "" && header != "*"
+}
@@ -0,0 +1,45 @@
+package httputil
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// WriteWorkflow writes the status workflow of ws.
+func WriteWorkflow(w http.ResponseWriter, r *http.Request, ws store.WorkflowStore) {
+	workflow, err := ws.GetWorkflow(r.Context())
+	if err != nil {
+		InternalError(w, err.Error())
+		return
+	}
+
+	WriteJSON(w, workflow, http.StatusOK)
+}
+
+// CheckTransition checks a change of t's status against the workflow of ws,
+// and that t is not marked done while blocked. If the status is unknown or
+// cannot be reached from the current one, it writes an error and returns
+// false.
+func CheckTransition(w http.ResponseWriter, r *http.Request, ws store.WorkflowStore, t models.Todo, to string) bool {
+	workflow, err := ws.GetWorkflow(r.Context())
+	if err != nil {
+		InternalError(w, err.Error())
+		return false
+	}
+	if err := workflow.CheckTransition(t.Status, to); err != nil {
+		if errors.Is(err, models.ErrUnknownStatus) {
+			BadRequest(w, err.Error())
+		} else {
+			Conflict(w, err.Error())
+		}
+		return false
+	}
+	if to == string(models.StatusDone) && t.Blocked && t.Status != to {
+		Conflict(w, "todo is blocked by unfinished todos")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,41 @@
+// Package markdown renders the markdown descriptions of todos to HTML that
+// is safe to embed in a page.
+package markdown
+
+import (
+	"bytes"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+
+	"github.com/akhilmk/packup/internal/models"
+)
+
+var (
+	// Raw HTML in the source is left out by goldmark already; the policy
+	// catches anything else, such as javascript: links.
+	md     = goldmark.New(goldmark.WithExtensions(extension.GFM))
+	policy = bluemonday.UGCPolicy()
+)
+
+// ToHTML renders src, CommonMark with GitHub's extensions, to sanitized HTML.
+func ToHTML(src string) string {
+	var buf bytes.Buffer
+	_ = md.Convert([]byte(src), &buf) // writing to a bytes.Buffer cannot fail
+	return policy.Sanitize(buf.String())
+}
+
+// RenderDescription sets t.DescriptionHTML from t.Description.
+func RenderDescription(t *models.Todo) {
+	if t.Description != "" {
+		t.DescriptionHTML = ToHTML(t.Description)
+	}
+}
+
+// RenderDescriptions is RenderDescription for each of todos.
+func RenderDescriptions(todos []models.Todo) {
+	for i := range todos {
+		RenderDescription(&todos[i])
+	}
+}
@@ -0,0 +1,47 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/akhilmk/packup/internal/models"
+)
+
+func TestToHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		contains string
+		excludes string
+	}{
+		{"Emphasis", "Bring **boots**", "<strong>boots</strong>", ""},
+		{"List", "- tent\n- stove", "<li>tent</li>", ""},
+		{"Link", "[map](https://example.com/map)", `href="https://example.com/map"`, ""},
+		{"Strikethrough", "~~skip~~", "<del>skip</del>", ""},
+		{"Raw HTML", "<script>alert(1)</script>", "", "<script"},
+		{"Event handler", `<img src="x" onerror="alert(1)">`, "", "onerror"},
+		{"Script link", "[click](javascript:alert(1))", "click", "javascript:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ToHTML(tt.src)
+			if tt.contains != "" && !strings.Contains(got, tt.contains) {
+				t.Errorf("Expected %q in %q", tt.contains, got)
+			}
+			if tt.excludes != "" && strings.Contains(got, tt.excludes) {
+				t.Errorf("Expected no %q in %q", tt.excludes, got)
+			}
+		})
+	}
+}
+
+func TestRenderDescriptions(t *testing.T) {
+	todos := []models.Todo{{ID: "a", Description: "*one*"}, {ID: "b"}}
+	RenderDescriptions(todos)
+	if todos[0].DescriptionHTML != "<p><em>one</em></p>\n" {
+		t.Errorf("Unexpected HTML %q", todos[0].DescriptionHTML)
+	}
+	if todos[1].DescriptionHTML != "" {
+		t.Errorf("Expected no HTML without a description, got %q", todos[1].DescriptionHTML)
+	}
+}
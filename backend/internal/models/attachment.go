@@ -0,0 +1,66 @@
+package models
+
+import (
+	"path"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Attachment constants.
+const (
+	// MaxAttachmentSize is the maximum size of an attachment in bytes.
+	MaxAttachmentSize = 10 << 20
+
+	// MaxFilenameLength is the maximum length of an attachment's file name.
+	MaxFilenameLength = 255
+)
+
+// AttachmentTypes are the content types attachments may have, as sniffed
+// from their contents: documents and images.
+var AttachmentTypes = []string{
+	"application/pdf",
+	"image/gif",
+	"image/jpeg",
+	"image/png",
+	"image/webp",
+	"text/plain",
+}
+
+// Attachment is a file attached to a todo. Like comments, attachments on a
+// default task belong to one user's copy of it; the contents are kept in a
+// blob store under the attachment's ID.
+type Attachment struct {
+	ID     string `json:"id"`
+	TodoID string `json:"todo_id"`
+	// UserID is the user whose todo the file is attached to.
+	UserID string `json:"user_id"`
+	// UploadedBy is the user or admin who attached the file, or nil if
+	// their account is gone.
+	UploadedBy  *string   `json:"uploaded_by"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CleanFilename returns the base name of an uploaded file without control
+// characters, shortened to MaxFilenameLength, or "attachment" if nothing is
+// left.
+func CleanFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name))
+	for len(name) > MaxFilenameLength {
+		r := []rune(name)
+		name = string(r[:len(r)-1])
+	}
+	if name == "" || name == "." || name == "/" || name == ".." {
+		return "attachment"
+	}
+	return name
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditAction identifies the kind of change an audit event records.
+type AuditAction string
+
+// Audited actions.
+const (
+	AuditTodoCreate        AuditAction = "todo.create"
+	AuditTodoUpdate        AuditAction = "todo.update"
+	AuditTodoDelete        AuditAction = "todo.delete"
+	AuditTodoRestore       AuditAction = "todo.restore"
+	AuditTodoReorder       AuditAction = "todo.reorder"
+	AuditTodoPublish       AuditAction = "todo.publish"
+	AuditUserCreate        AuditAction = "user.create"
+	AuditUserRoleChange    AuditAction = "user.role_change"
+	AuditTagSave           AuditAction = "tag.save"
+	AuditTagRemove         AuditAction = "tag.remove"
+	AuditCommentCreate     AuditAction = "comment.create"
+	AuditCommentUpdate     AuditAction = "comment.update"
+	AuditCommentDelete     AuditAction = "comment.delete"
+	AuditAttachmentAdd     AuditAction = "attachment.add"
+	AuditAttachmentDelete  AuditAction = "attachment.delete"
+	AuditWorkflowUpdate    AuditAction = "workflow.update"
+	AuditTemplateSave      AuditAction = "template.save"
+	AuditTemplateDelete    AuditAction = "template.delete"
+	AuditGroupSave         AuditAction = "group.save"
+	AuditGroupDelete       AuditAction = "group.delete"
+	AuditGroupAddMember    AuditAction = "group.add_member"
+	AuditGroupRemoveMember AuditAction = "group.remove_member"
+)
+
+// AuditEvent records a single change to a todo, its comments or attachments,
+// a user, the tag palette, the status workflow, a checklist template or a
+// user group.
+type AuditEvent struct {
+	ID int64 `json:"id"`
+	// ActorID is the user who made the change, or nil for changes made by
+	// the system (e.g. a role granted through ADMIN_EMAILS).
+	ActorID *string `json:"actor_id"`
+	// UserID is the user whose data changed. It is nil for changes to
+	// default tasks that affect every user.
+	UserID *string     `json:"user_id,omitempty"`
+	TodoID *string     `json:"todo_id,omitempty"`
+	Action AuditAction `json:"action"`
+	// Before and After are JSON snapshots of the changed record.
+	Before    json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After     json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	CreatedAt time.Time       `json:"created_at"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxCommentLength is the maximum length of a comment body.
+const MaxCommentLength = 2000
+
+// Comment is a message in the thread between a user and the admins about a
+// todo. Personal todos have a single thread, with their owner; default tasks
+// have one thread per user.
+type Comment struct {
+	ID     string `json:"id"`
+	TodoID string `json:"todo_id"`
+	// UserID is the user whose thread the comment is in.
+	UserID string `json:"user_id"`
+	// AuthorID is the user or admin who wrote the comment, or nil if their
+	// account is gone.
+	AuthorID  *string    `json:"author_id"`
+	Body      string     `json:"body"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+}
+
+// ErrInvalidComment is returned by NormalizeComment. It is meant for the client.
+var ErrInvalidComment = fmt.Errorf("comment body must be 1 to %d characters", MaxCommentLength)
+
+// NormalizeComment trims a comment body and checks its length.
+func NormalizeComment(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || len([]rune(body)) > MaxCommentLength {
+		return "", ErrInvalidComment
+	}
+	return body, nil
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+)
+
+// MaxBlockers is the maximum number of todos a todo can depend on.
+const MaxBlockers = 20
+
+// ErrInvalidBlockers is returned by NormalizeBlockers. It is meant for the
+// client.
+var ErrInvalidBlockers = fmt.Errorf("blocked_by must list at most %d todo IDs", MaxBlockers)
+
+// NormalizeBlockers returns the IDs of a todo's blockers sorted and without
+// duplicates.
+func NormalizeBlockers(ids []string) ([]string, error) {
+	blockers := []string{}
+	for _, id := range ids {
+		if id == "" {
+			return nil, ErrInvalidBlockers
+		}
+		blockers = append(blockers, id)
+	}
+	slices.Sort(blockers)
+	blockers = slices.Compact(blockers)
+	if len(blockers) > MaxBlockers {
+		return nil, ErrInvalidBlockers
+	}
+	return blockers, nil
+}
+
+// CanDependOn reports whether t can be blocked by blocker. Default tasks can
+// only depend on default tasks that reach every user they reach, and personal
+// todos on default tasks or on other todos of the same user. Whether a
+// default task is meant for the owner of a personal todo is up to the
+// caller.
+func (t Todo) CanDependOn(blocker Todo) bool {
+	if blocker.ID == t.ID {
+		return false
+	}
+	if blocker.IsDefaultTask {
+		return !t.IsDefaultTask || blocker.Reaches(t)
+	}
+	return !t.IsDefaultTask && t.UserID != nil && blocker.UserID != nil && *t.UserID == *blocker.UserID
+}
+
+// Reaches reports whether the default task t is meant for every user other
+// is: t targets no group, or every group other targets.
+func (t Todo) Reaches(other Todo) bool {
+	if len(t.Groups) == 0 {
+		return true
+	}
+	if len(other.Groups) == 0 {
+		return false
+	}
+	for _, id := range other.Groups {
+		if !slices.Contains(t.Groups, id) {
+			return false
+		}
+	}
+	return true
+}
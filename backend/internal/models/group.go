@@ -0,0 +1,56 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Group limits.
+const (
+	// MaxGroupNameLength is the maximum length of a group name.
+	MaxGroupNameLength = 100
+
+	// MaxTodoGroups is the maximum number of groups a default task can
+	// target.
+	MaxTodoGroups = 20
+)
+
+// Group is a set of users, such as the customers on a plan or in a region,
+// that default tasks can be targeted to.
+type Group struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	MemberCount int       `json:"member_count"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ErrInvalidGroups is returned by NormalizeGroups. It is meant for the
+// client.
+var ErrInvalidGroups = fmt.Errorf("groups must list at most %d group IDs", MaxTodoGroups)
+
+// NormalizeGroupName trims a group name. It reports false if the name is
+// empty or too long.
+func NormalizeGroupName(name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	return name, name != "" && len([]rune(name)) <= MaxGroupNameLength
+}
+
+// NormalizeGroups returns the IDs of the groups a default task targets
+// sorted and without duplicates.
+func NormalizeGroups(ids []string) ([]string, error) {
+	groups := []string{}
+	for _, id := range ids {
+		if id == "" {
+			return nil, ErrInvalidGroups
+		}
+		groups = append(groups, id)
+	}
+	slices.Sort(groups)
+	groups = slices.Compact(groups)
+	if len(groups) > MaxTodoGroups {
+		return nil, ErrInvalidGroups
+	}
+	return groups, nil
+}
@@ -0,0 +1,30 @@
+package models
+
+// Propagation is how a change to a default task's text or description
+// carries over to the progress users have made on it.
+type Propagation string
+
+// Propagation policies.
+const (
+	// PropagateKeep leaves every user's status as it is.
+	PropagateKeep Propagation = "keep"
+
+	// PropagateResetAll moves every user back to pending.
+	PropagateResetAll Propagation = "reset_all"
+
+	// PropagateResetDone moves only the users who are done back to
+	// pending.
+	PropagateResetDone Propagation = "reset_done"
+)
+
+// ParsePropagation returns the policy named by s, or keep if s is empty. It
+// reports false for anything else.
+func ParsePropagation(s string) (Propagation, bool) {
+	switch p := Propagation(s); p {
+	case "":
+		return PropagateKeep, true
+	case PropagateKeep, PropagateResetAll, PropagateResetDone:
+		return p, true
+	}
+	return "", false
+}
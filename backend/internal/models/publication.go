@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// Publication says where a default task stands in its publishing schedule.
+// Users only see default tasks that are live.
+type Publication string
+
+// Publication states.
+const (
+	PublicationDraft     Publication = "draft"     // kept from users until published
+	PublicationScheduled Publication = "scheduled" // published at PublishAt
+	PublicationLive      Publication = "live"
+	PublicationExpired   Publication = "expired" // withdrawn at ExpiresAt
+)
+
+// IsValid checks if the publication state is a known one.
+func (p Publication) IsValid() bool {
+	switch p {
+	case PublicationDraft, PublicationScheduled, PublicationLive, PublicationExpired:
+		return true
+	}
+	return false
+}
+
+// PublicationAt returns where the todo stands at now. Todos that are neither
+// drafts nor scheduled are live until they expire.
+func (t Todo) PublicationAt(now time.Time) Publication {
+	switch {
+	case t.Draft:
+		return PublicationDraft
+	case t.PublishAt != nil && t.PublishAt.After(now):
+		return PublicationScheduled
+	case t.ExpiresAt != nil && !t.ExpiresAt.After(now):
+		return PublicationExpired
+	}
+	return PublicationLive
+}
+
+// ValidateSchedule checks that a default task expires after it is published,
+// if it has both dates. Zero times count as unset.
+func ValidateSchedule(publishAt, expiresAt *time.Time) bool {
+	if publishAt == nil || publishAt.IsZero() || expiresAt == nil || expiresAt.IsZero() {
+		return true
+	}
+	return expiresAt.After(*publishAt)
+}
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recurrence frequencies.
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+)
+
+// MaxRecurrenceInterval bounds the INTERVAL of a recurrence rule.
+const MaxRecurrenceInterval = 365
+
+// ErrInvalidRecurrence is returned for recurrence rules outside the supported
+// subset of RFC 5545.
+var ErrInvalidRecurrence = errors.New("invalid recurrence: want FREQ=DAILY, WEEKLY or MONTHLY with an optional INTERVAL, and for MONTHLY an optional BYMONTHDAY from 1 to 31")
+
+// Recurrence is how often a recurring todo comes back once done: every
+// Interval days, weeks or months.
+type Recurrence struct {
+	Freq     string
+	Interval int
+
+	// ByMonthDay is the day of the month monthly occurrences fall on, or
+	// the last day of shorter months. Zero means the day of the due date.
+	ByMonthDay int
+}
+
+// ParseRecurrence parses a recurrence rule in the RRULE subset supported by
+// the API, such as "FREQ=WEEKLY;INTERVAL=2" or "FREQ=MONTHLY;BYMONTHDAY=31". The "RRULE:" prefix is optional
+// and names are case-insensitive.
+func ParseRecurrence(s string) (Recurrence, error) {
+	s = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "RRULE:")
+	r := Recurrence{Interval: 1}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || seen[name] {
+			return Recurrence{}, ErrInvalidRecurrence
+		}
+		seen[name] = true
+		switch name {
+		case "FREQ":
+			r.Freq = value
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > MaxRecurrenceInterval {
+				return Recurrence{}, ErrInvalidRecurrence
+			}
+			r.Interval = n
+		case "BYMONTHDAY":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 31 {
+				return Recurrence{}, ErrInvalidRecurrence
+			}
+			r.ByMonthDay = n
+		default:
+			return Recurrence{}, ErrInvalidRecurrence
+		}
+	}
+	switch r.Freq {
+	case FreqDaily, FreqWeekly:
+		if r.ByMonthDay == 0 {
+			return r, nil
+		}
+	case FreqMonthly:
+		return r, nil
+	}
+	return Recurrence{}, ErrInvalidRecurrence
+}
+
+// NormalizeRecurrence returns the canonical form of the rule s, or "" if s is
+// empty.
+func NormalizeRecurrence(s string) (string, error) {
+	if strings.TrimSpace(s) == "" {
+		return "", nil
+	}
+	r, err := ParseRecurrence(s)
+	if err != nil {
+		return "", err
+	}
+	return r.String(), nil
+}
+
+// String returns the rule in canonical form, leaving out an interval of 1.
+func (r Recurrence) String() string {
+	s := "FREQ=" + r.Freq
+	if r.Interval > 1 {
+		s += fmt.Sprintf(";INTERVAL=%d", r.Interval)
+	}
+	if r.ByMonthDay > 0 {
+		s += fmt.Sprintf(";BYMONTHDAY=%d", r.ByMonthDay)
+	}
+	return s
+}
+
+// Anchored returns r with monthly occurrences pinned to the day of the
+// month of start, the due date of the first occurrence, unless r already
+// names a day. Later occurrences then go back to that day after falling on
+// the last day of a shorter month.
+func (r Recurrence) Anchored(start *time.Time) Recurrence {
+	if r.Freq == FreqMonthly && r.ByMonthDay == 0 && start != nil && !start.IsZero() {
+		r.ByMonthDay = start.Day()
+	}
+	return r
+}
+
+// Next returns the due date of the occurrence after one due at due, stepping
+// by the interval until it is after now. Occurrences without a due date are
+// stepped from now. Monthly steps are counted from due and land on
+// ByMonthDay, or due's own day, or else on the last day of shorter months.
+func (r Recurrence) Next(due *time.Time, now time.Time) time.Time {
+	start := now
+	if due != nil {
+		start = *due
+	}
+	day := r.ByMonthDay
+	if day == 0 {
+		day = start.Day()
+	}
+	for n := r.Interval; ; n += r.Interval {
+		var next time.Time
+		switch r.Freq {
+		case FreqDaily:
+			next = start.AddDate(0, 0, n)
+		case FreqWeekly:
+			next = start.AddDate(0, 0, 7*n)
+		default:
+			next = addMonths(start, n, day)
+		}
+		if next.After(now) {
+			return next
+		}
+	}
+}
+
+// addMonths returns t moved n months on to the given day of the month, or
+// to the month's last day if it is shorter.
+func addMonths(t time.Time, n, day int) time.Time {
+	year, month, _ := t.Date()
+	first := time.Date(year, month+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(day, last)-1)
+}
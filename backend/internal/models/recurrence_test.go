@@ -0,0 +1,89 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecurrenceNext(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 9, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		rule     string
+		due      time.Time
+		now      time.Time
+		expected time.Time
+	}{
+		{"Daily", "FREQ=DAILY;INTERVAL=2", date(2026, time.March, 1), date(2026, time.March, 1), date(2026, time.March, 3)},
+		{"Weekly catching up", "FREQ=WEEKLY", date(2026, time.March, 1), date(2026, time.March, 20), date(2026, time.March, 22)},
+		{"Monthly", "FREQ=MONTHLY", date(2026, time.March, 15), date(2026, time.March, 15), date(2026, time.April, 15)},
+		{"Day 31 into February", "FREQ=MONTHLY", date(2027, time.January, 31), date(2027, time.January, 31), date(2027, time.February, 28)},
+		{"Day 31 into a leap February", "FREQ=MONTHLY", date(2028, time.January, 31), date(2028, time.January, 31), date(2028, time.February, 29)},
+		{"Day 31 into a 30-day month", "FREQ=MONTHLY", date(2026, time.August, 31), date(2026, time.August, 31), date(2026, time.September, 30)},
+		{"Day 31 catching up", "FREQ=MONTHLY", date(2027, time.January, 31), date(2027, time.March, 1), date(2027, time.March, 31)},
+		{"Day 31 every two months", "FREQ=MONTHLY;INTERVAL=2", date(2026, time.December, 31), date(2026, time.December, 31), date(2027, time.February, 28)},
+		{"Back to day 31", "FREQ=MONTHLY;BYMONTHDAY=31", date(2027, time.February, 28), date(2027, time.February, 28), date(2027, time.March, 31)},
+		{"Day of month before the due date's", "FREQ=MONTHLY;BYMONTHDAY=5", date(2027, time.January, 20), date(2027, time.January, 20), date(2027, time.February, 5)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := ParseRecurrence(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRecurrence failed: %v", err)
+			}
+			if next := r.Next(&tt.due, tt.now); !next.Equal(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, next)
+			}
+		})
+	}
+}
+
+func TestRecurrenceSeries(t *testing.T) {
+	r, err := ParseRecurrence("FREQ=MONTHLY")
+	if err != nil {
+		t.Fatalf("ParseRecurrence failed: %v", err)
+	}
+	due := time.Date(2027, time.January, 31, 9, 0, 0, 0, time.UTC)
+	r = r.Anchored(&due)
+	if r.String() != "FREQ=MONTHLY;BYMONTHDAY=31" {
+		t.Errorf("Expected the rule pinned to day 31, got %s", r)
+	}
+
+	// Each occurrence steps from the one before
+	for _, want := range []time.Time{
+		time.Date(2027, time.February, 28, 9, 0, 0, 0, time.UTC),
+		time.Date(2027, time.March, 31, 9, 0, 0, 0, time.UTC),
+		time.Date(2027, time.April, 30, 9, 0, 0, 0, time.UTC),
+	} {
+		r = r.Anchored(&due)
+		due = r.Next(&due, due)
+		if !due.Equal(want) {
+			t.Fatalf("Expected %v, got %v", want, due)
+		}
+	}
+}
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		rule     string
+		expected string
+		valid    bool
+	}{
+		{"rrule:freq=monthly;bymonthday=31;interval=2", "FREQ=MONTHLY;INTERVAL=2;BYMONTHDAY=31", true},
+		{"FREQ=WEEKLY;INTERVAL=1", "FREQ=WEEKLY", true},
+		{"FREQ=MONTHLY;BYMONTHDAY=32", "", false},
+		{"FREQ=MONTHLY;BYMONTHDAY=0", "", false},
+		{"FREQ=WEEKLY;BYMONTHDAY=3", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			got, err := NormalizeRecurrence(tt.rule)
+			if (err == nil) != tt.valid || got != tt.expected {
+				t.Errorf("Expected %q (valid %v), got %q, %v", tt.expected, tt.valid, got, err)
+			}
+		})
+	}
+}
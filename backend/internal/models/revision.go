@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// TodoRevision is a snapshot of a todo's global fields after a change.
+// Revisions are numbered from 1 per todo.
+type TodoRevision struct {
+	TodoID          string    `json:"todo_id"`
+	Revision        int       `json:"revision"`
+	Text            string    `json:"text"`
+	Status          string    `json:"status"`
+	SharedWithAdmin bool      `json:"shared_with_admin"`
+	HiddenFromUser  bool      `json:"hidden_from_user"`
+	ChangedBy       *string   `json:"changed_by,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+
+	// Changes lists the fields that differ from the previous revision.
+	Changes map[string]FieldChange `json:"changes,omitempty"`
+}
+
+// FieldChange is the old and new value of a changed field.
+type FieldChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// DiffRevisions sets Changes on each revision relative to the one before it.
+// revs must be sorted oldest first.
+func DiffRevisions(revs []TodoRevision) {
+	for i := 1; i < len(revs); i++ {
+		prev, cur := revs[i-1], &revs[i]
+		cur.Changes = map[string]FieldChange{}
+		if prev.Text != cur.Text {
+			cur.Changes["text"] = FieldChange{prev.Text, cur.Text}
+		}
+		if prev.Status != cur.Status {
+			cur.Changes["status"] = FieldChange{prev.Status, cur.Status}
+		}
+		if prev.SharedWithAdmin != cur.SharedWithAdmin {
+			cur.Changes["shared_with_admin"] = FieldChange{prev.SharedWithAdmin, cur.SharedWithAdmin}
+		}
+		if prev.HiddenFromUser != cur.HiddenFromUser {
+			cur.Changes["hidden_from_user"] = FieldChange{prev.HiddenFromUser, cur.HiddenFromUser}
+		}
+	}
+}
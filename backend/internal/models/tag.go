@@ -0,0 +1,70 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Tag constants.
+const (
+	// MaxTagLength is the maximum length of a tag name.
+	MaxTagLength = 32
+
+	// MaxTodoTags is the maximum number of tags on a todo.
+	MaxTodoTags = 20
+)
+
+// Tag is a label attached to todos by name. Curated tags make up the
+// palette admins offer to users; any other name can be used freely.
+type Tag struct {
+	Name    string `json:"name"`
+	Color   string `json:"color,omitempty"`
+	Curated bool   `json:"curated"`
+}
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+
+// ValidateColor checks that a tag color is empty or of the form #rrggbb.
+func ValidateColor(color string) bool {
+	return color == "" || colorPattern.MatchString(color)
+}
+
+// NormalizeTag returns the canonical form of a tag name: trimmed and lower
+// case. It reports false if the name is empty, too long, or contains
+// anything but letters, digits, '-' and '_'.
+func NormalizeTag(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || len(name) > MaxTagLength {
+		return "", false
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '-' && r != '_' {
+			return "", false
+		}
+	}
+	return name, true
+}
+
+// ErrInvalidTags is returned by NormalizeTags. It is meant for the client.
+var ErrInvalidTags = fmt.Errorf("tags must be up to %d letters, digits, '-' or '_', at most %d per todo", MaxTagLength, MaxTodoTags)
+
+// NormalizeTags normalizes tag names, sorted and without duplicates.
+func NormalizeTags(names []string) ([]string, error) {
+	tags := []string{}
+	for _, name := range names {
+		tag, ok := NormalizeTag(name)
+		if !ok {
+			return nil, ErrInvalidTags
+		}
+		tags = append(tags, tag)
+	}
+	slices.Sort(tags)
+	tags = slices.Compact(tags)
+	if len(tags) > MaxTodoTags {
+		return nil, ErrInvalidTags
+	}
+	return tags, nil
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Template limits.
+const (
+	// MaxTemplateNameLength is the maximum length of a template name.
+	MaxTemplateNameLength = 100
+
+	// MaxTemplateTasks is the maximum number of tasks in a template.
+	MaxTemplateTasks = 100
+)
+
+// Template is a named checklist of tasks that admins build once and apply
+// to selected users. Applying it adds each of its tasks, in order, to the
+// user's list as an admin-created todo.
+type Template struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Tasks     []TemplateTask `json:"tasks"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// TemplateTask is a task of a template. The todos it was applied as keep
+// its ID, so they can be re-synced when the task changes.
+type TemplateTask struct {
+	ID          string `json:"id"`
+	Text        string `json:"text"`
+	Description string `json:"description,omitempty"`
+	Priority    string `json:"priority"`
+}
+
+// ErrInvalidTemplate is returned by NormalizeTemplate. It is meant for the
+// client.
+var ErrInvalidTemplate = fmt.Errorf("template must have a name of 1 to %d characters and at most %d tasks", MaxTemplateNameLength, MaxTemplateTasks)
+
+// NormalizeTemplate trims the template's name and checks it and its tasks.
+// Tasks without a priority get normal priority. Task IDs are left to the
+// caller, but must not repeat.
+func NormalizeTemplate(t Template) (Template, error) {
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" || len([]rune(t.Name)) > MaxTemplateNameLength || len(t.Tasks) > MaxTemplateTasks {
+		return t, ErrInvalidTemplate
+	}
+
+	tasks := make([]TemplateTask, len(t.Tasks))
+	seen := map[string]bool{}
+	for i, task := range t.Tasks {
+		if !ValidateText(task.Text) {
+			return t, fmt.Errorf("task %d: text cannot be empty or exceed %d characters", i+1, MaxTextLength)
+		}
+		if !ValidateDescription(task.Description) {
+			return t, fmt.Errorf("task %d: description limit of %d characters exceeded", i+1, MaxDescriptionLength)
+		}
+		priority, ok := ParsePriority(task.Priority)
+		if !ok {
+			return t, fmt.Errorf("task %d: invalid priority", i+1)
+		}
+		task.Priority = string(priority)
+		if task.ID != "" {
+			if seen[task.ID] {
+				return t, fmt.Errorf("task %d: duplicate id %s", i+1, task.ID)
+			}
+			seen[task.ID] = true
+		}
+		tasks[i] = task
+	}
+	t.Tasks = tasks
+	return t, nil
+}
@@ -1,7 +1,11 @@
 // Package models contains shared data types used across the application.
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
 // TodoStatus represents the status of a todo item.
 type TodoStatus string
@@ -13,18 +17,70 @@ const (
 	StatusDone       TodoStatus = "done"
 )
 
-// IsValid checks if the status is a valid todo status.
-func (s TodoStatus) IsValid() bool {
+// String returns the string representation of the status.
+func (s TodoStatus) String() string {
+	return string(s)
+}
+
+// TodoSource says where a todo in a user's list comes from.
+type TodoSource string
+
+// Todo sources.
+const (
+	SourceDefault  TodoSource = "default"  // global default task
+	SourceAdmin    TodoSource = "admin"    // personal todo added by an admin
+	SourcePersonal TodoSource = "personal" // personal todo the user created
+)
+
+// IsValid checks if the source is a valid todo source.
+func (s TodoSource) IsValid() bool {
 	switch s {
-	case StatusPending, StatusInProgress, StatusDone:
+	case SourceDefault, SourceAdmin, SourcePersonal:
 		return true
 	}
 	return false
 }
 
-// String returns the string representation of the status.
-func (s TodoStatus) String() string {
-	return string(s)
+// TodoPriority says how urgent a todo is.
+type TodoPriority string
+
+// Todo priorities, from least to most urgent.
+const (
+	PriorityLow    TodoPriority = "low"
+	PriorityNormal TodoPriority = "normal"
+	PriorityHigh   TodoPriority = "high"
+	PriorityUrgent TodoPriority = "urgent"
+)
+
+// IsValid checks if the priority is a valid todo priority.
+func (p TodoPriority) IsValid() bool {
+	return p.Rank() >= 0
+}
+
+// Rank orders priorities from 0 for low to 3 for urgent. It returns -1 for
+// invalid priorities.
+func (p TodoPriority) Rank() int {
+	switch p {
+	case PriorityLow:
+		return 0
+	case PriorityNormal:
+		return 1
+	case PriorityHigh:
+		return 2
+	case PriorityUrgent:
+		return 3
+	}
+	return -1
+}
+
+// ParsePriority returns the priority named by s, or normal if s is empty. It
+// reports false for anything else.
+func ParsePriority(s string) (TodoPriority, bool) {
+	if s == "" {
+		return PriorityNormal, true
+	}
+	p := TodoPriority(s)
+	return p, p.IsValid()
 }
 
 // Todo constants.
@@ -32,25 +88,188 @@ const (
 	// MaxTextLength is the maximum length of todo text.
 	MaxTextLength = 200
 
+	// MaxDescriptionLength is the maximum length of a todo's description.
+	MaxDescriptionLength = 10000
+
 	// PositionIncrement is the increment used for positioning todos.
 	PositionIncrement = 1024.0
 )
 
 // Todo represents a todo item.
 type Todo struct {
-	ID              string    `json:"id"`
-	Text            string    `json:"text"`
-	Status          string    `json:"status"`
-	Created         time.Time `json:"created"`
-	Position        float64   `json:"position"`
-	CreatedByUserID *string   `json:"created_by_user_id,omitempty"`
-	IsDefaultTask   bool      `json:"is_default_task"`
-	SharedWithAdmin bool      `json:"shared_with_admin"`
-	HiddenFromUser  bool      `json:"hidden_from_user"`
-	UserID          *string   `json:"user_id,omitempty"`
+	ID              string     `json:"id"`
+	Text            string     `json:"text"`
+	Status          string     `json:"status"`
+	Created         time.Time  `json:"created"`
+	Position        float64    `json:"position"`
+	CreatedByUserID *string    `json:"created_by_user_id,omitempty"`
+	IsDefaultTask   bool       `json:"is_default_task"`
+	SharedWithAdmin bool       `json:"shared_with_admin"`
+	HiddenFromUser  bool       `json:"hidden_from_user"`
+	UserID          *string    `json:"user_id,omitempty"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty"`
+
+	// Description is a longer markdown text. Like Text, only whoever
+	// created the todo can change it.
+	Description string `json:"description,omitempty"`
+
+	// DescriptionHTML is Description rendered to sanitized HTML. It is
+	// only set when a client asks for it.
+	DescriptionHTML string `json:"description_html,omitempty"`
+
+	// DueAt is when the todo must be done. For default tasks read as a
+	// user, it is that user's own due date if they have set one.
+	DueAt *time.Time `json:"due_at,omitempty"`
+
+	// Overdue is set when the todo is encoded, from DueAt and Status.
+	Overdue bool `json:"overdue"`
+
+	// ParentID is set on subtasks. Subtasks belong to the same list as
+	// their parent.
+	ParentID *string `json:"parent_id,omitempty"`
+
+	// Subtasks rolls up the todo's direct subtasks, if it has any.
+	Subtasks *Subtasks `json:"subtasks,omitempty"`
+
+	// Tags are the names of the todo's tags, sorted.
+	Tags []string `json:"tags,omitempty"`
+
+	// Priority is one of low, normal, high or urgent. It is the same for
+	// every user of a default task.
+	Priority string `json:"priority"`
+
+	// Recurrence is the rule the todo recurs by, such as FREQ=WEEKLY, or
+	// empty if it does not recur. Marking a recurring todo done brings it
+	// back as its next occurrence.
+	Recurrence string `json:"recurrence,omitempty"`
+
+	// Occurrence counts the occurrences of a recurring todo done before
+	// this one. For default tasks read as a user, it is that user's count.
+	Occurrence int `json:"occurrence,omitempty"`
+
+	// TemplateTaskID is set on todos added from a template, to the task
+	// they were added from, until the task is removed from the template.
+	TemplateTaskID *string `json:"template_task_id,omitempty"`
+
+	// BlockedBy lists the IDs of the live todos this one depends on.
+	BlockedBy []string `json:"blocked_by,omitempty"`
+
+	// Blocked is set while any of BlockedBy is not done: for default
+	// tasks by the status of the user the todo is read as, or of its
+	// owner.
+	Blocked bool `json:"blocked"`
+
+	// Groups lists the IDs of the groups a default task targets. Only their
+	// members see it; without groups, every user does.
+	Groups []string `json:"groups,omitempty"`
+
+	// Draft keeps a default task from users until it is published.
+	Draft bool `json:"draft,omitempty"`
+
+	// PublishAt and ExpiresAt bound when users see a default task. Either
+	// may be unset.
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// Publication is set on default tasks when encoded, from Draft,
+	// PublishAt and ExpiresAt.
+	Publication Publication `json:"publication,omitempty"`
+
+	// CommentCount counts the comments on the todo: in the thread of the
+	// user it is read as, or in every thread otherwise.
+	CommentCount int `json:"comment_count"`
+
+	// Version counts changes to the todo's text, status and visibility.
+	Version int `json:"version"`
+
+	// StateVersion counts changes to a user's own status for a default
+	// task. It is only set when the todo is read as that user.
+	StateVersion int `json:"state_version,omitempty"`
+
+	// UpdatedSinceDone is set on a default task read as a user who had
+	// completed it when an admin changed it and reset their progress. It
+	// clears once the user changes their status again.
+	UpdatedSinceDone bool `json:"updated_since_done,omitempty"`
+}
+
+// IsOverdue reports whether the todo is past its due date at now and not
+// yet done.
+func (t Todo) IsOverdue(now time.Time) bool {
+	return t.DueAt != nil && t.DueAt.Before(now) && t.Status != string(StatusDone)
+}
+
+// MarshalJSON encodes the todo with Overdue and Publication computed for the
+// current time.
+func (t Todo) MarshalJSON() ([]byte, error) {
+	type todo Todo // without methods, so it encodes normally
+	now := time.Now()
+	t.Overdue = t.IsOverdue(now)
+	if t.IsDefaultTask {
+		t.Publication = t.PublicationAt(now)
+	}
+	return json.Marshal(todo(t))
+}
+
+// Subtasks summarizes the direct subtasks of a todo, by the status shown
+// along with the todo.
+type Subtasks struct {
+	Total int `json:"total"`
+	Done  int `json:"done"`
+
+	// Status is done once every subtask is done, in-progress once any has
+	// been started and pending otherwise.
+	Status TodoStatus `json:"status"`
+}
+
+// RollUp summarizes total subtasks, of which done are done and started are
+// in progress or done. It returns nil if there are no subtasks.
+func RollUp(total, done, started int) *Subtasks {
+	if total == 0 {
+		return nil
+	}
+	st := &Subtasks{Total: total, Done: done, Status: StatusPending}
+	switch {
+	case done == total:
+		st.Status = StatusDone
+	case started > 0:
+		st.Status = StatusInProgress
+	}
+	return st
+}
+
+// OverdueTodo is a todo past its due date in a user's list.
+type OverdueTodo struct {
+	UserID string `json:"user_id"`
+	Todo   Todo   `json:"todo"`
+}
+
+// Source returns where the todo comes from.
+func (t Todo) Source() TodoSource {
+	switch {
+	case t.IsDefaultTask:
+		return SourceDefault
+	case t.CreatedByUserID != nil && t.UserID != nil && *t.CreatedByUserID == *t.UserID:
+		return SourcePersonal
+	}
+	return SourceAdmin
+}
+
+// ETag returns the entity tag of the todo as served by the API. Default
+// tasks read as a user also carry the version of that user's status.
+func (t Todo) ETag() string {
+	if t.StateVersion > 0 {
+		return fmt.Sprintf(`"%d.%d"`, t.Version, t.StateVersion)
+	}
+	return fmt.Sprintf(`"%d"`, t.Version)
 }
 
 // ValidateText validates the todo text length.
 func ValidateText(text string) bool {
 	return len(text) > 0 && len(text) <= MaxTextLength
 }
+
+// ValidateDescription validates the todo description length. Descriptions
+// can be empty.
+func ValidateDescription(description string) bool {
+	return len(description) <= MaxDescriptionLength
+}
@@ -0,0 +1,173 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// MaxStatusLength is the maximum length of a status name.
+const MaxStatusLength = 32
+
+// MaxWorkflowStatuses is the maximum number of statuses in a workflow,
+// counting the built-in ones.
+const MaxWorkflowStatuses = 20
+
+// BuiltinStatuses are the statuses every workflow has, in order.
+var BuiltinStatuses = []string{string(StatusPending), string(StatusInProgress), string(StatusDone)}
+
+// Workflow is the set of statuses todos can be in and the moves allowed
+// between them. Admins can add statuses, such as blocked or needs-review, to
+// the built-in pending, in-progress and done.
+//
+// Done is the only status in which a todo counts as finished: overdue todos,
+// subtask progress and dependencies all look for it. It cannot be removed
+// or renamed, and it must be reachable from pending.
+type Workflow struct {
+	// Statuses lists every status, the built-in ones first.
+	Statuses []string `json:"statuses"`
+
+	// Transitions maps each status to the statuses it can move to. If it is
+	// empty, any status can move to any other; otherwise a status without
+	// an entry cannot be left.
+	Transitions map[string][]string `json:"transitions,omitempty"`
+}
+
+// DefaultWorkflow returns the workflow of the built-in statuses, without
+// restrictions on transitions.
+func DefaultWorkflow() Workflow {
+	return Workflow{Statuses: slices.Clone(BuiltinStatuses)}
+}
+
+// Has reports whether status is part of the workflow.
+func (w Workflow) Has(status string) bool {
+	return slices.Contains(w.Statuses, status)
+}
+
+// Allows reports whether a todo can move from one status to another. Staying
+// in the same status is always allowed, and so is leaving a status that has
+// been removed from the workflow.
+func (w Workflow) Allows(from, to string) bool {
+	if from == to || len(w.Transitions) == 0 || !w.Has(from) {
+		return true
+	}
+	return slices.Contains(w.Transitions[from], to)
+}
+
+// ErrUnknownStatus is returned by CheckTransition for a status that is not
+// part of the workflow.
+var ErrUnknownStatus = errors.New("invalid status")
+
+// TransitionError is returned by CheckTransition for a move the workflow
+// does not allow.
+type TransitionError struct {
+	From, To string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot move from %s to %s", e.From, e.To)
+}
+
+// CheckTransition checks that a todo can move from one status to another. It
+// returns ErrUnknownStatus if to is not part of the workflow, or a
+// *TransitionError if the move is not allowed.
+func (w Workflow) CheckTransition(from, to string) error {
+	if !w.Has(to) {
+		return ErrUnknownStatus
+	}
+	if !w.Allows(from, to) {
+		return &TransitionError{From: from, To: to}
+	}
+	return nil
+}
+
+// ValidStatusName reports whether name can be used as a status: up to
+// MaxStatusLength lower case letters, digits and '-'.
+func ValidStatusName(name string) bool {
+	if name == "" || len(name) > MaxStatusLength {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// ErrInvalidWorkflow is returned by NormalizeWorkflow. It is meant for the
+// client.
+var ErrInvalidWorkflow = fmt.Errorf("statuses must be up to %d lower case letters, digits or '-', at most %d in all, and transitions must be between them", MaxStatusLength, MaxWorkflowStatuses)
+
+// ErrDoneUnreachable is returned by NormalizeWorkflow if the transitions do
+// not let a pending todo be marked done. It is meant for the client.
+var ErrDoneUnreachable = errors.New("transitions must let pending todos reach done")
+
+// NormalizeWorkflow returns w with the built-in statuses first, then the
+// others in the order given, without duplicates, and each status's
+// transitions sorted in the same order.
+func NormalizeWorkflow(w Workflow) (Workflow, error) {
+	out := DefaultWorkflow()
+	for _, name := range w.Statuses {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ValidStatusName(name) {
+			return Workflow{}, ErrInvalidWorkflow
+		}
+		if !out.Has(name) {
+			out.Statuses = append(out.Statuses, name)
+		}
+	}
+	if len(out.Statuses) > MaxWorkflowStatuses {
+		return Workflow{}, ErrInvalidWorkflow
+	}
+
+	for _, from := range slices.Sorted(maps.Keys(w.Transitions)) {
+		if !out.Has(from) {
+			return Workflow{}, ErrInvalidWorkflow
+		}
+		var to []string
+		for _, name := range out.Statuses {
+			if name != from && slices.Contains(w.Transitions[from], name) {
+				to = append(to, name)
+			}
+		}
+		for _, name := range w.Transitions[from] {
+			if !out.Has(name) {
+				return Workflow{}, ErrInvalidWorkflow
+			}
+		}
+		if len(to) > 0 {
+			if out.Transitions == nil {
+				out.Transitions = map[string][]string{}
+			}
+			out.Transitions[from] = to
+		}
+	}
+	if !out.reaches(string(StatusPending), string(StatusDone)) {
+		return Workflow{}, ErrDoneUnreachable
+	}
+	return out, nil
+}
+
+// reaches reports whether a todo in status from can get to status to
+// through the workflow's transitions.
+func (w Workflow) reaches(from, to string) bool {
+	seen := map[string]bool{from: true}
+	queue := []string{from}
+	for len(queue) > 0 {
+		status := queue[0]
+		queue = queue[1:]
+		if w.Allows(status, to) {
+			return true
+		}
+		for _, next := range w.Transitions[status] {
+			if !seen[next] {
+				seen[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return false
+}
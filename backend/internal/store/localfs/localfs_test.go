@@ -0,0 +1,33 @@
+package localfs
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/akhilmk/packup/internal/store"
+	"github.com/akhilmk/packup/internal/store/storetest"
+)
+
+// TestStore runs the blob store conformance suite in a temporary directory
+func TestStore(t *testing.T) {
+	storetest.RunBlobStore(t, func(t *testing.T) store.BlobStore {
+		s, err := New(t.TempDir())
+		if err != nil {
+			t.Fatalf("Failed to create store: %v", err)
+		}
+		return s
+	})
+}
+
+func TestInvalidKeys(t *testing.T) {
+	s, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	for _, key := range []string{"", ".", "..", "../escape", "a/b", `a\b`, ".hidden"} {
+		if err := s.PutBlob(context.Background(), key, strings.NewReader("data")); err == nil {
+			t.Errorf("Expected key %q to be rejected", key)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// sortAttachments orders attachments oldest first, by ID within equal times.
+func sortAttachments(attachments []models.Attachment) {
+	sort.Slice(attachments, func(i, j int) bool {
+		if !attachments[i].CreatedAt.Equal(attachments[j].CreatedAt) {
+			return attachments[i].CreatedAt.Before(attachments[j].CreatedAt)
+		}
+		return attachments[i].ID < attachments[j].ID
+	})
+}
+
+func (s *Store) ListAttachments(ctx context.Context, todoID, userID string) ([]models.Attachment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	attachments := []models.Attachment{}
+	for _, a := range s.attachments {
+		if a.TodoID == todoID && a.UserID == userID {
+			attachments = append(attachments, a)
+		}
+	}
+	sortAttachments(attachments)
+	return attachments, nil
+}
+
+func (s *Store) ListPurgeableAttachments(ctx context.Context, before time.Time) ([]models.Attachment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	purged, _ := s.purgeable(before)
+	attachments := []models.Attachment{}
+	for _, a := range s.attachments {
+		if slices.Contains(purged, a.TodoID) {
+			attachments = append(attachments, a)
+		}
+	}
+	sortAttachments(attachments)
+	return attachments, nil
+}
+
+func (s *Store) GetAttachment(ctx context.Context, id string) (models.Attachment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	a, ok := s.attachments[id]
+	if !ok {
+		return models.Attachment{}, store.ErrNotFound
+	}
+	return a, nil
+}
+
+func (s *Store) CreateAttachment(ctx context.Context, a models.Attachment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.attachments[a.ID] = a
+	return nil
+}
+
+func (s *Store) DeleteAttachment(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.attachments[id]; !ok {
+		return store.ErrNotFound
+	}
+	delete(s.attachments, id)
+	return nil
+}
+
+func (s *Store) PutBlob(ctx context.Context, key string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.blobs[key] = data
+	return nil
+}
+
+func (s *Store) OpenBlob(ctx context.Context, key string) (io.ReadCloser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, ok := s.blobs[key]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *Store) DeleteBlob(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.blobs, key)
+	return nil
+}
@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+func (s *Store) RecordAuditEvent(ctx context.Context, e *models.AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e.ID = int64(len(s.events) + 1)
+	s.events = append(s.events, *e)
+	return nil
+}
+
+func (s *Store) ListAuditEvents(ctx context.Context, f store.AuditFilter) ([]models.AuditEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matches := func(field *string, want string) bool {
+		return want == "" || (field != nil && *field == want)
+	}
+
+	events := []models.AuditEvent{}
+	for i := len(s.events) - 1; i >= 0; i-- {
+		e := s.events[i]
+		if !matches(e.UserID, f.UserID) || !matches(e.TodoID, f.TodoID) || !matches(e.ActorID, f.ActorID) {
+			continue
+		}
+		if f.Action != "" && e.Action != f.Action {
+			continue
+		}
+		if (!f.From.IsZero() && e.CreatedAt.Before(f.From)) || (!f.To.IsZero() && !e.CreatedAt.Before(f.To)) {
+			continue
+		}
+		events = append(events, e)
+	}
+	sortEvents(events)
+
+	if f.Limit > 0 && len(events) > f.Limit {
+		events = events[:f.Limit]
+	}
+	return events, nil
+}
@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// withCounts returns t with the roll-up of its subtasks (see withSubtasks)
+// and its comment count, both as seen by userID or, if userID is empty,
+// globally, and with its blockers (see withBlockers). Callers must hold s.mu.
+func (s *Store) withCounts(t models.Todo, userID string) models.Todo {
+	t = s.withSubtasks(t, userID)
+	t = s.withBlockers(t, userID)
+	t.CommentCount = 0
+	for _, c := range s.comments {
+		if c.TodoID == t.ID && (userID == "" || c.UserID == userID) {
+			t.CommentCount++
+		}
+	}
+	return t
+}
+
+func (s *Store) ListComments(ctx context.Context, todoID, userID string) ([]models.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	comments := []models.Comment{}
+	for _, c := range s.comments {
+		if c.TodoID == todoID && c.UserID == userID {
+			comments = append(comments, c)
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		if !comments[i].CreatedAt.Equal(comments[j].CreatedAt) {
+			return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+		}
+		return comments[i].ID < comments[j].ID
+	})
+	return comments, nil
+}
+
+func (s *Store) GetComment(ctx context.Context, id string) (models.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.comments[id]
+	if !ok {
+		return models.Comment{}, store.ErrNotFound
+	}
+	return c, nil
+}
+
+func (s *Store) CreateComment(ctx context.Context, c models.Comment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.comments[c.ID] = c
+	return nil
+}
+
+func (s *Store) UpdateComment(ctx context.Context, id, body string, editedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.comments[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	c.Body = body
+	c.EditedAt = &editedAt
+	s.comments[id] = c
+	return nil
+}
+
+func (s *Store) DeleteComment(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.comments[id]; !ok {
+		return store.ErrNotFound
+	}
+	delete(s.comments, id)
+	return nil
+}
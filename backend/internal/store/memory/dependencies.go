@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"slices"
+
+	"github.com/akhilmk/packup/internal/models"
+)
+
+// withBlockers sets the live blockers of t that userID or, if userID is
+// empty, the todo's owner can see, and whether any of them is unfinished for
+// that user. Default tasks have no owner, so all their blockers count.
+// Callers must hold s.mu.
+func (s *Store) withBlockers(t models.Todo, userID string) models.Todo {
+	if userID == "" && t.UserID != nil {
+		userID = *t.UserID
+	}
+	var live []string
+	t.Blocked = false
+	for _, id := range t.BlockedBy {
+		b, ok := s.live(id)
+		if !ok || (userID != "" && (!s.targets(b, userID) || !published(b))) {
+			continue
+		}
+		live = append(live, id)
+		status := b.Status
+		if st, ok := s.states[stateKey{userID, id}]; ok && b.IsDefaultTask {
+			status = st.status
+		}
+		if status != string(models.StatusDone) {
+			t.Blocked = true
+		}
+	}
+	t.BlockedBy = live
+	return t
+}
+
+// reaches reports whether target is a blocker of id, at any depth, or id
+// itself. Callers must hold s.mu.
+func (s *Store) reaches(id, target string) bool {
+	seen := map[string]bool{}
+	next := []string{id}
+	for len(next) > 0 {
+		id, next = next[0], next[1:]
+		if id == target {
+			return true
+		}
+		if !seen[id] {
+			seen[id] = true
+			next = append(next, s.todos[id].BlockedBy...)
+		}
+	}
+	return false
+}
+
+// dependencyCycle reports whether giving todoID these blockers would make it
+// depend on itself. Callers must hold s.mu.
+func (s *Store) dependencyCycle(todoID string, blockers []string) bool {
+	return slices.ContainsFunc(blockers, func(id string) bool { return s.reaches(id, todoID) })
+}
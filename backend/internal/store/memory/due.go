@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// dueAt returns the stored value of a due date, or of another optional time,
+// nil for nil or the zero time.
+func dueAt(t *time.Time) *time.Time {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	due := *t
+	return &due
+}
+
+func (s *Store) SetDefaultTodoDueAt(ctx context.Context, userID, todoID string, due *time.Time, ifVersion *int) error {
+	if due == nil {
+		due = &time.Time{}
+	}
+	return s.UpdateDefaultTodoState(ctx, userID, todoID, store.StateUpdate{DueAt: due}, nil, ifVersion)
+}
+
+// setDefaultTodoDueAt overrides the due date of the default task t for a
+// user, as SetDefaultTodoDueAt does. Callers must hold s.mu.
+func (s *Store) setDefaultTodoDueAt(userID string, t models.Todo, due *time.Time) {
+	key := stateKey{userID, t.ID}
+	st, ok := s.states[key]
+	if !ok {
+		st.status = t.Status
+		st.position = t.Position
+	}
+	st.dueAt = dueAt(due)
+	st.version++
+	st.updatedAt = time.Now()
+	s.states[key] = st
+}
+
+func (s *Store) ListOverdueTodos(ctx context.Context, userID string, now time.Time, limit int) ([]models.OverdueTodo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	todos := []models.OverdueTodo{}
+	add := func(userID string, t models.Todo) {
+		if t.IsOverdue(now) {
+			todos = append(todos, models.OverdueTodo{UserID: userID, Todo: t})
+		}
+	}
+	for _, t := range s.todos {
+		if t.DeletedAt != nil {
+			continue
+		}
+		if t.IsDefaultTask {
+			for _, u := range s.users {
+				if u.Role != string(models.RoleAdmin) && (userID == "" || u.ID == userID) && s.targets(t, u.ID) && published(t) {
+					add(u.ID, s.userView(t, u.ID))
+				}
+			}
+		} else if t.SharedWithAdmin && t.UserID != nil && (userID == "" || *t.UserID == userID) {
+			add(*t.UserID, s.withCounts(t, ""))
+		}
+	}
+
+	sort.Slice(todos, func(i, j int) bool {
+		a, b := todos[i], todos[j]
+		if !a.Todo.DueAt.Equal(*b.Todo.DueAt) {
+			return a.Todo.DueAt.Before(*b.Todo.DueAt)
+		}
+		if a.UserID != b.UserID {
+			return a.UserID < b.UserID
+		}
+		return a.Todo.ID < b.Todo.ID
+	})
+	if len(todos) > limit {
+		todos = todos[:limit]
+	}
+	return todos, nil
+}
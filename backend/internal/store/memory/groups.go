@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// targets reports whether t is meant for userID: personal todos are, and
+// default tasks that target no group or one the user is a member of.
+// Callers must hold s.mu.
+func (s *Store) targets(t models.Todo, userID string) bool {
+	if !t.IsDefaultTask || len(t.Groups) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(t.Groups, func(id string) bool { return s.members[id][userID] })
+}
+
+func (s *Store) ListGroups(ctx context.Context) ([]models.Group, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	groups := []models.Group{}
+	for _, g := range s.groups {
+		g.MemberCount = len(s.members[g.ID])
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if c := strings.Compare(groups[i].Name, groups[j].Name); c != 0 {
+			return c < 0
+		}
+		return groups[i].ID < groups[j].ID
+	})
+	return groups, nil
+}
+
+func (s *Store) GetGroup(ctx context.Context, id string) (models.Group, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	g, ok := s.groups[id]
+	if !ok {
+		return models.Group{}, store.ErrNotFound
+	}
+	g.MemberCount = len(s.members[id])
+	return g, nil
+}
+
+func (s *Store) SaveGroup(ctx context.Context, g models.Group) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if old, ok := s.groups[g.ID]; ok {
+		g.CreatedAt = old.CreatedAt
+	}
+	g.MemberCount = 0
+	s.groups[g.ID] = g
+	return nil
+}
+
+func (s *Store) DeleteGroup(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.groups[id]; !ok {
+		return store.ErrNotFound
+	}
+	for _, t := range s.todos {
+		if slices.Contains(t.Groups, id) {
+			return store.ErrConflict
+		}
+	}
+	delete(s.groups, id)
+	delete(s.members, id)
+	return nil
+}
+
+func (s *Store) ListGroupMembers(ctx context.Context, groupID string) ([]models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := []models.User{}
+	for id := range s.members[groupID] {
+		if u, ok := s.users[id]; ok {
+			users = append(users, u)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].Email != users[j].Email {
+			return users[i].Email < users[j].Email
+		}
+		return users[i].ID < users[j].ID
+	})
+	return users, nil
+}
+
+func (s *Store) AddGroupMember(ctx context.Context, groupID, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.members[groupID] == nil {
+		s.members[groupID] = map[string]bool{}
+	}
+	s.members[groupID][userID] = true
+	return nil
+}
+
+func (s *Store) RemoveGroupMember(ctx context.Context, groupID, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.members[groupID][userID] {
+		return store.ErrNotFound
+	}
+	delete(s.members[groupID], userID)
+	return nil
+}
@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// filterTodos sorts todos and returns the page of them selected by f.
+func filterTodos(todos []models.Todo, f store.TodoFilter) []models.Todo {
+	sortTodos(todos, f.Sort)
+
+	page := []models.Todo{}
+	for _, t := range todos {
+		if f.Limit > 0 && len(page) == f.Limit {
+			break
+		}
+		if matchTodo(t, f) {
+			page = append(page, t)
+		}
+	}
+	return page
+}
+
+func matchTodo(t models.Todo, f store.TodoFilter) bool {
+	if f.Status != "" && t.Status != f.Status {
+		return false
+	}
+	if f.Source != "" && t.Source() != f.Source {
+		return false
+	}
+	if f.ParentID != "" && (t.ParentID == nil || *t.ParentID != f.ParentID) {
+		return false
+	}
+	if !hasTags(t, f.Tags) {
+		return false
+	}
+	if !inRange(t.Created, f.From, f.To) {
+		return false
+	}
+	if !f.DueFrom.IsZero() || !f.DueTo.IsZero() {
+		if t.DueAt == nil || !inRange(*t.DueAt, f.DueFrom, f.DueTo) {
+			return false
+		}
+	}
+	if !f.OverdueAt.IsZero() && !t.IsOverdue(f.OverdueAt) {
+		return false
+	}
+	if f.Publication != "" && t.PublicationAt(time.Now()) != f.Publication {
+		return false
+	}
+	if c := f.After; c != nil {
+		// Only todos sorting after the cursor, as in sortTodos
+		cursor := models.Todo{ID: c.ID, Created: c.Created, Position: c.Position, DueAt: c.Due, Priority: c.Priority}
+		return lessTodo(cursor, t, f.Sort)
+	}
+	return true
+}
+
+// inRange reports whether t lies in [from, to), ignoring zero bounds.
+func inRange(t, from, to time.Time) bool {
+	return (from.IsZero() || !t.Before(from)) && (to.IsZero() || t.Before(to))
+}
@@ -0,0 +1,121 @@
+// Package memory implements the store interfaces in process memory.
+// It mirrors the semantics of the postgres package and is intended for
+// tests and local experiments; nothing is persisted.
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// Store implements store.Store, and store.BlobStore for tests.
+type Store struct {
+	mu          sync.RWMutex
+	users       map[string]models.User
+	sessions    map[string]session
+	todos       map[string]models.Todo
+	states      map[stateKey]todoState
+	revisions   map[string][]models.TodoRevision
+	tags        map[string]models.Tag
+	comments    map[string]models.Comment
+	attachments map[string]models.Attachment
+	blobs       map[string][]byte
+	workflow    models.Workflow
+	templates   map[string]models.Template
+	applied     map[string]map[string]bool // template ID to user IDs
+	groups      map[string]models.Group
+	members     map[string]map[string]bool // group ID to user IDs
+	events      []models.AuditEvent
+}
+
+var (
+	_ store.Store     = (*Store)(nil)
+	_ store.BlobStore = (*Store)(nil)
+)
+
+type session struct {
+	userID    string
+	expiresAt time.Time
+}
+
+// stateKey identifies a user's state for a default task.
+type stateKey struct {
+	userID string
+	todoID string
+}
+
+// todoState is a user's own status, position, due date and occurrence count
+// for a default task, and whether it changed since the user completed it.
+type todoState struct {
+	status           string
+	position         float64
+	dueAt            *time.Time
+	occurrence       int
+	updatedSinceDone bool
+	version          int
+	updatedAt        time.Time
+}
+
+// New returns an empty Store.
+func New() *Store {
+	return &Store{
+		users:       map[string]models.User{},
+		sessions:    map[string]session{},
+		todos:       map[string]models.Todo{},
+		states:      map[stateKey]todoState{},
+		revisions:   map[string][]models.TodoRevision{},
+		tags:        map[string]models.Tag{},
+		comments:    map[string]models.Comment{},
+		attachments: map[string]models.Attachment{},
+		blobs:       map[string][]byte{},
+		workflow:    models.DefaultWorkflow(),
+		templates:   map[string]models.Template{},
+		applied:     map[string]map[string]bool{},
+		groups:      map[string]models.Group{},
+		members:     map[string]map[string]bool{},
+	}
+}
+
+// sortTodos orders todos by position, by due date (todos without one last)
+// for SortDue, or by priority and then position for SortPriority, then
+// newest first and by ID within equal times.
+func sortTodos(todos []models.Todo, by store.TodoSort) {
+	sort.Slice(todos, func(i, j int) bool { return lessTodo(todos[i], todos[j], by) })
+}
+
+// lessTodo reports whether a sorts before b in the order of sortTodos.
+func lessTodo(a, b models.Todo, by store.TodoSort) bool {
+	if by == store.SortDue {
+		if (a.DueAt == nil) != (b.DueAt == nil) {
+			return b.DueAt == nil
+		}
+		if a.DueAt != nil && !a.DueAt.Equal(*b.DueAt) {
+			return a.DueAt.Before(*b.DueAt)
+		}
+	} else {
+		if by == store.SortPriority && a.Priority != b.Priority {
+			return models.TodoPriority(a.Priority).Rank() > models.TodoPriority(b.Priority).Rank()
+		}
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+	}
+	if !a.Created.Equal(b.Created) {
+		return a.Created.After(b.Created)
+	}
+	return a.ID < b.ID
+}
+
+// sortEvents orders audit events newest first, by insertion within equal times.
+func sortEvents(events []models.AuditEvent) {
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].CreatedAt.Equal(events[j].CreatedAt) {
+			return events[i].CreatedAt.After(events[j].CreatedAt)
+		}
+		return events[i].ID > events[j].ID
+	})
+}
@@ -0,0 +1,18 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/akhilmk/packup/internal/store"
+	"github.com/akhilmk/packup/internal/store/storetest"
+)
+
+// TestStore runs the store conformance suite against the memory store
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store { return New() })
+}
+
+// TestBlobStore runs the blob store conformance suite against the memory store
+func TestBlobStore(t *testing.T) {
+	storetest.RunBlobStore(t, func(t *testing.T) store.BlobStore { return New() })
+}
@@ -0,0 +1,26 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+)
+
+// resetUserStates moves the users of a default task back to pending as
+// policy p says, flagging those who were done. Callers must hold s.mu.
+func (s *Store) resetUserStates(todoID string, p models.Propagation) {
+	for key, st := range s.states {
+		if key.todoID != todoID || st.status == string(models.StatusPending) {
+			continue
+		}
+		done := st.status == string(models.StatusDone)
+		if !done && p != models.PropagateResetAll {
+			continue
+		}
+		st.status = string(models.StatusPending)
+		st.updatedSinceDone = st.updatedSinceDone || done
+		st.version++
+		st.updatedAt = time.Now()
+		s.states[key] = st
+	}
+}
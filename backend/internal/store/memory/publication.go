@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// published reports whether users can see t now: personal todos, and default
+// tasks that are published and not yet expired.
+func published(t models.Todo) bool {
+	return t.PublicationAt(time.Now()) == models.PublicationLive
+}
+
+func (s *Store) PublishTodo(ctx context.Context, id string, at time.Time, ifVersion *int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.live(id)
+	if !ok {
+		return store.ErrNotFound
+	}
+	if ifVersion != nil && *ifVersion != t.Version {
+		return store.ErrConflict
+	}
+	live := func(c models.Todo) bool { return c.DeletedAt == nil }
+	for _, id := range append([]string{id}, s.subtree(id, live)...) {
+		t := s.todos[id]
+		if t.Draft || (t.PublishAt != nil && t.PublishAt.After(at)) {
+			t.PublishAt = &at
+		}
+		if t.ExpiresAt != nil && !t.ExpiresAt.After(at) {
+			t.ExpiresAt = nil
+		}
+		t.Draft = false
+		t.Version++
+		s.todos[id] = t
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// recordRevision snapshots the todo as its next revision unless the latest
+// revision already matches. Callers must hold s.mu for writing.
+func (s *Store) recordRevision(todoID string, actorID *string) {
+	t := s.todos[todoID]
+	revs := s.revisions[todoID]
+	if n := len(revs); n > 0 {
+		last := revs[n-1]
+		if last.Text == t.Text && last.Status == t.Status && last.SharedWithAdmin == t.SharedWithAdmin && last.HiddenFromUser == t.HiddenFromUser {
+			return
+		}
+	}
+	s.revisions[todoID] = append(revs, models.TodoRevision{
+		TodoID:          todoID,
+		Revision:        len(revs) + 1,
+		Text:            t.Text,
+		Status:          t.Status,
+		SharedWithAdmin: t.SharedWithAdmin,
+		HiddenFromUser:  t.HiddenFromUser,
+		ChangedBy:       actorID,
+		CreatedAt:       time.Now(),
+	})
+}
+
+func (s *Store) ListTodoRevisions(ctx context.Context, todoID string) ([]models.TodoRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]models.TodoRevision{}, s.revisions[todoID]...), nil
+}
+
+func (s *Store) GetTodoRevision(ctx context.Context, todoID string, revision int) (models.TodoRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	revs := s.revisions[todoID]
+	if revision < 1 || revision > len(revs) {
+		return models.TodoRevision{}, store.ErrNotFound
+	}
+	return revs[revision-1], nil
+}
@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// matches reports whether every search word starts a word of text, which
+// roughly approximates the stemming of the database backends.
+func matches(text string, words []string) bool {
+	textWords := store.SearchWords(text)
+	for _, w := range words {
+		found := false
+		for _, tw := range textWords {
+			if len(tw) >= len(w) && tw[:len(w)] == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// search returns up to limit of the given todos matching query, newest first.
+func search(todos []models.Todo, query string, limit int) []models.Todo {
+	words := store.SearchWords(query)
+	found := []models.Todo{}
+	if len(words) == 0 {
+		return found
+	}
+	for _, t := range todos {
+		if matches(t.Text, words) {
+			found = append(found, t)
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].Created.After(found[j].Created) })
+
+	if len(found) > limit {
+		found = found[:limit]
+	}
+	return found
+}
+
+func (s *Store) SearchUserTodos(ctx context.Context, userID, query string, includeDefault bool, limit int) ([]models.Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return search(s.userTodos(userID, includeDefault), query, limit), nil
+}
+
+func (s *Store) SearchSharedTodos(ctx context.Context, userID, query string, limit int) ([]models.Todo, error) {
+	if userID != "" {
+		todos, err := s.ListSharedTodos(ctx, userID, store.TodoFilter{})
+		if err != nil {
+			return nil, err
+		}
+		return search(todos, query, limit), nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	todos := []models.Todo{}
+	for _, t := range s.todos {
+		if (t.IsDefaultTask || t.SharedWithAdmin) && t.DeletedAt == nil {
+			todos = append(todos, s.withCounts(t, ""))
+		}
+	}
+	return search(todos, query, limit), nil
+}
@@ -0,0 +1,43 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+func (s *Store) CreateSession(ctx context.Context, token, userID string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return store.ErrNotFound
+	}
+	s.sessions[token] = session{userID: userID, expiresAt: expiresAt}
+	return nil
+}
+
+func (s *Store) GetSessionUser(ctx context.Context, token string) (models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sess, ok := s.sessions[token]
+	if !ok || !sess.expiresAt.After(time.Now()) {
+		return models.User{}, store.ErrNotFound
+	}
+	u, ok := s.users[sess.userID]
+	if !ok {
+		return models.User{}, store.ErrNotFound
+	}
+	return u, nil
+}
+
+func (s *Store) DeleteSession(ctx context.Context, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, token)
+	return nil
+}
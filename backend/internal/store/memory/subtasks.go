@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+)
+
+// withSubtasks returns t with the roll-up of its live subtasks, by their
+// status as seen by userID, or by their global status if userID is empty.
+// Callers must hold s.mu.
+func (s *Store) withSubtasks(t models.Todo, userID string) models.Todo {
+	var total, done, started int
+	for _, c := range s.todos {
+		if c.DeletedAt != nil || c.ParentID == nil || *c.ParentID != t.ID {
+			continue
+		}
+		status := c.Status
+		if st, ok := s.states[stateKey{userID, c.ID}]; ok && userID != "" {
+			status = st.status
+		}
+		total++
+		if status == string(models.StatusDone) {
+			done++
+		}
+		if status != string(models.StatusPending) {
+			started++
+		}
+	}
+	t.Subtasks = models.RollUp(total, done, started)
+	return t
+}
+
+// subtree returns the IDs of the subtasks of id at any depth, stopping at
+// the todos rejected by keep. Callers must hold s.mu.
+func (s *Store) subtree(id string, keep func(models.Todo) bool) []string {
+	var ids []string
+	for _, c := range s.todos {
+		if c.ParentID != nil && *c.ParentID == id && keep(c) {
+			ids = append(ids, c.ID)
+			ids = append(ids, s.subtree(c.ID, keep)...)
+		}
+	}
+	return ids
+}
+
+// inTrashWithParent reports whether a trashed todo's parent is in the trash
+// too. Such todos are listed and restored through their parent. Callers must
+// hold s.mu.
+func (s *Store) inTrashWithParent(t models.Todo) bool {
+	if t.ParentID == nil {
+		return false
+	}
+	p, ok := s.todos[*t.ParentID]
+	return ok && p.DeletedAt != nil
+}
+
+// trash sets the deletion time of the given todos. Callers must hold s.mu.
+func (s *Store) trash(ids []string, at *time.Time) {
+	for _, id := range ids {
+		t := s.todos[id]
+		t.DeletedAt = at
+		s.todos[id] = t
+	}
+}
@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// setTags sets the tags of a todo, creating tags on first use. Callers must
+// hold s.mu for writing.
+func (s *Store) setTags(t *models.Todo, tags []string) {
+	t.Tags = slices.Clone(tags)
+	for _, name := range tags {
+		if _, ok := s.tags[name]; !ok {
+			s.tags[name] = models.Tag{Name: name}
+		}
+	}
+}
+
+// hasTags reports whether t has every one of tags.
+func hasTags(t models.Todo, tags []string) bool {
+	for _, tag := range tags {
+		if !slices.Contains(t.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Store) ListTags(ctx context.Context, all bool) ([]models.Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	used := map[string]bool{}
+	if all {
+		for _, t := range s.todos {
+			if t.DeletedAt == nil {
+				for _, name := range t.Tags {
+					used[name] = true
+				}
+			}
+		}
+	}
+
+	tags := []models.Tag{}
+	for _, tag := range s.tags {
+		if tag.Curated || used[tag.Name] {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
+
+func (s *Store) SaveCuratedTag(ctx context.Context, tag models.Tag) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tag.Curated = true
+	s.tags[tag.Name] = tag
+	return nil
+}
+
+func (s *Store) RemoveCuratedTag(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tag, ok := s.tags[name]
+	if !ok || !tag.Curated {
+		return store.ErrNotFound
+	}
+	tag.Curated = false
+	s.tags[name] = tag
+	return nil
+}
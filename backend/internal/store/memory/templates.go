@@ -0,0 +1,144 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+func (s *Store) ListTemplates(ctx context.Context) ([]models.Template, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	templates := []models.Template{}
+	for _, t := range s.templates {
+		templates = append(templates, cloneTemplate(t))
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		if c := strings.Compare(templates[i].Name, templates[j].Name); c != 0 {
+			return c < 0
+		}
+		return templates[i].ID < templates[j].ID
+	})
+	return templates, nil
+}
+
+func (s *Store) GetTemplate(ctx context.Context, id string) (models.Template, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.templates[id]
+	if !ok {
+		return models.Template{}, store.ErrNotFound
+	}
+	return cloneTemplate(t), nil
+}
+
+func (s *Store) SaveTemplate(ctx context.Context, t models.Template) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if old, ok := s.templates[t.ID]; ok {
+		t.CreatedAt = old.CreatedAt
+		for _, task := range old.Tasks {
+			if !slices.ContainsFunc(t.Tasks, func(kept models.TemplateTask) bool { return kept.ID == task.ID }) {
+				s.unlinkTemplateTask(task.ID)
+			}
+		}
+	}
+	s.templates[t.ID] = cloneTemplate(t)
+	return nil
+}
+
+func (s *Store) DeleteTemplate(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.templates[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	for _, task := range t.Tasks {
+		s.unlinkTemplateTask(task.ID)
+	}
+	delete(s.templates, id)
+	delete(s.applied, id)
+	return nil
+}
+
+// unlinkTemplateTask clears the task of the todos added from a removed
+// template task. Callers must hold s.mu.
+func (s *Store) unlinkTemplateTask(taskID string) {
+	for id, todo := range s.todos {
+		if todo.TemplateTaskID != nil && *todo.TemplateTaskID == taskID {
+			todo.TemplateTaskID = nil
+			s.todos[id] = todo
+		}
+	}
+}
+
+func (s *Store) AddTemplateUser(ctx context.Context, templateID, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.templates[templateID]; !ok {
+		return store.ErrNotFound
+	}
+	if s.applied[templateID] == nil {
+		s.applied[templateID] = map[string]bool{}
+	}
+	s.applied[templateID][userID] = true
+	return nil
+}
+
+func (s *Store) ListTemplateUsers(ctx context.Context, templateID string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := []string{}
+	for id := range s.applied[templateID] {
+		users = append(users, id)
+	}
+	slices.Sort(users)
+	return users, nil
+}
+
+func (s *Store) ListTemplateTodos(ctx context.Context, templateID, userID string) ([]models.Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tasks := s.templates[templateID].Tasks
+	position := func(t models.Todo) int {
+		if t.TemplateTaskID == nil {
+			return -1
+		}
+		return slices.IndexFunc(tasks, func(task models.TemplateTask) bool { return task.ID == *t.TemplateTaskID })
+	}
+
+	todos := []models.Todo{}
+	for _, t := range s.todos {
+		if t.UserID != nil && *t.UserID == userID && position(t) >= 0 {
+			todos = append(todos, s.withCounts(t, ""))
+		}
+	}
+	sort.Slice(todos, func(i, j int) bool {
+		if pi, pj := position(todos[i]), position(todos[j]); pi != pj {
+			return pi < pj
+		}
+		return todos[i].Created.Before(todos[j].Created)
+	})
+	return todos, nil
+}
+
+// cloneTemplate returns a copy of t that shares nothing with it.
+func cloneTemplate(t models.Template) models.Template {
+	t.Tasks = slices.Clone(t.Tasks)
+	if t.Tasks == nil {
+		t.Tasks = []models.TemplateTask{}
+	}
+	return t
+}
@@ -0,0 +1,478 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// live returns the todo with the given ID unless it is in the trash.
+// Callers must hold s.mu.
+func (s *Store) live(id string) (models.Todo, bool) {
+	t, ok := s.todos[id]
+	if !ok || t.DeletedAt != nil {
+		return models.Todo{}, false
+	}
+	return t, true
+}
+
+// userView returns t as seen by userID, applying the user's status and
+// position and due date for default tasks. Callers must hold s.mu.
+func (s *Store) userView(t models.Todo, userID string) models.Todo {
+	if t.IsDefaultTask {
+		if st, ok := s.states[stateKey{userID, t.ID}]; ok {
+			t.Status = st.status
+			t.Position = st.position
+			t.StateVersion = st.version
+			if st.dueAt != nil {
+				t.DueAt = st.dueAt
+			}
+			t.Occurrence = st.occurrence
+			t.UpdatedSinceDone = st.updatedSinceDone
+		}
+	}
+	return s.withCounts(t, userID)
+}
+
+func (s *Store) ListUserTodos(ctx context.Context, userID string, includeDefault bool, f store.TodoFilter) ([]models.Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return filterTodos(s.userTodos(userID, includeDefault), f), nil
+}
+
+// userTodos returns every todo in a user's own list, sorted. Callers must
+// hold s.mu.
+func (s *Store) userTodos(userID string, includeDefault bool) []models.Todo {
+	todos := []models.Todo{}
+	for _, t := range s.todos {
+		if t.DeletedAt != nil {
+			continue
+		}
+		owned := t.UserID != nil && *t.UserID == userID
+		if !includeDefault {
+			if owned && !t.IsDefaultTask {
+				todos = append(todos, s.withCounts(t, ""))
+			}
+			continue
+		}
+		if (owned || t.IsDefaultTask) && !t.HiddenFromUser && s.targets(t, userID) && published(t) {
+			todos = append(todos, s.userView(t, userID))
+		}
+	}
+	sortTodos(todos, store.SortPosition)
+	return todos
+}
+
+func (s *Store) ListSharedTodos(ctx context.Context, userID string, f store.TodoFilter) ([]models.Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	todos := []models.Todo{}
+	for _, t := range s.todos {
+		shared := t.UserID != nil && *t.UserID == userID && t.SharedWithAdmin
+		if (shared || t.IsDefaultTask) && t.DeletedAt == nil && s.targets(t, userID) && published(t) {
+			todos = append(todos, s.userView(t, userID))
+		}
+	}
+	return filterTodos(todos, f), nil
+}
+
+func (s *Store) ListDefaultTodos(ctx context.Context, f store.TodoFilter) ([]models.Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	todos := []models.Todo{}
+	for _, t := range s.todos {
+		if t.IsDefaultTask && t.DeletedAt == nil {
+			todos = append(todos, s.withCounts(t, ""))
+		}
+	}
+	return filterTodos(todos, f), nil
+}
+
+func (s *Store) GetTodo(ctx context.Context, id string) (models.Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.live(id)
+	if !ok {
+		return models.Todo{}, store.ErrNotFound
+	}
+	return s.withCounts(t, ""), nil
+}
+
+func (s *Store) GetUserTodo(ctx context.Context, id, userID string) (models.Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.live(id)
+	if !ok || !s.targets(t, userID) || !published(t) {
+		return models.Todo{}, store.ErrNotFound
+	}
+	return s.userView(t, userID), nil
+}
+
+func (s *Store) CreateTodo(ctx context.Context, t *models.Todo) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.insertTodo(t)
+	return nil
+}
+
+// insertTodo adds a todo at the top of its list, as CreateTodo does. Callers
+// must hold s.mu.
+func (s *Store) insertTodo(t *models.Todo) {
+	// Put the new todo above every other todo in its list
+	var minPos float64
+	found := false
+	for _, other := range s.todos {
+		if other.DeletedAt != nil {
+			continue
+		}
+		sameList := other.IsDefaultTask
+		if !t.IsDefaultTask {
+			sameList = t.UserID != nil && other.UserID != nil && *other.UserID == *t.UserID
+		}
+		if sameList && (!found || other.Position < minPos) {
+			minPos = other.Position
+			found = true
+		}
+	}
+	t.Position = minPos - models.PositionIncrement
+	t.Version = 1
+	if t.Priority == "" {
+		t.Priority = string(models.PriorityNormal)
+	}
+
+	stored := *t
+	stored.DueAt = dueAt(t.DueAt)
+	stored.PublishAt = dueAt(t.PublishAt)
+	stored.ExpiresAt = dueAt(t.ExpiresAt)
+	s.setTags(&stored, t.Tags)
+	stored.BlockedBy = slices.Clone(t.BlockedBy)
+	stored.Groups = slices.Clone(t.Groups)
+	s.todos[t.ID] = stored
+	s.recordRevision(t.ID, t.CreatedByUserID)
+}
+
+func (s *Store) UpdateTodo(ctx context.Context, id string, u store.TodoUpdate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.live(id)
+	if !ok {
+		return store.ErrNotFound
+	}
+	if u.IfVersion != nil && *u.IfVersion != t.Version {
+		return store.ErrConflict
+	}
+	if u.BlockedBy != nil && s.dependencyCycle(id, *u.BlockedBy) {
+		return store.ErrDependencyCycle
+	}
+
+	// Completing a recurring todo ends its recurrence here and carries it
+	// over to the next occurrence.
+	rule := t.Recurrence
+	if u.Recurrence != nil {
+		rule = *u.Recurrence
+	}
+	completes := u.Status != nil && *u.Status == string(models.StatusDone) && t.Status != *u.Status && !t.IsDefaultTask && rule != ""
+	if completes {
+		cleared := ""
+		u.Recurrence = &cleared
+	}
+
+	if u.Text != nil {
+		t.Text = *u.Text
+	}
+	if u.Description != nil {
+		t.Description = *u.Description
+	}
+	if u.Status != nil {
+		t.Status = *u.Status
+	}
+	if u.SharedWithAdmin != nil {
+		t.SharedWithAdmin = *u.SharedWithAdmin
+	}
+	if u.HiddenFromUser != nil {
+		t.HiddenFromUser = *u.HiddenFromUser
+	}
+	if u.DueAt != nil {
+		t.DueAt = dueAt(u.DueAt)
+	}
+	if u.Tags != nil {
+		s.setTags(&t, *u.Tags)
+	}
+	if u.Priority != nil {
+		t.Priority = *u.Priority
+	}
+	if u.Recurrence != nil {
+		t.Recurrence = *u.Recurrence
+	}
+	if u.Draft != nil {
+		t.Draft = *u.Draft
+	}
+	if u.PublishAt != nil {
+		t.PublishAt = dueAt(u.PublishAt)
+	}
+	if u.ExpiresAt != nil {
+		t.ExpiresAt = dueAt(u.ExpiresAt)
+	}
+	if u.BlockedBy != nil {
+		t.BlockedBy = slices.Clone(*u.BlockedBy)
+	}
+	if u.Groups != nil {
+		t.Groups = slices.Clone(*u.Groups)
+	}
+	t.Version++
+	s.todos[id] = t
+	if u.Resets() {
+		s.resetUserStates(id, u.Propagate)
+	}
+
+	var actorID *string
+	if u.ActorID != "" {
+		actorID = &u.ActorID
+	}
+	s.recordRevision(id, actorID)
+
+	if completes {
+		t.Recurrence = rule
+		if next, ok := store.NextOccurrence(t, time.Now()); ok {
+			s.insertTodo(&next)
+		}
+	}
+	return nil
+}
+
+func (s *Store) SetDefaultTodoStatus(ctx context.Context, userID, todoID, status string, ifVersion *int) error {
+	return s.UpdateDefaultTodoState(ctx, userID, todoID, store.StateUpdate{Status: &status}, nil, ifVersion)
+}
+
+func (s *Store) UpdateDefaultTodoState(ctx context.Context, userID, todoID string, u store.StateUpdate, ifVersion, ifStateVersion *int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.live(todoID)
+	if !ok || !t.IsDefaultTask {
+		return store.ErrNotFound
+	}
+	if ifVersion != nil && *ifVersion != t.Version {
+		return store.ErrConflict
+	}
+	if st := s.states[stateKey{userID, todoID}]; ifStateVersion != nil && *ifStateVersion != st.version {
+		return store.ErrConflict
+	}
+	// Both writes are checked, so neither can fail now
+	if u.Status != nil {
+		s.setDefaultTodoStatus(userID, t, *u.Status)
+	}
+	if u.DueAt != nil {
+		s.setDefaultTodoDueAt(userID, t, u.DueAt)
+	}
+	return nil
+}
+
+// setDefaultTodoStatus records a user's status for the default task t, as
+// SetDefaultTodoStatus does. Callers must hold s.mu.
+func (s *Store) setDefaultTodoStatus(userID string, t models.Todo, status string) {
+	key := stateKey{userID, t.ID}
+	st, ok := s.states[key]
+	if !ok {
+		st.position = t.Position
+	}
+	st.status = status
+	st.updatedSinceDone = false
+	if r, err := models.ParseRecurrence(t.Recurrence); err == nil && status == string(models.StatusDone) {
+		// Move on to the next occurrence instead.
+		due := t.DueAt
+		if st.dueAt != nil {
+			due = st.dueAt
+		}
+		// Monthly occurrences keep to the day the task itself is due
+		next := r.Anchored(t.DueAt).Next(due, time.Now())
+		st.status = string(models.StatusPending)
+		st.dueAt = &next
+		st.occurrence++
+	}
+	st.version++
+	st.updatedAt = time.Now()
+	s.states[key] = st
+}
+
+func (s *Store) ReorderTodos(ctx context.Context, userID string, ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Validate everything first so a bad ID leaves the order untouched
+	for _, id := range ids {
+		if _, ok := s.live(id); !ok {
+			return store.ErrNotFound
+		}
+	}
+
+	for i, id := range ids {
+		pos := float64(i) * models.PositionIncrement
+		t := s.todos[id]
+
+		if t.IsDefaultTask {
+			key := stateKey{userID, id}
+			st, ok := s.states[key]
+			if !ok {
+				st.status = string(models.StatusPending)
+			}
+			st.position = pos
+			st.updatedAt = time.Now()
+			s.states[key] = st
+		} else if t.UserID != nil && *t.UserID == userID {
+			t.Position = pos
+			s.todos[id] = t
+		}
+	}
+	return nil
+}
+
+func (s *Store) ReorderDefaultTodos(ctx context.Context, ids []string, resetUsers bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Validate everything first so a bad ID leaves the order untouched
+	for _, id := range ids {
+		if t, ok := s.live(id); !ok || !t.IsDefaultTask {
+			return store.ErrNotFound
+		}
+	}
+
+	for i, id := range ids {
+		pos := float64(i) * models.PositionIncrement
+		t := s.todos[id]
+		t.Position = pos
+		s.todos[id] = t
+		if !resetUsers {
+			continue
+		}
+		for key, st := range s.states {
+			if key.todoID == id {
+				st.position = pos
+				st.updatedAt = time.Now()
+				s.states[key] = st
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Store) DeleteTodo(ctx context.Context, id string, ifVersion *int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.live(id)
+	if !ok {
+		return store.ErrNotFound
+	}
+	if ifVersion != nil && *ifVersion != t.Version {
+		return store.ErrConflict
+	}
+	now := time.Now()
+	live := func(c models.Todo) bool { return c.DeletedAt == nil }
+	s.trash(append([]string{id}, s.subtree(id, live)...), &now)
+	return nil
+}
+
+func (s *Store) ListDeletedTodos(ctx context.Context, userID string) ([]models.Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	todos := []models.Todo{}
+	for _, t := range s.todos {
+		if t.DeletedAt == nil {
+			continue
+		}
+		owned := !t.IsDefaultTask && t.UserID != nil && *t.UserID == userID
+		if ((userID == "" && t.IsDefaultTask) || owned) && !s.inTrashWithParent(t) {
+			todos = append(todos, s.withCounts(t, ""))
+		}
+	}
+	sort.Slice(todos, func(i, j int) bool { return todos[i].DeletedAt.After(*todos[j].DeletedAt) })
+	return todos, nil
+}
+
+func (s *Store) GetDeletedTodo(ctx context.Context, id string) (models.Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.todos[id]
+	if !ok || t.DeletedAt == nil {
+		return models.Todo{}, store.ErrNotFound
+	}
+	return s.withCounts(t, ""), nil
+}
+
+func (s *Store) RestoreTodo(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.todos[id]
+	if !ok || t.DeletedAt == nil {
+		return store.ErrNotFound
+	}
+	// Subtasks deleted along with the todo come back with it
+	deletedWith := func(c models.Todo) bool { return c.DeletedAt != nil && c.DeletedAt.Equal(*t.DeletedAt) }
+	s.trash(append([]string{id}, s.subtree(id, deletedWith)...), nil)
+	return nil
+}
+
+func (s *Store) PurgeDeletedTodos(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged, n := s.purgeable(before)
+	for _, id := range purged {
+		delete(s.todos, id)
+		delete(s.revisions, id)
+		for key := range s.states {
+			if key.todoID == id {
+				delete(s.states, key)
+			}
+		}
+		for cid, c := range s.comments {
+			if c.TodoID == id {
+				delete(s.comments, cid)
+			}
+		}
+		for aid, a := range s.attachments {
+			if a.TodoID == id {
+				delete(s.attachments, aid)
+			}
+		}
+	}
+	return n, nil
+}
+
+// purgeable returns the IDs of the todos PurgeDeletedTodos removes for
+// before, and how many of them are not just removed with their parent.
+// Callers must hold s.mu.
+func (s *Store) purgeable(before time.Time) ([]string, int64) {
+	var n int64
+	var purged []string
+	for id, t := range s.todos {
+		if t.DeletedAt == nil || !t.DeletedAt.Before(before) {
+			continue
+		}
+		purged = append(purged, id)
+		n++
+	}
+
+	// Subtasks go with their parent, as with ON DELETE CASCADE
+	all := func(models.Todo) bool { return true }
+	for _, id := range purged {
+		purged = append(purged, s.subtree(id, all)...)
+	}
+	return purged, n
+}
@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// errDuplicateUser mirrors the unique constraints on the users table.
+var errDuplicateUser = errors.New("memory: user with the same id, google_id or email already exists")
+
+func (s *Store) GetUser(ctx context.Context, id string) (models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return models.User{}, store.ErrNotFound
+	}
+	return u, nil
+}
+
+func (s *Store) GetUserByGoogleID(ctx context.Context, googleID string) (models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if u.GoogleID == googleID {
+			return u, nil
+		}
+	}
+	return models.User{}, store.ErrNotFound
+}
+
+func (s *Store) CreateUser(ctx context.Context, u models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, other := range s.users {
+		if other.ID == u.ID || other.GoogleID == u.GoogleID || other.Email == u.Email {
+			return errDuplicateUser
+		}
+	}
+	if u.CreatedAt.IsZero() {
+		u.CreatedAt = time.Now()
+	}
+	s.users[u.ID] = u
+	return nil
+}
+
+func (s *Store) UpdateUserRole(ctx context.Context, id, role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	u.Role = role
+	s.users[id] = u
+	return nil
+}
+
+func (s *Store) ListUsers(ctx context.Context, f store.UserFilter) ([]models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := []models.User{}
+	for _, u := range s.users {
+		if u.Role != string(models.RoleAdmin) && inRange(u.CreatedAt, f.From, f.To) {
+			users = append(users, u)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].CreatedAt.After(users[j].CreatedAt)
+		}
+		return users[i].ID < users[j].ID
+	})
+
+	if c := f.After; c != nil {
+		i := 0
+		for i < len(users) && !(users[i].CreatedAt.Before(c.Created) || users[i].CreatedAt.Equal(c.Created) && users[i].ID > c.ID) {
+			i++
+		}
+		users = users[i:]
+	}
+	if f.Limit > 0 && len(users) > f.Limit {
+		users = users[:f.Limit]
+	}
+	return users, nil
+}
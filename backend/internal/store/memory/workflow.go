@@ -0,0 +1,36 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/akhilmk/packup/internal/models"
+)
+
+func (s *Store) GetWorkflow(ctx context.Context) (models.Workflow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return cloneWorkflow(s.workflow), nil
+}
+
+func (s *Store) SaveWorkflow(ctx context.Context, w models.Workflow) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.workflow = cloneWorkflow(w)
+	return nil
+}
+
+// cloneWorkflow returns a copy of w that shares nothing with it.
+func cloneWorkflow(w models.Workflow) models.Workflow {
+	c := models.Workflow{Statuses: slices.Clone(w.Statuses)}
+	if len(w.Transitions) > 0 {
+		c.Transitions = maps.Clone(w.Transitions)
+		for from, to := range c.Transitions {
+			c.Transitions[from] = slices.Clone(to)
+		}
+	}
+	return c
+}
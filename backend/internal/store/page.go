@@ -0,0 +1,60 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+)
+
+// ErrInvalidCursor is returned by ParseCursor for malformed tokens.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks the last item of a page; listings continue after it in their
+// sort order. Each listing uses the fields it sorts on and ignores the rest.
+type Cursor struct {
+	Position float64    `json:"p,omitempty"`
+	Due      *time.Time `json:"d,omitempty"`
+	Priority string     `json:"r,omitempty"`
+	Created  time.Time  `json:"c"`
+	ID       string     `json:"i"`
+}
+
+// TodoCursor returns the cursor continuing a todo listing after t.
+func TodoCursor(t models.Todo) Cursor {
+	return Cursor{Position: t.Position, Due: t.DueAt, Priority: t.Priority, Created: t.Created, ID: t.ID}
+}
+
+// UserCursor returns the cursor continuing a user listing after u.
+func UserCursor(u models.User) Cursor {
+	return Cursor{Created: u.CreatedAt, ID: u.ID}
+}
+
+// String encodes the cursor as an opaque token for API clients.
+func (c Cursor) String() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// ParseCursor decodes a token returned by Cursor.String.
+func ParseCursor(token string) (Cursor, error) {
+	var c Cursor
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || json.Unmarshal(b, &c) != nil || c.ID == "" {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// Paginate trims items, fetched with a limit of limit+1, to a page of limit
+// items. If more items follow, it also returns the token of the cursor after
+// the last one.
+func Paginate[T any](items []T, limit int, cursor func(T) Cursor) ([]T, string) {
+	if limit <= 0 || len(items) <= limit {
+		return items, ""
+	}
+	items = items[:limit]
+	return items, cursor(items[limit-1]).String()
+}
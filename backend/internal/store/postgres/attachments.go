@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+	"github.com/jackc/pgx/v5"
+)
+
+const attachmentColumns = `id, todo_id, user_id, uploaded_by, filename, content_type, size, created_at`
+
+func scanAttachment(row pgx.Row) (models.Attachment, error) {
+	var a models.Attachment
+	err := row.Scan(&a.ID, &a.TodoID, &a.UserID, &a.UploadedBy, &a.Filename, &a.ContentType, &a.Size, &a.CreatedAt)
+	return a, err
+}
+
+func (s *Store) queryAttachments(ctx context.Context, query string, args ...any) ([]models.Attachment, error) {
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []models.Attachment{}
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+func (s *Store) ListAttachments(ctx context.Context, todoID, userID string) ([]models.Attachment, error) {
+	return s.queryAttachments(ctx, `
+		SELECT `+attachmentColumns+` FROM todo_attachments
+		WHERE todo_id = $1 AND user_id = $2
+		ORDER BY created_at, id
+	`, todoID, userID)
+}
+
+func (s *Store) ListPurgeableAttachments(ctx context.Context, before time.Time) ([]models.Attachment, error) {
+	// Subtasks are purged with their parent, as with ON DELETE CASCADE
+	return s.queryAttachments(ctx, `
+		WITH RECURSIVE purged(id) AS (
+			SELECT id FROM todos WHERE deleted_at < $1
+			UNION
+			SELECT c.id FROM todos c JOIN purged ON c.parent_id = purged.id
+		)
+		SELECT `+attachmentColumns+` FROM todo_attachments
+		WHERE todo_id IN (SELECT id FROM purged)
+		ORDER BY created_at, id
+	`, before)
+}
+
+func (s *Store) GetAttachment(ctx context.Context, id string) (models.Attachment, error) {
+	a, err := scanAttachment(s.db.QueryRow(ctx, `SELECT `+attachmentColumns+` FROM todo_attachments WHERE id = $1`, id))
+	return a, mapErr(err)
+}
+
+func (s *Store) CreateAttachment(ctx context.Context, a models.Attachment) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO todo_attachments (id, todo_id, user_id, uploaded_by, filename, content_type, size, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, a.ID, a.TodoID, a.UserID, a.UploadedBy, a.Filename, a.ContentType, a.Size, a.CreatedAt)
+	return err
+}
+
+func (s *Store) DeleteAttachment(ctx context.Context, id string) error {
+	cmd, err := s.db.Exec(ctx, `DELETE FROM todo_attachments WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/akhilmk/packup/internal/store"
+	"github.com/jackc/pgx/v5"
+)
+
+// blockerForOwner matches the blockers b the owner of todo t can see: live,
+// and meant for them as targetsViewer matches todos for the viewer. Default
+// tasks have no owner, so all their blockers count.
+const blockerForOwner = `(t.user_id IS NULL OR (` + blockerLive + ` AND (b.is_default_task = false
+	OR NOT EXISTS (SELECT 1 FROM todo_groups tg WHERE tg.todo_id = b.id)
+	OR EXISTS (SELECT 1 FROM todo_groups tg JOIN user_group_members gm ON gm.group_id = tg.group_id
+		WHERE tg.todo_id = b.id AND gm.user_id = t.user_id))))`
+
+// blockerForViewer matches the blockers b the viewing user can see.
+const blockerForViewer = `(` + blockerLive + ` AND (b.is_default_task = false
+	OR NOT EXISTS (SELECT 1 FROM todo_groups tg WHERE tg.todo_id = b.id)
+	OR EXISTS (SELECT 1 FROM todo_groups tg JOIN user_group_members gm ON gm.group_id = tg.group_id
+		WHERE tg.todo_id = b.id AND gm.user_id = viewer.id)))`
+
+// todoBlockers selects the IDs of a todo's live blockers its owner can see,
+// sorted, and whether any of them is unfinished for the owner, by the owner's
+// own status of default tasks.
+const todoBlockers = `
+	ARRAY(SELECT d.blocker_id FROM todo_dependencies d JOIN todos b ON b.id = d.blocker_id
+		WHERE d.todo_id = t.id AND b.deleted_at IS NULL AND ` + blockerForOwner + ` ORDER BY d.blocker_id) as blocked_by,
+	EXISTS (SELECT 1 FROM todo_dependencies d JOIN todos b ON b.id = d.blocker_id
+		WHERE d.todo_id = t.id AND b.deleted_at IS NULL AND ` + blockerForOwner + `
+		AND COALESCE((SELECT bs.status FROM user_todo_state bs WHERE bs.todo_id = b.id AND bs.user_id = t.user_id AND b.is_default_task), b.status) <> 'done'
+	) as blocked`
+
+// userTodoBlockers is todoBlockers for the viewing user, by their status of
+// each blocker.
+const userTodoBlockers = `
+	ARRAY(SELECT d.blocker_id FROM todo_dependencies d JOIN todos b ON b.id = d.blocker_id
+		WHERE d.todo_id = t.id AND b.deleted_at IS NULL AND ` + blockerForViewer + ` ORDER BY d.blocker_id) as blocked_by,
+	EXISTS (SELECT 1 FROM todo_dependencies d JOIN todos b ON b.id = d.blocker_id
+		WHERE d.todo_id = t.id AND b.deleted_at IS NULL AND ` + blockerForViewer + `
+		AND COALESCE((SELECT bs.status FROM user_todo_state bs WHERE bs.todo_id = b.id AND bs.user_id = viewer.id AND b.is_default_task), b.status) <> 'done'
+	) as blocked`
+
+// reaches reports whether todo $2 is a blocker of todo $1, at any depth, or
+// $1 itself.
+const reaches = `
+	WITH RECURSIVE reach(id) AS (
+		SELECT $1::text
+		UNION
+		SELECT d.blocker_id FROM todo_dependencies d JOIN reach ON d.todo_id = reach.id
+	)
+	SELECT EXISTS (SELECT 1 FROM reach WHERE id = $2)`
+
+// setTodoBlockers replaces the blockers of a todo. It fails with
+// store.ErrDependencyCycle if the todo would depend on itself.
+func setTodoBlockers(ctx context.Context, tx pgx.Tx, todoID string, blockers []string) error {
+	for _, id := range blockers {
+		var cycle bool
+		if err := tx.QueryRow(ctx, reaches, id, todoID).Scan(&cycle); err != nil {
+			return err
+		}
+		if cycle {
+			return store.ErrDependencyCycle
+		}
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM todo_dependencies WHERE todo_id = $1`, todoID); err != nil {
+		return err
+	}
+	for _, id := range blockers {
+		if _, err := tx.Exec(ctx, `INSERT INTO todo_dependencies (todo_id, blocker_id) VALUES ($1, $2)`, todoID, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+	"github.com/jackc/pgx/v5"
+)
+
+// dueAt returns the column value of a due date, or of another optional time,
+// NULL for nil or the zero time.
+func dueAt(t *time.Time) *time.Time {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+func (s *Store) SetDefaultTodoDueAt(ctx context.Context, userID, todoID string, due *time.Time, ifVersion *int) error {
+	if due == nil {
+		due = &time.Time{}
+	}
+	return s.UpdateDefaultTodoState(ctx, userID, todoID, store.StateUpdate{DueAt: due}, nil, ifVersion)
+}
+
+// setDefaultTodoDueAt overrides the due date of a default task for a user,
+// as SetDefaultTodoDueAt does.
+func setDefaultTodoDueAt(ctx context.Context, tx pgx.Tx, userID, todoID string, due *time.Time, ifVersion *int) error {
+	// New rows start from the task's global status and position.
+	// A missing row counts as version 0.
+	cmd, err := tx.Exec(ctx, `
+		INSERT INTO user_todo_state (user_id, todo_id, status, position, due_at, version, updated_at)
+		SELECT $1, t.id, t.status, t.position, $3::timestamptz, 1, now()
+		FROM todos t
+		WHERE t.id = $2 AND ($4::integer IS NULL
+			OR $4 = COALESCE((SELECT version FROM user_todo_state WHERE user_id=$1 AND todo_id=$2), 0))
+		ON CONFLICT (user_id, todo_id)
+		DO UPDATE SET due_at = EXCLUDED.due_at, version = user_todo_state.version + 1, updated_at = now()
+		WHERE $4::integer IS NULL OR user_todo_state.version = $4
+	`, userID, todoID, dueAt(due), ifVersion)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return store.ErrConflict
+	}
+	return nil
+}
+
+// overdueTodos selects every todo visible to admins as user_id plus the
+// columns of userTodoColumns: default tasks once per non-admin user they are
+// meant for, as that user sees them, and personal todos shared with admins.
+const overdueTodos = `
+	SELECT viewer.id AS user_id, ` + userTodoColumns + `
+	FROM users viewer
+	JOIN todos t ON t.is_default_task = true
+	LEFT JOIN user_todo_state uts ON t.id = uts.todo_id AND uts.user_id = viewer.id
+	WHERE viewer.role != 'admin' AND t.deleted_at IS NULL AND ` + targetsViewer + ` AND ` + liveNow + `
+	UNION ALL
+	SELECT t.user_id, ` + todoColumns + `
+	FROM todos t
+	WHERE t.is_default_task = false AND t.shared_with_admin = true AND t.deleted_at IS NULL`
+
+func (s *Store) ListOverdueTodos(ctx context.Context, userID string, now time.Time, limit int) ([]models.OverdueTodo, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT * FROM (`+overdueTodos+`) o
+		WHERE o.due_at < $1 AND o.status <> 'done' AND ($2 = '' OR o.user_id = $2)
+		ORDER BY o.due_at, o.user_id, o.id
+		LIMIT $3
+	`, now, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	todos := []models.OverdueTodo{}
+	for rows.Next() {
+		var userID string
+		var r todoRow
+		if err := rows.Scan(append([]any{&userID}, r.fields()...)...); err != nil {
+			return nil, err
+		}
+		todos = append(todos, models.OverdueTodo{UserID: userID, Todo: r.todo()})
+	}
+	return todos, rows.Err()
+}
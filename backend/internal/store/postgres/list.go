@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// userStatus, userPosition and userDue are the status, position and due date
+// of userTodoColumns, for filtering and ordering on them.
+const (
+	userStatus   = `CASE WHEN t.is_default_task THEN COALESCE(uts.status, t.status) ELSE t.status END`
+	userPosition = `CASE WHEN t.is_default_task THEN COALESCE(uts.position, t.position) ELSE t.position END`
+	userDue      = `CASE WHEN t.is_default_task THEN COALESCE(uts.due_at, t.due_at) ELSE t.due_at END`
+)
+
+// priorityRank ranks t.priority as models.TodoPriority.Rank does, for
+// ordering by it.
+const priorityRank = `CASE t.priority WHEN 'low' THEN 0 WHEN 'normal' THEN 1 WHEN 'high' THEN 2 WHEN 'urgent' THEN 3 ELSE -1 END`
+
+// todoList builds a todo listing query, narrowed and paged by a
+// store.TodoFilter on top of the conditions selecting the list itself.
+type todoList struct {
+	selects  string // select list and FROM clause
+	status   string // expression of the listed status
+	position string // expression of the listed position
+	due      string // expression of the listed due date
+	conds    []string
+	args     []any
+}
+
+// arg binds value to the next query parameter and returns its placeholder.
+func (l *todoList) arg(value any) string {
+	l.args = append(l.args, value)
+	return fmt.Sprintf("$%d", len(l.args))
+}
+
+func (l *todoList) query(f store.TodoFilter) (string, []any) {
+	conds := append([]string{}, l.conds...)
+	if f.Status != "" {
+		conds = append(conds, l.status+" = "+l.arg(f.Status))
+	}
+	switch f.Source {
+	case models.SourceDefault:
+		conds = append(conds, "t.is_default_task = true")
+	case models.SourceAdmin:
+		conds = append(conds, "t.is_default_task = false AND t.created_by_user_id IS DISTINCT FROM t.user_id")
+	case models.SourcePersonal:
+		conds = append(conds, "t.is_default_task = false AND t.created_by_user_id = t.user_id")
+	}
+	if f.ParentID != "" {
+		conds = append(conds, "t.parent_id = "+l.arg(f.ParentID))
+	}
+	for _, tag := range f.Tags {
+		conds = append(conds, fmt.Sprintf(hasTag, l.arg(tag)))
+	}
+	if !f.From.IsZero() {
+		conds = append(conds, "t.created >= "+l.arg(f.From))
+	}
+	if !f.To.IsZero() {
+		conds = append(conds, "t.created < "+l.arg(f.To))
+	}
+	if !f.DueFrom.IsZero() {
+		conds = append(conds, l.due+" >= "+l.arg(f.DueFrom))
+	}
+	if !f.DueTo.IsZero() {
+		conds = append(conds, l.due+" < "+l.arg(f.DueTo))
+	}
+	if !f.OverdueAt.IsZero() {
+		conds = append(conds, l.due+" < "+l.arg(f.OverdueAt), l.status+" <> 'done'")
+	}
+	if f.Publication != "" {
+		conds = append(conds, inPublication(f.Publication, l.arg(time.Now())))
+	}
+
+	// Keyset conditions continue after the cursor in the order below
+	order := l.position + " ASC"
+	switch f.Sort {
+	case store.SortDue:
+		order = l.due + " ASC NULLS LAST"
+	case store.SortPriority:
+		order = priorityRank + " DESC, " + order
+	}
+	if c := f.After; c != nil {
+		created, id := l.arg(c.Created), l.arg(c.ID)
+		after := fmt.Sprintf("(t.created < %[1]s OR (t.created = %[1]s AND t.id > %[2]s))", created, id)
+		switch {
+		case f.Sort != store.SortDue:
+			pos := l.arg(c.Position)
+			after = fmt.Sprintf("(%[1]s > %[2]s OR (%[1]s = %[2]s AND %[3]s))", l.position, pos, after)
+			if f.Sort == store.SortPriority {
+				rank := l.arg(models.TodoPriority(c.Priority).Rank())
+				after = fmt.Sprintf("(%[1]s < %[2]s OR (%[1]s = %[2]s AND %[3]s))", priorityRank, rank, after)
+			}
+		case c.Due != nil:
+			due := l.arg(c.Due)
+			after = fmt.Sprintf("(%[1]s > %[2]s OR %[1]s IS NULL OR (%[1]s = %[2]s AND %[3]s))", l.due, due, after)
+		default:
+			after = fmt.Sprintf("(%s IS NULL AND %s)", l.due, after)
+		}
+		conds = append(conds, after)
+	}
+
+	query := "SELECT " + l.selects + " WHERE " + strings.Join(conds, " AND ") +
+		" ORDER BY " + order + ", t.created DESC, t.id ASC"
+	if f.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", f.Limit)
+	}
+	return query, l.args
+}
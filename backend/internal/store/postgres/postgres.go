@@ -0,0 +1,30 @@
+// Package postgres implements the store interfaces on top of PostgreSQL.
+package postgres
+
+import (
+	"errors"
+
+	"github.com/akhilmk/packup/internal/store"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Store implements store.Store.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+var _ store.Store = (*Store)(nil)
+
+// New returns a Store backed by the given connection pool.
+func New(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+// mapErr translates driver errors into store errors.
+func mapErr(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return store.ErrNotFound
+	}
+	return err
+}
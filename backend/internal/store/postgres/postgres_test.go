@@ -0,0 +1,54 @@
+package postgres_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/akhilmk/packup/internal/database"
+	"github.com/akhilmk/packup/internal/store"
+	"github.com/akhilmk/packup/internal/store/storetest"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// emptyTables truncates every table but schema_migrations, so that tables
+// added by later migrations are emptied too.
+const emptyTables = `
+	DO $$ BEGIN
+		EXECUTE (
+			SELECT 'TRUNCATE ' || string_agg(quote_ident(tablename), ', ') || ' RESTART IDENTITY CASCADE'
+			FROM pg_tables
+			WHERE schemaname = current_schema() AND tablename <> 'schema_migrations'
+		);
+	END $$`
+
+// TestStore runs the store conformance suite against the PostgreSQL database
+// in TEST_DATABASE_URL. Every table is emptied before each subtest, so never
+// point it at a database holding real data.
+func TestStore(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	t.Setenv("DATABASE_URL", url)
+
+	ctx := context.Background()
+	db, err := database.New(ctx)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer db.Close()
+
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer pool.Close()
+
+	storetest.Run(t, func(t *testing.T) store.Store {
+		if _, err := pool.Exec(ctx, emptyTables); err != nil {
+			t.Fatalf("Failed to empty database: %v", err)
+		}
+		return db
+	})
+}
@@ -0,0 +1,20 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// resetUserStates moves the users of a default task back to pending as
+// policy p says, flagging those who were done.
+func resetUserStates(ctx context.Context, tx pgx.Tx, todoID string, p models.Propagation) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE user_todo_state
+		SET status = 'pending', updated_since_done = (updated_since_done OR status = 'done'),
+			version = version + 1, updated_at = now()
+		WHERE todo_id = $1 AND status <> 'pending' AND ($2::boolean OR status = 'done')
+	`, todoID, p == models.PropagateResetAll)
+	return err
+}
@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+	"github.com/jackc/pgx/v5"
+)
+
+// completedRecurrence returns the rule a personal todo recurs by when it is
+// about to be marked done, or "" if it does not recur or is already done.
+// rule, if set, is the rule the same update gives the todo. The todo is
+// locked until tx ends, so that it is completed only once.
+func completedRecurrence(ctx context.Context, tx pgx.Tx, id string, rule *string) (string, error) {
+	var current, status string
+	var isDefault bool
+	err := tx.QueryRow(ctx, `SELECT recurrence, status, is_default_task FROM todos WHERE id=$1 AND deleted_at IS NULL FOR UPDATE`, id).Scan(&current, &status, &isDefault)
+	if errors.Is(err, pgx.ErrNoRows) || isDefault || status == string(models.StatusDone) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if rule != nil {
+		current = *rule
+	}
+	return current, nil
+}
+
+// insertNextOccurrence creates the occurrence of the todo id that follows
+// it, recurring by rule.
+func insertNextOccurrence(ctx context.Context, tx pgx.Tx, id, rule string) error {
+	t, err := scanTodo(tx.QueryRow(ctx, `SELECT `+todoColumns+` FROM todos t WHERE t.id = $1`, id))
+	if err != nil {
+		return err
+	}
+	t.Recurrence = rule
+	next, ok := store.NextOccurrence(t, time.Now())
+	if !ok {
+		return nil
+	}
+	return insertTodo(ctx, tx, &next)
+}
+
+// advanceDefaultTodo moves userID on to the next occurrence of a recurring
+// default task, as SetDefaultTodoStatus does when it is marked done. It
+// reports false, changing nothing, if the task does not recur.
+func advanceDefaultTodo(ctx context.Context, tx pgx.Tx, userID, todoID string, ifVersion *int) (bool, error) {
+	var rule string
+	var start, due *time.Time
+	var occurrence int
+	err := tx.QueryRow(ctx, `
+		SELECT t.recurrence, t.due_at, COALESCE(uts.due_at, t.due_at), COALESCE(uts.occurrence, 0)
+		FROM todos t
+		LEFT JOIN user_todo_state uts ON uts.todo_id = t.id AND uts.user_id = $1
+		WHERE t.id = $2 AND t.is_default_task = true
+		FOR UPDATE OF t
+	`, userID, todoID).Scan(&rule, &start, &due, &occurrence)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	r, err := models.ParseRecurrence(rule)
+	if err != nil {
+		return false, nil
+	}
+
+	// Monthly occurrences keep to the day the task itself is due
+	next := r.Anchored(start).Next(due, time.Now())
+	// New rows start from the task's global position.
+	// A missing row counts as version 0.
+	cmd, err := tx.Exec(ctx, `
+		INSERT INTO user_todo_state (user_id, todo_id, status, position, due_at, occurrence, version, updated_at)
+		SELECT $1, t.id, $3, t.position, $4, $5, 1, now()
+		FROM todos t
+		WHERE t.id = $2 AND ($6::integer IS NULL
+			OR $6 = COALESCE((SELECT version FROM user_todo_state WHERE user_id=$1 AND todo_id=$2), 0))
+		ON CONFLICT (user_id, todo_id)
+		DO UPDATE SET status = EXCLUDED.status, due_at = EXCLUDED.due_at, occurrence = EXCLUDED.occurrence,
+			updated_since_done = false, version = user_todo_state.version + 1, updated_at = now()
+		WHERE $6::integer IS NULL OR user_todo_state.version = $6
+	`, userID, todoID, string(models.StatusPending), next, occurrence+1, ifVersion)
+	if err != nil {
+		return false, err
+	}
+	if cmd.RowsAffected() == 0 {
+		return false, store.ErrConflict
+	}
+	return true, nil
+}
@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/akhilmk/packup/internal/models"
+)
+
+// Search queries join the parsed search text as "query", which matches
+// todos containing every word, and put the best matches first.
+const searchOrder = `
+	ORDER BY ts_rank(t.search_vector, query) DESC, t.created DESC`
+
+func (s *Store) SearchUserTodos(ctx context.Context, userID, query string, includeDefault bool, limit int) ([]models.Todo, error) {
+	if !includeDefault {
+		return s.queryTodos(ctx, `
+			SELECT `+todoColumns+`
+			FROM todos t
+			CROSS JOIN plainto_tsquery('english', $2) AS query
+			WHERE t.user_id = $1 AND t.is_default_task = false AND t.deleted_at IS NULL
+				AND t.search_vector @@ query`+searchOrder+`
+			LIMIT $3
+		`, userID, query, limit)
+	}
+
+	return s.queryTodos(ctx, `
+		SELECT `+userTodoColumns+userTodoJoin+`
+		CROSS JOIN plainto_tsquery('english', $2) AS query
+		WHERE (t.user_id = $1 OR t.is_default_task = true) AND t.hidden_from_user = false AND t.deleted_at IS NULL
+			AND `+targetsViewer+` AND `+liveNow+` AND t.search_vector @@ query`+searchOrder+`
+		LIMIT $3
+	`, userID, query, limit)
+}
+
+func (s *Store) SearchSharedTodos(ctx context.Context, userID, query string, limit int) ([]models.Todo, error) {
+	if userID == "" {
+		// Default tasks with their global status, plus every user's shared todos
+		return s.queryTodos(ctx, `
+			SELECT `+todoColumns+`
+			FROM todos t
+			CROSS JOIN plainto_tsquery('english', $1) AS query
+			WHERE (t.is_default_task = true OR t.shared_with_admin = true) AND t.deleted_at IS NULL
+				AND t.search_vector @@ query`+searchOrder+`
+			LIMIT $2
+		`, query, limit)
+	}
+
+	return s.queryTodos(ctx, `
+		SELECT `+userTodoColumns+userTodoJoin+`
+		CROSS JOIN plainto_tsquery('english', $2) AS query
+		WHERE ((t.user_id = $1 AND t.shared_with_admin = true) OR t.is_default_task = true) AND t.deleted_at IS NULL
+			AND `+targetsViewer+` AND `+liveNow+` AND t.search_vector @@ query`+searchOrder+`
+		LIMIT $3
+	`, userID, query, limit)
+}
@@ -0,0 +1,29 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+)
+
+func (s *Store) CreateSession(ctx context.Context, token, userID string, expiresAt time.Time) error {
+	_, err := s.db.Exec(ctx, "INSERT INTO sessions(token, user_id, expires_at) VALUES($1,$2,$3)", token, userID, expiresAt)
+	return err
+}
+
+func (s *Store) GetSessionUser(ctx context.Context, token string) (models.User, error) {
+	var u models.User
+	err := s.db.QueryRow(ctx, `
+		SELECT u.id, u.google_id, u.email, u.name, u.avatar_url, u.role, u.created_at
+		FROM sessions s
+		JOIN users u ON s.user_id = u.id
+		WHERE s.token = $1 AND s.expires_at > now()
+	`, token).Scan(&u.ID, &u.GoogleID, &u.Email, &u.Name, &u.AvatarURL, &u.Role, &u.CreatedAt)
+	return u, mapErr(err)
+}
+
+func (s *Store) DeleteSession(ctx context.Context, token string) error {
+	_, err := s.db.Exec(ctx, "DELETE FROM sessions WHERE token=$1", token)
+	return err
+}
@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+	"github.com/jackc/pgx/v5"
+)
+
+// todoTags selects the names of a todo's tags, sorted.
+const todoTags = `
+	ARRAY(SELECT tg.name FROM todo_tags tt JOIN tags tg ON tg.id = tt.tag_id WHERE tt.todo_id = t.id ORDER BY tg.name) as tags`
+
+// hasTag matches todos with the tag named by the parameter %s.
+const hasTag = `EXISTS (SELECT 1 FROM todo_tags tt JOIN tags tg ON tg.id = tt.tag_id WHERE tt.todo_id = t.id AND tg.name = %s)`
+
+// setTodoTags replaces the tags of a todo, creating tags on first use.
+func setTodoTags(ctx context.Context, tx pgx.Tx, todoID string, tags []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM todo_tags WHERE todo_id = $1`, todoID); err != nil {
+		return err
+	}
+	for _, name := range tags {
+		if _, err := tx.Exec(ctx, `INSERT INTO tags (name) VALUES ($1) ON CONFLICT (name) DO NOTHING`, name); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `INSERT INTO todo_tags (todo_id, tag_id) SELECT $1, id FROM tags WHERE name = $2`, todoID, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) ListTags(ctx context.Context, all bool) ([]models.Tag, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT tg.name, tg.color, tg.curated
+		FROM tags tg
+		WHERE tg.curated OR ($1 AND EXISTS (
+			SELECT 1 FROM todo_tags tt JOIN todos t ON t.id = tt.todo_id
+			WHERE tt.tag_id = tg.id AND t.deleted_at IS NULL
+		))
+		ORDER BY tg.name
+	`, all)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.Name, &tag.Color, &tag.Curated); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+func (s *Store) SaveCuratedTag(ctx context.Context, tag models.Tag) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO tags (name, color, curated) VALUES ($1, $2, true)
+		ON CONFLICT (name) DO UPDATE SET color = EXCLUDED.color, curated = true
+	`, tag.Name, tag.Color)
+	return err
+}
+
+func (s *Store) RemoveCuratedTag(ctx context.Context, name string) error {
+	cmd, err := s.db.Exec(ctx, `UPDATE tags SET curated = false WHERE name = $1 AND curated`, name)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,577 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+	"github.com/jackc/pgx/v5"
+)
+
+// todoColumns selects a todo with its global status and position.
+const todoColumns = `
+	t.id,
+	t.text,
+	t.description,
+	t.status,
+	t.created,
+	t.position,
+	t.created_by_user_id,
+	t.is_default_task,
+	t.shared_with_admin,
+	t.hidden_from_user,
+	t.user_id,
+	t.deleted_at,
+	t.due_at,
+	t.parent_id,
+	t.priority,
+	t.recurrence,
+	t.occurrence,
+	t.template_task_id,
+	t.draft,
+	t.publish_at,
+	t.expires_at,
+	t.version,
+	0 as state_version,
+	false as updated_since_done,` + subtaskCounts + `,` + todoTags + `,` + commentCount + `,` + todoBlockers + `,` + todoGroups
+
+// userTodoColumns selects a todo as seen by the viewer, using the
+// user-specific status/position/due date from user_todo_state for default tasks.
+// It must be used together with userTodoJoin, or another join naming the
+// viewing user viewer and their state uts.
+const userTodoColumns = `
+	t.id,
+	t.text,
+	t.description,
+	CASE
+		WHEN t.is_default_task THEN COALESCE(uts.status, t.status)
+		ELSE t.status
+	END as status,
+	t.created,
+	CASE
+		WHEN t.is_default_task THEN COALESCE(uts.position, t.position)
+		ELSE t.position
+	END as position,
+	t.created_by_user_id,
+	t.is_default_task,
+	t.shared_with_admin,
+	t.hidden_from_user,
+	t.user_id,
+	t.deleted_at,
+	CASE
+		WHEN t.is_default_task THEN COALESCE(uts.due_at, t.due_at)
+		ELSE t.due_at
+	END as due_at,
+	t.parent_id,
+	t.priority,
+	t.recurrence,
+	CASE
+		WHEN t.is_default_task THEN COALESCE(uts.occurrence, 0)
+		ELSE t.occurrence
+	END as occurrence,
+	t.template_task_id,
+	t.draft,
+	t.publish_at,
+	t.expires_at,
+	t.version,
+	COALESCE(uts.version, 0) as state_version,
+	COALESCE(uts.updated_since_done, false) as updated_since_done,` + userSubtaskCounts + `,` + todoTags + `,` + userCommentCount + `,` + userTodoBlockers + `,` + todoGroups
+
+// userTodoJoin joins todos with the state of the user bound to $1.
+const userTodoJoin = `
+	FROM todos t
+	CROSS JOIN (SELECT $1::text AS id) viewer
+	LEFT JOIN user_todo_state uts ON t.id = uts.todo_id AND uts.user_id = viewer.id AND t.is_default_task = true`
+
+// todoRow is a todo as selected by todoColumns and userTodoColumns.
+type todoRow struct {
+	models.Todo
+	subtasks, subtasksDone, subtasksStarted int
+}
+
+// fields returns the scan destinations of the row's columns.
+func (r *todoRow) fields() []any {
+	return []any{&r.ID, &r.Text, &r.Description, &r.Status, &r.Created, &r.Position, &r.CreatedByUserID, &r.IsDefaultTask, &r.SharedWithAdmin, &r.HiddenFromUser, &r.UserID, &r.DeletedAt, &r.DueAt, &r.ParentID, &r.Priority, &r.Recurrence, &r.Occurrence, &r.TemplateTaskID, &r.Draft, &r.PublishAt, &r.ExpiresAt, &r.Version, &r.StateVersion, &r.UpdatedSinceDone, &r.subtasks, &r.subtasksDone, &r.subtasksStarted, &r.Tags, &r.CommentCount, &r.BlockedBy, &r.Blocked, &r.Groups}
+}
+
+func (r *todoRow) todo() models.Todo {
+	t := r.Todo
+	t.Subtasks = models.RollUp(r.subtasks, r.subtasksDone, r.subtasksStarted)
+	return t
+}
+
+func scanTodo(row pgx.Row) (models.Todo, error) {
+	var r todoRow
+	err := row.Scan(r.fields()...)
+	return r.todo(), err
+}
+
+func (s *Store) queryTodos(ctx context.Context, query string, args ...any) ([]models.Todo, error) {
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	todos := []models.Todo{}
+	for rows.Next() {
+		t, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, t)
+	}
+	return todos, rows.Err()
+}
+
+func (s *Store) ListUserTodos(ctx context.Context, userID string, includeDefault bool, f store.TodoFilter) ([]models.Todo, error) {
+	if !includeDefault {
+		// Only the user's personal todos (exclude default tasks)
+		l := todoList{
+			selects:  todoColumns + ` FROM todos t`,
+			status:   "t.status",
+			position: "t.position",
+			due:      "t.due_at",
+			conds:    []string{"t.user_id = $1", "t.is_default_task = false", "t.deleted_at IS NULL"},
+			args:     []any{userID},
+		}
+		query, args := l.query(f)
+		return s.queryTodos(ctx, query, args...)
+	}
+
+	// User's own todos + all default tasks
+	l := todoList{
+		selects:  userTodoColumns + userTodoJoin,
+		status:   userStatus,
+		position: userPosition,
+		due:      userDue,
+		conds:    []string{"(t.user_id = $1 OR t.is_default_task = true)", "t.hidden_from_user = false", "t.deleted_at IS NULL", targetsViewer, liveNow},
+		args:     []any{userID},
+	}
+	query, args := l.query(f)
+	return s.queryTodos(ctx, query, args...)
+}
+
+func (s *Store) ListSharedTodos(ctx context.Context, userID string, f store.TodoFilter) ([]models.Todo, error) {
+	// Personal todos are only visible to admins once shared
+	l := todoList{
+		selects:  userTodoColumns + userTodoJoin,
+		status:   userStatus,
+		position: userPosition,
+		due:      userDue,
+		conds:    []string{"((t.user_id = $1 AND t.shared_with_admin = true) OR t.is_default_task = true)", "t.deleted_at IS NULL", targetsViewer, liveNow},
+		args:     []any{userID},
+	}
+	query, args := l.query(f)
+	return s.queryTodos(ctx, query, args...)
+}
+
+func (s *Store) ListDefaultTodos(ctx context.Context, f store.TodoFilter) ([]models.Todo, error) {
+	l := todoList{
+		selects:  todoColumns + ` FROM todos t`,
+		status:   "t.status",
+		position: "t.position",
+		due:      "t.due_at",
+		conds:    []string{"t.is_default_task = true", "t.deleted_at IS NULL"},
+	}
+	query, args := l.query(f)
+	return s.queryTodos(ctx, query, args...)
+}
+
+func (s *Store) GetTodo(ctx context.Context, id string) (models.Todo, error) {
+	t, err := scanTodo(s.db.QueryRow(ctx, `SELECT `+todoColumns+` FROM todos t WHERE t.id = $1 AND t.deleted_at IS NULL`, id))
+	return t, mapErr(err)
+}
+
+func (s *Store) GetUserTodo(ctx context.Context, id, userID string) (models.Todo, error) {
+	t, err := scanTodo(s.db.QueryRow(ctx, `SELECT `+userTodoColumns+userTodoJoin+` WHERE t.id = $2 AND t.deleted_at IS NULL AND `+targetsViewer+` AND `+liveNow, userID, id))
+	return t, mapErr(err)
+}
+
+func (s *Store) CreateTodo(ctx context.Context, t *models.Todo) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := insertTodo(ctx, tx, t); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// insertTodo inserts a todo at the top of its list, as CreateTodo does.
+func insertTodo(ctx context.Context, tx pgx.Tx, t *models.Todo) error {
+	// Get min position within the todo's list to put it at the top
+	var minPos float64
+	if t.IsDefaultTask {
+		_ = tx.QueryRow(ctx, `SELECT COALESCE(MIN(position), 0) FROM todos WHERE is_default_task=true AND deleted_at IS NULL`).Scan(&minPos)
+	} else {
+		_ = tx.QueryRow(ctx, `SELECT COALESCE(MIN(position), 0) FROM todos WHERE user_id=$1 AND deleted_at IS NULL`, t.UserID).Scan(&minPos)
+	}
+	t.Position = minPos - models.PositionIncrement
+	t.Version = 1
+	if t.Priority == "" {
+		t.Priority = string(models.PriorityNormal)
+	}
+
+	_, err := tx.Exec(ctx, `
+		INSERT INTO todos(id, text, description, status, created, position, user_id, created_by_user_id, is_default_task, shared_with_admin, hidden_from_user, due_at, parent_id, priority, recurrence, occurrence, template_task_id, draft, publish_at, expires_at)
+		VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20)
+	`, t.ID, t.Text, t.Description, t.Status, t.Created, t.Position, t.UserID, t.CreatedByUserID, t.IsDefaultTask, t.SharedWithAdmin, t.HiddenFromUser, dueAt(t.DueAt), t.ParentID, t.Priority, t.Recurrence, t.Occurrence, t.TemplateTaskID, t.Draft, dueAt(t.PublishAt), dueAt(t.ExpiresAt))
+	if err != nil {
+		return err
+	}
+	if err := setTodoTags(ctx, tx, t.ID, t.Tags); err != nil {
+		return err
+	}
+	if err := setTodoBlockers(ctx, tx, t.ID, t.BlockedBy); err != nil {
+		return err
+	}
+	if err := setTodoGroups(ctx, tx, t.ID, t.Groups); err != nil {
+		return err
+	}
+	return recordRevision(ctx, tx, t.ID, t.CreatedByUserID)
+}
+
+func (s *Store) UpdateTodo(ctx context.Context, id string, u store.TodoUpdate) error {
+	if u.IsEmpty() {
+		return nil
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Completing a recurring todo ends its recurrence here and carries it
+	// over to the next occurrence.
+	var rule string
+	if u.Status != nil && *u.Status == string(models.StatusDone) {
+		if rule, err = completedRecurrence(ctx, tx, id, u.Recurrence); err != nil {
+			return err
+		}
+		if rule != "" {
+			cleared := ""
+			u.Recurrence = &cleared
+		}
+	}
+
+	// Build dynamic query
+	query := "UPDATE todos SET "
+	var args []interface{}
+	argID := 1
+
+	set := func(column string, value any) {
+		query += fmt.Sprintf("%s = $%d, ", column, argID)
+		args = append(args, value)
+		argID++
+	}
+	if u.Text != nil {
+		set("text", *u.Text)
+	}
+	if u.Description != nil {
+		set("description", *u.Description)
+	}
+	if u.Status != nil {
+		set("status", *u.Status)
+	}
+	if u.SharedWithAdmin != nil {
+		set("shared_with_admin", *u.SharedWithAdmin)
+	}
+	if u.HiddenFromUser != nil {
+		set("hidden_from_user", *u.HiddenFromUser)
+	}
+	if u.DueAt != nil {
+		set("due_at", dueAt(u.DueAt))
+	}
+	if u.Priority != nil {
+		set("priority", *u.Priority)
+	}
+	if u.Recurrence != nil {
+		set("recurrence", *u.Recurrence)
+	}
+	if u.Draft != nil {
+		set("draft", *u.Draft)
+	}
+	if u.PublishAt != nil {
+		set("publish_at", dueAt(u.PublishAt))
+	}
+	if u.ExpiresAt != nil {
+		set("expires_at", dueAt(u.ExpiresAt))
+	}
+
+	query += "version = version + 1"
+	query += fmt.Sprintf(" WHERE id = $%d AND deleted_at IS NULL", argID)
+	args = append(args, id)
+	if u.IfVersion != nil {
+		query += fmt.Sprintf(" AND version = $%d", argID+1)
+		args = append(args, *u.IfVersion)
+	}
+
+	cmd, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return missingOrConflict(ctx, tx, id)
+	}
+	if u.Tags != nil {
+		if err := setTodoTags(ctx, tx, id, *u.Tags); err != nil {
+			return err
+		}
+	}
+	if u.BlockedBy != nil {
+		if err := setTodoBlockers(ctx, tx, id, *u.BlockedBy); err != nil {
+			return err
+		}
+	}
+	if u.Groups != nil {
+		if err := setTodoGroups(ctx, tx, id, *u.Groups); err != nil {
+			return err
+		}
+	}
+	if u.Resets() {
+		if err := resetUserStates(ctx, tx, id, u.Propagate); err != nil {
+			return err
+		}
+	}
+
+	var actorID *string
+	if u.ActorID != "" {
+		actorID = &u.ActorID
+	}
+	if err := recordRevision(ctx, tx, id, actorID); err != nil {
+		return err
+	}
+	if rule != "" {
+		if err := insertNextOccurrence(ctx, tx, id, rule); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// missingOrConflict explains why a conditional write to a live todo matched
+// no rows.
+func missingOrConflict(ctx context.Context, tx pgx.Tx, id string) error {
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM todos WHERE id=$1 AND deleted_at IS NULL)`, id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return store.ErrNotFound
+	}
+	return store.ErrConflict
+}
+
+func (s *Store) SetDefaultTodoStatus(ctx context.Context, userID, todoID, status string, ifVersion *int) error {
+	return s.UpdateDefaultTodoState(ctx, userID, todoID, store.StateUpdate{Status: &status}, nil, ifVersion)
+}
+
+func (s *Store) UpdateDefaultTodoState(ctx context.Context, userID, todoID string, u store.StateUpdate, ifVersion, ifStateVersion *int) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Hold off changes to the task until the user's state is written
+	var version int
+	if err := tx.QueryRow(ctx, `SELECT version FROM todos WHERE id = $1 AND is_default_task = true AND deleted_at IS NULL FOR SHARE`, todoID).Scan(&version); err != nil {
+		return mapErr(err)
+	}
+	if ifVersion != nil && *ifVersion != version {
+		return store.ErrConflict
+	}
+	if u.Status != nil {
+		if err := setDefaultTodoStatus(ctx, tx, userID, todoID, *u.Status, ifStateVersion); err != nil {
+			return err
+		}
+		if ifStateVersion != nil {
+			next := *ifStateVersion + 1
+			ifStateVersion = &next
+		}
+	}
+	if u.DueAt != nil {
+		if err := setDefaultTodoDueAt(ctx, tx, userID, todoID, u.DueAt, ifStateVersion); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// setDefaultTodoStatus records a user's status for a default task, as
+// SetDefaultTodoStatus does.
+func setDefaultTodoStatus(ctx context.Context, tx pgx.Tx, userID, todoID, status string, ifVersion *int) error {
+	if status == string(models.StatusDone) {
+		if advanced, err := advanceDefaultTodo(ctx, tx, userID, todoID, ifVersion); advanced || err != nil {
+			return err
+		}
+	}
+
+	// Keep the user's existing position, or start from the global one.
+	// A missing row counts as version 0.
+	cmd, err := tx.Exec(ctx, `
+		INSERT INTO user_todo_state (user_id, todo_id, status, position, version, updated_at)
+		SELECT $1, $2, $3,
+			COALESCE(
+				(SELECT position FROM user_todo_state WHERE user_id=$1 AND todo_id=$2),
+				(SELECT position FROM todos WHERE id=$2)
+			),
+			1, now()
+		WHERE $4::integer IS NULL
+			OR $4 = COALESCE((SELECT version FROM user_todo_state WHERE user_id=$1 AND todo_id=$2), 0)
+		ON CONFLICT (user_id, todo_id)
+		DO UPDATE SET status = EXCLUDED.status, updated_since_done = false, version = user_todo_state.version + 1, updated_at = now()
+		WHERE $4::integer IS NULL OR user_todo_state.version = $4
+	`, userID, todoID, status, ifVersion)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return store.ErrConflict
+	}
+	return nil
+}
+
+func (s *Store) ReorderTodos(ctx context.Context, userID string, ids []string) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for i, id := range ids {
+		pos := float64(i) * models.PositionIncrement
+
+		// Check if this is a default task
+		var isDefaultTask bool
+		if err := tx.QueryRow(ctx, `SELECT is_default_task FROM todos WHERE id=$1 AND deleted_at IS NULL`, id).Scan(&isDefaultTask); err != nil {
+			return mapErr(err)
+		}
+
+		if isDefaultTask {
+			// For default tasks, UPSERT into user_todo_state
+			_, err = tx.Exec(ctx, `
+				INSERT INTO user_todo_state (user_id, todo_id, status, position, updated_at)
+				VALUES ($1, $2, $3, $4, now())
+				ON CONFLICT (user_id, todo_id)
+				DO UPDATE SET position = EXCLUDED.position, updated_at = now()
+			`, userID, id, string(models.StatusPending), pos)
+		} else {
+			// For personal todos, update todos table
+			_, err = tx.Exec(ctx, `
+				UPDATE todos
+				SET position = $1
+				WHERE id = $2 AND user_id = $3
+			`, pos, id, userID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (s *Store) ReorderDefaultTodos(ctx context.Context, ids []string, resetUsers bool) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for i, id := range ids {
+		pos := float64(i) * models.PositionIncrement
+		cmd, err := tx.Exec(ctx, `
+			UPDATE todos SET position = $1
+			WHERE id = $2 AND is_default_task = true AND deleted_at IS NULL
+		`, pos, id)
+		if err != nil {
+			return err
+		}
+		if cmd.RowsAffected() == 0 {
+			return store.ErrNotFound
+		}
+		if resetUsers {
+			if _, err := tx.Exec(ctx, `UPDATE user_todo_state SET position = $1, updated_at = now() WHERE todo_id = $2`, pos, id); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (s *Store) DeleteTodo(ctx context.Context, id string, ifVersion *int) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var deletedAt time.Time
+	err = tx.QueryRow(ctx, `
+		UPDATE todos SET deleted_at = now()
+		WHERE id=$1 AND deleted_at IS NULL AND ($2::integer IS NULL OR version = $2)
+		RETURNING deleted_at
+	`, id, ifVersion).Scan(&deletedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return missingOrConflict(ctx, tx, id)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, trashSubtree, id, deletedAt); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Store) ListDeletedTodos(ctx context.Context, userID string) ([]models.Todo, error) {
+	if userID == "" {
+		return s.queryTodos(ctx, `
+			SELECT `+todoColumns+`
+			FROM todos t
+			WHERE t.is_default_task = true AND t.deleted_at IS NOT NULL AND NOT `+inTrashWithParent+`
+			ORDER BY t.deleted_at DESC
+		`)
+	}
+	return s.queryTodos(ctx, `
+		SELECT `+todoColumns+`
+		FROM todos t
+		WHERE t.user_id = $1 AND t.is_default_task = false AND t.deleted_at IS NOT NULL AND NOT `+inTrashWithParent+`
+		ORDER BY t.deleted_at DESC
+	`, userID)
+}
+
+func (s *Store) GetDeletedTodo(ctx context.Context, id string) (models.Todo, error) {
+	t, err := scanTodo(s.db.QueryRow(ctx, `SELECT `+todoColumns+` FROM todos t WHERE t.id = $1 AND t.deleted_at IS NOT NULL`, id))
+	return t, mapErr(err)
+}
+
+func (s *Store) RestoreTodo(ctx context.Context, id string) error {
+	cmd, err := s.db.Exec(ctx, restoreSubtree, id)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) PurgeDeletedTodos(ctx context.Context, before time.Time) (int64, error) {
+	// user_todo_state rows go with the todo via ON DELETE CASCADE
+	cmd, err := s.db.Exec(ctx, `DELETE FROM todos WHERE deleted_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return cmd.RowsAffected(), nil
+}
@@ -0,0 +1,38 @@
+package store
+
+import (
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/google/uuid"
+)
+
+// NextOccurrence returns the occurrence of the recurring todo t that follows
+// it once done at now: a pending copy under a new ID, due at the next date of
+// its rule and with its occurrence count bumped. Monthly rules are pinned to
+// the day t is due, so the series keeps to it. It reports false if t does
+// not recur.
+func NextOccurrence(t models.Todo, now time.Time) (models.Todo, bool) {
+	r, err := models.ParseRecurrence(t.Recurrence)
+	if err != nil {
+		return models.Todo{}, false
+	}
+	r = r.Anchored(t.DueAt)
+	due := r.Next(t.DueAt, now)
+	next := t
+	next.ID = uuid.NewString()
+	next.Status = string(models.StatusPending)
+	next.Created = now
+	next.DueAt = &due
+	next.Occurrence = t.Occurrence + 1
+	next.Recurrence = r.String()
+	next.Tags = append([]string(nil), t.Tags...)
+	next.BlockedBy = append([]string(nil), t.BlockedBy...)
+	next.Blocked = false
+	next.Subtasks = nil
+	next.CommentCount = 0
+	next.Version = 0
+	next.StateVersion = 0
+	next.DeletedAt = nil
+	return next, true
+}
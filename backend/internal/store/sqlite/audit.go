@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+func (s *Store) RecordAuditEvent(ctx context.Context, e *models.AuditEvent) error {
+	return s.db.QueryRowContext(ctx, `
+		INSERT INTO audit_events(actor_id, user_id, todo_id, action, before_value, after_value, created_at)
+		VALUES($1,$2,$3,$4,$5,$6,$7)
+		RETURNING id
+	`, e.ActorID, e.UserID, e.TodoID, e.Action, nullJSON(e.Before), nullJSON(e.After), e.CreatedAt.UTC()).Scan(&e.ID)
+}
+
+func (s *Store) ListAuditEvents(ctx context.Context, f store.AuditFilter) ([]models.AuditEvent, error) {
+	var conds []string
+	var args []any
+	where := func(cond string, value any) {
+		args = append(args, value)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if f.UserID != "" {
+		where("user_id = $%d", f.UserID)
+	}
+	if f.TodoID != "" {
+		where("todo_id = $%d", f.TodoID)
+	}
+	if f.ActorID != "" {
+		where("actor_id = $%d", f.ActorID)
+	}
+	if f.Action != "" {
+		where("action = $%d", f.Action)
+	}
+	if !f.From.IsZero() {
+		where("created_at >= $%d", f.From.UTC())
+	}
+	if !f.To.IsZero() {
+		where("created_at < $%d", f.To.UTC())
+	}
+
+	query := `SELECT id, actor_id, user_id, todo_id, action, before_value, after_value, created_at FROM audit_events`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+	if f.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", f.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.AuditEvent{}
+	for rows.Next() {
+		var e models.AuditEvent
+		var before, after []byte
+		if err := rows.Scan(&e.ID, &e.ActorID, &e.UserID, &e.TodoID, &e.Action, &before, &after, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Before, e.After = before, after
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// nullJSON returns nil for an empty JSON value so that it is stored as NULL.
+func nullJSON(v []byte) any {
+	if len(v) == 0 {
+		return nil
+	}
+	return string(v)
+}
@@ -0,0 +1,67 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+)
+
+// commentCount selects the number of comments on a todo, in every thread.
+const commentCount = `
+	(SELECT COUNT(*) FROM todo_comments cm WHERE cm.todo_id = t.id) as comments`
+
+// userCommentCount is commentCount in the viewer's thread only.
+const userCommentCount = `
+	(SELECT COUNT(*) FROM todo_comments cm WHERE cm.todo_id = t.id AND cm.user_id = viewer.id) as comments`
+
+const commentColumns = `id, todo_id, user_id, author_id, body, created_at, edited_at`
+
+func scanComment(row scanner) (models.Comment, error) {
+	var c models.Comment
+	err := row.Scan(&c.ID, &c.TodoID, &c.UserID, &c.AuthorID, &c.Body, &c.CreatedAt, &c.EditedAt)
+	return c, err
+}
+
+func (s *Store) ListComments(ctx context.Context, todoID, userID string) ([]models.Comment, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+commentColumns+` FROM todo_comments
+		WHERE todo_id = $1 AND user_id = $2
+		ORDER BY created_at, id
+	`, todoID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []models.Comment{}
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+	return comments, rows.Err()
+}
+
+func (s *Store) GetComment(ctx context.Context, id string) (models.Comment, error) {
+	c, err := scanComment(s.db.QueryRowContext(ctx, `SELECT `+commentColumns+` FROM todo_comments WHERE id = $1`, id))
+	return c, mapErr(err)
+}
+
+func (s *Store) CreateComment(ctx context.Context, c models.Comment) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO todo_comments (id, todo_id, user_id, author_id, body, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, c.ID, c.TodoID, c.UserID, c.AuthorID, c.Body, c.CreatedAt.UTC())
+	return err
+}
+
+func (s *Store) UpdateComment(ctx context.Context, id, body string, editedAt time.Time) error {
+	return requireRows(s.db.ExecContext(ctx, `UPDATE todo_comments SET body = $1, edited_at = $2 WHERE id = $3`, body, editedAt.UTC(), id))
+}
+
+func (s *Store) DeleteComment(ctx context.Context, id string) error {
+	return requireRows(s.db.ExecContext(ctx, `DELETE FROM todo_comments WHERE id = $1`, id))
+}
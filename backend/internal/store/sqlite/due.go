@@ -0,0 +1,115 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// timeFormat is the layout of times written with _time_format=sqlite.
+const timeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+// nullTime scans a nullable time. The driver only parses times from
+// DATETIME columns, so times computed by expressions arrive as text.
+type nullTime struct {
+	t **time.Time
+}
+
+func (n nullTime) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*n.t = nil
+	case time.Time:
+		*n.t = &v
+	case string:
+		t, err := time.Parse(timeFormat, v)
+		if err != nil {
+			return err
+		}
+		*n.t = &t
+	default:
+		return fmt.Errorf("cannot scan %T into a time", src)
+	}
+	return nil
+}
+
+// dueAt returns the column value of a due date, or of another optional time,
+// NULL for nil or the zero time.
+func dueAt(t *time.Time) *time.Time {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+func (s *Store) SetDefaultTodoDueAt(ctx context.Context, userID, todoID string, due *time.Time, ifVersion *int) error {
+	if due == nil {
+		due = &time.Time{}
+	}
+	return s.UpdateDefaultTodoState(ctx, userID, todoID, store.StateUpdate{DueAt: due}, nil, ifVersion)
+}
+
+// setDefaultTodoDueAt overrides the due date of a default task for a user,
+// as SetDefaultTodoDueAt does.
+func setDefaultTodoDueAt(ctx context.Context, tx *sql.Tx, userID, todoID string, due *time.Time, ifVersion *int) error {
+	// New rows start from the task's global status and position.
+	// A missing row counts as version 0.
+	err := requireRows(tx.ExecContext(ctx, `
+		INSERT INTO user_todo_state (user_id, todo_id, status, position, due_at, version, updated_at)
+		SELECT $1, t.id, t.status, t.position, $3, 1, $5
+		FROM todos t
+		WHERE t.id = $2 AND ($4 IS NULL
+			OR $4 = COALESCE((SELECT version FROM user_todo_state WHERE user_id=$1 AND todo_id=$2), 0))
+		ON CONFLICT (user_id, todo_id)
+		DO UPDATE SET due_at = excluded.due_at, version = user_todo_state.version + 1, updated_at = excluded.updated_at
+		WHERE $4 IS NULL OR user_todo_state.version = $4
+	`, userID, todoID, dueAt(due), ifVersion, time.Now().UTC()))
+	if errors.Is(err, store.ErrNotFound) {
+		return store.ErrConflict
+	}
+	return err
+}
+
+// overdueTodos selects every todo visible to admins as user_id plus the
+// columns of userTodoColumns: default tasks once per non-admin user they are
+// meant for, as that user sees them, and personal todos shared with admins.
+const overdueTodos = `
+	SELECT viewer.id AS user_id, ` + userTodoColumns + `
+	FROM users viewer
+	JOIN todos t ON t.is_default_task = true
+	LEFT JOIN user_todo_state uts ON t.id = uts.todo_id AND uts.user_id = viewer.id
+	WHERE viewer.role != 'admin' AND t.deleted_at IS NULL AND ` + targetsViewer + ` AND ` + liveNow + `
+	UNION ALL
+	SELECT t.user_id, ` + todoColumns + `
+	FROM todos t
+	WHERE t.is_default_task = false AND t.shared_with_admin = true AND t.deleted_at IS NULL`
+
+func (s *Store) ListOverdueTodos(ctx context.Context, userID string, now time.Time, limit int) ([]models.OverdueTodo, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT * FROM (`+overdueTodos+`) o
+		WHERE o.due_at < $1 AND o.status <> 'done' AND ($2 = '' OR o.user_id = $2)
+		ORDER BY o.due_at, o.user_id, o.id
+		LIMIT $3
+	`, now.UTC(), userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	todos := []models.OverdueTodo{}
+	for rows.Next() {
+		var userID string
+		var r todoRow
+		if err := rows.Scan(append([]any{&userID}, r.fields()...)...); err != nil {
+			return nil, err
+		}
+		todos = append(todos, models.OverdueTodo{UserID: userID, Todo: r.todo()})
+	}
+	return todos, rows.Err()
+}
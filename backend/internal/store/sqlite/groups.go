@@ -0,0 +1,129 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// todoGroups selects the IDs of the groups a default task targets, sorted
+// and joined by commas (see tagList).
+const todoGroups = `
+	(SELECT group_concat(group_id, ',') FROM (
+		SELECT group_id FROM todo_groups WHERE todo_id = t.id ORDER BY group_id
+	)) as target_groups`
+
+// targetsViewer matches the todos meant for the viewing user: personal todos,
+// and default tasks that target no group or one the viewer is a member of.
+const targetsViewer = `(t.is_default_task = false
+	OR NOT EXISTS (SELECT 1 FROM todo_groups tg WHERE tg.todo_id = t.id)
+	OR EXISTS (SELECT 1 FROM todo_groups tg JOIN user_group_members gm ON gm.group_id = tg.group_id
+		WHERE tg.todo_id = t.id AND gm.user_id = viewer.id))`
+
+// groupColumns selects a group with its member count.
+const groupColumns = `
+	g.id, g.name, g.created_at,
+	(SELECT COUNT(*) FROM user_group_members gm WHERE gm.group_id = g.id)`
+
+// setTodoGroups replaces the groups a todo targets.
+func setTodoGroups(ctx context.Context, tx *sql.Tx, todoID string, groups []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM todo_groups WHERE todo_id = $1`, todoID); err != nil {
+		return err
+	}
+	for _, id := range groups {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO todo_groups (todo_id, group_id) VALUES ($1, $2)`, todoID, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) ListGroups(ctx context.Context) ([]models.Group, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+groupColumns+` FROM user_groups g ORDER BY g.name, g.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := []models.Group{}
+	for rows.Next() {
+		var g models.Group
+		if err := rows.Scan(&g.ID, &g.Name, &g.CreatedAt, &g.MemberCount); err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+func (s *Store) GetGroup(ctx context.Context, id string) (models.Group, error) {
+	var g models.Group
+	err := s.db.QueryRowContext(ctx, `SELECT `+groupColumns+` FROM user_groups g WHERE g.id = $1`, id).
+		Scan(&g.ID, &g.Name, &g.CreatedAt, &g.MemberCount)
+	return g, mapErr(err)
+}
+
+func (s *Store) SaveGroup(ctx context.Context, g models.Group) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO user_groups (id, name, created_at) VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET name = excluded.name
+	`, g.ID, g.Name, g.CreatedAt.UTC())
+	return err
+}
+
+func (s *Store) DeleteGroup(ctx context.Context, id string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var targeted bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM todo_groups WHERE group_id = $1)`, id).Scan(&targeted); err != nil {
+		return err
+	}
+	if targeted {
+		return store.ErrConflict
+	}
+	if err := requireRows(tx.ExecContext(ctx, `DELETE FROM user_groups WHERE id = $1`, id)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Store) ListGroupMembers(ctx context.Context, groupID string) ([]models.User, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT u.id, u.email, u.name, u.avatar_url, u.role, u.created_at
+		FROM user_group_members gm JOIN users u ON u.id = gm.user_id
+		WHERE gm.group_id = $1
+		ORDER BY u.email, u.id
+	`, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var u models.User
+		if err := rows.Scan(&u.ID, &u.Email, &u.Name, &u.AvatarURL, &u.Role, &u.CreatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+func (s *Store) AddGroupMember(ctx context.Context, groupID, userID string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO user_group_members (group_id, user_id) VALUES ($1, $2)
+		ON CONFLICT (group_id, user_id) DO NOTHING
+	`, groupID, userID)
+	return err
+}
+
+func (s *Store) RemoveGroupMember(ctx context.Context, groupID, userID string) error {
+	return requireRows(s.db.ExecContext(ctx, `DELETE FROM user_group_members WHERE group_id = $1 AND user_id = $2`, groupID, userID))
+}
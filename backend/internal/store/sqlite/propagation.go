@@ -0,0 +1,21 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+)
+
+// resetUserStates moves the users of a default task back to pending as
+// policy p says, flagging those who were done.
+func resetUserStates(ctx context.Context, tx *sql.Tx, todoID string, p models.Propagation) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE user_todo_state
+		SET status = 'pending', updated_since_done = (updated_since_done OR status = 'done'),
+			version = version + 1, updated_at = $2
+		WHERE todo_id = $1 AND status <> 'pending' AND ($3 OR status = 'done')
+	`, todoID, time.Now().UTC(), p == models.PropagateResetAll)
+	return err
+}
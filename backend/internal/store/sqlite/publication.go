@@ -0,0 +1,71 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// sqlNow is the current time in the format times are stored in, so that it
+// compares with them as text.
+const sqlNow = `strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')`
+
+// liveNow matches the todos users can see now: personal todos, and default
+// tasks that are published and not yet expired.
+const liveNow = `(t.draft = false
+	AND (t.publish_at IS NULL OR t.publish_at <= ` + sqlNow + `)
+	AND (t.expires_at IS NULL OR t.expires_at > ` + sqlNow + `))`
+
+// blockerLive is liveNow for the blockers b of a todo.
+const blockerLive = `(b.draft = false
+	AND (b.publish_at IS NULL OR b.publish_at <= ` + sqlNow + `)
+	AND (b.expires_at IS NULL OR b.expires_at > ` + sqlNow + `))`
+
+// inPublication returns the condition matching the todos in state p at the
+// time bound to the placeholder now, as models.Todo.PublicationAt has it.
+func inPublication(p models.Publication, now string) string {
+	published := "t.draft = false AND (t.publish_at IS NULL OR t.publish_at <= " + now + ")"
+	switch p {
+	case models.PublicationDraft:
+		return "t.draft = true"
+	case models.PublicationScheduled:
+		return "t.draft = false AND t.publish_at > " + now
+	case models.PublicationExpired:
+		return published + " AND t.expires_at <= " + now
+	}
+	return published + " AND (t.expires_at IS NULL OR t.expires_at > " + now + ")"
+}
+
+// publishSubtree publishes todo $1 and its live subtasks at $2, if the todo
+// is at version $3 or $3 is NULL.
+const publishSubtree = `
+	WITH RECURSIVE subtree(id) AS (
+		SELECT id FROM todos WHERE id = $1 AND deleted_at IS NULL AND ($3 IS NULL OR version = $3)
+		UNION ALL
+		SELECT c.id FROM todos c JOIN subtree ON c.parent_id = subtree.id WHERE c.deleted_at IS NULL
+	)
+	UPDATE todos SET
+		draft = false,
+		publish_at = CASE WHEN draft OR publish_at > $2 THEN $2 ELSE publish_at END,
+		expires_at = CASE WHEN expires_at <= $2 THEN NULL ELSE expires_at END,
+		version = version + 1
+	WHERE id IN (SELECT id FROM subtree)`
+
+func (s *Store) PublishTodo(ctx context.Context, id string, at time.Time, ifVersion *int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := requireRows(tx.ExecContext(ctx, publishSubtree, id, at.UTC(), ifVersion)); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return missingOrConflict(ctx, tx, id)
+		}
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,91 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// completedRecurrence returns the rule a personal todo recurs by when it is
+// about to be marked done, or "" if it does not recur or is already done.
+// rule, if set, is the rule the same update gives the todo.
+func completedRecurrence(ctx context.Context, tx *sql.Tx, id string, rule *string) (string, error) {
+	var current, status string
+	var isDefault bool
+	err := tx.QueryRowContext(ctx, `SELECT recurrence, status, is_default_task FROM todos WHERE id=$1 AND deleted_at IS NULL`, id).Scan(&current, &status, &isDefault)
+	if errors.Is(err, sql.ErrNoRows) || isDefault || status == string(models.StatusDone) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if rule != nil {
+		current = *rule
+	}
+	return current, nil
+}
+
+// insertNextOccurrence creates the occurrence of the todo id that follows
+// it, recurring by rule.
+func insertNextOccurrence(ctx context.Context, tx *sql.Tx, id, rule string) error {
+	t, err := scanTodo(tx.QueryRowContext(ctx, `SELECT `+todoColumns+` FROM todos t WHERE t.id = $1`, id))
+	if err != nil {
+		return err
+	}
+	t.Recurrence = rule
+	next, ok := store.NextOccurrence(t, time.Now().UTC())
+	if !ok {
+		return nil
+	}
+	return insertTodo(ctx, tx, &next)
+}
+
+// advanceDefaultTodo moves userID on to the next occurrence of a recurring
+// default task, as SetDefaultTodoStatus does when it is marked done. It
+// reports false, changing nothing, if the task does not recur.
+func advanceDefaultTodo(ctx context.Context, tx *sql.Tx, userID, todoID string, ifVersion *int) (bool, error) {
+	var rule string
+	var start, due *time.Time
+	var occurrence int
+	err := tx.QueryRowContext(ctx, `
+		SELECT t.recurrence, t.due_at, COALESCE(uts.due_at, t.due_at), COALESCE(uts.occurrence, 0)
+		FROM todos t
+		LEFT JOIN user_todo_state uts ON uts.todo_id = t.id AND uts.user_id = $1
+		WHERE t.id = $2 AND t.is_default_task = true
+	`, userID, todoID).Scan(&rule, nullTime{&start}, nullTime{&due}, &occurrence)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	r, err := models.ParseRecurrence(rule)
+	if err != nil {
+		return false, nil
+	}
+
+	// Monthly occurrences keep to the day the task itself is due
+	now := time.Now().UTC()
+	next := r.Anchored(start).Next(due, now)
+	// New rows start from the task's global position.
+	// A missing row counts as version 0.
+	err = requireRows(tx.ExecContext(ctx, `
+		INSERT INTO user_todo_state (user_id, todo_id, status, position, due_at, occurrence, version, updated_at)
+		SELECT $1, t.id, $3, t.position, $4, $5, 1, $7
+		FROM todos t
+		WHERE t.id = $2 AND ($6 IS NULL
+			OR $6 = COALESCE((SELECT version FROM user_todo_state WHERE user_id=$1 AND todo_id=$2), 0))
+		ON CONFLICT (user_id, todo_id)
+		DO UPDATE SET status = excluded.status, due_at = excluded.due_at, occurrence = excluded.occurrence,
+			updated_since_done = false, version = user_todo_state.version + 1, updated_at = excluded.updated_at
+		WHERE $6 IS NULL OR user_todo_state.version = $6
+	`, userID, todoID, string(models.StatusPending), next.UTC(), occurrence+1, ifVersion, now))
+	if errors.Is(err, store.ErrNotFound) {
+		return false, store.ErrConflict
+	}
+	return err == nil, err
+}
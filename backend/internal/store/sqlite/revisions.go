@@ -0,0 +1,60 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+)
+
+// insertRevision snapshots todo $1 as its next revision, attributed to $2,
+// unless the latest revision already matches.
+const insertRevision = `
+	INSERT INTO todo_revisions (todo_id, revision, text, status, shared_with_admin, hidden_from_user, changed_by, created_at)
+	SELECT t.id, COALESCE(r.revision, 0) + 1, t.text, t.status, t.shared_with_admin, t.hidden_from_user, $2, $3
+	FROM todos t
+	LEFT JOIN todo_revisions r ON r.todo_id = t.id
+		AND r.revision = (SELECT MAX(revision) FROM todo_revisions WHERE todo_id = t.id)
+	WHERE t.id = $1
+		AND (r.todo_id IS NULL
+			OR r.text <> t.text
+			OR r.status <> t.status
+			OR r.shared_with_admin <> t.shared_with_admin
+			OR r.hidden_from_user <> t.hidden_from_user)`
+
+const revisionColumns = `todo_id, revision, text, status, shared_with_admin, hidden_from_user, changed_by, created_at`
+
+func recordRevision(ctx context.Context, tx *sql.Tx, todoID string, actorID *string) error {
+	_, err := tx.ExecContext(ctx, insertRevision, todoID, actorID, time.Now().UTC())
+	return err
+}
+
+func scanRevision(row scanner) (models.TodoRevision, error) {
+	var r models.TodoRevision
+	err := row.Scan(&r.TodoID, &r.Revision, &r.Text, &r.Status, &r.SharedWithAdmin, &r.HiddenFromUser, &r.ChangedBy, &r.CreatedAt)
+	return r, err
+}
+
+func (s *Store) ListTodoRevisions(ctx context.Context, todoID string) ([]models.TodoRevision, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+revisionColumns+` FROM todo_revisions WHERE todo_id = $1 ORDER BY revision`, todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revs := []models.TodoRevision{}
+	for rows.Next() {
+		r, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revs = append(revs, r)
+	}
+	return revs, rows.Err()
+}
+
+func (s *Store) GetTodoRevision(ctx context.Context, todoID string, revision int) (models.TodoRevision, error) {
+	r, err := scanRevision(s.db.QueryRowContext(ctx, `SELECT `+revisionColumns+` FROM todo_revisions WHERE todo_id = $1 AND revision = $2`, todoID, revision))
+	return r, mapErr(err)
+}
@@ -0,0 +1,77 @@
+package sqlite
+
+import (
+	"context"
+	"strings"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// Search queries join the todos_fts index and put the best matches first.
+const (
+	searchJoin = `
+	JOIN todos_fts ON todos_fts.todo_id = t.id`
+	searchOrder = `
+	ORDER BY todos_fts.rank, t.created DESC`
+)
+
+// matchQuery turns search text into an FTS5 query matching todos that
+// contain every word. Words are quoted so that FTS5 operators in the text
+// are treated literally. It returns "" if the text has no words.
+func matchQuery(query string) string {
+	words := store.SearchWords(query)
+	for i, w := range words {
+		words[i] = `"` + w + `"`
+	}
+	return strings.Join(words, " ")
+}
+
+func (s *Store) SearchUserTodos(ctx context.Context, userID, query string, includeDefault bool, limit int) ([]models.Todo, error) {
+	match := matchQuery(query)
+	if match == "" {
+		return []models.Todo{}, nil
+	}
+
+	if !includeDefault {
+		return s.queryTodos(ctx, `
+			SELECT `+todoColumns+`
+			FROM todos t`+searchJoin+`
+			WHERE t.user_id = $1 AND t.is_default_task = false AND t.deleted_at IS NULL
+				AND todos_fts MATCH $2`+searchOrder+`
+			LIMIT $3
+		`, userID, match, limit)
+	}
+
+	return s.queryTodos(ctx, `
+		SELECT `+userTodoColumns+userTodoJoin+searchJoin+`
+		WHERE (t.user_id = $1 OR t.is_default_task = true) AND t.hidden_from_user = false AND t.deleted_at IS NULL
+			AND `+targetsViewer+` AND `+liveNow+` AND todos_fts MATCH $2`+searchOrder+`
+		LIMIT $3
+	`, userID, match, limit)
+}
+
+func (s *Store) SearchSharedTodos(ctx context.Context, userID, query string, limit int) ([]models.Todo, error) {
+	match := matchQuery(query)
+	if match == "" {
+		return []models.Todo{}, nil
+	}
+
+	if userID == "" {
+		// Default tasks with their global status, plus every user's shared todos
+		return s.queryTodos(ctx, `
+			SELECT `+todoColumns+`
+			FROM todos t`+searchJoin+`
+			WHERE (t.is_default_task = true OR t.shared_with_admin = true) AND t.deleted_at IS NULL
+				AND todos_fts MATCH $1`+searchOrder+`
+			LIMIT $2
+		`, match, limit)
+	}
+
+	return s.queryTodos(ctx, `
+		SELECT `+userTodoColumns+userTodoJoin+searchJoin+`
+		WHERE ((t.user_id = $1 AND t.shared_with_admin = true) OR t.is_default_task = true) AND t.deleted_at IS NULL
+			AND `+targetsViewer+` AND `+liveNow+` AND todos_fts MATCH $2`+searchOrder+`
+		LIMIT $3
+	`, userID, match, limit)
+}
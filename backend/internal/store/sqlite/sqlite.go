@@ -0,0 +1,48 @@
+// Package sqlite implements the store interfaces on top of SQLite.
+//
+// The database must be opened with the _time_format=sqlite DSN parameter so
+// that times are written in a sortable UTC text format; all times are
+// converted to UTC before they are written.
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// Store implements store.Store.
+type Store struct {
+	db *sql.DB
+}
+
+var _ store.Store = (*Store)(nil)
+
+// New returns a Store backed by the given database handle.
+func New(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// mapErr translates driver errors into store errors.
+func mapErr(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.ErrNotFound
+	}
+	return err
+}
+
+// requireRows returns store.ErrNotFound if res affected no rows.
+func requireRows(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
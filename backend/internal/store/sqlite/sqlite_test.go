@@ -0,0 +1,28 @@
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/akhilmk/packup/internal/database"
+	"github.com/akhilmk/packup/internal/store"
+	"github.com/akhilmk/packup/internal/store/storetest"
+)
+
+// TestStore runs the store conformance suite against a migrated SQLite file
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store {
+		ctx := context.Background()
+		db, err := database.OpenSQLite(ctx, filepath.Join(t.TempDir(), "packup.db"))
+		if err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+		t.Cleanup(db.Close)
+
+		if err := db.Migrator.Up(ctx); err != nil {
+			t.Fatalf("Failed to migrate: %v", err)
+		}
+		return db
+	})
+}
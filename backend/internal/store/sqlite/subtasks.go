@@ -0,0 +1,45 @@
+package sqlite
+
+// subtaskCounts selects the number of a todo's direct subtasks, and of those
+// done and started, by their global status.
+const subtaskCounts = `
+	(SELECT COUNT(*) FROM todos c WHERE c.parent_id = t.id AND c.deleted_at IS NULL) as subtasks,
+	(SELECT COUNT(*) FROM todos c WHERE c.parent_id = t.id AND c.deleted_at IS NULL AND c.status = 'done') as subtasks_done,
+	(SELECT COUNT(*) FROM todos c WHERE c.parent_id = t.id AND c.deleted_at IS NULL AND c.status <> 'pending') as subtasks_started`
+
+// userSubtaskCounts is subtaskCounts by the viewer's status of each subtask.
+const userSubtaskCounts = `
+	(SELECT COUNT(*) FROM todos c WHERE c.parent_id = t.id AND c.deleted_at IS NULL) as subtasks,
+	(SELECT COUNT(*) FROM todos c WHERE c.parent_id = t.id AND c.deleted_at IS NULL AND ` + viewerStatus + ` = 'done') as subtasks_done,
+	(SELECT COUNT(*) FROM todos c WHERE c.parent_id = t.id AND c.deleted_at IS NULL AND ` + viewerStatus + ` <> 'pending') as subtasks_started`
+
+// viewerStatus is the viewer's status of subtask c. Only default tasks have
+// per-user state.
+const viewerStatus = `COALESCE((SELECT cs.status FROM user_todo_state cs WHERE cs.todo_id = c.id AND cs.user_id = viewer.id), c.status)`
+
+// trashSubtree moves the live subtasks of todo $1, at any depth, to the
+// trash along with it, deleted at $2.
+const trashSubtree = `
+	WITH RECURSIVE subtree(id) AS (
+		SELECT id FROM todos WHERE parent_id = $1 AND deleted_at IS NULL
+		UNION ALL
+		SELECT c.id FROM todos c JOIN subtree ON c.parent_id = subtree.id WHERE c.deleted_at IS NULL
+	)
+	UPDATE todos SET deleted_at = $2 WHERE id IN (SELECT id FROM subtree)`
+
+// restoreSubtree restores todo $1 from the trash along with the subtasks
+// that were deleted with it.
+const restoreSubtree = `
+	WITH RECURSIVE root(deleted_at) AS (
+		SELECT deleted_at FROM todos WHERE id = $1 AND deleted_at IS NOT NULL
+	), subtree(id) AS (
+		SELECT id FROM todos WHERE id = $1
+		UNION ALL
+		SELECT c.id FROM todos c JOIN subtree ON c.parent_id = subtree.id
+		WHERE c.deleted_at = (SELECT deleted_at FROM root)
+	)
+	UPDATE todos SET deleted_at = NULL WHERE id IN (SELECT id FROM subtree) AND deleted_at IS NOT NULL`
+
+// inTrashWithParent matches trashed todos whose parent is in the trash too.
+// They are listed and restored through their parent.
+const inTrashWithParent = `EXISTS (SELECT 1 FROM todos p WHERE p.id = t.parent_id AND p.deleted_at IS NOT NULL)`
@@ -0,0 +1,180 @@
+package sqlite
+
+import (
+	"context"
+	"slices"
+
+	"github.com/akhilmk/packup/internal/models"
+)
+
+func (s *Store) ListTemplates(ctx context.Context) ([]models.Template, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, name, created_at, updated_at FROM templates ORDER BY name, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []models.Template{}
+	for rows.Next() {
+		var t models.Template
+		if err := rows.Scan(&t.ID, &t.Name, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	tasks, err := s.templateTasks(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	for i := range templates {
+		templates[i].Tasks = tasks[templates[i].ID]
+		if templates[i].Tasks == nil {
+			templates[i].Tasks = []models.TemplateTask{}
+		}
+	}
+	return templates, nil
+}
+
+func (s *Store) GetTemplate(ctx context.Context, id string) (models.Template, error) {
+	var t models.Template
+	err := s.db.QueryRowContext(ctx, `SELECT id, name, created_at, updated_at FROM templates WHERE id = $1`, id).
+		Scan(&t.ID, &t.Name, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return t, mapErr(err)
+	}
+
+	tasks, err := s.templateTasks(ctx, id)
+	if err != nil {
+		return t, err
+	}
+	t.Tasks = tasks[id]
+	if t.Tasks == nil {
+		t.Tasks = []models.TemplateTask{}
+	}
+	return t, nil
+}
+
+// templateTasks returns the tasks of the template with the given ID, or of
+// every template if it is empty, in order and by template ID.
+func (s *Store) templateTasks(ctx context.Context, templateID string) (map[string][]models.TemplateTask, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT template_id, id, text, description, priority FROM template_tasks
+		WHERE $1 = '' OR template_id = $1
+		ORDER BY template_id, position
+	`, templateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := map[string][]models.TemplateTask{}
+	for rows.Next() {
+		var id string
+		var task models.TemplateTask
+		if err := rows.Scan(&id, &task.ID, &task.Text, &task.Description, &task.Priority); err != nil {
+			return nil, err
+		}
+		tasks[id] = append(tasks[id], task)
+	}
+	return tasks, rows.Err()
+}
+
+func (s *Store) SaveTemplate(ctx context.Context, t models.Template) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO templates (id, name, created_at, updated_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE SET name = excluded.name, updated_at = excluded.updated_at
+	`, t.ID, t.Name, t.CreatedAt.UTC(), t.UpdatedAt.UTC())
+	if err != nil {
+		return err
+	}
+
+	// Remove the tasks that are gone, which unlinks the todos added from them
+	rows, err := tx.QueryContext(ctx, `SELECT id FROM template_tasks WHERE template_id = $1`, t.ID)
+	if err != nil {
+		return err
+	}
+	var removed []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		if !slices.ContainsFunc(t.Tasks, func(task models.TemplateTask) bool { return task.ID == id }) {
+			removed = append(removed, id)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, id := range removed {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM template_tasks WHERE id = $1`, id); err != nil {
+			return err
+		}
+	}
+
+	for i, task := range t.Tasks {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO template_tasks (id, template_id, position, text, description, priority)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (id) DO UPDATE SET position = excluded.position, text = excluded.text,
+				description = excluded.description, priority = excluded.priority
+			WHERE template_tasks.template_id = excluded.template_id
+		`, task.ID, t.ID, i, task.Text, task.Description, task.Priority)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *Store) DeleteTemplate(ctx context.Context, id string) error {
+	return requireRows(s.db.ExecContext(ctx, `DELETE FROM templates WHERE id = $1`, id))
+}
+
+func (s *Store) AddTemplateUser(ctx context.Context, templateID, userID string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO template_users (template_id, user_id) VALUES ($1, $2)
+		ON CONFLICT (template_id, user_id) DO NOTHING
+	`, templateID, userID)
+	return err
+}
+
+func (s *Store) ListTemplateUsers(ctx context.Context, templateID string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT user_id FROM template_users WHERE template_id = $1 ORDER BY user_id`, templateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		users = append(users, id)
+	}
+	return users, rows.Err()
+}
+
+func (s *Store) ListTemplateTodos(ctx context.Context, templateID, userID string) ([]models.Todo, error) {
+	return s.queryTodos(ctx, `
+		SELECT `+todoColumns+`
+		FROM todos t
+		JOIN template_tasks tt ON tt.id = t.template_task_id
+		WHERE tt.template_id = $1 AND t.user_id = $2
+		ORDER BY tt.position, t.created
+	`, templateID, userID)
+}
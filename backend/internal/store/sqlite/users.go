@@ -0,0 +1,77 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+func (s *Store) GetUser(ctx context.Context, id string) (models.User, error) {
+	var u models.User
+	err := s.db.QueryRowContext(ctx, "SELECT id, google_id, email, name, avatar_url, role, created_at FROM users WHERE id=$1", id).
+		Scan(&u.ID, &u.GoogleID, &u.Email, &u.Name, &u.AvatarURL, &u.Role, &u.CreatedAt)
+	return u, mapErr(err)
+}
+
+func (s *Store) GetUserByGoogleID(ctx context.Context, googleID string) (models.User, error) {
+	var u models.User
+	err := s.db.QueryRowContext(ctx, "SELECT id, google_id, email, name, avatar_url, role, created_at FROM users WHERE google_id=$1", googleID).
+		Scan(&u.ID, &u.GoogleID, &u.Email, &u.Name, &u.AvatarURL, &u.Role, &u.CreatedAt)
+	return u, mapErr(err)
+}
+
+func (s *Store) CreateUser(ctx context.Context, u models.User) error {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO users(id, google_id, email, name, avatar_url, role, created_at) VALUES($1,$2,$3,$4,$5,$6,$7)",
+		u.ID, u.GoogleID, u.Email, u.Name, u.AvatarURL, u.Role, u.CreatedAt.UTC())
+	return err
+}
+
+func (s *Store) UpdateUserRole(ctx context.Context, id, role string) error {
+	return requireRows(s.db.ExecContext(ctx, "UPDATE users SET role=$1 WHERE id=$2", role, id))
+}
+
+func (s *Store) ListUsers(ctx context.Context, f store.UserFilter) ([]models.User, error) {
+	conds := []string{"role != 'admin'"}
+	var args []any
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if !f.From.IsZero() {
+		conds = append(conds, "created_at >= "+arg(f.From.UTC()))
+	}
+	if !f.To.IsZero() {
+		conds = append(conds, "created_at < "+arg(f.To.UTC()))
+	}
+	if c := f.After; c != nil {
+		created, id := arg(c.Created.UTC()), arg(c.ID)
+		conds = append(conds, fmt.Sprintf("(created_at < %[1]s OR (created_at = %[1]s AND id > %[2]s))", created, id))
+	}
+
+	query := `
+		SELECT id, email, name, avatar_url, role, created_at
+		FROM users
+		WHERE ` + strings.Join(conds, " AND ") + `
+		ORDER BY created_at DESC, id ASC`
+	if f.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", f.Limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var u models.User
+		if err := rows.Scan(&u.ID, &u.Email, &u.Name, &u.AvatarURL, &u.Role, &u.CreatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
@@ -0,0 +1,87 @@
+package sqlite
+
+import (
+	"context"
+	"slices"
+
+	"github.com/akhilmk/packup/internal/models"
+)
+
+func (s *Store) GetWorkflow(ctx context.Context) (models.Workflow, error) {
+	w := models.DefaultWorkflow()
+
+	rows, err := s.db.QueryContext(ctx, `SELECT name FROM workflow_statuses ORDER BY position, name`)
+	if err != nil {
+		return w, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return w, err
+		}
+		w.Statuses = append(w.Statuses, name)
+	}
+	if err := rows.Err(); err != nil {
+		return w, err
+	}
+
+	rows, err = s.db.QueryContext(ctx, `SELECT from_status, to_status FROM workflow_transitions`)
+	if err != nil {
+		return w, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var from, to string
+		if err := rows.Scan(&from, &to); err != nil {
+			return w, err
+		}
+		if w.Transitions == nil {
+			w.Transitions = map[string][]string{}
+		}
+		w.Transitions[from] = append(w.Transitions[from], to)
+	}
+	if err := rows.Err(); err != nil {
+		return w, err
+	}
+
+	// Order transitions like the statuses they lead to
+	for from, to := range w.Transitions {
+		slices.SortFunc(to, func(a, b string) int {
+			return slices.Index(w.Statuses, a) - slices.Index(w.Statuses, b)
+		})
+		w.Transitions[from] = to
+	}
+	return w, nil
+}
+
+func (s *Store) SaveWorkflow(ctx context.Context, w models.Workflow) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM workflow_statuses`); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM workflow_transitions`); err != nil {
+		return err
+	}
+	for i, name := range w.Statuses {
+		if slices.Contains(models.BuiltinStatuses, name) {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO workflow_statuses (name, position) VALUES ($1, $2)`, name, i); err != nil {
+			return err
+		}
+	}
+	for from, to := range w.Transitions {
+		for _, name := range to {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO workflow_transitions (from_status, to_status) VALUES ($1, $2)`, from, name); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
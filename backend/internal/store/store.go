@@ -0,0 +1,521 @@
+// Package store defines the persistence interfaces used by the HTTP handlers.
+// Implementations live in the postgres, sqlite and memory subpackages; blob
+// storage for attachments lives in localfs (and memory, for tests).
+package store
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/akhilmk/packup/internal/models"
+)
+
+// ErrNotFound is returned when the requested record does not exist.
+var ErrNotFound = errors.New("not found")
+
+// ErrConflict is returned when a conditional write finds the record at a
+// different version than the caller expected.
+var ErrConflict = errors.New("version conflict")
+
+// ErrDependencyCycle is returned when a todo would end up depending on
+// itself, directly or through other todos.
+var ErrDependencyCycle = errors.New("dependency cycle")
+
+// Store is implemented by every storage backend.
+type Store interface {
+	TodoStore
+	TagStore
+	CommentStore
+	AttachmentStore
+	WorkflowStore
+	TemplateStore
+	GroupStore
+	UserStore
+	SessionStore
+	AuditStore
+}
+
+// StateUpdate holds the fields to change on a user's state of a default
+// task. Nil fields are left unchanged.
+type StateUpdate struct {
+	Status *string
+
+	// DueAt overrides the task's due date for the user; the zero time
+	// removes the override.
+	DueAt *time.Time
+}
+
+// TodoUpdate holds the fields to change on a todo. Nil fields are left unchanged.
+type TodoUpdate struct {
+	Text            *string
+	Status          *string
+	SharedWithAdmin *bool
+	HiddenFromUser  *bool
+
+	// Description sets the markdown description; an empty string clears it.
+	Description *string
+
+	// DueAt sets the due date; a zero time clears it.
+	DueAt *time.Time
+
+	// Tags replaces the todo's tags with these normalized names.
+	Tags *[]string
+
+	Priority *string
+
+	// BlockedBy replaces the todo's blockers with these normalized IDs.
+	BlockedBy *[]string
+
+	// Groups replaces the groups a default task targets with these
+	// normalized IDs; an empty list makes it visible to every user.
+	Groups *[]string
+
+	// Draft keeps a default task from users, or makes it visible.
+	Draft *bool
+
+	// PublishAt and ExpiresAt set when users see a default task; a zero
+	// time clears either.
+	PublishAt *time.Time
+	ExpiresAt *time.Time
+
+	// Propagate moves users of a default task back to pending as the
+	// policy says, flagging those who were done with UpdatedSinceDone.
+	// The zero value keeps their progress.
+	Propagate models.Propagation
+
+	// Recurrence sets the recurrence rule, in canonical form; an empty
+	// string makes the todo stop recurring.
+	Recurrence *string
+
+	// ActorID is the user making the change, recorded in the todo's history.
+	ActorID string
+
+	// IfVersion, if set, applies the update only when the todo is still at
+	// this version; otherwise the update fails with ErrConflict.
+	IfVersion *int
+}
+
+// IsEmpty reports whether the update changes nothing.
+func (u TodoUpdate) IsEmpty() bool {
+	return u.Text == nil && u.Description == nil && u.Status == nil && u.SharedWithAdmin == nil && u.HiddenFromUser == nil && u.DueAt == nil && u.Tags == nil && u.Priority == nil && u.Recurrence == nil && u.BlockedBy == nil && u.Groups == nil && u.Draft == nil && u.PublishAt == nil && u.ExpiresAt == nil && !u.Resets()
+}
+
+// Resets reports whether the update moves users of a default task back to
+// pending.
+func (u TodoUpdate) Resets() bool {
+	return u.Propagate == models.PropagateResetAll || u.Propagate == models.PropagateResetDone
+}
+
+// TodoFilter narrows and pages a todo listing. Empty fields match everything.
+type TodoFilter struct {
+	// Status matches the status shown in the listing, which for default
+	// tasks read as a user is that user's own status.
+	Status string
+	Source models.TodoSource
+
+	// ParentID keeps only the subtasks of that todo.
+	ParentID string
+
+	// Tags keeps only the todos with every one of these tags.
+	Tags []string
+
+	// From and To bound the creation time; From is inclusive, To exclusive.
+	From time.Time
+	To   time.Time
+
+	// DueFrom and DueTo bound the due date, as shown in the listing, in the
+	// same way. Todos without a due date are excluded by either bound.
+	DueFrom time.Time
+	DueTo   time.Time
+
+	// OverdueAt keeps only the todos that are overdue at that time.
+	OverdueAt time.Time
+
+	// Publication keeps only the default tasks in that state now.
+	Publication models.Publication
+
+	Sort TodoSort
+
+	// After continues the listing after the last todo of a previous page.
+	After *Cursor
+
+	// Limit caps the number of todos returned. Zero means no limit.
+	Limit int
+}
+
+// TodoSort orders a todo listing. Ties are broken newest first.
+type TodoSort string
+
+// Todo listing orders.
+const (
+	SortPosition TodoSort = ""         // by position
+	SortDue      TodoSort = "due"      // by due date, todos without one last
+	SortPriority TodoSort = "priority" // most urgent first, then by position
+)
+
+// UserFilter narrows and pages a user listing. Empty fields match everything.
+type UserFilter struct {
+	// From and To bound the creation time; From is inclusive, To exclusive.
+	From time.Time
+	To   time.Time
+
+	// After continues the listing after the last user of a previous page.
+	After *Cursor
+
+	// Limit caps the number of users returned. Zero means no limit.
+	Limit int
+}
+
+// SearchWords splits a search query into the words that must all appear in
+// a matching todo. Punctuation separates words and is otherwise ignored.
+func SearchWords(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// TodoStore persists todos and the per-user state of default tasks.
+//
+// Methods that take a userID return default tasks with that user's status and
+// position (from user_todo_state) in place of the global values.
+//
+// Listings are ordered by position (or as chosen by TodoFilter.Sort), newest
+// first within equal positions, and take a TodoFilter to narrow and page them.
+//
+// Deleted todos stay in the trash until restored or purged. Only the trash
+// methods see them; everything else treats them as not found.
+//
+// A todo with a ParentID is a subtask of that todo, in the same list. Todos
+// are returned with the roll-up of their live direct subtasks, by each
+// subtask's status as shown in the todo itself.
+//
+// Todos are returned with their comment count, in the thread of the user
+// they are read as (see CommentStore), or in every thread otherwise.
+//
+// A default task that targets groups is only part of the lists, searches and
+// overdue todos of their members, and read as anyone else it is not found.
+// Default tasks that are not live, being drafts, scheduled for later or
+// expired, are left out in the same way.
+//
+// A todo's version is bumped by every update, and a user's state version by
+// every change to their status or due date for a default task; reordering
+// bumps neither.
+// Writes that take an expected version fail with ErrConflict if it is stale.
+type TodoStore interface {
+	// ListUserTodos returns the todos a user sees in their own list: their
+	// personal todos plus, when includeDefault is set, all default tasks.
+	// Tasks hidden from the user are excluded when includeDefault is set.
+	ListUserTodos(ctx context.Context, userID string, includeDefault bool, f TodoFilter) ([]models.Todo, error)
+
+	// ListSharedTodos returns the todos an admin sees for a user: personal
+	// todos shared with admins plus all default tasks.
+	ListSharedTodos(ctx context.Context, userID string, f TodoFilter) ([]models.Todo, error)
+
+	// SearchUserTodos returns up to limit todos from a user's own list (as
+	// with ListUserTodos) whose text matches query, best matches first.
+	SearchUserTodos(ctx context.Context, userID, query string, includeDefault bool, limit int) ([]models.Todo, error)
+
+	// SearchSharedTodos returns up to limit todos an admin can see whose text
+	// matches query, best matches first: default tasks plus personal todos
+	// shared with admins, for userID (as with ListSharedTodos) or, if userID
+	// is empty, for every user.
+	SearchSharedTodos(ctx context.Context, userID, query string, limit int) ([]models.Todo, error)
+
+	// ListDefaultTodos returns all global default tasks.
+	ListDefaultTodos(ctx context.Context, f TodoFilter) ([]models.Todo, error)
+
+	// GetTodo returns a todo with its global status and position.
+	GetTodo(ctx context.Context, id string) (models.Todo, error)
+
+	// GetUserTodo returns a todo as seen by userID.
+	GetUserTodo(ctx context.Context, id, userID string) (models.Todo, error)
+
+	// CreateTodo inserts a todo at the top of its list (the owner's todos,
+	// or the default tasks) and sets t.Position accordingly. The todo's
+	// first revision is attributed to t.CreatedByUserID. t.Tags,
+	// t.BlockedBy and t.Groups must be normalized; an empty t.Priority is
+	// stored as normal.
+	CreateTodo(ctx context.Context, t *models.Todo) error
+
+	// UpdateTodo changes the global fields of a todo, bumps its version and,
+	// if anything actually changed, records a new revision. Marking a
+	// recurring personal todo done ends its recurrence and creates its next
+	// occurrence, as with NextOccurrence, at the top of the owner's list.
+	// Changing blockers so that the todo would depend on itself fails with
+	// ErrDependencyCycle. Users reset by u.Propagate get their state
+	// version bumped.
+	UpdateTodo(ctx context.Context, id string, u TodoUpdate) error
+
+	// ListTodoRevisions returns a todo's revisions, oldest first.
+	ListTodoRevisions(ctx context.Context, todoID string) ([]models.TodoRevision, error)
+
+	// GetTodoRevision returns a single revision of a todo.
+	GetTodoRevision(ctx context.Context, todoID string, revision int) (models.TodoRevision, error)
+
+	// SetDefaultTodoStatus records a user's status for a default task. If
+	// ifVersion is set, the user's state must still be at that version.
+	// Marking a recurring default task done instead moves the user on to
+	// its next occurrence: pending again, due at the next date and with
+	// the user's occurrence count bumped.
+	SetDefaultTodoStatus(ctx context.Context, userID, todoID, status string, ifVersion *int) error
+
+	// SetDefaultTodoDueAt overrides the due date of a default task for a
+	// user, or removes the override if dueAt is nil or zero. If ifVersion is set,
+	// the user's state must still be at that version.
+	SetDefaultTodoDueAt(ctx context.Context, userID, todoID string, dueAt *time.Time, ifVersion *int) error
+
+	// UpdateDefaultTodoState changes a user's status and due date of a
+	// default task together, as SetDefaultTodoStatus and then
+	// SetDefaultTodoDueAt would, or not at all. If ifVersion is set, the
+	// task must still be at that version, and if ifStateVersion is set, the
+	// user's state must be too.
+	UpdateDefaultTodoState(ctx context.Context, userID, todoID string, u StateUpdate, ifVersion, ifStateVersion *int) error
+
+	// PublishTodo makes a default task and its live subtasks, at any depth,
+	// visible to users from at: drafts and tasks scheduled later are
+	// published at at, and expiry dates not after it are cleared. If
+	// ifVersion is set, the task must still be at that version.
+	PublishTodo(ctx context.Context, id string, at time.Time, ifVersion *int) error
+
+	// ListOverdueTodos returns up to limit todos that are overdue at now
+	// and visible to admins, most overdue first: default tasks for each
+	// non-admin user, by that user's status and due date, plus personal
+	// todos shared with admins. If userID is set, only that user's are
+	// returned.
+	ListOverdueTodos(ctx context.Context, userID string, now time.Time, limit int) ([]models.OverdueTodo, error)
+
+	// ReorderTodos positions ids in the given order for userID. Default tasks
+	// are reordered per user; personal todos only if owned by userID.
+	ReorderTodos(ctx context.Context, userID string, ids []string) error
+
+	// ReorderDefaultTodos positions the default tasks ids in the given
+	// order, which users see unless they have reordered the tasks
+	// themselves. If resetUsers is set, their own positions are reset to
+	// it too. It fails with ErrNotFound, changing nothing, if any of ids is
+	// not a live default task.
+	ReorderDefaultTodos(ctx context.Context, ids []string, resetUsers bool) error
+
+	// DeleteTodo moves a todo and its subtasks, at any depth, to the trash.
+	// Per-user state is kept so that restoring the todo brings back every
+	// user's progress. If ifVersion is set, the todo must still be at that
+	// version.
+	DeleteTodo(ctx context.Context, id string, ifVersion *int) error
+
+	// ListDeletedTodos returns the trash, most recently deleted first: the
+	// personal todos owned by userID, or the default tasks if userID is empty.
+	// Subtasks whose parent is in the trash too are left out.
+	ListDeletedTodos(ctx context.Context, userID string) ([]models.Todo, error)
+
+	// GetDeletedTodo returns a todo from the trash.
+	GetDeletedTodo(ctx context.Context, id string) (models.Todo, error)
+
+	// RestoreTodo moves a todo out of the trash, along with the subtasks
+	// that were deleted with it.
+	RestoreTodo(ctx context.Context, id string) error
+
+	// PurgeDeletedTodos permanently removes todos deleted before the given
+	// time, along with their per-user state, comments, attachments and
+	// subtasks, and returns how many were removed, not counting subtasks
+	// removed only with their parent.
+	PurgeDeletedTodos(ctx context.Context, before time.Time) (int64, error)
+}
+
+// TagStore persists the palette of curated tags. Tags are attached to todos
+// through TodoStore, and created on first use.
+type TagStore interface {
+	// ListTags returns the palette or, if all is set, also every tag on a
+	// live todo, by name.
+	ListTags(ctx context.Context, all bool) ([]models.Tag, error)
+
+	// SaveCuratedTag adds a tag to the palette, or changes its color if it
+	// is already there.
+	SaveCuratedTag(ctx context.Context, tag models.Tag) error
+
+	// RemoveCuratedTag takes a tag out of the palette. Todos keep the tag.
+	RemoveCuratedTag(ctx context.Context, name string) error
+}
+
+// CommentStore persists the comment threads on todos. Comments stay with
+// their todo in the trash and are removed when it is purged.
+type CommentStore interface {
+	// ListComments returns the comments in userID's thread on a todo,
+	// oldest first.
+	ListComments(ctx context.Context, todoID, userID string) ([]models.Comment, error)
+
+	// GetComment returns a single comment.
+	GetComment(ctx context.Context, id string) (models.Comment, error)
+
+	// CreateComment inserts a comment.
+	CreateComment(ctx context.Context, c models.Comment) error
+
+	// UpdateComment replaces the body of a comment and sets its edit time.
+	UpdateComment(ctx context.Context, id, body string, editedAt time.Time) error
+
+	// DeleteComment removes a comment.
+	DeleteComment(ctx context.Context, id string) error
+}
+
+// AttachmentStore persists what is known about the files attached to todos.
+// Their contents are kept in a BlobStore. Attachments stay with their todo
+// in the trash and are removed when it is purged.
+type AttachmentStore interface {
+	// ListAttachments returns the files attached to userID's todo (or copy
+	// of a default task), oldest first.
+	ListAttachments(ctx context.Context, todoID, userID string) ([]models.Attachment, error)
+
+	// ListPurgeableAttachments returns the attachments of the todos
+	// PurgeDeletedTodos would remove for the same time, so that their
+	// contents can be removed too.
+	ListPurgeableAttachments(ctx context.Context, before time.Time) ([]models.Attachment, error)
+
+	// GetAttachment returns a single attachment.
+	GetAttachment(ctx context.Context, id string) (models.Attachment, error)
+
+	// CreateAttachment inserts an attachment.
+	CreateAttachment(ctx context.Context, a models.Attachment) error
+
+	// DeleteAttachment removes an attachment.
+	DeleteAttachment(ctx context.Context, id string) error
+}
+
+// WorkflowStore persists the status workflow configured by admins.
+type WorkflowStore interface {
+	// GetWorkflow returns the workflow, with the built-in statuses first.
+	// Until one is saved, it is models.DefaultWorkflow.
+	GetWorkflow(ctx context.Context) (models.Workflow, error)
+
+	// SaveWorkflow replaces the workflow with w, which must be normalized.
+	// Todos keep their status if it is removed.
+	SaveWorkflow(ctx context.Context, w models.Workflow) error
+}
+
+// TemplateStore persists the checklist templates admins apply to users, and
+// which users each was applied to.
+type TemplateStore interface {
+	// ListTemplates returns every template by name.
+	ListTemplates(ctx context.Context) ([]models.Template, error)
+
+	// GetTemplate returns a single template with its tasks in order.
+	GetTemplate(ctx context.Context, id string) (models.Template, error)
+
+	// SaveTemplate creates t, or replaces its name and tasks, which must be
+	// normalized and have IDs. Todos added from tasks that are removed
+	// stay in users' lists, no longer linked to the template.
+	SaveTemplate(ctx context.Context, t models.Template) error
+
+	// DeleteTemplate removes a template. Todos added from it stay.
+	DeleteTemplate(ctx context.Context, id string) error
+
+	// AddTemplateUser records that a template was applied to a user.
+	AddTemplateUser(ctx context.Context, templateID, userID string) error
+
+	// ListTemplateUsers returns the IDs of the users a template was applied
+	// to, sorted.
+	ListTemplateUsers(ctx context.Context, templateID string) ([]string, error)
+
+	// ListTemplateTodos returns the todos of userID added from the tasks
+	// of a template, including those in the trash, in the order of the
+	// tasks.
+	ListTemplateTodos(ctx context.Context, templateID, userID string) ([]models.Todo, error)
+}
+
+// GroupStore persists the groups users are sorted into and their members.
+// Default tasks are targeted to groups through TodoStore.
+type GroupStore interface {
+	// ListGroups returns every group by name.
+	ListGroups(ctx context.Context) ([]models.Group, error)
+
+	// GetGroup returns a single group.
+	GetGroup(ctx context.Context, id string) (models.Group, error)
+
+	// SaveGroup creates g, or renames it.
+	SaveGroup(ctx context.Context, g models.Group) error
+
+	// DeleteGroup removes a group along with its memberships. It fails with
+	// ErrConflict while default tasks, including those in the trash,
+	// target the group.
+	DeleteGroup(ctx context.Context, id string) error
+
+	// ListGroupMembers returns the members of a group by email.
+	ListGroupMembers(ctx context.Context, groupID string) ([]models.User, error)
+
+	// AddGroupMember adds a user to a group. Adding a member again is not
+	// an error.
+	AddGroupMember(ctx context.Context, groupID, userID string) error
+
+	// RemoveGroupMember removes a user from a group, or fails with
+	// ErrNotFound if they are not a member.
+	RemoveGroupMember(ctx context.Context, groupID, userID string) error
+}
+
+// BlobStore keeps the contents of attachments by key.
+type BlobStore interface {
+	// PutBlob stores the contents of r under key, replacing any earlier
+	// contents only once r has been read in full.
+	PutBlob(ctx context.Context, key string, r io.Reader) error
+
+	// OpenBlob returns the contents stored under key.
+	OpenBlob(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// DeleteBlob removes the contents stored under key. Removing missing
+	// contents is not an error.
+	DeleteBlob(ctx context.Context, key string) error
+}
+
+// UserStore persists user accounts.
+type UserStore interface {
+	// GetUser returns the user with the given ID.
+	GetUser(ctx context.Context, id string) (models.User, error)
+
+	// GetUserByGoogleID returns the user linked to a Google account.
+	GetUserByGoogleID(ctx context.Context, googleID string) (models.User, error)
+
+	// CreateUser inserts a new user.
+	CreateUser(ctx context.Context, u models.User) error
+
+	// UpdateUserRole changes a user's role.
+	UpdateUserRole(ctx context.Context, id, role string) error
+
+	// ListUsers returns the non-admin users matching f, newest first.
+	ListUsers(ctx context.Context, f UserFilter) ([]models.User, error)
+}
+
+// SessionStore persists login sessions.
+type SessionStore interface {
+	// CreateSession stores a session token for userID.
+	CreateSession(ctx context.Context, token, userID string, expiresAt time.Time) error
+
+	// GetSessionUser returns the user owning an unexpired session token.
+	GetSessionUser(ctx context.Context, token string) (models.User, error)
+
+	// DeleteSession removes a session token.
+	DeleteSession(ctx context.Context, token string) error
+}
+
+// AuditFilter selects audit events. Empty fields match everything.
+type AuditFilter struct {
+	UserID  string
+	TodoID  string
+	ActorID string
+	Action  models.AuditAction
+
+	// From and To bound the event time; From is inclusive, To exclusive.
+	From time.Time
+	To   time.Time
+
+	// Limit caps the number of events returned. Zero means no limit.
+	Limit int
+}
+
+// AuditStore persists the audit log.
+type AuditStore interface {
+	// RecordAuditEvent appends an event to the log and sets e.ID.
+	RecordAuditEvent(ctx context.Context, e *models.AuditEvent) error
+
+	// ListAuditEvents returns the events matching f, newest first.
+	ListAuditEvents(ctx context.Context, f AuditFilter) ([]models.AuditEvent, error)
+}
@@ -0,0 +1,2432 @@
+// Package storetest is a conformance suite for store.Store implementations,
+// so that every backend behaves the same way for the handlers.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// Run runs the suite. newStore must return an empty store for each call.
+func Run(t *testing.T, newStore func(t *testing.T) store.Store) {
+	t.Run("Users", func(t *testing.T) { testUsers(t, newStore(t)) })
+	t.Run("Sessions", func(t *testing.T) { testSessions(t, newStore(t)) })
+	t.Run("Todos", func(t *testing.T) { testTodos(t, newStore(t)) })
+	t.Run("DefaultTodos", func(t *testing.T) { testDefaultTodos(t, newStore(t)) })
+	t.Run("Trash", func(t *testing.T) { testTrash(t, newStore(t)) })
+	t.Run("Audit", func(t *testing.T) { testAudit(t, newStore(t)) })
+	t.Run("Revisions", func(t *testing.T) { testRevisions(t, newStore(t)) })
+	t.Run("Versions", func(t *testing.T) { testVersions(t, newStore(t)) })
+	t.Run("Search", func(t *testing.T) { testSearch(t, newStore(t)) })
+	t.Run("Pagination", func(t *testing.T) { testPagination(t, newStore(t)) })
+	t.Run("DueDates", func(t *testing.T) { testDueDates(t, newStore(t)) })
+	t.Run("Subtasks", func(t *testing.T) { testSubtasks(t, newStore(t)) })
+	t.Run("Tags", func(t *testing.T) { testTags(t, newStore(t)) })
+	t.Run("Priorities", func(t *testing.T) { testPriorities(t, newStore(t)) })
+	t.Run("Comments", func(t *testing.T) { testComments(t, newStore(t)) })
+	t.Run("Attachments", func(t *testing.T) { testAttachments(t, newStore(t)) })
+	t.Run("Recurrence", func(t *testing.T) { testRecurrence(t, newStore(t)) })
+	t.Run("Workflow", func(t *testing.T) { testWorkflow(t, newStore(t)) })
+	t.Run("Dependencies", func(t *testing.T) { testDependencies(t, newStore(t)) })
+	t.Run("Descriptions", func(t *testing.T) { testDescriptions(t, newStore(t)) })
+	t.Run("Templates", func(t *testing.T) { testTemplates(t, newStore(t)) })
+	t.Run("Groups", func(t *testing.T) { testGroups(t, newStore(t)) })
+	t.Run("Propagation", func(t *testing.T) { testPropagation(t, newStore(t)) })
+	t.Run("Publication", func(t *testing.T) { testPublication(t, newStore(t)) })
+	t.Run("DefaultOrder", func(t *testing.T) { testDefaultOrder(t, newStore(t)) })
+}
+
+// RunBlobStore runs the suite for store.BlobStore implementations.
+// newStore must return an empty store for each call.
+func RunBlobStore(t *testing.T, newStore func(t *testing.T) store.BlobStore) {
+	t.Run("Blobs", func(t *testing.T) { testBlobs(t, newStore(t)) })
+}
+
+// CreateUser inserts a user with the given ID and role.
+func CreateUser(t *testing.T, s store.Store, id string, role models.UserRole) models.User {
+	t.Helper()
+	u := models.User{
+		ID:        id,
+		GoogleID:  "google-" + id,
+		Email:     id + "@example.com",
+		Name:      id,
+		Role:      string(role),
+		CreatedAt: time.Now(),
+	}
+	if err := s.CreateUser(context.Background(), u); err != nil {
+		t.Fatalf("Failed to create user %s: %v", id, err)
+	}
+	return u
+}
+
+// CreateTodo inserts a personal todo owned by userID, or a default task if
+// userID is empty.
+func CreateTodo(t *testing.T, s store.Store, id, userID string) models.Todo {
+	t.Helper()
+	todo := models.Todo{
+		ID:      id,
+		Text:    "todo " + id,
+		Status:  string(models.StatusPending),
+		Created: time.Now(),
+	}
+	if userID == "" {
+		todo.IsDefaultTask = true
+	} else {
+		todo.UserID = &userID
+		todo.CreatedByUserID = &userID
+	}
+	if err := s.CreateTodo(context.Background(), &todo); err != nil {
+		t.Fatalf("Failed to create todo %s: %v", id, err)
+	}
+	return todo
+}
+
+func version(n int) *int { return &n }
+
+func ids(todos []models.Todo) []string {
+	out := make([]string, len(todos))
+	for i, t := range todos {
+		out[i] = t.ID
+	}
+	return out
+}
+
+func testUsers(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	CreateUser(t, s, "admin-1", models.RoleAdmin)
+	u1 := CreateUser(t, s, "user-1", models.RoleUser)
+	time.Sleep(time.Millisecond)
+	CreateUser(t, s, "user-2", models.RoleUser)
+
+	got, err := s.GetUser(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("GetUser failed: %v", err)
+	}
+	if got.Email != u1.Email || got.GoogleID != u1.GoogleID || got.Role != u1.Role {
+		t.Errorf("Expected %+v, got %+v", u1, got)
+	}
+
+	got, err = s.GetUserByGoogleID(ctx, u1.GoogleID)
+	if err != nil || got.ID != "user-1" {
+		t.Errorf("Expected user-1 by Google ID, got %q (%v)", got.ID, err)
+	}
+
+	if _, err := s.GetUser(ctx, "missing"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for missing user, got %v", err)
+	}
+	if err := s.CreateUser(ctx, u1); err == nil {
+		t.Error("Expected error creating duplicate user")
+	}
+
+	users, err := s.ListUsers(ctx, store.UserFilter{})
+	if err != nil {
+		t.Fatalf("ListUsers failed: %v", err)
+	}
+	userIDs := make([]string, len(users))
+	for i, u := range users {
+		userIDs[i] = u.ID
+	}
+	if !slices.Equal(userIDs, []string{"user-2", "user-1"}) {
+		t.Errorf("Expected non-admin users newest first, got %v", userIDs)
+	}
+
+	if err := s.UpdateUserRole(ctx, "user-1", string(models.RoleAdmin)); err != nil {
+		t.Fatalf("UpdateUserRole failed: %v", err)
+	}
+	if got, _ := s.GetUser(ctx, "user-1"); got.Role != string(models.RoleAdmin) {
+		t.Errorf("Expected role admin, got %s", got.Role)
+	}
+	if err := s.UpdateUserRole(ctx, "missing", string(models.RoleAdmin)); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound updating missing user, got %v", err)
+	}
+}
+
+func testSessions(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	CreateUser(t, s, "user-1", models.RoleUser)
+
+	if err := s.CreateSession(ctx, "live", "user-1", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	if err := s.CreateSession(ctx, "expired", "user-1", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	u, err := s.GetSessionUser(ctx, "live")
+	if err != nil || u.ID != "user-1" {
+		t.Errorf("Expected user-1 for live session, got %q (%v)", u.ID, err)
+	}
+	if _, err := s.GetSessionUser(ctx, "expired"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for expired session, got %v", err)
+	}
+
+	if err := s.DeleteSession(ctx, "live"); err != nil {
+		t.Fatalf("DeleteSession failed: %v", err)
+	}
+	if _, err := s.GetSessionUser(ctx, "live"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for deleted session, got %v", err)
+	}
+}
+
+func testTodos(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	CreateUser(t, s, "user-1", models.RoleUser)
+	CreateUser(t, s, "user-2", models.RoleUser)
+
+	first := CreateTodo(t, s, "first", "user-1")
+	second := CreateTodo(t, s, "second", "user-1")
+	CreateTodo(t, s, "other", "user-2")
+
+	if second.Position >= first.Position {
+		t.Errorf("Expected new todo above existing one, got %v >= %v", second.Position, first.Position)
+	}
+
+	todos, err := s.ListUserTodos(ctx, "user-1", false, store.TodoFilter{})
+	if err != nil {
+		t.Fatalf("ListUserTodos failed: %v", err)
+	}
+	if got := ids(todos); !slices.Equal(got, []string{"second", "first"}) {
+		t.Errorf("Expected [second first], got %v", got)
+	}
+
+	got, err := s.GetTodo(ctx, "first")
+	if err != nil {
+		t.Fatalf("GetTodo failed: %v", err)
+	}
+	if got.Text != first.Text || got.UserID == nil || *got.UserID != "user-1" || got.IsDefaultTask {
+		t.Errorf("Unexpected todo %+v", got)
+	}
+	if got.Created.Sub(first.Created).Abs() > time.Millisecond {
+		t.Errorf("Expected created %v, got %v", first.Created, got.Created)
+	}
+
+	text, done, shared := "updated", string(models.StatusDone), true
+	if err := s.UpdateTodo(ctx, "first", store.TodoUpdate{Text: &text, Status: &done, SharedWithAdmin: &shared}); err != nil {
+		t.Fatalf("UpdateTodo failed: %v", err)
+	}
+	got, _ = s.GetTodo(ctx, "first")
+	if got.Text != text || got.Status != done || !got.SharedWithAdmin {
+		t.Errorf("Expected updated todo, got %+v", got)
+	}
+	if err := s.UpdateTodo(ctx, "missing", store.TodoUpdate{Text: &text}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound updating missing todo, got %v", err)
+	}
+
+	todos, err = s.ListSharedTodos(ctx, "user-1", store.TodoFilter{})
+	if err != nil {
+		t.Fatalf("ListSharedTodos failed: %v", err)
+	}
+	if got := ids(todos); !slices.Equal(got, []string{"first"}) {
+		t.Errorf("Expected only the shared todo, got %v", got)
+	}
+
+	if err := s.ReorderTodos(ctx, "user-1", []string{"first", "second", "other"}); err != nil {
+		t.Fatalf("ReorderTodos failed: %v", err)
+	}
+	todos, _ = s.ListUserTodos(ctx, "user-1", false, store.TodoFilter{})
+	if got := ids(todos); !slices.Equal(got, []string{"first", "second"}) {
+		t.Errorf("Expected [first second] after reorder, got %v", got)
+	}
+	if o, _ := s.GetTodo(ctx, "other"); o.Position == 0 {
+		t.Error("Expected another user's todo to keep its position")
+	}
+	if err := s.ReorderTodos(ctx, "user-1", []string{"missing"}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound reordering missing todo, got %v", err)
+	}
+
+	if err := s.DeleteTodo(ctx, "first", nil); err != nil {
+		t.Fatalf("DeleteTodo failed: %v", err)
+	}
+	if _, err := s.GetTodo(ctx, "first"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for deleted todo, got %v", err)
+	}
+	if err := s.DeleteTodo(ctx, "first", nil); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting twice, got %v", err)
+	}
+}
+
+func testDefaultTodos(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	CreateUser(t, s, "user-1", models.RoleUser)
+	CreateUser(t, s, "user-2", models.RoleUser)
+
+	def := CreateTodo(t, s, "default", "")
+	hidden := CreateTodo(t, s, "hidden", "")
+	CreateTodo(t, s, "personal", "user-1")
+
+	hide := true
+	if err := s.UpdateTodo(ctx, hidden.ID, store.TodoUpdate{HiddenFromUser: &hide}); err != nil {
+		t.Fatalf("UpdateTodo failed: %v", err)
+	}
+
+	defaults, err := s.ListDefaultTodos(ctx, store.TodoFilter{})
+	if err != nil {
+		t.Fatalf("ListDefaultTodos failed: %v", err)
+	}
+	if got := ids(defaults); !slices.Equal(got, []string{"hidden", "default"}) {
+		t.Errorf("Expected [hidden default], got %v", got)
+	}
+
+	todos, err := s.ListUserTodos(ctx, "user-1", true, store.TodoFilter{})
+	if err != nil {
+		t.Fatalf("ListUserTodos failed: %v", err)
+	}
+	if got := ids(todos); !slices.Equal(got, []string{"default", "personal"}) && !slices.Equal(got, []string{"personal", "default"}) {
+		t.Errorf("Expected personal and visible default todos, got %v", got)
+	}
+
+	// Status is tracked per user
+	if err := s.SetDefaultTodoStatus(ctx, "user-1", def.ID, string(models.StatusDone), nil); err != nil {
+		t.Fatalf("SetDefaultTodoStatus failed: %v", err)
+	}
+	if got, _ := s.GetUserTodo(ctx, def.ID, "user-1"); got.Status != string(models.StatusDone) {
+		t.Errorf("Expected done for user-1, got %s", got.Status)
+	}
+	if got, _ := s.GetUserTodo(ctx, def.ID, "user-2"); got.Status != string(models.StatusPending) {
+		t.Errorf("Expected pending for user-2, got %s", got.Status)
+	}
+	if got, _ := s.GetTodo(ctx, def.ID); got.Status != string(models.StatusPending) {
+		t.Errorf("Expected global status pending, got %s", got.Status)
+	}
+
+	// Position is tracked per user and keeps the user's status
+	if err := s.ReorderTodos(ctx, "user-1", []string{"personal", def.ID}); err != nil {
+		t.Fatalf("ReorderTodos failed: %v", err)
+	}
+	got, _ := s.GetUserTodo(ctx, def.ID, "user-1")
+	if got.Position != models.PositionIncrement || got.Status != string(models.StatusDone) {
+		t.Errorf("Expected position %v and status done, got %v and %s", models.PositionIncrement, got.Position, got.Status)
+	}
+	if other, _ := s.GetUserTodo(ctx, def.ID, "user-2"); other.Position != def.Position {
+		t.Errorf("Expected global position %v for user-2, got %v", def.Position, other.Position)
+	}
+
+	// Reordering before any status change starts the user at pending
+	if err := s.ReorderTodos(ctx, "user-2", []string{def.ID}); err != nil {
+		t.Fatalf("ReorderTodos failed: %v", err)
+	}
+	if other, _ := s.GetUserTodo(ctx, def.ID, "user-2"); other.Status != string(models.StatusPending) {
+		t.Errorf("Expected pending for user-2 after reorder, got %s", other.Status)
+	}
+
+	if err := s.DeleteTodo(ctx, def.ID, nil); err != nil {
+		t.Fatalf("DeleteTodo failed: %v", err)
+	}
+	if _, err := s.GetUserTodo(ctx, def.ID, "user-1"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for deleted default task, got %v", err)
+	}
+}
+
+func testTrash(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	CreateUser(t, s, "user-1", models.RoleUser)
+
+	def := CreateTodo(t, s, "default", "")
+	CreateTodo(t, s, "personal", "user-1")
+	CreateTodo(t, s, "kept", "user-1")
+
+	if err := s.SetDefaultTodoStatus(ctx, "user-1", def.ID, string(models.StatusDone), nil); err != nil {
+		t.Fatalf("SetDefaultTodoStatus failed: %v", err)
+	}
+	if err := s.DeleteTodo(ctx, def.ID, nil); err != nil {
+		t.Fatalf("DeleteTodo failed: %v", err)
+	}
+	if err := s.DeleteTodo(ctx, "personal", nil); err != nil {
+		t.Fatalf("DeleteTodo failed: %v", err)
+	}
+
+	// Trashed todos are hidden from everything but the trash methods
+	todos, _ := s.ListUserTodos(ctx, "user-1", true, store.TodoFilter{})
+	if got := ids(todos); !slices.Equal(got, []string{"kept"}) {
+		t.Errorf("Expected only [kept] in the list, got %v", got)
+	}
+	if defaults, _ := s.ListDefaultTodos(ctx, store.TodoFilter{}); len(defaults) != 0 {
+		t.Errorf("Expected no live default tasks, got %v", ids(defaults))
+	}
+	text := "changed"
+	if err := s.UpdateTodo(ctx, "personal", store.TodoUpdate{Text: &text}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound updating a trashed todo, got %v", err)
+	}
+	if err := s.DeleteTodo(ctx, "personal", nil); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting a trashed todo, got %v", err)
+	}
+
+	trash, err := s.ListDeletedTodos(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("ListDeletedTodos failed: %v", err)
+	}
+	if got := ids(trash); !slices.Equal(got, []string{"personal"}) {
+		t.Errorf("Expected [personal] in the user's trash, got %v", got)
+	}
+	if trash[0].DeletedAt == nil {
+		t.Error("Expected deleted_at to be set")
+	}
+	trash, _ = s.ListDeletedTodos(ctx, "")
+	if got := ids(trash); !slices.Equal(got, []string{"default"}) {
+		t.Errorf("Expected [default] in the default task trash, got %v", got)
+	}
+	if _, err := s.GetDeletedTodo(ctx, "kept"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound getting a live todo from the trash, got %v", err)
+	}
+
+	// Restoring brings back each user's progress
+	if err := s.RestoreTodo(ctx, def.ID); err != nil {
+		t.Fatalf("RestoreTodo failed: %v", err)
+	}
+	got, err := s.GetUserTodo(ctx, def.ID, "user-1")
+	if err != nil {
+		t.Fatalf("GetUserTodo failed: %v", err)
+	}
+	if got.Status != string(models.StatusDone) || got.DeletedAt != nil {
+		t.Errorf("Expected restored default task with status done, got %+v", got)
+	}
+	if err := s.RestoreTodo(ctx, def.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound restoring a live todo, got %v", err)
+	}
+
+	// Purging only removes todos deleted before the cutoff
+	n, err := s.PurgeDeletedTodos(ctx, time.Now().Add(-time.Hour))
+	if err != nil || n != 0 {
+		t.Errorf("Expected nothing purged before the cutoff, got %d (%v)", n, err)
+	}
+	n, err = s.PurgeDeletedTodos(ctx, time.Now().Add(time.Second))
+	if err != nil || n != 1 {
+		t.Errorf("Expected 1 todo purged, got %d (%v)", n, err)
+	}
+	if _, err := s.GetDeletedTodo(ctx, "personal"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected purged todo to be gone, got %v", err)
+	}
+	if err := s.RestoreTodo(ctx, "personal"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound restoring a purged todo, got %v", err)
+	}
+}
+
+func testAudit(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	admin, user, todo := "admin-1", "user-1", "todo-1"
+	start := time.Now()
+
+	record := func(e models.AuditEvent) models.AuditEvent {
+		t.Helper()
+		if err := s.RecordAuditEvent(ctx, &e); err != nil {
+			t.Fatalf("RecordAuditEvent failed: %v", err)
+		}
+		if e.ID == 0 {
+			t.Error("Expected the event ID to be set")
+		}
+		return e
+	}
+
+	record(models.AuditEvent{Action: models.AuditUserRoleChange, UserID: &user, After: []byte(`{"role":"admin"}`), CreatedAt: start})
+	record(models.AuditEvent{ActorID: &user, UserID: &user, TodoID: &todo, Action: models.AuditTodoCreate, After: []byte(`{"text":"a"}`), CreatedAt: start.Add(time.Second)})
+	last := record(models.AuditEvent{ActorID: &admin, UserID: &user, TodoID: &todo, Action: models.AuditTodoUpdate, Before: []byte(`{"status":"pending"}`), After: []byte(`{"status":"done"}`), CreatedAt: start.Add(2 * time.Second)})
+
+	events, err := s.ListAuditEvents(ctx, store.AuditFilter{})
+	if err != nil {
+		t.Fatalf("ListAuditEvents failed: %v", err)
+	}
+	if len(events) != 3 || events[0].ID != last.ID {
+		t.Fatalf("Expected 3 events newest first, got %+v", events)
+	}
+	got := events[0]
+	if got.ActorID == nil || *got.ActorID != admin || got.Action != models.AuditTodoUpdate {
+		t.Errorf("Unexpected event %+v", got)
+	}
+	if string(got.After) != `{"status":"done"}` && string(got.After) != `{"status": "done"}` {
+		t.Errorf("Expected after value to round-trip, got %s", got.After)
+	}
+	if events[2].ActorID != nil || events[2].TodoID != nil || events[2].Before != nil {
+		t.Errorf("Expected empty fields to stay empty, got %+v", events[2])
+	}
+
+	tests := []struct {
+		name     string
+		filter   store.AuditFilter
+		expected int
+	}{
+		{"By user", store.AuditFilter{UserID: user}, 3},
+		{"By todo", store.AuditFilter{TodoID: todo}, 2},
+		{"By actor", store.AuditFilter{ActorID: admin}, 1},
+		{"By action", store.AuditFilter{Action: models.AuditTodoCreate}, 1},
+		{"From", store.AuditFilter{From: start.Add(time.Second)}, 2},
+		{"To", store.AuditFilter{To: start.Add(time.Second)}, 1},
+		{"Limit", store.AuditFilter{Limit: 2}, 2},
+		{"No match", store.AuditFilter{TodoID: "missing"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := s.ListAuditEvents(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("ListAuditEvents failed: %v", err)
+			}
+			if len(events) != tt.expected {
+				t.Errorf("Expected %d events, got %d", tt.expected, len(events))
+			}
+		})
+	}
+}
+
+func testRevisions(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	CreateUser(t, s, "user-1", models.RoleUser)
+	CreateUser(t, s, "admin-1", models.RoleAdmin)
+	CreateTodo(t, s, "todo-1", "user-1")
+
+	done := string(models.StatusDone)
+	text := "renamed"
+	if err := s.UpdateTodo(ctx, "todo-1", store.TodoUpdate{Status: &done, ActorID: "admin-1"}); err != nil {
+		t.Fatalf("UpdateTodo failed: %v", err)
+	}
+	// An update that changes nothing adds no revision
+	if err := s.UpdateTodo(ctx, "todo-1", store.TodoUpdate{Status: &done, ActorID: "admin-1"}); err != nil {
+		t.Fatalf("UpdateTodo failed: %v", err)
+	}
+	if err := s.UpdateTodo(ctx, "todo-1", store.TodoUpdate{Text: &text, ActorID: "user-1"}); err != nil {
+		t.Fatalf("UpdateTodo failed: %v", err)
+	}
+
+	revs, err := s.ListTodoRevisions(ctx, "todo-1")
+	if err != nil {
+		t.Fatalf("ListTodoRevisions failed: %v", err)
+	}
+	if len(revs) != 3 {
+		t.Fatalf("Expected 3 revisions, got %+v", revs)
+	}
+	for i, r := range revs {
+		if r.Revision != i+1 {
+			t.Errorf("Expected revision %d, got %d", i+1, r.Revision)
+		}
+	}
+	if revs[0].Text != "todo todo-1" || revs[0].Status != string(models.StatusPending) || revs[0].ChangedBy == nil || *revs[0].ChangedBy != "user-1" {
+		t.Errorf("Unexpected first revision %+v", revs[0])
+	}
+	if revs[1].Status != done || revs[1].ChangedBy == nil || *revs[1].ChangedBy != "admin-1" {
+		t.Errorf("Unexpected second revision %+v", revs[1])
+	}
+	if revs[2].Text != text || revs[2].Status != done {
+		t.Errorf("Unexpected third revision %+v", revs[2])
+	}
+
+	got, err := s.GetTodoRevision(ctx, "todo-1", 2)
+	if err != nil {
+		t.Fatalf("GetTodoRevision failed: %v", err)
+	}
+	if got.Status != done || got.Text != "todo todo-1" {
+		t.Errorf("Unexpected revision %+v", got)
+	}
+	if _, err := s.GetTodoRevision(ctx, "todo-1", 4); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing revision, got %v", err)
+	}
+
+	// Purging a todo removes its history
+	if err := s.DeleteTodo(ctx, "todo-1", nil); err != nil {
+		t.Fatalf("DeleteTodo failed: %v", err)
+	}
+	if _, err := s.PurgeDeletedTodos(ctx, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("PurgeDeletedTodos failed: %v", err)
+	}
+	revs, err = s.ListTodoRevisions(ctx, "todo-1")
+	if err != nil {
+		t.Fatalf("ListTodoRevisions failed: %v", err)
+	}
+	if len(revs) != 0 {
+		t.Errorf("Expected no revisions after purge, got %d", len(revs))
+	}
+}
+
+func testVersions(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	CreateUser(t, s, "user-1", models.RoleUser)
+	CreateUser(t, s, "user-2", models.RoleUser)
+	own := CreateTodo(t, s, "own", "user-1")
+	def := CreateTodo(t, s, "default", "")
+
+	if own.Version != 1 {
+		t.Errorf("Expected CreateTodo to set version 1, got %d", own.Version)
+	}
+
+	text := "edited"
+	if err := s.UpdateTodo(ctx, "own", store.TodoUpdate{Text: &text, IfVersion: version(1)}); err != nil {
+		t.Fatalf("UpdateTodo failed: %v", err)
+	}
+	if err := s.UpdateTodo(ctx, "own", store.TodoUpdate{Text: &text, IfVersion: version(1)}); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("Expected ErrConflict for a stale version, got %v", err)
+	}
+	if err := s.UpdateTodo(ctx, "missing", store.TodoUpdate{Text: &text, IfVersion: version(1)}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing todo, got %v", err)
+	}
+
+	// Reordering is not a content change
+	if err := s.ReorderTodos(ctx, "user-1", []string{"default", "own"}); err != nil {
+		t.Fatalf("ReorderTodos failed: %v", err)
+	}
+	got, _ := s.GetTodo(ctx, "own")
+	if got.Version != 2 {
+		t.Errorf("Expected version 2, got %d", got.Version)
+	}
+	got, _ = s.GetUserTodo(ctx, def.ID, "user-1")
+	if got.Version != 1 || got.StateVersion != 0 {
+		t.Errorf("Expected default task at version 1.0 after reorder, got %d.%d", got.Version, got.StateVersion)
+	}
+
+	done := string(models.StatusDone)
+	if err := s.SetDefaultTodoStatus(ctx, "user-1", def.ID, done, version(0)); err != nil {
+		t.Fatalf("SetDefaultTodoStatus failed: %v", err)
+	}
+	if err := s.SetDefaultTodoStatus(ctx, "user-1", def.ID, done, version(0)); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("Expected ErrConflict for a stale state version, got %v", err)
+	}
+	if err := s.SetDefaultTodoStatus(ctx, "user-1", def.ID, done, version(1)); err != nil {
+		t.Fatalf("SetDefaultTodoStatus failed: %v", err)
+	}
+	if err := s.SetDefaultTodoStatus(ctx, "user-1", def.ID, done, nil); err != nil {
+		t.Fatalf("SetDefaultTodoStatus failed: %v", err)
+	}
+	got, _ = s.GetUserTodo(ctx, def.ID, "user-1")
+	if got.StateVersion != 3 || got.Position != 0 {
+		t.Errorf("Expected state version 3 with the reordered position kept, got %d at %v", got.StateVersion, got.Position)
+	}
+
+	// Users without state for the task are at state version 0
+	if err := s.SetDefaultTodoStatus(ctx, "user-2", def.ID, done, version(3)); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("Expected ErrConflict for another user's state version, got %v", err)
+	}
+	got, _ = s.GetUserTodo(ctx, def.ID, "user-2")
+	if got.StateVersion != 0 || got.Status != string(models.StatusPending) {
+		t.Errorf("Expected user-2's state to be untouched, got %+v", got)
+	}
+
+	// Status and due date change together, or not at all
+	due := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	pending := string(models.StatusPending)
+	both := store.StateUpdate{Status: &pending, DueAt: &due}
+	if err := s.UpdateDefaultTodoState(ctx, "user-1", def.ID, both, version(0), version(3)); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("Expected ErrConflict for a stale task version, got %v", err)
+	}
+	if err := s.UpdateDefaultTodoState(ctx, "user-1", def.ID, both, version(1), version(2)); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("Expected ErrConflict for a stale state version, got %v", err)
+	}
+	if got, _ = s.GetUserTodo(ctx, def.ID, "user-1"); got.Status != done || got.DueAt != nil || got.StateVersion != 3 {
+		t.Errorf("Expected rejected updates to change nothing, got %s, due %v, state version %d", got.Status, got.DueAt, got.StateVersion)
+	}
+	if err := s.UpdateDefaultTodoState(ctx, "user-1", def.ID, both, version(1), version(3)); err != nil {
+		t.Fatalf("UpdateDefaultTodoState failed: %v", err)
+	}
+	if got, _ = s.GetUserTodo(ctx, def.ID, "user-1"); got.Status != pending || got.DueAt == nil || !got.DueAt.Equal(due) || got.StateVersion != 5 {
+		t.Errorf("Expected both fields changed, got %s, due %v, state version %d", got.Status, got.DueAt, got.StateVersion)
+	}
+	if err := s.UpdateDefaultTodoState(ctx, "user-1", "missing", both, nil, nil); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing task, got %v", err)
+	}
+
+	if err := s.DeleteTodo(ctx, "own", version(1)); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("Expected ErrConflict deleting a stale version, got %v", err)
+	}
+	if err := s.DeleteTodo(ctx, "own", version(2)); err != nil {
+		t.Fatalf("DeleteTodo failed: %v", err)
+	}
+	if err := s.DeleteTodo(ctx, "own", version(2)); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting a trashed todo, got %v", err)
+	}
+}
+
+func testSearch(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	CreateUser(t, s, "user-1", models.RoleUser)
+	CreateUser(t, s, "user-2", models.RoleUser)
+	CreateUser(t, s, "admin-1", models.RoleAdmin)
+
+	create := func(id, text string, edit func(*models.Todo)) {
+		t.Helper()
+		todo := CreateTodo(t, s, id, "user-1")
+		todo.SharedWithAdmin = true
+		if edit != nil {
+			edit(&todo)
+		}
+		if err := s.UpdateTodo(ctx, id, store.TodoUpdate{Text: &text, SharedWithAdmin: &todo.SharedWithAdmin, HiddenFromUser: &todo.HiddenFromUser}); err != nil {
+			t.Fatalf("Failed to update todo %s: %v", id, err)
+		}
+	}
+	create("renew", "Renew passport", nil)
+	create("photos", "Passport photos", func(t *models.Todo) { t.SharedWithAdmin = false })
+	create("hidden", "Hidden passport note", func(t *models.Todo) { t.HiddenFromUser = true })
+	create("flights", "Book flights", nil)
+	create("old", "Old passport", nil)
+	CreateTodo(t, s, "other", "user-2")
+	other := "Passport copy"
+	shared := true
+	s.UpdateTodo(ctx, "other", store.TodoUpdate{Text: &other, SharedWithAdmin: &shared})
+	CreateTodo(t, s, "default", "")
+	bring := "Bring passport"
+	s.UpdateTodo(ctx, "default", store.TodoUpdate{Text: &bring})
+	if err := s.DeleteTodo(ctx, "old", nil); err != nil {
+		t.Fatalf("DeleteTodo failed: %v", err)
+	}
+
+	sorted := func(todos []models.Todo) []string {
+		out := ids(todos)
+		slices.Sort(out)
+		return out
+	}
+
+	tests := []struct {
+		name     string
+		search   func() ([]models.Todo, error)
+		expected []string
+	}{
+		{"User's list", func() ([]models.Todo, error) { return s.SearchUserTodos(ctx, "user-1", "passport", true, 10) }, []string{"default", "photos", "renew"}},
+		{"User's personal todos", func() ([]models.Todo, error) { return s.SearchUserTodos(ctx, "user-1", "passport", false, 10) }, []string{"hidden", "photos", "renew"}},
+		{"Every word must match", func() ([]models.Todo, error) { return s.SearchUserTodos(ctx, "user-1", "passport photos", true, 10) }, []string{"photos"}},
+		{"Case and punctuation are ignored", func() ([]models.Todo, error) { return s.SearchUserTodos(ctx, "user-1", "PASSPORT!", true, 10) }, []string{"default", "photos", "renew"}},
+		{"Search operators are literal", func() ([]models.Todo, error) { return s.SearchUserTodos(ctx, "user-1", `"book" OR`, true, 10) }, []string{}},
+		{"No words", func() ([]models.Todo, error) { return s.SearchUserTodos(ctx, "user-1", "!!", true, 10) }, []string{}},
+		{"Limit", func() ([]models.Todo, error) { return s.SearchUserTodos(ctx, "user-1", "passport", true, 1) }, nil},
+		{"Admin across users", func() ([]models.Todo, error) { return s.SearchSharedTodos(ctx, "", "passport", 10) }, []string{"default", "hidden", "other", "renew"}},
+		{"Admin for one user", func() ([]models.Todo, error) { return s.SearchSharedTodos(ctx, "user-1", "passport", 10) }, []string{"default", "hidden", "renew"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todos, err := tt.search()
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+			if tt.expected == nil {
+				if len(todos) != 1 {
+					t.Errorf("Expected 1 result, got %v", ids(todos))
+				}
+				return
+			}
+			if got := sorted(todos); !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	// The index follows text changes
+	renamed := "Renew ID card"
+	if err := s.UpdateTodo(ctx, "renew", store.TodoUpdate{Text: &renamed}); err != nil {
+		t.Fatalf("UpdateTodo failed: %v", err)
+	}
+	todos, err := s.SearchUserTodos(ctx, "user-1", "card", true, 10)
+	if err != nil {
+		t.Fatalf("SearchUserTodos failed: %v", err)
+	}
+	if got := ids(todos); !slices.Equal(got, []string{"renew"}) {
+		t.Errorf("Expected the renamed todo to match its new text, got %v", got)
+	}
+	todos, _ = s.SearchUserTodos(ctx, "user-1", "passport", false, 10)
+	if got := sorted(todos); !slices.Equal(got, []string{"hidden", "photos"}) {
+		t.Errorf("Expected the renamed todo to stop matching its old text, got %v", got)
+	}
+}
+
+func testPagination(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	CreateUser(t, s, "user-1", models.RoleUser)
+	CreateUser(t, s, "user-2", models.RoleUser)
+	CreateUser(t, s, "admin-1", models.RoleAdmin)
+	start := time.Now().Add(-time.Hour)
+
+	create := func(id, userID, createdBy string, age time.Duration) {
+		t.Helper()
+		todo := models.Todo{ID: id, Text: "todo " + id, Status: string(models.StatusPending), Created: start.Add(-age)}
+		if userID == "" {
+			todo.IsDefaultTask = true
+		} else {
+			todo.UserID = &userID
+			todo.CreatedByUserID = &createdBy
+		}
+		if err := s.CreateTodo(ctx, &todo); err != nil {
+			t.Fatalf("Failed to create todo %s: %v", id, err)
+		}
+	}
+	create("personal-1", "user-1", "user-1", 3*time.Hour)
+	create("personal-2", "user-1", "user-1", 2*time.Hour)
+	create("added", "user-1", "admin-1", time.Hour)
+	create("default-1", "", "", 2*time.Hour)
+	create("default-2", "", "", 0)
+	if err := s.SetDefaultTodoStatus(ctx, "user-1", "default-1", string(models.StatusDone), nil); err != nil {
+		t.Fatalf("SetDefaultTodoStatus failed: %v", err)
+	}
+
+	all, err := s.ListUserTodos(ctx, "user-1", true, store.TodoFilter{})
+	if err != nil {
+		t.Fatalf("ListUserTodos failed: %v", err)
+	}
+	if len(all) != 5 {
+		t.Fatalf("Expected 5 todos, got %v", ids(all))
+	}
+
+	// Paging through the list visits every todo once, in order
+	var paged []models.Todo
+	f := store.TodoFilter{Limit: 2}
+	for {
+		page, err := s.ListUserTodos(ctx, "user-1", true, f)
+		if err != nil {
+			t.Fatalf("ListUserTodos failed: %v", err)
+		}
+		paged = append(paged, page...)
+		if len(page) < f.Limit {
+			break
+		}
+		c := store.TodoCursor(page[len(page)-1])
+		f.After = &c
+	}
+	if !slices.Equal(ids(paged), ids(all)) {
+		t.Errorf("Expected pages to cover %v, got %v", ids(all), ids(paged))
+	}
+
+	sorted := func(todos []models.Todo) []string {
+		out := ids(todos)
+		slices.Sort(out)
+		return out
+	}
+
+	tests := []struct {
+		name     string
+		list     func(store.TodoFilter) ([]models.Todo, error)
+		filter   store.TodoFilter
+		expected []string
+	}{
+		{"User's status of default tasks", userList(ctx, s), store.TodoFilter{Status: string(models.StatusDone)}, []string{"default-1"}},
+		{"Pending", userList(ctx, s), store.TodoFilter{Status: string(models.StatusPending)}, []string{"added", "default-2", "personal-1", "personal-2"}},
+		{"Default source", userList(ctx, s), store.TodoFilter{Source: models.SourceDefault}, []string{"default-1", "default-2"}},
+		{"Admin source", userList(ctx, s), store.TodoFilter{Source: models.SourceAdmin}, []string{"added"}},
+		{"Personal source", userList(ctx, s), store.TodoFilter{Source: models.SourcePersonal}, []string{"personal-1", "personal-2"}},
+		{"Created from", userList(ctx, s), store.TodoFilter{From: start.Add(-2 * time.Hour)}, []string{"added", "default-1", "default-2", "personal-2"}},
+		{"Created to", userList(ctx, s), store.TodoFilter{To: start.Add(-2 * time.Hour)}, []string{"personal-1"}},
+		{"Combined", userList(ctx, s), store.TodoFilter{Source: models.SourceDefault, To: start}, []string{"default-1"}},
+		{"Shared status", func(f store.TodoFilter) ([]models.Todo, error) { return s.ListSharedTodos(ctx, "user-1", f) }, store.TodoFilter{Status: string(models.StatusDone)}, []string{"default-1"}},
+		{"Default tasks", func(f store.TodoFilter) ([]models.Todo, error) { return s.ListDefaultTodos(ctx, f) }, store.TodoFilter{Status: string(models.StatusPending)}, []string{"default-1", "default-2"}},
+		{"Limit", userList(ctx, s), store.TodoFilter{Source: models.SourcePersonal, Limit: 1}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todos, err := tt.list(tt.filter)
+			if err != nil {
+				t.Fatalf("List failed: %v", err)
+			}
+			if tt.expected == nil {
+				if len(todos) != 1 {
+					t.Errorf("Expected 1 todo, got %v", ids(todos))
+				}
+				return
+			}
+			if got := sorted(todos); !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	users, err := s.ListUsers(ctx, store.UserFilter{Limit: 1})
+	if err != nil {
+		t.Fatalf("ListUsers failed: %v", err)
+	}
+	if len(users) != 1 {
+		t.Fatalf("Expected 1 user, got %+v", users)
+	}
+	c := store.UserCursor(users[0])
+	rest, err := s.ListUsers(ctx, store.UserFilter{After: &c})
+	if err != nil {
+		t.Fatalf("ListUsers failed: %v", err)
+	}
+	if len(rest) != 1 || rest[0].ID == users[0].ID {
+		t.Errorf("Expected the other user after the cursor, got %+v", rest)
+	}
+	none, err := s.ListUsers(ctx, store.UserFilter{From: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("ListUsers failed: %v", err)
+	}
+	if len(none) != 0 {
+		t.Errorf("Expected no users created in the future, got %+v", none)
+	}
+}
+
+// userList lists user-1's own todos including default tasks.
+func userList(ctx context.Context, s store.Store) func(store.TodoFilter) ([]models.Todo, error) {
+	return func(f store.TodoFilter) ([]models.Todo, error) { return s.ListUserTodos(ctx, "user-1", true, f) }
+}
+
+func testDueDates(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	CreateUser(t, s, "user-1", models.RoleUser)
+	CreateUser(t, s, "user-2", models.RoleUser)
+	CreateUser(t, s, "admin-1", models.RoleAdmin)
+	now := time.Now().Truncate(time.Second)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	create := func(id, userID string, due *time.Time, status models.TodoStatus) {
+		t.Helper()
+		todo := models.Todo{ID: id, Text: "todo " + id, Status: string(status), Created: now, DueAt: due, SharedWithAdmin: true}
+		if userID == "" {
+			todo.IsDefaultTask = true
+		} else {
+			todo.UserID = &userID
+			todo.CreatedByUserID = &userID
+		}
+		if err := s.CreateTodo(ctx, &todo); err != nil {
+			t.Fatalf("Failed to create todo %s: %v", id, err)
+		}
+	}
+	create("late", "user-1", &past, models.StatusPending)
+	create("soon", "user-1", &future, models.StatusPending)
+	create("finished", "user-1", &past, models.StatusDone)
+	create("whenever", "user-1", nil, models.StatusPending)
+	create("default", "", &past, models.StatusPending)
+
+	dueOf := func(id, userID string) *time.Time {
+		t.Helper()
+		todo, err := s.GetUserTodo(ctx, id, userID)
+		if err != nil {
+			t.Fatalf("GetUserTodo failed: %v", err)
+		}
+		return todo.DueAt
+	}
+	if due := dueOf("late", "user-1"); due == nil || !due.Equal(past) {
+		t.Errorf("Expected due date %v, got %v", past, due)
+	}
+
+	// A user's due date for a default task overrides the global one
+	if err := s.SetDefaultTodoDueAt(ctx, "user-2", "default", &future, version(0)); err != nil {
+		t.Fatalf("SetDefaultTodoDueAt failed: %v", err)
+	}
+	if err := s.SetDefaultTodoDueAt(ctx, "user-2", "default", &future, version(0)); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("Expected ErrConflict for a stale state version, got %v", err)
+	}
+	if due := dueOf("default", "user-2"); due == nil || !due.Equal(future) {
+		t.Errorf("Expected user-2's due date %v, got %v", future, due)
+	}
+	if due := dueOf("default", "user-1"); due == nil || !due.Equal(past) {
+		t.Errorf("Expected the global due date %v for user-1, got %v", past, due)
+	}
+
+	todos, err := s.ListUserTodos(ctx, "user-1", true, store.TodoFilter{Sort: store.SortDue})
+	if err != nil {
+		t.Fatalf("ListUserTodos failed: %v", err)
+	}
+	if got := ids(todos); got[3] != "soon" || got[4] != "whenever" {
+		t.Errorf("Expected todos by due date with undated ones last, got %v", got)
+	}
+
+	// Paging by due date visits every todo once, in order
+	var paged []models.Todo
+	f := store.TodoFilter{Sort: store.SortDue, Limit: 2}
+	for {
+		page, err := s.ListUserTodos(ctx, "user-1", true, f)
+		if err != nil {
+			t.Fatalf("ListUserTodos failed: %v", err)
+		}
+		paged = append(paged, page...)
+		if len(page) < f.Limit {
+			break
+		}
+		c := store.TodoCursor(page[len(page)-1])
+		f.After = &c
+	}
+	if !slices.Equal(ids(paged), ids(todos)) {
+		t.Errorf("Expected pages to cover %v, got %v", ids(todos), ids(paged))
+	}
+
+	sorted := func(todos []models.Todo) []string {
+		out := ids(todos)
+		slices.Sort(out)
+		return out
+	}
+	tests := []struct {
+		name     string
+		userID   string
+		filter   store.TodoFilter
+		expected []string
+	}{
+		{"Overdue", "user-1", store.TodoFilter{OverdueAt: now}, []string{"default", "late"}},
+		{"Overdue with user's due date", "user-2", store.TodoFilter{OverdueAt: now}, []string{}},
+		{"Due from", "user-1", store.TodoFilter{DueFrom: now}, []string{"soon"}},
+		{"Due to", "user-1", store.TodoFilter{DueTo: now}, []string{"default", "finished", "late"}},
+		{"User's due date", "user-2", store.TodoFilter{DueFrom: now}, []string{"default"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todos, err := s.ListUserTodos(ctx, tt.userID, true, tt.filter)
+			if err != nil {
+				t.Fatalf("ListUserTodos failed: %v", err)
+			}
+			if got := sorted(todos); !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	overdue, err := s.ListOverdueTodos(ctx, "", now, 10)
+	if err != nil {
+		t.Fatalf("ListOverdueTodos failed: %v", err)
+	}
+	var got []string
+	for _, o := range overdue {
+		got = append(got, o.UserID+"/"+o.Todo.ID)
+	}
+	if !slices.Equal(got, []string{"user-1/default", "user-1/late"}) {
+		t.Errorf("Expected user-1's overdue todos, got %v", got)
+	}
+	if overdue, _ := s.ListOverdueTodos(ctx, "user-2", now, 10); len(overdue) != 0 {
+		t.Errorf("Expected nothing overdue for user-2, got %+v", overdue)
+	}
+
+	// Removing the override and clearing a due date
+	if err := s.SetDefaultTodoDueAt(ctx, "user-2", "default", nil, nil); err != nil {
+		t.Fatalf("SetDefaultTodoDueAt failed: %v", err)
+	}
+	if due := dueOf("default", "user-2"); due == nil || !due.Equal(past) {
+		t.Errorf("Expected the global due date %v after removing the override, got %v", past, due)
+	}
+	if err := s.UpdateTodo(ctx, "late", store.TodoUpdate{DueAt: &time.Time{}}); err != nil {
+		t.Fatalf("UpdateTodo failed: %v", err)
+	}
+	if due := dueOf("late", "user-1"); due != nil {
+		t.Errorf("Expected the due date to be cleared, got %v", due)
+	}
+}
+
+func testSubtasks(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	CreateUser(t, s, "user-1", models.RoleUser)
+	CreateUser(t, s, "user-2", models.RoleUser)
+
+	create := func(id, userID, parentID string) {
+		t.Helper()
+		todo := models.Todo{ID: id, Text: "todo " + id, Status: string(models.StatusPending), Created: time.Now(), ParentID: &parentID}
+		if parentID == "" {
+			todo.ParentID = nil
+		}
+		if userID == "" {
+			todo.IsDefaultTask = true
+		} else {
+			todo.UserID = &userID
+			todo.CreatedByUserID = &userID
+		}
+		if err := s.CreateTodo(ctx, &todo); err != nil {
+			t.Fatalf("Failed to create todo %s: %v", id, err)
+		}
+	}
+	create("trip", "", "")
+	create("passport", "", "trip")
+	create("tickets", "", "trip")
+	create("bag", "user-1", "")
+	create("socks", "user-1", "bag")
+	create("left", "user-1", "socks")
+	create("shirts", "user-1", "bag")
+
+	subtasks := func(id, userID string) *models.Subtasks {
+		t.Helper()
+		todo, err := s.GetUserTodo(ctx, id, userID)
+		if err != nil {
+			t.Fatalf("GetUserTodo failed: %v", err)
+		}
+		return todo.Subtasks
+	}
+	if st := subtasks("passport", "user-1"); st != nil {
+		t.Errorf("Expected no subtasks for a leaf, got %+v", st)
+	}
+	if st := subtasks("trip", "user-1"); st == nil || *st != (models.Subtasks{Total: 2, Status: models.StatusPending}) {
+		t.Errorf("Expected 2 pending subtasks, got %+v", st)
+	}
+
+	// Default subtasks roll up by each user's own status
+	if err := s.SetDefaultTodoStatus(ctx, "user-1", "passport", string(models.StatusDone), nil); err != nil {
+		t.Fatalf("SetDefaultTodoStatus failed: %v", err)
+	}
+	if st := subtasks("trip", "user-1"); st == nil || *st != (models.Subtasks{Total: 2, Done: 1, Status: models.StatusInProgress}) {
+		t.Errorf("Expected user-1's trip in progress, got %+v", st)
+	}
+	if st := subtasks("trip", "user-2"); st == nil || st.Status != models.StatusPending {
+		t.Errorf("Expected user-2's trip pending, got %+v", st)
+	}
+	if err := s.SetDefaultTodoStatus(ctx, "user-1", "tickets", string(models.StatusDone), nil); err != nil {
+		t.Fatalf("SetDefaultTodoStatus failed: %v", err)
+	}
+	if st := subtasks("trip", "user-1"); st == nil || *st != (models.Subtasks{Total: 2, Done: 2, Status: models.StatusDone}) {
+		t.Errorf("Expected user-1's trip done, got %+v", st)
+	}
+	if defaults, _ := s.ListDefaultTodos(ctx, store.TodoFilter{ParentID: "trip"}); !slices.Equal(ids(defaults), []string{"tickets", "passport"}) {
+		t.Errorf("Expected trip's subtasks [tickets passport], got %v", ids(defaults))
+	}
+
+	// Only the direct subtasks count towards a todo's progress
+	done := string(models.StatusDone)
+	if err := s.UpdateTodo(ctx, "left", store.TodoUpdate{Status: &done}); err != nil {
+		t.Fatalf("UpdateTodo failed: %v", err)
+	}
+	if st := subtasks("bag", "user-1"); st == nil || *st != (models.Subtasks{Total: 2, Status: models.StatusPending}) {
+		t.Errorf("Expected bag's 2 direct subtasks pending, got %+v", st)
+	}
+	if st := subtasks("socks", "user-1"); st == nil || st.Status != models.StatusDone {
+		t.Errorf("Expected socks done, got %+v", st)
+	}
+
+	// Subtasks go to the trash with their parent, and come back with it
+	if err := s.DeleteTodo(ctx, "shirts", nil); err != nil {
+		t.Fatalf("DeleteTodo failed: %v", err)
+	}
+	if st := subtasks("bag", "user-1"); st == nil || st.Total != 1 {
+		t.Errorf("Expected trashed subtasks not to count, got %+v", st)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if err := s.DeleteTodo(ctx, "bag", nil); err != nil {
+		t.Fatalf("DeleteTodo failed: %v", err)
+	}
+	if _, err := s.GetTodo(ctx, "left"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected a nested subtask in the trash, got %v", err)
+	}
+	trash, err := s.ListDeletedTodos(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("ListDeletedTodos failed: %v", err)
+	}
+	if got := ids(trash); !slices.Equal(got, []string{"bag"}) {
+		t.Errorf("Expected only [bag] listed in the trash, got %v", got)
+	}
+	if err := s.RestoreTodo(ctx, "bag"); err != nil {
+		t.Fatalf("RestoreTodo failed: %v", err)
+	}
+	todos, _ := s.ListUserTodos(ctx, "user-1", false, store.TodoFilter{})
+	if got := ids(todos); !slices.Equal(got, []string{"left", "socks", "bag"}) {
+		t.Errorf("Expected bag restored with socks and left, got %v", got)
+	}
+	if trash, _ := s.ListDeletedTodos(ctx, "user-1"); !slices.Equal(ids(trash), []string{"shirts"}) {
+		t.Errorf("Expected shirts, deleted on its own, to stay in the trash, got %v", ids(trash))
+	}
+
+	// Purging a todo removes its subtasks
+	if err := s.DeleteTodo(ctx, "trip", nil); err != nil {
+		t.Fatalf("DeleteTodo failed: %v", err)
+	}
+	if _, err := s.PurgeDeletedTodos(ctx, time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("PurgeDeletedTodos failed: %v", err)
+	}
+	if _, err := s.GetDeletedTodo(ctx, "passport"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected purged subtask to be gone, got %v", err)
+	}
+}
+
+func testTags(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	CreateUser(t, s, "user-1", models.RoleUser)
+
+	create := func(id, userID string, tags ...string) {
+		t.Helper()
+		todo := models.Todo{ID: id, Text: "todo " + id, Status: string(models.StatusPending), Created: time.Now(), Tags: tags}
+		if userID == "" {
+			todo.IsDefaultTask = true
+		} else {
+			todo.UserID = &userID
+			todo.CreatedByUserID = &userID
+		}
+		if err := s.CreateTodo(ctx, &todo); err != nil {
+			t.Fatalf("Failed to create todo %s: %v", id, err)
+		}
+	}
+	create("passport", "user-1", "docs", "travel")
+	create("tickets", "user-1", "travel")
+	create("plain", "user-1")
+	create("visa", "", "docs")
+
+	todo, err := s.GetUserTodo(ctx, "passport", "user-1")
+	if err != nil {
+		t.Fatalf("GetUserTodo failed: %v", err)
+	}
+	if !slices.Equal(todo.Tags, []string{"docs", "travel"}) {
+		t.Errorf("Expected tags [docs travel], got %v", todo.Tags)
+	}
+	if todo, _ := s.GetTodo(ctx, "plain"); len(todo.Tags) != 0 {
+		t.Errorf("Expected no tags, got %v", todo.Tags)
+	}
+
+	sorted := func(todos []models.Todo) []string {
+		out := ids(todos)
+		slices.Sort(out)
+		return out
+	}
+	tests := []struct {
+		name     string
+		tags     []string
+		expected []string
+	}{
+		{"One tag", []string{"travel"}, []string{"passport", "tickets"}},
+		{"Default task", []string{"docs"}, []string{"passport", "visa"}},
+		{"Every tag", []string{"docs", "travel"}, []string{"passport"}},
+		{"Unused tag", []string{"food"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todos, err := s.ListUserTodos(ctx, "user-1", true, store.TodoFilter{Tags: tt.tags})
+			if err != nil {
+				t.Fatalf("ListUserTodos failed: %v", err)
+			}
+			if got := sorted(todos); !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	// Updating replaces the tags, leaving other fields alone
+	tags := []string{"food"}
+	if err := s.UpdateTodo(ctx, "tickets", store.TodoUpdate{Tags: &tags}); err != nil {
+		t.Fatalf("UpdateTodo failed: %v", err)
+	}
+	if todo, _ := s.GetTodo(ctx, "tickets"); !slices.Equal(todo.Tags, tags) || todo.Text != "todo tickets" {
+		t.Errorf("Expected tickets tagged [food], got %+v", todo)
+	}
+	tags = []string{}
+	if err := s.UpdateTodo(ctx, "passport", store.TodoUpdate{Tags: &tags}); err != nil {
+		t.Fatalf("UpdateTodo failed: %v", err)
+	}
+	if todo, _ := s.GetTodo(ctx, "passport"); len(todo.Tags) != 0 {
+		t.Errorf("Expected passport's tags cleared, got %v", todo.Tags)
+	}
+
+	// The palette holds curated tags only, unless all tags in use are asked for
+	if err := s.SaveCuratedTag(ctx, models.Tag{Name: "urgent", Color: "#ff0000"}); err != nil {
+		t.Fatalf("SaveCuratedTag failed: %v", err)
+	}
+	if err := s.SaveCuratedTag(ctx, models.Tag{Name: "docs", Color: "#0000ff"}); err != nil {
+		t.Fatalf("SaveCuratedTag failed: %v", err)
+	}
+	palette, err := s.ListTags(ctx, false)
+	if err != nil {
+		t.Fatalf("ListTags failed: %v", err)
+	}
+	expected := []models.Tag{{Name: "docs", Color: "#0000ff", Curated: true}, {Name: "urgent", Color: "#ff0000", Curated: true}}
+	if !slices.Equal(palette, expected) {
+		t.Errorf("Expected palette %v, got %v", expected, palette)
+	}
+	all, _ := s.ListTags(ctx, true)
+	var names []string
+	for _, tag := range all {
+		names = append(names, tag.Name)
+	}
+	if !slices.Equal(names, []string{"docs", "food", "urgent"}) {
+		t.Errorf("Expected tags [docs food urgent], got %v", names)
+	}
+
+	if err := s.RemoveCuratedTag(ctx, "docs"); err != nil {
+		t.Fatalf("RemoveCuratedTag failed: %v", err)
+	}
+	if err := s.RemoveCuratedTag(ctx, "docs"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound removing a tag twice, got %v", err)
+	}
+	if palette, _ := s.ListTags(ctx, false); len(palette) != 1 || palette[0].Name != "urgent" {
+		t.Errorf("Expected only urgent left in the palette, got %v", palette)
+	}
+	if todo, _ := s.GetTodo(ctx, "visa"); !slices.Equal(todo.Tags, []string{"docs"}) {
+		t.Errorf("Expected visa to keep its tag, got %v", todo.Tags)
+	}
+}
+
+func testPriorities(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	CreateUser(t, s, "user-1", models.RoleUser)
+
+	create := func(id, userID string, priority models.TodoPriority) {
+		t.Helper()
+		todo := models.Todo{ID: id, Text: "todo " + id, Status: string(models.StatusPending), Created: time.Now(), Priority: string(priority)}
+		if userID == "" {
+			todo.IsDefaultTask = true
+		} else {
+			todo.UserID = &userID
+			todo.CreatedByUserID = &userID
+		}
+		if err := s.CreateTodo(ctx, &todo); err != nil {
+			t.Fatalf("Failed to create todo %s: %v", id, err)
+		}
+	}
+	create("someday", "user-1", models.PriorityLow)
+	create("plain", "user-1", "")
+	create("fire", "user-1", models.PriorityUrgent)
+	create("default-high", "", models.PriorityHigh)
+	create("default-urgent", "", models.PriorityUrgent)
+
+	if todo, _ := s.GetTodo(ctx, "plain"); todo.Priority != string(models.PriorityNormal) {
+		t.Errorf("Expected an unset priority to be normal, got %q", todo.Priority)
+	}
+
+	// The user's own order applies within each priority
+	if err := s.ReorderTodos(ctx, "user-1", []string{"default-urgent", "fire"}); err != nil {
+		t.Fatalf("ReorderTodos failed: %v", err)
+	}
+	todos, err := s.ListUserTodos(ctx, "user-1", true, store.TodoFilter{Sort: store.SortPriority})
+	if err != nil {
+		t.Fatalf("ListUserTodos failed: %v", err)
+	}
+	expected := []string{"default-urgent", "fire", "default-high", "plain", "someday"}
+	if got := ids(todos); !slices.Equal(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	// Paging by priority visits every todo once, in order
+	var paged []models.Todo
+	f := store.TodoFilter{Sort: store.SortPriority, Limit: 2}
+	for {
+		page, err := s.ListUserTodos(ctx, "user-1", true, f)
+		if err != nil {
+			t.Fatalf("ListUserTodos failed: %v", err)
+		}
+		paged = append(paged, page...)
+		if len(page) < f.Limit {
+			break
+		}
+		c := store.TodoCursor(page[len(page)-1])
+		f.After = &c
+	}
+	if !slices.Equal(ids(paged), expected) {
+		t.Errorf("Expected pages to cover %v, got %v", expected, ids(paged))
+	}
+
+	priority := string(models.PriorityHigh)
+	if err := s.UpdateTodo(ctx, "someday", store.TodoUpdate{Priority: &priority}); err != nil {
+		t.Fatalf("UpdateTodo failed: %v", err)
+	}
+	if todo, _ := s.GetUserTodo(ctx, "someday", "user-1"); todo.Priority != priority || todo.Text != "todo someday" {
+		t.Errorf("Expected someday at high priority, got %+v", todo)
+	}
+}
+
+func testComments(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	CreateUser(t, s, "user-1", models.RoleUser)
+	CreateUser(t, s, "user-2", models.RoleUser)
+	admin := CreateUser(t, s, "admin-1", models.RoleAdmin)
+	CreateTodo(t, s, "personal", "user-1")
+	CreateTodo(t, s, "default", "")
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	comment := func(id, todoID, userID, authorID string, n int) {
+		t.Helper()
+		c := models.Comment{ID: id, TodoID: todoID, UserID: userID, AuthorID: &authorID, Body: "comment " + id, CreatedAt: start.Add(time.Duration(n) * time.Minute)}
+		if err := s.CreateComment(ctx, c); err != nil {
+			t.Fatalf("Failed to create comment %s: %v", id, err)
+		}
+	}
+	comment("c2", "personal", "user-1", admin.ID, 2)
+	comment("c1", "personal", "user-1", "user-1", 1)
+	comment("c3", "default", "user-1", "user-1", 3)
+	comment("c4", "default", "user-2", "user-2", 4)
+	comment("c5", "default", "user-2", admin.ID, 5)
+
+	commentIDs := func(comments []models.Comment) []string {
+		out := make([]string, len(comments))
+		for i, c := range comments {
+			out[i] = c.ID
+		}
+		return out
+	}
+	threads := []struct {
+		name     string
+		todoID   string
+		userID   string
+		expected []string
+	}{
+		{"Personal todo", "personal", "user-1", []string{"c1", "c2"}},
+		{"Default task", "default", "user-2", []string{"c4", "c5"}},
+		{"Other user's thread", "personal", "user-2", []string{}},
+	}
+	for _, tt := range threads {
+		t.Run(tt.name, func(t *testing.T) {
+			comments, err := s.ListComments(ctx, tt.todoID, tt.userID)
+			if err != nil {
+				t.Fatalf("ListComments failed: %v", err)
+			}
+			if got := commentIDs(comments); !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	// Counts are per thread when read as a user, over every thread otherwise
+	counts := []struct {
+		name     string
+		get      func() (models.Todo, error)
+		expected int
+	}{
+		{"User's personal todo", func() (models.Todo, error) { return s.GetUserTodo(ctx, "personal", "user-1") }, 2},
+		{"User's default task", func() (models.Todo, error) { return s.GetUserTodo(ctx, "default", "user-1") }, 1},
+		{"Global default task", func() (models.Todo, error) { return s.GetTodo(ctx, "default") }, 3},
+	}
+	for _, tt := range counts {
+		t.Run(tt.name, func(t *testing.T) {
+			todo, err := tt.get()
+			if err != nil {
+				t.Fatalf("Failed to get todo: %v", err)
+			}
+			if todo.CommentCount != tt.expected {
+				t.Errorf("Expected %d comments, got %d", tt.expected, todo.CommentCount)
+			}
+		})
+	}
+	todos, err := s.ListSharedTodos(ctx, "user-2", store.TodoFilter{})
+	if err != nil {
+		t.Fatalf("ListSharedTodos failed: %v", err)
+	}
+	if len(todos) != 1 || todos[0].CommentCount != 2 {
+		t.Errorf("Expected the default task with 2 comments, got %+v", todos)
+	}
+
+	edited := time.Now().Truncate(time.Millisecond)
+	if err := s.UpdateComment(ctx, "c1", "edited", edited); err != nil {
+		t.Fatalf("UpdateComment failed: %v", err)
+	}
+	c, err := s.GetComment(ctx, "c1")
+	if err != nil {
+		t.Fatalf("GetComment failed: %v", err)
+	}
+	if c.Body != "edited" || c.EditedAt == nil || !c.EditedAt.Equal(edited) || c.AuthorID == nil || *c.AuthorID != "user-1" {
+		t.Errorf("Expected c1 edited by user-1, got %+v", c)
+	}
+
+	if err := s.DeleteComment(ctx, "c2"); err != nil {
+		t.Fatalf("DeleteComment failed: %v", err)
+	}
+	if _, err := s.GetComment(ctx, "c2"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a deleted comment, got %v", err)
+	}
+	if err := s.DeleteComment(ctx, "c2"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting twice, got %v", err)
+	}
+	if err := s.UpdateComment(ctx, "missing", "text", edited); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound updating a missing comment, got %v", err)
+	}
+
+	// Comments stay in the trash with their todo and go when it is purged
+	if err := s.DeleteTodo(ctx, "personal", nil); err != nil {
+		t.Fatalf("DeleteTodo failed: %v", err)
+	}
+	if _, err := s.GetComment(ctx, "c1"); err != nil {
+		t.Errorf("Expected comments kept in the trash, got %v", err)
+	}
+	if _, err := s.PurgeDeletedTodos(ctx, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("PurgeDeletedTodos failed: %v", err)
+	}
+	if _, err := s.GetComment(ctx, "c1"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected comments purged with their todo, got %v", err)
+	}
+}
+
+func testAttachments(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	CreateUser(t, s, "user-1", models.RoleUser)
+	CreateUser(t, s, "user-2", models.RoleUser)
+	CreateTodo(t, s, "personal", "user-1")
+	CreateTodo(t, s, "default", "")
+	owner, parentID := "user-1", "personal"
+	subtask := models.Todo{ID: "subtask", Text: "todo subtask", Status: string(models.StatusPending), Created: time.Now(), UserID: &owner, CreatedByUserID: &owner, ParentID: &parentID}
+	if err := s.CreateTodo(ctx, &subtask); err != nil {
+		t.Fatalf("Failed to create subtask: %v", err)
+	}
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	attach := func(id, todoID, userID string, n int) {
+		t.Helper()
+		a := models.Attachment{ID: id, TodoID: todoID, UserID: userID, UploadedBy: &userID, Filename: id + ".pdf", ContentType: "application/pdf", Size: int64(n * 100), CreatedAt: start.Add(time.Duration(n) * time.Minute)}
+		if err := s.CreateAttachment(ctx, a); err != nil {
+			t.Fatalf("Failed to create attachment %s: %v", id, err)
+		}
+	}
+	attach("a2", "personal", "user-1", 2)
+	attach("a1", "personal", "user-1", 1)
+	attach("a3", "default", "user-1", 3)
+	attach("a4", "default", "user-2", 4)
+	attach("a5", "subtask", "user-1", 5)
+
+	attachmentIDs := func(attachments []models.Attachment) []string {
+		out := make([]string, len(attachments))
+		for i, a := range attachments {
+			out[i] = a.ID
+		}
+		return out
+	}
+	tests := []struct {
+		name     string
+		todoID   string
+		userID   string
+		expected []string
+	}{
+		{"Personal todo", "personal", "user-1", []string{"a1", "a2"}},
+		{"Default task", "default", "user-2", []string{"a4"}},
+		{"Other user's todo", "personal", "user-2", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attachments, err := s.ListAttachments(ctx, tt.todoID, tt.userID)
+			if err != nil {
+				t.Fatalf("ListAttachments failed: %v", err)
+			}
+			if got := attachmentIDs(attachments); !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	a, err := s.GetAttachment(ctx, "a2")
+	if err != nil {
+		t.Fatalf("GetAttachment failed: %v", err)
+	}
+	if a.Filename != "a2.pdf" || a.ContentType != "application/pdf" || a.Size != 200 || a.UploadedBy == nil || *a.UploadedBy != "user-1" || !a.CreatedAt.Equal(start.Add(2*time.Minute)) {
+		t.Errorf("Expected a2 as created, got %+v", a)
+	}
+
+	if err := s.DeleteAttachment(ctx, "a2"); err != nil {
+		t.Fatalf("DeleteAttachment failed: %v", err)
+	}
+	if _, err := s.GetAttachment(ctx, "a2"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a deleted attachment, got %v", err)
+	}
+	if err := s.DeleteAttachment(ctx, "a2"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting twice, got %v", err)
+	}
+
+	// The attachments of purged todos and their subtasks are listed before
+	// the purge and gone after it
+	if err := s.DeleteTodo(ctx, "personal", nil); err != nil {
+		t.Fatalf("DeleteTodo failed: %v", err)
+	}
+	before := time.Now().Add(time.Minute)
+	purgeable, err := s.ListPurgeableAttachments(ctx, before)
+	if err != nil {
+		t.Fatalf("ListPurgeableAttachments failed: %v", err)
+	}
+	if got := attachmentIDs(purgeable); !slices.Equal(got, []string{"a1", "a5"}) {
+		t.Errorf("Expected [a1 a5] purgeable, got %v", got)
+	}
+	if _, err := s.PurgeDeletedTodos(ctx, before); err != nil {
+		t.Fatalf("PurgeDeletedTodos failed: %v", err)
+	}
+	if _, err := s.GetAttachment(ctx, "a5"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected attachments purged with their todo, got %v", err)
+	}
+	if _, err := s.GetAttachment(ctx, "a3"); err != nil {
+		t.Errorf("Expected other attachments kept, got %v", err)
+	}
+}
+
+func testBlobs(t *testing.T, s store.BlobStore) {
+	ctx := context.Background()
+
+	read := func(key string) (string, error) {
+		t.Helper()
+		r, err := s.OpenBlob(ctx, key)
+		if err != nil {
+			return "", err
+		}
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		return string(data), err
+	}
+
+	if err := s.PutBlob(ctx, "blob-1", strings.NewReader("first")); err != nil {
+		t.Fatalf("PutBlob failed: %v", err)
+	}
+	if got, err := read("blob-1"); err != nil || got != "first" {
+		t.Errorf("Expected first, got %q (%v)", got, err)
+	}
+
+	if err := s.PutBlob(ctx, "blob-1", strings.NewReader("second")); err != nil {
+		t.Fatalf("PutBlob failed: %v", err)
+	}
+	if got, err := read("blob-1"); err != nil || got != "second" {
+		t.Errorf("Expected contents replaced, got %q (%v)", got, err)
+	}
+
+	// Contents that fail to arrive leave the earlier contents in place
+	failing := io.MultiReader(strings.NewReader("partial"), iotest.ErrReader(errors.New("read failed")))
+	if err := s.PutBlob(ctx, "blob-1", failing); err == nil {
+		t.Errorf("Expected PutBlob to fail with its reader")
+	}
+	if got, err := read("blob-1"); err != nil || got != "second" {
+		t.Errorf("Expected contents kept after a failed put, got %q (%v)", got, err)
+	}
+
+	if err := s.DeleteBlob(ctx, "blob-1"); err != nil {
+		t.Fatalf("DeleteBlob failed: %v", err)
+	}
+	if _, err := read("blob-1"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a deleted blob, got %v", err)
+	}
+	if err := s.DeleteBlob(ctx, "blob-1"); err != nil {
+		t.Errorf("Expected deleting a missing blob to succeed, got %v", err)
+	}
+}
+
+func testRecurrence(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	CreateUser(t, s, "user-1", models.RoleUser)
+	CreateUser(t, s, "user-2", models.RoleUser)
+
+	// A weekly personal todo comes back a week later once done
+	userID := "user-1"
+	due := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	weekly := models.Todo{ID: "weekly", Text: "water plants", Status: string(models.StatusPending), Created: time.Now(), UserID: &userID, CreatedByUserID: &userID, DueAt: &due, Tags: []string{"home"}, Priority: string(models.PriorityHigh), Recurrence: "FREQ=WEEKLY"}
+	if err := s.CreateTodo(ctx, &weekly); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+	CreateTodo(t, s, "once", "user-1")
+
+	done := string(models.StatusDone)
+	if err := s.UpdateTodo(ctx, "weekly", store.TodoUpdate{Status: &done, ActorID: "user-1"}); err != nil {
+		t.Fatalf("UpdateTodo failed: %v", err)
+	}
+	completed, err := s.GetTodo(ctx, "weekly")
+	if err != nil {
+		t.Fatalf("GetTodo failed: %v", err)
+	}
+	if completed.Status != done || completed.Recurrence != "" {
+		t.Errorf("Expected the completed occurrence to be done and no longer recur, got %q, %q", completed.Status, completed.Recurrence)
+	}
+
+	todos, err := s.ListUserTodos(ctx, "user-1", false, store.TodoFilter{})
+	if err != nil {
+		t.Fatalf("ListUserTodos failed: %v", err)
+	}
+	if len(todos) != 3 {
+		t.Fatalf("Expected the next occurrence to be added, got %v", ids(todos))
+	}
+	next := todos[0]
+	if next.ID == "weekly" || next.ID == "once" {
+		t.Fatalf("Expected the next occurrence at the top, got %v", ids(todos))
+	}
+	if next.Text != weekly.Text || next.Status != string(models.StatusPending) || next.Recurrence != "FREQ=WEEKLY" || next.Occurrence != 1 {
+		t.Errorf("Unexpected next occurrence: %+v", next)
+	}
+	if next.DueAt == nil || !next.DueAt.Equal(due.AddDate(0, 0, 7)) {
+		t.Errorf("Expected the next occurrence due %v, got %v", due.AddDate(0, 0, 7), next.DueAt)
+	}
+	if !slices.Equal(next.Tags, []string{"home"}) || next.Priority != string(models.PriorityHigh) || next.CreatedByUserID == nil || *next.CreatedByUserID != "user-1" {
+		t.Errorf("Expected the next occurrence to keep tags, priority and creator, got %+v", next)
+	}
+	if revisions, _ := s.ListTodoRevisions(ctx, next.ID); len(revisions) != 1 {
+		t.Errorf("Expected the next occurrence to have its first revision, got %d", len(revisions))
+	}
+
+	// Marking the completed occurrence done again adds nothing
+	if err := s.UpdateTodo(ctx, "weekly", store.TodoUpdate{Status: &done}); err != nil {
+		t.Fatalf("UpdateTodo failed: %v", err)
+	}
+	if todos, _ := s.ListUserTodos(ctx, "user-1", false, store.TodoFilter{}); len(todos) != 3 {
+		t.Errorf("Expected no further occurrence, got %v", ids(todos))
+	}
+
+	// Clearing the rule stops a todo from recurring
+	none := ""
+	if err := s.UpdateTodo(ctx, next.ID, store.TodoUpdate{Recurrence: &none}); err != nil {
+		t.Fatalf("UpdateTodo failed: %v", err)
+	}
+	if err := s.UpdateTodo(ctx, next.ID, store.TodoUpdate{Status: &done}); err != nil {
+		t.Fatalf("UpdateTodo failed: %v", err)
+	}
+	if todos, _ := s.ListUserTodos(ctx, "user-1", false, store.TodoFilter{}); len(todos) != 3 {
+		t.Errorf("Expected a todo that no longer recurs not to come back, got %v", ids(todos))
+	}
+
+	// A recurring default task moves each user on to their own next
+	// occurrence
+	daily := models.Todo{ID: "daily", Text: "stand-up", Status: string(models.StatusPending), Created: time.Now(), IsDefaultTask: true, DueAt: &due, Recurrence: "FREQ=DAILY;INTERVAL=2"}
+	if err := s.CreateTodo(ctx, &daily); err != nil {
+		t.Fatalf("Failed to create default task: %v", err)
+	}
+	if err := s.SetDefaultTodoStatus(ctx, "user-1", "daily", done, nil); err != nil {
+		t.Fatalf("SetDefaultTodoStatus failed: %v", err)
+	}
+	mine, err := s.GetUserTodo(ctx, "daily", "user-1")
+	if err != nil {
+		t.Fatalf("GetUserTodo failed: %v", err)
+	}
+	if mine.Status != string(models.StatusPending) || mine.Occurrence != 1 || mine.DueAt == nil || !mine.DueAt.Equal(due.AddDate(0, 0, 2)) {
+		t.Errorf("Expected user-1 on the next occurrence, got %s, occurrence %d, due %v", mine.Status, mine.Occurrence, mine.DueAt)
+	}
+	if err := s.SetDefaultTodoStatus(ctx, "user-1", "daily", done, &mine.StateVersion); err != nil {
+		t.Fatalf("SetDefaultTodoStatus failed: %v", err)
+	}
+	if err := s.SetDefaultTodoStatus(ctx, "user-1", "daily", done, &mine.StateVersion); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("Expected ErrConflict for a stale state version, got %v", err)
+	}
+	if mine, _ = s.GetUserTodo(ctx, "daily", "user-1"); mine.Occurrence != 2 || !mine.DueAt.Equal(due.AddDate(0, 0, 4)) {
+		t.Errorf("Expected user-1 on the third occurrence, got occurrence %d, due %v", mine.Occurrence, mine.DueAt)
+	}
+	theirs, _ := s.GetUserTodo(ctx, "daily", "user-2")
+	if theirs.Occurrence != 0 || !theirs.DueAt.Equal(due) {
+		t.Errorf("Expected user-2 still on the first occurrence, got occurrence %d, due %v", theirs.Occurrence, theirs.DueAt)
+	}
+	if task, _ := s.GetTodo(ctx, "daily"); task.Status != string(models.StatusPending) || task.Occurrence != 0 {
+		t.Errorf("Expected the global task unchanged, got %s, occurrence %d", task.Status, task.Occurrence)
+	}
+
+	// Monthly series keep to the day of the month they started on
+	start := time.Date(2100, time.January, 31, 9, 0, 0, 0, time.UTC)
+	monthly := models.Todo{ID: "rent", Text: "pay rent", Status: string(models.StatusPending), Created: time.Now(), UserID: &userID, CreatedByUserID: &userID, DueAt: &start, Recurrence: "FREQ=MONTHLY"}
+	if err := s.CreateTodo(ctx, &monthly); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+	billing := models.Todo{ID: "billing", Text: "billing", Status: string(models.StatusPending), Created: time.Now(), IsDefaultTask: true, DueAt: &start, Recurrence: "FREQ=MONTHLY"}
+	if err := s.CreateTodo(ctx, &billing); err != nil {
+		t.Fatalf("Failed to create default task: %v", err)
+	}
+	current := "rent"
+	for _, day := range []time.Time{
+		time.Date(2100, time.February, 28, 9, 0, 0, 0, time.UTC),
+		time.Date(2100, time.March, 31, 9, 0, 0, 0, time.UTC),
+		time.Date(2100, time.April, 30, 9, 0, 0, 0, time.UTC),
+	} {
+		if err := s.UpdateTodo(ctx, current, store.TodoUpdate{Status: &done}); err != nil {
+			t.Fatalf("UpdateTodo failed: %v", err)
+		}
+		todos, err := s.ListUserTodos(ctx, "user-1", false, store.TodoFilter{})
+		if err != nil {
+			t.Fatalf("ListUserTodos failed: %v", err)
+		}
+		i := slices.IndexFunc(todos, func(todo models.Todo) bool { return todo.Text == "pay rent" && todo.Status != done })
+		if i < 0 || todos[i].DueAt == nil || !todos[i].DueAt.Equal(day) {
+			t.Fatalf("Expected the next rent due %v, got %v", day, ids(todos))
+		}
+		current = todos[i].ID
+
+		if err := s.SetDefaultTodoStatus(ctx, "user-2", "billing", done, nil); err != nil {
+			t.Fatalf("SetDefaultTodoStatus failed: %v", err)
+		}
+		if theirs, _ := s.GetUserTodo(ctx, "billing", "user-2"); theirs.DueAt == nil || !theirs.DueAt.Equal(day) {
+			t.Fatalf("Expected user-2's billing due %v, got %v", day, theirs.DueAt)
+		}
+	}
+
+	// Other statuses are recorded as usual
+	started := string(models.StatusInProgress)
+	if err := s.SetDefaultTodoStatus(ctx, "user-1", "daily", started, nil); err != nil {
+		t.Fatalf("SetDefaultTodoStatus failed: %v", err)
+	}
+	if mine, _ = s.GetUserTodo(ctx, "daily", "user-1"); mine.Status != started || mine.Occurrence != 2 {
+		t.Errorf("Expected in-progress on the same occurrence, got %s, occurrence %d", mine.Status, mine.Occurrence)
+	}
+}
+
+func testWorkflow(t *testing.T, s store.Store) {
+	ctx := context.Background()
+
+	w, err := s.GetWorkflow(ctx)
+	if err != nil {
+		t.Fatalf("GetWorkflow failed: %v", err)
+	}
+	if !reflect.DeepEqual(w, models.DefaultWorkflow()) {
+		t.Errorf("Expected the default workflow, got %+v", w)
+	}
+
+	custom, err := models.NormalizeWorkflow(models.Workflow{
+		Statuses: []string{"needs-review", "blocked"},
+		Transitions: map[string][]string{
+			"pending":      {"blocked", "in-progress"},
+			"in-progress":  {"needs-review", "blocked"},
+			"needs-review": {"done", "in-progress"},
+			"blocked":      {"pending"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NormalizeWorkflow failed: %v", err)
+	}
+	if err := s.SaveWorkflow(ctx, custom); err != nil {
+		t.Fatalf("SaveWorkflow failed: %v", err)
+	}
+	if w, _ = s.GetWorkflow(ctx); !reflect.DeepEqual(w, custom) {
+		t.Errorf("Expected %+v, got %+v", custom, w)
+	}
+
+	// Saving replaces the whole workflow
+	if err := s.SaveWorkflow(ctx, models.Workflow{Statuses: append(models.DefaultWorkflow().Statuses, "skipped")}); err != nil {
+		t.Fatalf("SaveWorkflow failed: %v", err)
+	}
+	w, _ = s.GetWorkflow(ctx)
+	if !slices.Equal(w.Statuses, []string{"pending", "in-progress", "done", "skipped"}) || len(w.Transitions) != 0 {
+		t.Errorf("Expected only skipped added and no transitions, got %+v", w)
+	}
+}
+
+func testDependencies(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	CreateUser(t, s, "user-1", models.RoleUser)
+	CreateUser(t, s, "user-2", models.RoleUser)
+
+	create := func(id, userID string, blockers ...string) {
+		t.Helper()
+		todo := models.Todo{ID: id, Text: "todo " + id, Status: string(models.StatusPending), Created: time.Now(), BlockedBy: blockers}
+		if userID == "" {
+			todo.IsDefaultTask = true
+		} else {
+			todo.UserID = &userID
+			todo.CreatedByUserID = &userID
+		}
+		if err := s.CreateTodo(ctx, &todo); err != nil {
+			t.Fatalf("Failed to create todo %s: %v", id, err)
+		}
+	}
+	create("flights", "")
+	create("itinerary", "", "flights")
+	create("visa", "user-1")
+	create("packing", "user-1", "flights", "visa")
+
+	// Default tasks are blocked by each user's own progress
+	itinerary, err := s.GetUserTodo(ctx, "itinerary", "user-1")
+	if err != nil {
+		t.Fatalf("GetUserTodo failed: %v", err)
+	}
+	if !itinerary.Blocked || !slices.Equal(itinerary.BlockedBy, []string{"flights"}) {
+		t.Errorf("Expected itinerary blocked by flights, got %v, %v", itinerary.Blocked, itinerary.BlockedBy)
+	}
+	if err := s.SetDefaultTodoStatus(ctx, "user-1", "flights", string(models.StatusDone), nil); err != nil {
+		t.Fatalf("SetDefaultTodoStatus failed: %v", err)
+	}
+	if itinerary, _ = s.GetUserTodo(ctx, "itinerary", "user-1"); itinerary.Blocked {
+		t.Error("Expected itinerary unblocked for user-1")
+	}
+	if theirs, _ := s.GetUserTodo(ctx, "itinerary", "user-2"); !theirs.Blocked {
+		t.Error("Expected itinerary still blocked for user-2")
+	}
+
+	// Personal todos are blocked by their owner's progress, in any listing
+	todos, err := s.ListUserTodos(ctx, "user-1", false, store.TodoFilter{})
+	if err != nil {
+		t.Fatalf("ListUserTodos failed: %v", err)
+	}
+	for _, todo := range todos {
+		if todo.ID == "packing" && (!todo.Blocked || !slices.Equal(todo.BlockedBy, []string{"flights", "visa"})) {
+			t.Errorf("Expected packing blocked by visa, got %v, %v", todo.Blocked, todo.BlockedBy)
+		}
+	}
+	done := string(models.StatusDone)
+	if err := s.UpdateTodo(ctx, "visa", store.TodoUpdate{Status: &done}); err != nil {
+		t.Fatalf("UpdateTodo failed: %v", err)
+	}
+	if packing, _ := s.GetTodo(ctx, "packing"); packing.Blocked {
+		t.Error("Expected packing unblocked once flights and visa are done")
+	}
+
+	// Blockers in the trash neither block nor show
+	if err := s.UpdateTodo(ctx, "visa", store.TodoUpdate{BlockedBy: &[]string{}}); err != nil {
+		t.Fatalf("UpdateTodo failed: %v", err)
+	}
+	if err := s.DeleteTodo(ctx, "flights", nil); err != nil {
+		t.Fatalf("DeleteTodo failed: %v", err)
+	}
+	if theirs, _ := s.GetUserTodo(ctx, "itinerary", "user-2"); theirs.Blocked || len(theirs.BlockedBy) != 0 {
+		t.Errorf("Expected a deleted blocker to be left out, got %v, %v", theirs.Blocked, theirs.BlockedBy)
+	}
+	if err := s.RestoreTodo(ctx, "flights"); err != nil {
+		t.Fatalf("RestoreTodo failed: %v", err)
+	}
+
+	// Cycles are refused, directly or through other todos
+	for _, tt := range []struct {
+		id       string
+		blockers []string
+	}{
+		{"flights", []string{"flights"}},
+		{"flights", []string{"itinerary"}},
+		{"visa", []string{"packing"}},
+	} {
+		if err := s.UpdateTodo(ctx, tt.id, store.TodoUpdate{BlockedBy: &tt.blockers}); !errors.Is(err, store.ErrDependencyCycle) {
+			t.Errorf("Expected ErrDependencyCycle blocking %s by %v, got %v", tt.id, tt.blockers, err)
+		}
+	}
+	if flights, _ := s.GetTodo(ctx, "flights"); len(flights.BlockedBy) != 0 {
+		t.Errorf("Expected a refused cycle to change nothing, got %v", flights.BlockedBy)
+	}
+
+	// Replacing blockers drops the old ones
+	blockers := []string{"flights"}
+	if err := s.UpdateTodo(ctx, "packing", store.TodoUpdate{BlockedBy: &blockers}); err != nil {
+		t.Fatalf("UpdateTodo failed: %v", err)
+	}
+	if packing, _ := s.GetTodo(ctx, "packing"); !slices.Equal(packing.BlockedBy, blockers) {
+		t.Errorf("Expected packing blocked by %v, got %v", blockers, packing.BlockedBy)
+	}
+}
+
+func testDescriptions(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	CreateUser(t, s, "user-1", models.RoleUser)
+
+	userID := "user-1"
+	own := models.Todo{ID: "own", Text: "Own", Description: "See **the list**", Status: string(models.StatusPending), Created: time.Now(), UserID: &userID, CreatedByUserID: &userID}
+	if err := s.CreateTodo(ctx, &own); err != nil {
+		t.Fatalf("CreateTodo failed: %v", err)
+	}
+	def := models.Todo{ID: "default", Text: "Default", Description: "# Steps", Status: string(models.StatusPending), Created: time.Now(), IsDefaultTask: true}
+	if err := s.CreateTodo(ctx, &def); err != nil {
+		t.Fatalf("CreateTodo failed: %v", err)
+	}
+
+	if got, _ := s.GetTodo(ctx, "own"); got.Description != own.Description {
+		t.Errorf("Expected description %q, got %q", own.Description, got.Description)
+	}
+	todos, _ := s.ListUserTodos(ctx, "user-1", true, store.TodoFilter{})
+	for _, todo := range todos {
+		if todo.ID == "default" && todo.Description != def.Description {
+			t.Errorf("Expected users to see the default task's description, got %q", todo.Description)
+		}
+	}
+
+	// Descriptions change without touching the text, and can be cleared
+	description := "Updated"
+	if err := s.UpdateTodo(ctx, "own", store.TodoUpdate{Description: &description}); err != nil {
+		t.Fatalf("UpdateTodo failed: %v", err)
+	}
+	if got, _ := s.GetUserTodo(ctx, "own", "user-1"); got.Description != description || got.Text != "Own" || got.Version != 2 {
+		t.Errorf("Expected the description updated, got %+v", got)
+	}
+	cleared := ""
+	if err := s.UpdateTodo(ctx, "default", store.TodoUpdate{Description: &cleared}); err != nil {
+		t.Fatalf("UpdateTodo failed: %v", err)
+	}
+	if got, _ := s.GetUserTodo(ctx, "default", "user-1"); got.Description != "" {
+		t.Errorf("Expected the description cleared, got %q", got.Description)
+	}
+}
+
+func testTemplates(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	CreateUser(t, s, "user-1", models.RoleUser)
+	CreateUser(t, s, "admin-1", models.RoleAdmin)
+
+	created := time.Now().Truncate(time.Millisecond)
+	trip := models.Template{
+		ID:   "trip",
+		Name: "Trip",
+		Tasks: []models.TemplateTask{
+			{ID: "book", Text: "Book", Description: "Early", Priority: string(models.PriorityHigh)},
+			{ID: "pack", Text: "Pack", Priority: string(models.PriorityNormal)},
+		},
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+	if err := s.SaveTemplate(ctx, trip); err != nil {
+		t.Fatalf("SaveTemplate failed: %v", err)
+	}
+	if err := s.SaveTemplate(ctx, models.Template{ID: "empty", Name: "Empty", CreatedAt: created, UpdatedAt: created}); err != nil {
+		t.Fatalf("SaveTemplate failed: %v", err)
+	}
+
+	templates, err := s.ListTemplates(ctx)
+	if err != nil {
+		t.Fatalf("ListTemplates failed: %v", err)
+	}
+	if len(templates) != 2 || templates[0].ID != "empty" || len(templates[0].Tasks) != 0 || !slices.Equal(templates[1].Tasks, trip.Tasks) {
+		t.Errorf("Expected both templates by name, got %+v", templates)
+	}
+	got, err := s.GetTemplate(ctx, "trip")
+	if err != nil {
+		t.Fatalf("GetTemplate failed: %v", err)
+	}
+	if got.Name != "Trip" || !slices.Equal(got.Tasks, trip.Tasks) || !got.CreatedAt.Equal(created) {
+		t.Errorf("Unexpected template %+v", got)
+	}
+	if _, err := s.GetTemplate(ctx, "missing"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing template, got %v", err)
+	}
+
+	// Todos added from the template are listed in the order of its tasks
+	userID, adminID := "user-1", "admin-1"
+	for _, task := range []string{"pack", "book"} {
+		todo := models.Todo{ID: "todo-" + task, Text: task, Status: string(models.StatusPending), Created: time.Now(), UserID: &userID, CreatedByUserID: &adminID, SharedWithAdmin: true, TemplateTaskID: &task}
+		if err := s.CreateTodo(ctx, &todo); err != nil {
+			t.Fatalf("CreateTodo failed: %v", err)
+		}
+	}
+	if err := s.AddTemplateUser(ctx, "trip", "user-1"); err != nil {
+		t.Fatalf("AddTemplateUser failed: %v", err)
+	}
+	if err := s.AddTemplateUser(ctx, "trip", "user-1"); err != nil {
+		t.Fatalf("AddTemplateUser failed applying again: %v", err)
+	}
+	if users, _ := s.ListTemplateUsers(ctx, "trip"); !slices.Equal(users, []string{"user-1"}) {
+		t.Errorf("Expected the template applied to user-1, got %v", users)
+	}
+	todos, err := s.ListTemplateTodos(ctx, "trip", "user-1")
+	if err != nil {
+		t.Fatalf("ListTemplateTodos failed: %v", err)
+	}
+	if !slices.Equal(ids(todos), []string{"todo-book", "todo-pack"}) || todos[0].TemplateTaskID == nil || *todos[0].TemplateTaskID != "book" {
+		t.Errorf("Expected [todo-book todo-pack] linked to their tasks, got %+v", todos)
+	}
+
+	// Trashed todos are still listed, so they are not added again
+	if err := s.DeleteTodo(ctx, "todo-pack", nil); err != nil {
+		t.Fatalf("DeleteTodo failed: %v", err)
+	}
+	if todos, _ := s.ListTemplateTodos(ctx, "trip", "user-1"); len(todos) != 2 || todos[1].DeletedAt == nil {
+		t.Errorf("Expected the trashed todo listed, got %+v", todos)
+	}
+
+	// Removing a task unlinks its todos; changed tasks keep their ID
+	trip.Name = "Road trip"
+	trip.Tasks = []models.TemplateTask{{ID: "pack", Text: "Pack light", Priority: string(models.PriorityLow)}, {ID: "fuel", Text: "Fuel", Priority: string(models.PriorityNormal)}}
+	trip.UpdatedAt = created.Add(time.Hour)
+	if err := s.SaveTemplate(ctx, trip); err != nil {
+		t.Fatalf("SaveTemplate failed: %v", err)
+	}
+	got, _ = s.GetTemplate(ctx, "trip")
+	if got.Name != "Road trip" || !slices.Equal(got.Tasks, trip.Tasks) || !got.CreatedAt.Equal(created) || !got.UpdatedAt.Equal(trip.UpdatedAt) {
+		t.Errorf("Unexpected template after update %+v", got)
+	}
+	if todo, _ := s.GetTodo(ctx, "todo-book"); todo.TemplateTaskID != nil {
+		t.Errorf("Expected the todo of a removed task unlinked, got %v", *todo.TemplateTaskID)
+	}
+	if todos, _ := s.ListTemplateTodos(ctx, "trip", "user-1"); !slices.Equal(ids(todos), []string{"todo-pack"}) {
+		t.Errorf("Expected only todo-pack still linked, got %v", ids(todos))
+	}
+
+	if err := s.DeleteTemplate(ctx, "trip"); err != nil {
+		t.Fatalf("DeleteTemplate failed: %v", err)
+	}
+	if _, err := s.GetTemplate(ctx, "trip"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+	if users, _ := s.ListTemplateUsers(ctx, "trip"); len(users) != 0 {
+		t.Errorf("Expected no users of a deleted template, got %v", users)
+	}
+	if err := s.DeleteTemplate(ctx, "trip"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting twice, got %v", err)
+	}
+	if todo, err := s.GetDeletedTodo(ctx, "todo-pack"); err != nil || todo.TemplateTaskID != nil {
+		t.Errorf("Expected todos to outlive their template, got %+v, %v", todo, err)
+	}
+}
+
+func testGroups(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	CreateUser(t, s, "user-1", models.RoleUser)
+	CreateUser(t, s, "user-2", models.RoleUser)
+
+	created := time.Now().Truncate(time.Millisecond)
+	for _, g := range []models.Group{{ID: "pro", Name: "Pro", CreatedAt: created}, {ID: "eu", Name: "EU", CreatedAt: created}, {ID: "eu", Name: "Europe", CreatedAt: created.Add(time.Hour)}} {
+		if err := s.SaveGroup(ctx, g); err != nil {
+			t.Fatalf("SaveGroup failed: %v", err)
+		}
+	}
+	for _, m := range [][2]string{{"pro", "user-1"}, {"pro", "user-1"}, {"eu", "user-2"}} {
+		if err := s.AddGroupMember(ctx, m[0], m[1]); err != nil {
+			t.Fatalf("AddGroupMember failed: %v", err)
+		}
+	}
+
+	groups, err := s.ListGroups(ctx)
+	if err != nil {
+		t.Fatalf("ListGroups failed: %v", err)
+	}
+	if len(groups) != 2 || groups[0].Name != "Europe" || !groups[0].CreatedAt.Equal(created) || groups[1].ID != "pro" || groups[1].MemberCount != 1 {
+		t.Errorf("Expected both groups by name with one member each, got %+v", groups)
+	}
+	members, err := s.ListGroupMembers(ctx, "pro")
+	if err != nil {
+		t.Fatalf("ListGroupMembers failed: %v", err)
+	}
+	if len(members) != 1 || members[0].ID != "user-1" || members[0].Email != "user-1@example.com" {
+		t.Errorf("Expected user-1 in pro, got %+v", members)
+	}
+	if _, err := s.GetGroup(ctx, "missing"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing group, got %v", err)
+	}
+
+	// Default tasks for everyone, for pro and, once updated, for eu
+	CreateTodo(t, s, "everyone", "")
+	past := time.Now().Add(-time.Hour)
+	pro := models.Todo{ID: "pro-only", Text: "todo pro-only", Status: string(models.StatusPending), Created: time.Now(), IsDefaultTask: true, DueAt: &past, Groups: []string{"pro"}}
+	if err := s.CreateTodo(ctx, &pro); err != nil {
+		t.Fatalf("CreateTodo failed: %v", err)
+	}
+	CreateTodo(t, s, "eu-only", "")
+	if err := s.UpdateTodo(ctx, "eu-only", store.TodoUpdate{Groups: &[]string{"eu"}}); err != nil {
+		t.Fatalf("UpdateTodo failed: %v", err)
+	}
+
+	listed := func(userID string) []string {
+		t.Helper()
+		todos, err := s.ListUserTodos(ctx, userID, true, store.TodoFilter{})
+		if err != nil {
+			t.Fatalf("ListUserTodos failed: %v", err)
+		}
+		shared, err := s.ListSharedTodos(ctx, userID, store.TodoFilter{})
+		if err != nil {
+			t.Fatalf("ListSharedTodos failed: %v", err)
+		}
+		if !slices.Equal(ids(todos), ids(shared)) {
+			t.Errorf("Expected admins to see the same default tasks as %s, got %v and %v", userID, ids(shared), ids(todos))
+		}
+		return ids(todos)
+	}
+	if got := listed("user-1"); !slices.Equal(got, []string{"pro-only", "everyone"}) {
+		t.Errorf("Expected user-1 to see the pro and everyone tasks, got %v", got)
+	}
+	if got := listed("user-2"); !slices.Equal(got, []string{"eu-only", "everyone"}) {
+		t.Errorf("Expected user-2 to see the eu and everyone tasks, got %v", got)
+	}
+
+	if todo, err := s.GetUserTodo(ctx, "pro-only", "user-1"); err != nil || !slices.Equal(todo.Groups, []string{"pro"}) {
+		t.Errorf("Expected user-1 to read the pro task with its groups, got %+v, %v", todo, err)
+	}
+	if _, err := s.GetUserTodo(ctx, "pro-only", "user-2"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound reading another group's task, got %v", err)
+	}
+	if todo, err := s.GetTodo(ctx, "eu-only"); err != nil || !slices.Equal(todo.Groups, []string{"eu"}) {
+		t.Errorf("Expected the eu task to target eu, got %+v, %v", todo, err)
+	}
+	found, err := s.SearchUserTodos(ctx, "user-2", "todo", true, 10)
+	if err != nil {
+		t.Fatalf("SearchUserTodos failed: %v", err)
+	}
+	if got := ids(found); len(got) != 2 || slices.Contains(got, "pro-only") {
+		t.Errorf("Expected search to leave out another group's task, got %v", got)
+	}
+	overdue, err := s.ListOverdueTodos(ctx, "", time.Now(), 10)
+	if err != nil {
+		t.Fatalf("ListOverdueTodos failed: %v", err)
+	}
+	if len(overdue) != 1 || overdue[0].UserID != "user-1" {
+		t.Errorf("Expected the pro task overdue only for user-1, got %+v", overdue)
+	}
+
+	// Groups cannot be deleted while tasks target them
+	if err := s.DeleteGroup(ctx, "pro"); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("Expected ErrConflict deleting a targeted group, got %v", err)
+	}
+	if err := s.UpdateTodo(ctx, "pro-only", store.TodoUpdate{Groups: &[]string{}}); err != nil {
+		t.Fatalf("UpdateTodo failed: %v", err)
+	}
+	if got := listed("user-2"); !slices.Equal(got, []string{"eu-only", "pro-only", "everyone"}) {
+		t.Errorf("Expected a task without groups to be shown to everyone, got %v", got)
+	}
+	if err := s.DeleteGroup(ctx, "pro"); err != nil {
+		t.Fatalf("DeleteGroup failed: %v", err)
+	}
+	if _, err := s.GetGroup(ctx, "pro"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+	if err := s.DeleteGroup(ctx, "pro"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting a missing group, got %v", err)
+	}
+
+	if err := s.RemoveGroupMember(ctx, "eu", "user-2"); err != nil {
+		t.Fatalf("RemoveGroupMember failed: %v", err)
+	}
+	if err := s.RemoveGroupMember(ctx, "eu", "user-2"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound removing a non-member, got %v", err)
+	}
+	if got := listed("user-2"); !slices.Equal(got, []string{"pro-only", "everyone"}) {
+		t.Errorf("Expected user-2 to lose the eu task on leaving eu, got %v", got)
+	}
+
+	// Blockers not meant for a user neither block them nor show
+	userID := "user-1"
+	for _, todo := range []models.Todo{
+		{ID: "after-eu", Text: "todo after-eu", Status: string(models.StatusPending), Created: time.Now(), IsDefaultTask: true, BlockedBy: []string{"eu-only"}},
+		{ID: "own-after-eu", Text: "todo own-after-eu", Status: string(models.StatusPending), Created: time.Now(), UserID: &userID, CreatedByUserID: &userID, BlockedBy: []string{"eu-only"}},
+	} {
+		if err := s.CreateTodo(ctx, &todo); err != nil {
+			t.Fatalf("CreateTodo failed: %v", err)
+		}
+	}
+	if todo, _ := s.GetUserTodo(ctx, "after-eu", "user-1"); todo.Blocked || len(todo.BlockedBy) != 0 {
+		t.Errorf("Expected user-1 not blocked by the eu task, got %v, %v", todo.Blocked, todo.BlockedBy)
+	}
+	if todo, _ := s.GetTodo(ctx, "own-after-eu"); todo.Blocked || len(todo.BlockedBy) != 0 {
+		t.Errorf("Expected user-1's todo not blocked by the eu task, got %v, %v", todo.Blocked, todo.BlockedBy)
+	}
+	if todo, _ := s.GetTodo(ctx, "after-eu"); !todo.Blocked || !slices.Equal(todo.BlockedBy, []string{"eu-only"}) {
+		t.Errorf("Expected the task itself blocked by the eu task, got %v, %v", todo.Blocked, todo.BlockedBy)
+	}
+	if err := s.AddGroupMember(ctx, "eu", "user-1"); err != nil {
+		t.Fatalf("AddGroupMember failed: %v", err)
+	}
+	if todo, _ := s.GetUserTodo(ctx, "after-eu", "user-1"); !todo.Blocked || !slices.Equal(todo.BlockedBy, []string{"eu-only"}) {
+		t.Errorf("Expected user-1 blocked by the eu task on joining eu, got %v, %v", todo.Blocked, todo.BlockedBy)
+	}
+	if todo, _ := s.GetTodo(ctx, "own-after-eu"); !todo.Blocked {
+		t.Errorf("Expected user-1's todo blocked by the eu task on joining eu")
+	}
+}
+
+func testPropagation(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	for _, id := range []string{"user-1", "user-2", "user-3"} {
+		CreateUser(t, s, id, models.RoleUser)
+	}
+	def := CreateTodo(t, s, "def", "")
+	setStatus := func(userID string, status models.TodoStatus) {
+		t.Helper()
+		if err := s.SetDefaultTodoStatus(ctx, userID, def.ID, string(status), nil); err != nil {
+			t.Fatalf("SetDefaultTodoStatus failed: %v", err)
+		}
+	}
+	check := func(userID string, status models.TodoStatus, flagged bool) models.Todo {
+		t.Helper()
+		got, err := s.GetUserTodo(ctx, def.ID, userID)
+		if err != nil {
+			t.Fatalf("GetUserTodo failed: %v", err)
+		}
+		if got.Status != string(status) || got.UpdatedSinceDone != flagged {
+			t.Errorf("Expected %s to be %s with updated_since_done %v, got %s and %v", userID, status, flagged, got.Status, got.UpdatedSinceDone)
+		}
+		return got
+	}
+	update := func(text string, p models.Propagation) {
+		t.Helper()
+		if err := s.UpdateTodo(ctx, def.ID, store.TodoUpdate{Text: &text, Propagate: p}); err != nil {
+			t.Fatalf("UpdateTodo failed: %v", err)
+		}
+	}
+	setStatus("user-1", models.StatusDone)
+	setStatus("user-2", models.StatusInProgress)
+
+	// Keeping progress changes no one's status
+	update("first edit", models.PropagateKeep)
+	check("user-1", models.StatusDone, false)
+	check("user-2", models.StatusInProgress, false)
+
+	// Resetting done users leaves those still working on it
+	before := check("user-1", models.StatusDone, false)
+	update("second edit", models.PropagateResetDone)
+	if got := check("user-1", models.StatusPending, true); got.StateVersion <= before.StateVersion || got.Text != "second edit" {
+		t.Errorf("Expected the reset to bump user-1's state version, got %d after %d", got.StateVersion, before.StateVersion)
+	}
+	check("user-2", models.StatusInProgress, false)
+	check("user-3", models.StatusPending, false)
+
+	// Changing status clears the flag
+	setStatus("user-1", models.StatusDone)
+	check("user-1", models.StatusDone, false)
+
+	update("third edit", models.PropagateResetAll)
+	check("user-1", models.StatusPending, true)
+	check("user-2", models.StatusPending, false)
+	check("user-3", models.StatusPending, false)
+	setStatus("user-1", models.StatusInProgress)
+	check("user-1", models.StatusInProgress, false)
+
+	// A reset on its own is a change
+	if err := s.UpdateTodo(ctx, def.ID, store.TodoUpdate{Propagate: models.PropagateResetAll}); err != nil {
+		t.Fatalf("UpdateTodo failed: %v", err)
+	}
+	check("user-1", models.StatusPending, false)
+}
+
+func testPublication(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	CreateUser(t, s, "user-1", models.RoleUser)
+
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	CreateTodo(t, s, "always", "")
+	draftID := "draft"
+	for _, todo := range []models.Todo{
+		{ID: "draft", Draft: true, DueAt: &past},
+		{ID: "draft-sub", Draft: true, ParentID: &draftID},
+		{ID: "scheduled", PublishAt: &future},
+		{ID: "expired", ExpiresAt: &past},
+		{ID: "window", PublishAt: &past, ExpiresAt: &future, DueAt: &past},
+	} {
+		todo.Text, todo.Status, todo.Created, todo.IsDefaultTask = "todo "+todo.ID, string(models.StatusPending), time.Now(), true
+		if err := s.CreateTodo(ctx, &todo); err != nil {
+			t.Fatalf("CreateTodo failed: %v", err)
+		}
+	}
+
+	listed := func() []string {
+		t.Helper()
+		todos, err := s.ListUserTodos(ctx, "user-1", true, store.TodoFilter{})
+		if err != nil {
+			t.Fatalf("ListUserTodos failed: %v", err)
+		}
+		shared, err := s.ListSharedTodos(ctx, "user-1", store.TodoFilter{})
+		if err != nil {
+			t.Fatalf("ListSharedTodos failed: %v", err)
+		}
+		if !slices.Equal(ids(todos), ids(shared)) {
+			t.Errorf("Expected admins to see the same default tasks as user-1, got %v and %v", ids(shared), ids(todos))
+		}
+		return ids(todos)
+	}
+	if got := listed(); !slices.Equal(got, []string{"window", "always"}) {
+		t.Errorf("Expected only the live tasks, got %v", got)
+	}
+	if _, err := s.GetUserTodo(ctx, "draft", "user-1"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound reading a draft as a user, got %v", err)
+	}
+	found, err := s.SearchUserTodos(ctx, "user-1", "todo", true, 10)
+	if err != nil {
+		t.Fatalf("SearchUserTodos failed: %v", err)
+	}
+	if got := ids(found); len(got) != 2 || !slices.Contains(got, "window") || !slices.Contains(got, "always") {
+		t.Errorf("Expected search to find only the live tasks, got %v", got)
+	}
+	overdue, err := s.ListOverdueTodos(ctx, "", time.Now(), 10)
+	if err != nil {
+		t.Fatalf("ListOverdueTodos failed: %v", err)
+	}
+	if len(overdue) != 1 || overdue[0].Todo.ID != "window" {
+		t.Errorf("Expected only the live task overdue, got %+v", overdue)
+	}
+
+	// Admins list the default tasks by state
+	for _, tt := range []struct {
+		p    models.Publication
+		want []string
+	}{
+		{models.PublicationDraft, []string{"draft-sub", "draft"}},
+		{models.PublicationScheduled, []string{"scheduled"}},
+		{models.PublicationLive, []string{"window", "always"}},
+		{models.PublicationExpired, []string{"expired"}},
+	} {
+		todos, err := s.ListDefaultTodos(ctx, store.TodoFilter{Publication: tt.p})
+		if err != nil {
+			t.Fatalf("ListDefaultTodos failed: %v", err)
+		}
+		if got := ids(todos); !slices.Equal(got, tt.want) {
+			t.Errorf("Expected %s tasks %v, got %v", tt.p, tt.want, got)
+		}
+		for _, todo := range todos {
+			if todo.PublicationAt(time.Now()) != tt.p {
+				t.Errorf("Expected %s to read back as %s, got %+v", todo.ID, tt.p, todo)
+			}
+		}
+	}
+
+	// Publishing takes subtasks along and lifts the expiry date
+	before, _ := s.GetTodo(ctx, "draft")
+	stale := before.Version - 1
+	if err := s.PublishTodo(ctx, "draft", time.Now(), &stale); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("Expected ErrConflict publishing at a stale version, got %v", err)
+	}
+	if got, _ := s.GetTodo(ctx, "draft"); !got.Draft {
+		t.Errorf("Expected a stale publish to change nothing, got %+v", got)
+	}
+	if err := s.PublishTodo(ctx, "draft", time.Now(), &before.Version); err != nil {
+		t.Fatalf("PublishTodo failed: %v", err)
+	}
+	if err := s.PublishTodo(ctx, "expired", time.Now(), nil); err != nil {
+		t.Fatalf("PublishTodo failed: %v", err)
+	}
+	if got, _ := s.GetTodo(ctx, "draft"); got.Draft || got.PublishAt == nil || got.Version != before.Version+1 {
+		t.Errorf("Expected the draft to be published with a new version, got %+v", got)
+	}
+	if got, _ := s.GetTodo(ctx, "expired"); got.ExpiresAt != nil {
+		t.Errorf("Expected the expiry date to be cleared, got %v", got.ExpiresAt)
+	}
+	if got := listed(); !slices.Equal(got, []string{"window", "expired", "draft-sub", "draft", "always"}) {
+		t.Errorf("Expected the published tasks to be listed, got %v", got)
+	}
+	if err := s.PublishTodo(ctx, "missing", time.Now(), nil); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound publishing a missing task, got %v", err)
+	}
+
+	// Updates move tasks in and out of view
+	draft, zero := true, time.Time{}
+	if err := s.UpdateTodo(ctx, "always", store.TodoUpdate{Draft: &draft}); err != nil {
+		t.Fatalf("UpdateTodo failed: %v", err)
+	}
+	if err := s.UpdateTodo(ctx, "scheduled", store.TodoUpdate{PublishAt: &zero}); err != nil {
+		t.Fatalf("UpdateTodo failed: %v", err)
+	}
+	if err := s.UpdateTodo(ctx, "window", store.TodoUpdate{ExpiresAt: &past}); err != nil {
+		t.Fatalf("UpdateTodo failed: %v", err)
+	}
+	if got := listed(); !slices.Equal(got, []string{"expired", "scheduled", "draft-sub", "draft"}) {
+		t.Errorf("Expected the updated schedules to apply, got %v", got)
+	}
+
+	// Blockers users cannot see neither block them nor show
+	userID := "user-1"
+	for _, todo := range []models.Todo{
+		{ID: "after", IsDefaultTask: true, BlockedBy: []string{"always", "window"}},
+		{ID: "own-after", UserID: &userID, CreatedByUserID: &userID, BlockedBy: []string{"always", "window"}},
+	} {
+		todo.Text, todo.Status, todo.Created = "todo "+todo.ID, string(models.StatusPending), time.Now()
+		if err := s.CreateTodo(ctx, &todo); err != nil {
+			t.Fatalf("CreateTodo failed: %v", err)
+		}
+	}
+	if todo, _ := s.GetUserTodo(ctx, "after", "user-1"); todo.Blocked || len(todo.BlockedBy) != 0 {
+		t.Errorf("Expected user-1 not blocked by a draft or expired task, got %v, %v", todo.Blocked, todo.BlockedBy)
+	}
+	if todo, _ := s.GetTodo(ctx, "own-after"); todo.Blocked || len(todo.BlockedBy) != 0 {
+		t.Errorf("Expected user-1's todo not blocked by a draft or expired task, got %v, %v", todo.Blocked, todo.BlockedBy)
+	}
+	if todo, _ := s.GetTodo(ctx, "after"); !todo.Blocked || !slices.Equal(todo.BlockedBy, []string{"always", "window"}) {
+		t.Errorf("Expected the task itself blocked by both, got %v, %v", todo.Blocked, todo.BlockedBy)
+	}
+	if err := s.PublishTodo(ctx, "always", time.Now(), nil); err != nil {
+		t.Fatalf("PublishTodo failed: %v", err)
+	}
+	if todo, _ := s.GetUserTodo(ctx, "after", "user-1"); !todo.Blocked || !slices.Equal(todo.BlockedBy, []string{"always"}) {
+		t.Errorf("Expected user-1 blocked by the published task, got %v, %v", todo.Blocked, todo.BlockedBy)
+	}
+}
+
+func testDefaultOrder(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	CreateUser(t, s, "user-1", models.RoleUser)
+	CreateUser(t, s, "user-2", models.RoleUser)
+	for _, id := range []string{"a", "b", "c"} {
+		CreateTodo(t, s, id, "")
+	}
+	CreateTodo(t, s, "personal", "user-1")
+
+	listed := func(userID string) []string {
+		t.Helper()
+		todos, err := s.ListUserTodos(ctx, userID, true, store.TodoFilter{Source: models.SourceDefault})
+		if err != nil {
+			t.Fatalf("ListUserTodos failed: %v", err)
+		}
+		return ids(todos)
+	}
+	defaults := func() []string {
+		t.Helper()
+		todos, err := s.ListDefaultTodos(ctx, store.TodoFilter{})
+		if err != nil {
+			t.Fatalf("ListDefaultTodos failed: %v", err)
+		}
+		return ids(todos)
+	}
+	if err := s.ReorderTodos(ctx, "user-2", []string{"a", "b", "c"}); err != nil {
+		t.Fatalf("ReorderTodos failed: %v", err)
+	}
+
+	// Users who have not reordered the tasks follow the canonical order
+	if err := s.ReorderDefaultTodos(ctx, []string{"b", "a", "c"}, false); err != nil {
+		t.Fatalf("ReorderDefaultTodos failed: %v", err)
+	}
+	if got := defaults(); !slices.Equal(got, []string{"b", "a", "c"}) {
+		t.Errorf("Expected the new canonical order, got %v", got)
+	}
+	if got := listed("user-1"); !slices.Equal(got, []string{"b", "a", "c"}) {
+		t.Errorf("Expected user-1 to follow the canonical order, got %v", got)
+	}
+	if got := listed("user-2"); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("Expected user-2 to keep their own order, got %v", got)
+	}
+
+	// A bad ID changes nothing
+	if err := s.ReorderDefaultTodos(ctx, []string{"c", "b", "personal"}, true); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound reordering a personal todo, got %v", err)
+	}
+	if got := defaults(); !slices.Equal(got, []string{"b", "a", "c"}) {
+		t.Errorf("Expected the order to be unchanged, got %v", got)
+	}
+
+	if err := s.ReorderDefaultTodos(ctx, []string{"c", "b", "a"}, true); err != nil {
+		t.Fatalf("ReorderDefaultTodos failed: %v", err)
+	}
+	if got := listed("user-2"); !slices.Equal(got, []string{"c", "b", "a"}) {
+		t.Errorf("Expected user-2's order to be reset, got %v", got)
+	}
+}
@@ -0,0 +1,165 @@
+package todo
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/akhilmk/packup/internal/attachment"
+	"github.com/akhilmk/packup/internal/audit"
+	"github.com/akhilmk/packup/internal/httputil"
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+	"github.com/google/uuid"
+)
+
+// ListAttachments lists the files attached to a todo
+// @Summary List attachments
+// @Description Get the files attached to a todo in the authenticated user's list, oldest first. Files attached to a default task are only seen by the user who attached them (or had them attached by an admin) and the admins.
+// @Tags todos
+// @Produce  json
+// @Param id path string true "Todo ID"
+// @Success 200 {object} map[string][]models.Attachment
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/todos/{id}/attachments [get]
+func (h *Handler) ListAttachments(w http.ResponseWriter, r *http.Request) {
+	userID, todoID, ok := h.visibleTodo(w, r)
+	if !ok {
+		return
+	}
+
+	attachments, err := h.attachments.ListAttachments(r.Context(), todoID, userID)
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+
+	httputil.WriteJSON(w, map[string]any{"attachments": attachments}, http.StatusOK)
+}
+
+// UploadAttachment attaches a file to a todo
+// @Summary Upload attachment
+// @Description Attach a file to a todo in the authenticated user's list, as multipart/form-data in the file field. Files may be PDF documents, plain text or GIF, JPEG, PNG or WebP images of up to 10 MiB; the type is detected from the contents.
+// @Tags todos
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param id path string true "Todo ID"
+// @Param file formData file true "File to attach"
+// @Success 201 {object} models.Attachment
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 413 {object} httputil.APIError
+// @Failure 415 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/todos/{id}/attachments [post]
+func (h *Handler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	userID, todoID, ok := h.visibleTodo(w, r)
+	if !ok {
+		return
+	}
+
+	a := models.Attachment{
+		ID:         uuid.New().String(),
+		TodoID:     todoID,
+		UserID:     userID,
+		UploadedBy: &userID,
+		CreatedAt:  time.Now(),
+	}
+	if !attachment.Receive(w, r, h.blobs, &a) {
+		return
+	}
+	if err := h.attachments.CreateAttachment(r.Context(), a); err != nil {
+		attachment.Delete(r.Context(), h.blobs, a.ID)
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	h.audit.Record(r.Context(), audit.Event{ActorID: userID, Action: models.AuditAttachmentAdd, UserID: userID, TodoID: todoID, After: a})
+
+	httputil.WriteJSON(w, a, http.StatusCreated)
+}
+
+// DownloadAttachment sends the contents of an attachment
+// @Summary Download attachment
+// @Description Download a file attached to a todo in the authenticated user's list.
+// @Tags todos
+// @Produce  application/octet-stream
+// @Param id path string true "Todo ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Success 200 {file} file
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/todos/{id}/attachments/{attachmentId} [get]
+func (h *Handler) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	userID, todoID, ok := h.visibleTodo(w, r)
+	if !ok {
+		return
+	}
+	a, ok := h.todoAttachment(w, r, todoID, userID)
+	if !ok {
+		return
+	}
+
+	attachment.Serve(w, r, h.blobs, a)
+}
+
+// DeleteAttachment removes an attachment
+// @Summary Delete attachment
+// @Description Remove a file the authenticated user attached to a todo.
+// @Tags todos
+// @Produce  json
+// @Param id path string true "Todo ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Success 200 {object} map[string]bool
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/todos/{id}/attachments/{attachmentId} [delete]
+func (h *Handler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	userID, todoID, ok := h.visibleTodo(w, r)
+	if !ok {
+		return
+	}
+	a, ok := h.todoAttachment(w, r, todoID, userID)
+	if !ok {
+		return
+	}
+	if a.UploadedBy == nil || *a.UploadedBy != userID {
+		httputil.Forbidden(w, "forbidden: only the uploader can delete an attachment")
+		return
+	}
+
+	if err := h.attachments.DeleteAttachment(r.Context(), a.ID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			httputil.NotFound(w, "attachment not found")
+			return
+		}
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	attachment.Delete(r.Context(), h.blobs, a.ID)
+	h.audit.Record(r.Context(), audit.Event{ActorID: userID, Action: models.AuditAttachmentDelete, UserID: userID, TodoID: todoID, Before: a})
+
+	httputil.WriteSuccess(w)
+}
+
+// todoAttachment returns the attachment in the path if it is on userID's
+// todoID. It writes the error response and returns false otherwise.
+func (h *Handler) todoAttachment(w http.ResponseWriter, r *http.Request, todoID, userID string) (models.Attachment, bool) {
+	a, err := h.attachments.GetAttachment(r.Context(), r.PathValue("attachmentId"))
+	if err != nil || a.TodoID != todoID || a.UserID != userID {
+		httputil.NotFound(w, "attachment not found")
+		return models.Attachment{}, false
+	}
+	return a, true
+}
@@ -0,0 +1,234 @@
+package todo
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/akhilmk/packup/internal/audit"
+	"github.com/akhilmk/packup/internal/auth"
+	"github.com/akhilmk/packup/internal/httputil"
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+	"github.com/google/uuid"
+)
+
+// ListComments lists the comments on a todo
+// @Summary List comments
+// @Description Get the authenticated user's comment thread with the admins on a todo, oldest first. Default tasks have a separate thread for every user.
+// @Tags todos
+// @Produce  json
+// @Param id path string true "Todo ID"
+// @Success 200 {object} map[string][]models.Comment
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/todos/{id}/comments [get]
+func (h *Handler) ListComments(w http.ResponseWriter, r *http.Request) {
+	userID, todoID, ok := h.visibleTodo(w, r)
+	if !ok {
+		return
+	}
+
+	comments, err := h.comments.ListComments(r.Context(), todoID, userID)
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+
+	httputil.WriteJSON(w, map[string]any{"comments": comments}, http.StatusOK)
+}
+
+// CreateComment adds a comment to a todo
+// @Summary Add comment
+// @Description Add a comment to the authenticated user's thread with the admins on a todo. Admins only see the thread if they can see the todo.
+// @Tags todos
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Todo ID"
+// @Param comment body object true "Comment body"
+// @Success 201 {object} models.Comment
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/todos/{id}/comments [post]
+func (h *Handler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	userID, todoID, ok := h.visibleTodo(w, r)
+	if !ok {
+		return
+	}
+
+	body, ok := decodeComment(w, r)
+	if !ok {
+		return
+	}
+
+	c := models.Comment{
+		ID:        uuid.New().String(),
+		TodoID:    todoID,
+		UserID:    userID,
+		AuthorID:  &userID,
+		Body:      body,
+		CreatedAt: time.Now(),
+	}
+	if err := h.comments.CreateComment(r.Context(), c); err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	h.audit.Record(r.Context(), audit.Event{ActorID: userID, Action: models.AuditCommentCreate, UserID: userID, TodoID: todoID, After: c})
+
+	httputil.WriteJSON(w, c, http.StatusCreated)
+}
+
+// UpdateComment edits a comment
+// @Summary Edit comment
+// @Description Replace the body of one of the authenticated user's own comments.
+// @Tags todos
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Todo ID"
+// @Param commentId path string true "Comment ID"
+// @Param comment body object true "Comment body"
+// @Success 200 {object} models.Comment
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/todos/{id}/comments/{commentId} [put]
+func (h *Handler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	userID, todoID, ok := h.visibleTodo(w, r)
+	if !ok {
+		return
+	}
+	existing, ok := h.ownComment(w, r, todoID, userID)
+	if !ok {
+		return
+	}
+
+	body, ok := decodeComment(w, r)
+	if !ok {
+		return
+	}
+
+	c := existing
+	c.Body = body
+	now := time.Now()
+	c.EditedAt = &now
+	if err := h.comments.UpdateComment(r.Context(), c.ID, c.Body, now); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			httputil.NotFound(w, "comment not found")
+			return
+		}
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	h.audit.Record(r.Context(), audit.Event{ActorID: userID, Action: models.AuditCommentUpdate, UserID: userID, TodoID: todoID, Before: existing, After: c})
+
+	httputil.WriteJSON(w, c, http.StatusOK)
+}
+
+// DeleteComment deletes a comment
+// @Summary Delete comment
+// @Description Delete one of the authenticated user's own comments.
+// @Tags todos
+// @Produce  json
+// @Param id path string true "Todo ID"
+// @Param commentId path string true "Comment ID"
+// @Success 200 {object} map[string]bool
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/todos/{id}/comments/{commentId} [delete]
+func (h *Handler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	userID, todoID, ok := h.visibleTodo(w, r)
+	if !ok {
+		return
+	}
+	existing, ok := h.ownComment(w, r, todoID, userID)
+	if !ok {
+		return
+	}
+
+	if err := h.comments.DeleteComment(r.Context(), existing.ID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			httputil.NotFound(w, "comment not found")
+			return
+		}
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	h.audit.Record(r.Context(), audit.Event{ActorID: userID, Action: models.AuditCommentDelete, UserID: userID, TodoID: todoID, Before: existing})
+
+	httputil.WriteSuccess(w)
+}
+
+// visibleTodo identifies the todo in the path, which must be in the
+// authenticated user's own list, and the user whose comments and
+// attachments on it the request is about: the authenticated user. It writes
+// the error response and returns false otherwise.
+func (h *Handler) visibleTodo(w http.ResponseWriter, r *http.Request) (userID, todoID string, ok bool) {
+	userID, ok = auth.GetUserID(r.Context())
+	if !ok {
+		httputil.Unauthorized(w)
+		return "", "", false
+	}
+
+	todoID = r.PathValue("id")
+	if todoID == "" {
+		httputil.BadRequest(w, "id required")
+		return "", "", false
+	}
+
+	t, err := h.todos.GetUserTodo(r.Context(), todoID, userID)
+	if err != nil {
+		httputil.NotFound(w, "todo not found")
+		return "", "", false
+	}
+	if !canView(t, userID) {
+		httputil.Forbidden(w, "forbidden")
+		return "", "", false
+	}
+	return userID, todoID, true
+}
+
+// ownComment returns the comment in the path if it is in userID's thread on
+// todoID and written by userID. It writes the error response and returns
+// false otherwise.
+func (h *Handler) ownComment(w http.ResponseWriter, r *http.Request, todoID, userID string) (models.Comment, bool) {
+	c, err := h.comments.GetComment(r.Context(), r.PathValue("commentId"))
+	if err != nil || c.TodoID != todoID || c.UserID != userID {
+		httputil.NotFound(w, "comment not found")
+		return models.Comment{}, false
+	}
+	if c.AuthorID == nil || *c.AuthorID != userID {
+		httputil.Forbidden(w, "forbidden: only the author can change a comment")
+		return models.Comment{}, false
+	}
+	return c, true
+}
+
+// decodeComment reads and normalizes the body of a comment from the request.
+// It writes the error response and returns false if it is invalid.
+func decodeComment(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req struct {
+		Body string `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.BadRequest(w, "invalid json")
+		return "", false
+	}
+	body, err := models.NormalizeComment(req.Body)
+	if err != nil {
+		httputil.BadRequest(w, err.Error())
+		return "", false
+	}
+	return body, true
+}
@@ -0,0 +1,28 @@
+package todo
+
+import (
+	"net/http"
+
+	"github.com/akhilmk/packup/internal/auth"
+	"github.com/akhilmk/packup/internal/httputil"
+	"github.com/akhilmk/packup/internal/models"
+)
+
+// checkBlockers checks that t can depend on each of ids, which must be live
+// todos in the user's list. Todos the user cannot see are reported as not
+// found. Otherwise it writes an error and returns false.
+func (h *Handler) checkBlockers(w http.ResponseWriter, r *http.Request, t models.Todo, ids []string) bool {
+	userID, _ := auth.GetUserID(r.Context())
+	for _, id := range ids {
+		blocker, err := h.todos.GetUserTodo(r.Context(), id, userID)
+		if err != nil || !canView(blocker, userID) {
+			httputil.BadRequest(w, "blocker "+id+" not found")
+			return false
+		}
+		if !t.CanDependOn(blocker) {
+			httputil.BadRequest(w, "todo cannot depend on "+id)
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+package todo
+
+import (
+	"net/http"
+
+	"github.com/akhilmk/packup/internal/auth"
+	"github.com/akhilmk/packup/internal/httputil"
+	"github.com/akhilmk/packup/internal/models"
+)
+
+// History of a todo
+// @Summary Todo revision history
+// @Description Get every revision of a todo's text, status and visibility, oldest first, each with the fields changed since the previous revision. For default tasks this is the history of the shared task, not of the user's own progress.
+// @Tags todos
+// @Produce  json
+// @Param id path string true "Todo ID"
+// @Success 200 {object} map[string][]models.TodoRevision
+// @Failure 400 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/todos/{id}/history [get]
+func (h *Handler) History(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r.Context())
+	if !ok {
+		httputil.Unauthorized(w)
+		return
+	}
+
+	id := r.PathValue("id")
+	if id == "" {
+		httputil.BadRequest(w, "id required")
+		return
+	}
+
+	t, err := h.todos.GetTodo(r.Context(), id)
+	if err != nil {
+		httputil.NotFound(w, "todo not found")
+		return
+	}
+
+	// Users see the history of the todos in their own list
+	if !canView(t, userID) {
+		httputil.Forbidden(w, "forbidden")
+		return
+	}
+
+	revs, err := h.todos.ListTodoRevisions(r.Context(), id)
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	models.DiffRevisions(revs)
+
+	httputil.WriteJSON(w, map[string]any{"revisions": revs}, http.StatusOK)
+}
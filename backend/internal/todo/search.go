@@ -0,0 +1,64 @@
+package todo
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/akhilmk/packup/internal/auth"
+	"github.com/akhilmk/packup/internal/httputil"
+)
+
+// Search result sizes.
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// Search todos
+// @Summary Search todos
+// @Description Full-text search over the todos in the authenticated user's list, best matches first. Every word of the query must match.
+// @Tags todos
+// @Produce  json
+// @Param q query string true "Search text"
+// @Param limit query int false "Maximum number of results (default 20, max 100)"
+// @Success 200 {object} map[string][]models.Todo
+// @Failure 400 {object} httputil.APIError
+// @Failure 401 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/todos/search [get]
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r.Context())
+	if !ok {
+		httputil.Unauthorized(w)
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		httputil.BadRequest(w, "q required")
+		return
+	}
+
+	limit := defaultSearchLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		var err error
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxSearchLimit {
+			httputil.BadRequest(w, "limit must be between 1 and 100")
+			return
+		}
+	}
+
+	// Search the same todos that List returns
+	userRole, _ := auth.GetUserRole(r.Context())
+	excludeAdminTodos := r.URL.Query().Get("exclude_admin_todos") == "true" || userRole == "admin"
+
+	todos, err := h.todos.SearchUserTodos(r.Context(), userID, query, !excludeAdminTodos, limit)
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+
+	httputil.WriteJSON(w, map[string]any{"todos": todos}, http.StatusOK)
+}
@@ -0,0 +1,26 @@
+package todo
+
+import (
+	"net/http"
+
+	"github.com/akhilmk/packup/internal/httputil"
+)
+
+// ListTags lists the tag palette
+// @Summary List tags
+// @Description Get the palette of tags curated by admins, by name. Todos can also be given any other tag.
+// @Tags todos
+// @Produce  json
+// @Success 200 {object} map[string][]models.Tag
+// @Failure 401 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/tags [get]
+func (h *Handler) ListTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.tags.ListTags(r.Context(), false)
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+
+	httputil.WriteJSON(w, map[string]any{"tags": tags}, http.StatusOK)
+}
@@ -2,42 +2,80 @@ package todo
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/akhilmk/packup/internal/audit"
 	"github.com/akhilmk/packup/internal/auth"
 	"github.com/akhilmk/packup/internal/httputil"
+	"github.com/akhilmk/packup/internal/markdown"
 	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Handler struct {
-	db *pgxpool.Pool
+	todos       store.TodoStore
+	tags        store.TagStore
+	comments    store.CommentStore
+	attachments store.AttachmentStore
+	blobs       store.BlobStore
+	workflow    store.WorkflowStore
+	audit       *audit.Recorder
 }
 
-func NewHandler(db *pgxpool.Pool) *Handler {
-	return &Handler{db: db}
+func NewHandler(s store.Store, blobs store.BlobStore) *Handler {
+	return &Handler{todos: s, tags: s, comments: s, attachments: s, blobs: blobs, workflow: s, audit: audit.NewRecorder(s)}
 }
 
 // RegisterRoutes registers the specific routes to a mux using Go 1.22 enhanced routing
 func (h *Handler) RegisterRoutes(mux *http.ServeMux, middleware func(http.HandlerFunc) http.HandlerFunc) {
 	mux.HandleFunc("GET /api/todos", middleware(h.List))
 	mux.HandleFunc("POST /api/todos", middleware(h.Create))
+	mux.HandleFunc("GET /api/todos/search", middleware(h.Search))
+	mux.HandleFunc("GET /api/todos/{id}", middleware(h.Get))
 	mux.HandleFunc("PUT /api/todos/{id}", middleware(h.Update))
 	mux.HandleFunc("PUT /api/todos/reorder", middleware(h.Reorder))
 	mux.HandleFunc("DELETE /api/todos/{id}", middleware(h.Delete))
+	mux.HandleFunc("GET /api/todos/trash", middleware(h.ListTrash))
+	mux.HandleFunc("POST /api/todos/{id}/restore", middleware(h.Restore))
+	mux.HandleFunc("GET /api/todos/{id}/history", middleware(h.History))
+	mux.HandleFunc("GET /api/todos/{id}/comments", middleware(h.ListComments))
+	mux.HandleFunc("POST /api/todos/{id}/comments", middleware(h.CreateComment))
+	mux.HandleFunc("PUT /api/todos/{id}/comments/{commentId}", middleware(h.UpdateComment))
+	mux.HandleFunc("DELETE /api/todos/{id}/comments/{commentId}", middleware(h.DeleteComment))
+	mux.HandleFunc("GET /api/todos/{id}/attachments", middleware(h.ListAttachments))
+	mux.HandleFunc("POST /api/todos/{id}/attachments", middleware(h.UploadAttachment))
+	mux.HandleFunc("GET /api/todos/{id}/attachments/{attachmentId}", middleware(h.DownloadAttachment))
+	mux.HandleFunc("DELETE /api/todos/{id}/attachments/{attachmentId}", middleware(h.DeleteAttachment))
+	mux.HandleFunc("GET /api/tags", middleware(h.ListTags))
+	mux.HandleFunc("GET /api/workflow", middleware(h.GetWorkflow))
 }
 
 // List todos
 // @Summary List todos
-// @Description Get a list of todos for the authenticated user, including default tasks unless excluded.
+// @Description Get a list of todos for the authenticated user, including default tasks unless excluded. Default tasks targeted to groups the user is not a member of are left out. Results are paged; pass next_cursor back as cursor to get the next page.
 // @Tags todos
 // @Accept  json
 // @Produce  json
 // @Param exclude_admin_todos query bool false "Exclude global default tasks"
+// @Param status query string false "Only todos with this status, one of the workflow's"
+// @Param source query string false "Only todos from this source" Enums(default, admin, personal)
+// @Param parent_id query string false "Only the subtasks of this todo"
+// @Param tag query []string false "Only todos with every one of these tags" collectionFormat(multi)
+// @Param created_from query string false "Only todos created at or after this time (RFC 3339)"
+// @Param created_to query string false "Only todos created before this time (RFC 3339)"
+// @Param due_from query string false "Only todos due at or after this time (RFC 3339)"
+// @Param due_to query string false "Only todos due before this time (RFC 3339)"
+// @Param overdue query bool false "Only todos past their due date and not done"
+// @Param sort query string false "Order by position (default), due date with undated todos last, or priority with the most urgent first" Enums(position, due, priority)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param limit query int false "Maximum number of todos (default 100, max 500)"
+// @Param render query string false "With html, also render descriptions to sanitized HTML in description_html" Enums(markdown, html)
 // @Success 200 {object} map[string][]models.Todo
+// @Failure 400 {object} httputil.APIError
 // @Failure 401 {object} httputil.APIError
 // @Failure 500 {object} httputil.APIError
 // @Router /api/todos [get]
@@ -53,81 +91,101 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 	userRole, _ := auth.GetUserRole(r.Context())
 	excludeAdminTodos := r.URL.Query().Get("exclude_admin_todos") == "true" || userRole == "admin"
 
-	var query string
-	if excludeAdminTodos {
-		// Only return user's personal todos (exclude default tasks)
-		query = `
-			SELECT 
-				t.id, 
-				t.text, 
-				t.status,
-				t.created, 
-				t.position,
-				t.created_by_user_id, 
-				t.is_default_task,
-				t.shared_with_admin,
-				t.hidden_from_user
-			FROM todos t
-			WHERE t.user_id = $1 AND t.is_default_task = false
-			ORDER BY position ASC, created DESC 
-			LIMIT 100
-		`
-	} else {
-		// Return user's own todos + all default tasks
-		// For default tasks, use user-specific status/position from user_todo_state if exists
-		query = `
-			SELECT 
-				t.id, 
-				t.text, 
-				CASE 
-					WHEN t.is_default_task THEN COALESCE(uts.status, t.status)
-					ELSE t.status
-				END as status,
-				t.created, 
-				CASE 
-					WHEN t.is_default_task THEN COALESCE(uts.position, t.position)
-					ELSE t.position
-				END as position,
-				t.created_by_user_id, 
-				t.is_default_task,
-				t.shared_with_admin,
-				t.hidden_from_user
-			FROM todos t
-			LEFT JOIN user_todo_state uts ON t.id = uts.todo_id AND uts.user_id = $1 AND t.is_default_task = true
-			WHERE (t.user_id = $1 OR t.is_default_task = true) AND t.hidden_from_user = false
-			ORDER BY position ASC, created DESC 
-			LIMIT 100
-		`
-	}
-
-	rows, err := h.db.Query(r.Context(), query, userID)
+	workflow, err := h.workflow.GetWorkflow(r.Context())
 	if err != nil {
 		httputil.InternalError(w, err.Error())
 		return
 	}
-	defer rows.Close()
+	filter, err := httputil.ParseTodoFilter(r.URL.Query(), workflow)
+	if err != nil {
+		httputil.BadRequest(w, err.Error())
+		return
+	}
+	render, err := httputil.ParseRender(r.URL.Query())
+	if err != nil {
+		httputil.BadRequest(w, err.Error())
+		return
+	}
+	limit := filter.Limit
+	filter.Limit++ // One extra todo tells whether another page follows
 
-	var todos []models.Todo
-	for rows.Next() {
-		var t models.Todo
-		if err := rows.Scan(&t.ID, &t.Text, &t.Status, &t.Created, &t.Position, &t.CreatedByUserID, &t.IsDefaultTask, &t.SharedWithAdmin, &t.HiddenFromUser); err != nil {
-			httputil.InternalError(w, err.Error())
-			return
-		}
-		todos = append(todos, t)
+	todos, err := h.todos.ListUserTodos(r.Context(), userID, !excludeAdminTodos, filter)
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+
+	todos, next := store.Paginate(todos, limit, store.TodoCursor)
+	if render {
+		markdown.RenderDescriptions(todos)
+	}
+	httputil.WritePage(w, "todos", todos, next)
+}
+
+// Get todo
+// @Summary Get todo
+// @Description Get a single todo as seen by the authenticated user. Send the returned ETag back in If-Match to update or delete the todo only if nobody has changed it since.
+// @Tags todos
+// @Produce  json
+// @Param id path string true "Todo ID"
+// @Param render query string false "With html, also render descriptions to sanitized HTML in description_html" Enums(markdown, html)
+// @Success 200 {object} models.Todo
+// @Header 200 {string} ETag "Version of the todo"
+// @Failure 400 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Router /api/todos/{id} [get]
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r.Context())
+	if !ok {
+		httputil.Unauthorized(w)
+		return
+	}
+
+	id := r.PathValue("id")
+	if id == "" {
+		httputil.BadRequest(w, "id required")
+		return
+	}
+	render, err := httputil.ParseRender(r.URL.Query())
+	if err != nil {
+		httputil.BadRequest(w, err.Error())
+		return
+	}
+
+	t, err := h.todos.GetUserTodo(r.Context(), id, userID)
+	if err != nil {
+		httputil.NotFound(w, "todo not found")
+		return
+	}
+	if !canView(t, userID) {
+		httputil.Forbidden(w, "forbidden")
+		return
 	}
 
-	// Return empty array instead of null if nil
-	if todos == nil {
-		todos = []models.Todo{}
+	if render {
+		markdown.RenderDescription(&t)
 	}
+	httputil.SetETag(w, t.ETag())
+	httputil.WriteJSON(w, t, http.StatusOK)
+}
 
-	httputil.WriteJSON(w, map[string]any{"todos": todos}, http.StatusOK)
+// canView reports whether a todo is in userID's own list.
+func canView(t models.Todo, userID string) bool {
+	return (t.IsDefaultTask || (t.UserID != nil && *t.UserID == userID)) && !t.HiddenFromUser
+}
+
+// sameParent reports whether two parent IDs are the same, or both unset.
+func sameParent(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
 }
 
 // Create todo
 // @Summary Create todo
-// @Description Create a new personal todo item for the authenticated user.
+// @Description Create a new personal todo item for the authenticated user, with an optional markdown description. With parent_id, the todo is added as a subtask of one of the user's own personal todos and is shared with admins like its parent unless set otherwise. With recurrence, a rule such as FREQ=WEEKLY;INTERVAL=2, the todo comes back as its next occurrence once done. With blocked_by, the todo cannot be marked done until those todos in the user's list are.
 // @Tags todos
 // @Accept  json
 // @Produce  json
@@ -135,6 +193,7 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 // @Success 201 {object} models.Todo
 // @Failure 400 {object} httputil.APIError
 // @Failure 401 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
 // @Failure 500 {object} httputil.APIError
 // @Router /api/todos [post]
 func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
@@ -148,8 +207,15 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	// userRole, _ := r.Context().Value("user_role").(string)
 
 	var req struct {
-		Text            string `json:"text"`
-		SharedWithAdmin *bool  `json:"shared_with_admin"`
+		Text            string     `json:"text"`
+		Description     string     `json:"description"`
+		SharedWithAdmin *bool      `json:"shared_with_admin"`
+		DueAt           *time.Time `json:"due_at"`
+		ParentID        string     `json:"parent_id"`
+		Tags            []string   `json:"tags"`
+		Priority        string     `json:"priority"`
+		Recurrence      string     `json:"recurrence"`
+		BlockedBy       []string   `json:"blocked_by"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		httputil.BadRequest(w, "invalid json")
@@ -160,6 +226,30 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		httputil.BadRequest(w, fmt.Sprintf("text cannot be empty or exceed %d characters", models.MaxTextLength))
 		return
 	}
+	if !models.ValidateDescription(req.Description) {
+		httputil.BadRequest(w, fmt.Sprintf("description limit of %d characters exceeded", models.MaxDescriptionLength))
+		return
+	}
+	tags, err := models.NormalizeTags(req.Tags)
+	if err != nil {
+		httputil.BadRequest(w, err.Error())
+		return
+	}
+	priority, ok := models.ParsePriority(req.Priority)
+	if !ok {
+		httputil.BadRequest(w, "invalid priority")
+		return
+	}
+	recurrence, err := models.NormalizeRecurrence(req.Recurrence)
+	if err != nil {
+		httputil.BadRequest(w, err.Error())
+		return
+	}
+	blockedBy, err := models.NormalizeBlockers(req.BlockedBy)
+	if err != nil {
+		httputil.BadRequest(w, err.Error())
+		return
+	}
 
 	id := uuid.NewString()
 	status := string(models.StatusPending)
@@ -171,51 +261,72 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 
 	// Default to shared (true) unless explicitly set to false
 	sharedWithAdmin := true
+
+	// Subtasks stay in their parent's list: the user's own personal todos
+	var parentID *string
+	if req.ParentID != "" {
+		parent, err := h.todos.GetUserTodo(r.Context(), req.ParentID, userID)
+		if err != nil || !canView(parent, userID) {
+			httputil.BadRequest(w, "parent todo not found")
+			return
+		}
+		if parent.IsDefaultTask {
+			httputil.Forbidden(w, "forbidden: only admins can add subtasks to default tasks")
+			return
+		}
+		parentID = &parent.ID
+		sharedWithAdmin = parent.SharedWithAdmin
+	}
 	if req.SharedWithAdmin != nil {
 		sharedWithAdmin = *req.SharedWithAdmin
 	}
 
-	// Get min position for this user to put at top
-	var minPos float64
-	_ = h.db.QueryRow(r.Context(), `SELECT COALESCE(MIN(position), 0) FROM todos WHERE user_id=$1`, userID).Scan(&minPos)
-	position := minPos - models.PositionIncrement
-
-	// Insert personal todo
-	_, err := h.db.Exec(r.Context(), `
-		INSERT INTO todos(id, text, status, created, position, user_id, created_by_user_id, is_default_task, shared_with_admin) 
-		VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9)
-	`, id, req.Text, status, created, position, userID, userID, isDefaultTask, sharedWithAdmin)
-
-	if err != nil {
-		httputil.InternalError(w, err.Error())
-		return
-	}
-
+	// Insert personal todo (placed at the top of the user's list)
 	createdByUserID := userID
-	httputil.WriteJSON(w, models.Todo{
+	t := models.Todo{
 		ID:              id,
 		Text:            req.Text,
+		Description:     req.Description,
 		Status:          status,
 		Created:         created,
-		Position:        position,
 		CreatedByUserID: &createdByUserID,
 		IsDefaultTask:   isDefaultTask,
 		SharedWithAdmin: sharedWithAdmin,
-	}, http.StatusCreated)
+		UserID:          &createdByUserID,
+		DueAt:           req.DueAt,
+		ParentID:        parentID,
+		Tags:            tags,
+		Priority:        string(priority),
+		Recurrence:      recurrence,
+		BlockedBy:       blockedBy,
+	}
+	if !h.checkBlockers(w, r, t, blockedBy) {
+		return
+	}
+	if err := h.todos.CreateTodo(r.Context(), &t); err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	h.audit.Record(r.Context(), audit.Event{ActorID: userID, Action: models.AuditTodoCreate, UserID: userID, TodoID: t.ID, After: t})
+
+	httputil.WriteJSON(w, t, http.StatusCreated)
 }
 
 // Update todo
 // @Summary Update todo
-// @Description Update an existing todo item's text, status, or sharing status.
+// @Description Update an existing todo item's text, markdown description, status, sharing status, due date, priority, recurrence or tags, which replace the todo's tags. An empty recurrence stops the todo from recurring. blocked_by replaces the todos this one depends on; a todo cannot be marked done while any of them is unfinished. Marking a recurring todo done adds its next occurrence, or for a default task moves the authenticated user on to it. Setting the due date of a default task only changes it for the authenticated user, and clearing it restores the task's own. With If-Match, the update fails with 412 if the todo has changed since it was read.
 // @Tags todos
 // @Accept  json
 // @Produce  json
 // @Param id path string true "Todo ID"
+// @Param If-Match header string false "ETag of the todo as last read"
 // @Param todo body object true "Update fields"
 // @Success 200 {object} models.Todo
+// @Header 200 {string} ETag "Version of the updated todo"
 // @Failure 400 {object} httputil.APIError
 // @Failure 403 {object} httputil.APIError
 // @Failure 404 {object} httputil.APIError
+// @Failure 412 {object} httputil.APIError
 // @Failure 500 {object} httputil.APIError
 // @Router /api/todos/{id} [put]
 func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
@@ -232,37 +343,76 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Text            string `json:"text"`
-		Status          string `json:"status"`
-		SharedWithAdmin *bool  `json:"shared_with_admin,omitempty"`
+		Text            string     `json:"text"`
+		Description     *string    `json:"description,omitempty"`
+		Status          string     `json:"status"`
+		SharedWithAdmin *bool      `json:"shared_with_admin,omitempty"`
+		DueAt           *time.Time `json:"due_at,omitempty"`
+		ClearDueAt      bool       `json:"clear_due_at,omitempty"`
+		Tags            *[]string  `json:"tags,omitempty"`
+		Priority        string     `json:"priority"`
+		Recurrence      *string    `json:"recurrence,omitempty"`
+		BlockedBy       *[]string  `json:"blocked_by,omitempty"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		httputil.BadRequest(w, "invalid json")
 		return
 	}
 
+	var tags *[]string
+	if req.Tags != nil {
+		normalized, err := models.NormalizeTags(*req.Tags)
+		if err != nil {
+			httputil.BadRequest(w, err.Error())
+			return
+		}
+		tags = &normalized
+	}
+	var blockedBy *[]string
+	if req.BlockedBy != nil {
+		normalized, err := models.NormalizeBlockers(*req.BlockedBy)
+		if err != nil {
+			httputil.BadRequest(w, err.Error())
+			return
+		}
+		blockedBy = &normalized
+	}
+
+	// A zero due date clears it
+	if req.ClearDueAt {
+		if req.DueAt != nil {
+			httputil.BadRequest(w, "due_at and clear_due_at cannot be combined")
+			return
+		}
+		req.DueAt = &time.Time{}
+	}
+
 	if req.Text != "" && !models.ValidateText(req.Text) {
 		httputil.BadRequest(w, fmt.Sprintf("text limit of %d characters exceeded", models.MaxTextLength))
 		return
 	}
+	if req.Description != nil && !models.ValidateDescription(*req.Description) {
+		httputil.BadRequest(w, fmt.Sprintf("description limit of %d characters exceeded", models.MaxDescriptionLength))
+		return
+	}
 
-	// Validate status if provided
-	if req.Status != "" {
-		if !models.TodoStatus(req.Status).IsValid() {
-			httputil.BadRequest(w, "invalid status")
+	// Validate priority if provided; statuses are checked against the
+	// workflow once the todo is known
+	if req.Priority != "" && !models.TodoPriority(req.Priority).IsValid() {
+		httputil.BadRequest(w, "invalid priority")
+		return
+	}
+	if req.Recurrence != nil {
+		recurrence, err := models.NormalizeRecurrence(*req.Recurrence)
+		if err != nil {
+			httputil.BadRequest(w, err.Error())
 			return
 		}
+		req.Recurrence = &recurrence
 	}
 
 	// Check if todo exists and if user can update it
-	var isDefaultTask bool
-	var todoUserID *string
-	err := h.db.QueryRow(r.Context(), `
-		SELECT is_default_task, user_id 
-		FROM todos 
-		WHERE id=$1
-	`, id).Scan(&isDefaultTask, &todoUserID)
-
+	existing, err := h.todos.GetUserTodo(r.Context(), id, userID)
 	if err != nil {
 		httputil.NotFound(w, "todo not found")
 		return
@@ -271,46 +421,65 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	// Users can update:
 	// 1. Their own todos (user_id matches)
 	// 2. Default tasks (is_default_task=true)
-	canUpdate := isDefaultTask || (todoUserID != nil && *todoUserID == userID)
+	canUpdate := existing.IsDefaultTask || (existing.UserID != nil && *existing.UserID == userID)
 	if !canUpdate {
 		httputil.Forbidden(w, "forbidden")
 		return
 	}
 
+	// Refuse to overwrite changes the client hasn't seen
+	if !httputil.IfMatch(r, existing.ETag()) {
+		httputil.PreconditionFailed(w, "todo has been changed by someone else")
+		return
+	}
+	conditional := httputil.IsConditional(r)
+
+	if req.Status != "" && !httputil.CheckTransition(w, r, h.workflow, existing, req.Status) {
+		return
+	}
+
 	// Handle update based on todo type
-	if isDefaultTask {
-		// For default tasks, update user_todo_state (per-user status)
+	if existing.IsDefaultTask && req.Description != nil {
+		httputil.Forbidden(w, "forbidden: only admins can edit the description of default tasks")
+		return
+	}
+	if existing.IsDefaultTask && tags != nil {
+		httputil.Forbidden(w, "forbidden: only admins can tag default tasks")
+		return
+	}
+	if existing.IsDefaultTask && req.Priority != "" {
+		httputil.Forbidden(w, "forbidden: only admins can set the priority of default tasks")
+		return
+	}
+	if existing.IsDefaultTask && req.Recurrence != nil {
+		httputil.Forbidden(w, "forbidden: only admins can set the recurrence of default tasks")
+		return
+	}
+	if existing.IsDefaultTask && blockedBy != nil {
+		httputil.Forbidden(w, "forbidden: only admins can set the dependencies of default tasks")
+		return
+	}
+	if existing.IsDefaultTask {
+		// For default tasks, update user_todo_state (per-user status and due date)
 		// Only update status if provided (text updates not allowed for default tasks)
 		// Sharing cannot be toggled for default tasks
+		var ifVersion, ifStateVersion *int
+		if conditional {
+			ifVersion, ifStateVersion = &existing.Version, &existing.StateVersion
+		}
+		update := store.StateUpdate{DueAt: req.DueAt}
 		if req.Status != "" {
-			_, err = h.db.Exec(r.Context(), `
-				INSERT INTO user_todo_state (user_id, todo_id, status, position, updated_at)
-				VALUES ($1, $2, $3, 
-					(SELECT COALESCE(
-						(SELECT position FROM user_todo_state WHERE user_id=$1 AND todo_id=$2),
-						(SELECT position FROM todos WHERE id=$2)
-					)),
-					now())
-				ON CONFLICT (user_id, todo_id) 
-				DO UPDATE SET status = $3, updated_at = now()
-			`, userID, id, req.Status)
-			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
+			update.Status = &req.Status
+		}
+		if update.Status != nil || update.DueAt != nil {
+			err = h.todos.UpdateDefaultTodoState(r.Context(), userID, id, update, ifVersion, ifStateVersion)
 		}
 	} else {
 		// For personal todos, check permissions based on who created it
-		var createdBy *string
-		err := h.db.QueryRow(r.Context(), `SELECT created_by_user_id FROM todos WHERE id=$1`, id).Scan(&createdBy)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-
 		// Check if this is an admin-created task (created_by != user_id)
-		isAdminCreatedTask := createdBy != nil && *createdBy != userID
+		isAdminCreatedTask := existing.CreatedByUserID != nil && *existing.CreatedByUserID != userID
 
+		update := store.TodoUpdate{ActorID: userID}
 		if isAdminCreatedTask {
 			// User can ONLY update status on admin-created tasks
 			// Text and shared_with_admin updates are forbidden
@@ -318,87 +487,96 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 				httputil.Forbidden(w, "forbidden: cannot edit text of admin-assigned task")
 				return
 			}
+			if req.Description != nil {
+				httputil.Forbidden(w, "forbidden: cannot edit description of admin-assigned task")
+				return
+			}
 			if req.SharedWithAdmin != nil {
 				httputil.Forbidden(w, "forbidden: cannot change sharing status of admin-assigned task")
 				return
 			}
-			// Allow status update only
-			if req.Status != "" {
-				query := "UPDATE todos SET status = $1 WHERE id = $2"
-				_, err = h.db.Exec(r.Context(), query, req.Status, id)
+			if req.DueAt != nil {
+				httputil.Forbidden(w, "forbidden: cannot change due date of admin-assigned task")
+				return
 			}
-		} else {
-			// User's own task - allow all updates
-			// Build dynamic query
-			query := "UPDATE todos SET "
-			var args []interface{}
-			argID := 1
-
-			if req.Text != "" {
-				query += fmt.Sprintf("text = $%d, ", argID)
-				args = append(args, req.Text)
-				argID++
+			if tags != nil {
+				httputil.Forbidden(w, "forbidden: cannot change tags of admin-assigned task")
+				return
 			}
-			if req.Status != "" {
-				query += fmt.Sprintf("status = $%d, ", argID)
-				args = append(args, req.Status)
-				argID++
+			if req.Priority != "" {
+				httputil.Forbidden(w, "forbidden: cannot change priority of admin-assigned task")
+				return
 			}
-			if req.SharedWithAdmin != nil {
-				query += fmt.Sprintf("shared_with_admin = $%d, ", argID)
-				args = append(args, *req.SharedWithAdmin)
-				argID++
+			if req.Recurrence != nil {
+				httputil.Forbidden(w, "forbidden: cannot change recurrence of admin-assigned task")
+				return
 			}
-
-			if len(args) > 0 {
-				// Remove trailing comma and space
-				query = query[:len(query)-2]
-				query += fmt.Sprintf(" WHERE id = $%d", argID)
-				args = append(args, id)
-				_, err = h.db.Exec(r.Context(), query, args...)
+			if blockedBy != nil {
+				httputil.Forbidden(w, "forbidden: cannot change dependencies of admin-assigned task")
+				return
+			}
+		} else {
+			// User's own task - allow text, description, sharing, due date,
+			// tag, priority, recurrence and dependency updates
+			if blockedBy != nil && !h.checkBlockers(w, r, existing, *blockedBy) {
+				return
+			}
+			update.BlockedBy = blockedBy
+			if req.Text != "" {
+				update.Text = &req.Text
+			}
+			update.Description = req.Description
+			if req.Priority != "" {
+				update.Priority = &req.Priority
 			}
+			update.Recurrence = req.Recurrence
+			update.SharedWithAdmin = req.SharedWithAdmin
+			update.DueAt = req.DueAt
+			update.Tags = tags
 		}
+		if req.Status != "" {
+			update.Status = &req.Status
+		}
+		if conditional {
+			update.IfVersion = &existing.Version
+		}
+		err = h.todos.UpdateTodo(r.Context(), id, update)
 	}
 	if err != nil {
+		if errors.Is(err, store.ErrConflict) {
+			httputil.PreconditionFailed(w, "todo has been changed by someone else")
+			return
+		}
+		if errors.Is(err, store.ErrDependencyCycle) {
+			httputil.BadRequest(w, "blocked_by would create a dependency cycle")
+			return
+		}
 		httputil.InternalError(w, err.Error())
 		return
 	}
 
 	// Fetch and return updated todo with user-specific state
-	var t models.Todo
-	if err := h.db.QueryRow(r.Context(), `
-		SELECT 
-			t.id, t.text, 
-			CASE 
-				WHEN t.is_default_task THEN COALESCE(uts.status, t.status)
-				ELSE t.status
-			END as status,
-			t.created, 
-			CASE 
-				WHEN t.is_default_task THEN COALESCE(uts.position, t.position)
-				ELSE t.position
-			END as position,
-			t.created_by_user_id, t.is_default_task, t.shared_with_admin
-		FROM todos t
-		LEFT JOIN user_todo_state uts ON t.id = uts.todo_id AND uts.user_id = $2 AND t.is_default_task = true
-		WHERE t.id=$1
-	`, id, userID).Scan(&t.ID, &t.Text, &t.Status, &t.Created, &t.Position, &t.CreatedByUserID, &t.IsDefaultTask, &t.SharedWithAdmin); err != nil {
+	t, err := h.todos.GetUserTodo(r.Context(), id, userID)
+	if err != nil {
 		httputil.InternalError(w, err.Error())
 		return
 	}
+	h.audit.Record(r.Context(), audit.Event{ActorID: userID, Action: models.AuditTodoUpdate, UserID: audit.TodoOwner(t, userID), TodoID: id, Before: existing, After: t})
 
+	httputil.SetETag(w, t.ETag())
 	httputil.WriteJSON(w, t, http.StatusOK)
 }
 
 // Reorder todos
 // @Summary Reorder todos
-// @Description Update the order of todos for the authenticated user.
+// @Description Update the order of todos for the authenticated user. Subtasks are ordered within their parent, so all the todos must have the same parent, or none.
 // @Tags todos
 // @Accept  json
 // @Produce  json
 // @Param ids body object true "List of todo IDs in new order"
 // @Success 200 {object} map[string]bool
 // @Failure 400 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
 // @Failure 500 {object} httputil.APIError
 // @Router /api/todos/reorder [put]
 func (h *Handler) Reorder(w http.ResponseWriter, r *http.Request) {
@@ -420,70 +598,42 @@ func (h *Handler) Reorder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tx, err := h.db.Begin(r.Context())
-	if err != nil {
-		httputil.InternalError(w, err.Error())
-		return
-	}
-	defer tx.Rollback(r.Context())
-
+	// Subtasks are ordered within their parent, which all todos must share
+	var parentID *string
 	for i, id := range req.IDs {
-		pos := float64(i) * models.PositionIncrement
-
-		// Check if this is a default task
-		var isDefaultTask bool
-		err := tx.QueryRow(r.Context(), `SELECT is_default_task FROM todos WHERE id=$1`, id).Scan(&isDefaultTask)
-		if err != nil {
-			httputil.InternalError(w, err.Error())
+		t, err := h.todos.GetUserTodo(r.Context(), id, userID)
+		if err != nil || !canView(t, userID) {
+			httputil.NotFound(w, "todo not found")
 			return
 		}
-
-		if isDefaultTask {
-			// For default tasks, UPSERT into user_todo_state
-			_, err := tx.Exec(r.Context(), `
-				INSERT INTO user_todo_state (user_id, todo_id, status, position, updated_at)
-				VALUES ($1, $2, 
-					(SELECT COALESCE(status, 'pending') FROM user_todo_state WHERE user_id=$1 AND todo_id=$2),
-					$3, now())
-				ON CONFLICT (user_id, todo_id) 
-				DO UPDATE SET position = $3, updated_at = now()
-			`, userID, id, pos)
-			if err != nil {
-				httputil.InternalError(w, err.Error())
-				return
-			}
-		} else {
-			// For personal todos, update todos table
-			_, err := tx.Exec(r.Context(), `
-				UPDATE todos 
-				SET position = $1 
-				WHERE id = $2 AND user_id = $3
-			`, pos, id, userID)
-			if err != nil {
-				httputil.InternalError(w, err.Error())
-				return
-			}
+		if i > 0 && !sameParent(t.ParentID, parentID) {
+			httputil.BadRequest(w, "todos must have the same parent")
+			return
 		}
+		parentID = t.ParentID
 	}
 
-	if err := tx.Commit(r.Context()); err != nil {
+	if err := h.todos.ReorderTodos(r.Context(), userID, req.IDs); err != nil {
 		httputil.InternalError(w, err.Error())
 		return
 	}
+	h.audit.Record(r.Context(), audit.Event{ActorID: userID, Action: models.AuditTodoReorder, UserID: userID, After: req})
 
 	httputil.WriteSuccess(w)
 }
 
 // Delete todo
 // @Summary Delete todo
-// @Description Delete a todo item. Regular users can only delete their own non-admin-assigned tasks.
+// @Description Move a todo item, with its subtasks, to the trash. Regular users can only delete their own non-admin-assigned tasks. With If-Match, the delete fails with 412 if the todo has changed since it was read.
 // @Tags todos
 // @Produce  json
 // @Param id path string true "Todo ID"
+// @Param If-Match header string false "ETag of the todo as last read"
 // @Success 200 {object} map[string]bool
 // @Failure 400 {object} httputil.APIError
 // @Failure 403 {object} httputil.APIError
 // @Failure 404 {object} httputil.APIError
+// @Failure 412 {object} httputil.APIError
 // @Failure 500 {object} httputil.APIError
 // @Router /api/todos/{id} [delete]
 func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
@@ -502,48 +652,54 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if todo is a default task and who created it
-	var isDefaultTask bool
-	var todoUserID *string
-	var createdByUserID *string
-	err := h.db.QueryRow(r.Context(), `
-		SELECT is_default_task, user_id, created_by_user_id 
-		FROM todos 
-		WHERE id=$1
-	`, id).Scan(&isDefaultTask, &todoUserID, &createdByUserID)
-
+	t, err := h.todos.GetTodo(r.Context(), id)
 	if err != nil {
 		httputil.NotFound(w, "todo not found")
 		return
 	}
 
 	// Only admins can delete default tasks
-	if isDefaultTask && userRole != "admin" {
+	if t.IsDefaultTask && userRole != "admin" {
 		httputil.Forbidden(w, "forbidden: only admins can delete default tasks")
 		return
 	}
 
 	// Regular users can only delete their own todos (where they are the owner)
-	if !isDefaultTask && (todoUserID == nil || *todoUserID != userID) {
+	if !t.IsDefaultTask && (t.UserID == nil || *t.UserID != userID) {
 		httputil.Forbidden(w, "forbidden")
 		return
 	}
 
 	// Users can only delete todos they created themselves (not admin-created tasks)
-	if !isDefaultTask && userRole != "admin" {
-		if createdByUserID != nil && *createdByUserID != userID {
+	if !t.IsDefaultTask && userRole != "admin" {
+		if t.CreatedByUserID != nil && *t.CreatedByUserID != userID {
 			httputil.Forbidden(w, "forbidden: cannot delete admin-assigned task")
 			return
 		}
 	}
 
-	cmd, err := h.db.Exec(r.Context(), `DELETE FROM todos WHERE id=$1`, id)
-	if err != nil {
-		httputil.InternalError(w, err.Error())
+	// Refuse to delete changes the client hasn't seen
+	if !httputil.IfMatch(r, t.ETag()) {
+		httputil.PreconditionFailed(w, "todo has been changed by someone else")
 		return
 	}
-	if cmd.RowsAffected() == 0 {
-		httputil.NotFound(w, "todo not found")
+	var ifVersion *int
+	if httputil.IsConditional(r) {
+		ifVersion = &t.Version
+	}
+
+	if err := h.todos.DeleteTodo(r.Context(), id, ifVersion); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			httputil.NotFound(w, "todo not found")
+			return
+		}
+		if errors.Is(err, store.ErrConflict) {
+			httputil.PreconditionFailed(w, "todo has been changed by someone else")
+			return
+		}
+		httputil.InternalError(w, err.Error())
 		return
 	}
+	h.audit.Record(r.Context(), audit.Event{ActorID: userID, Action: models.AuditTodoDelete, UserID: audit.TodoOwner(t, ""), TodoID: id, Before: t})
 	httputil.WriteSuccess(w)
 }
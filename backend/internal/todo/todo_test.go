@@ -2,14 +2,21 @@ package todo
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/akhilmk/packup/internal/auth"
 	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+	"github.com/akhilmk/packup/internal/store/memory"
 )
 
 // Test structs for request/response validation
@@ -84,7 +91,7 @@ func TestTodoStruct(t *testing.T) {
 
 // TestListEndpointUnauthorized tests that List fails without auth
 func TestListEndpointUnauthorized(t *testing.T) {
-	handler := &Handler{db: nil} // nil db since we're testing auth check
+	handler := &Handler{} // no store since we're testing auth check
 
 	req := httptest.NewRequest("GET", "/api/todos", nil)
 	w := httptest.NewRecorder()
@@ -98,7 +105,7 @@ func TestListEndpointUnauthorized(t *testing.T) {
 
 // TestCreateEndpointUnauthorized tests that Create fails without auth
 func TestCreateEndpointUnauthorized(t *testing.T) {
-	handler := &Handler{db: nil}
+	handler := &Handler{}
 
 	body := bytes.NewBufferString(`{"text":"Test todo"}`)
 	req := httptest.NewRequest("POST", "/api/todos", body)
@@ -115,7 +122,7 @@ func TestCreateEndpointUnauthorized(t *testing.T) {
 // TestCreateTextValidation tests text validation in Create
 func TestCreateTextValidation(t *testing.T) {
 	t.Run("Empty text", func(t *testing.T) {
-		handler := &Handler{db: nil}
+		handler := &Handler{}
 
 		body := bytes.NewBufferString(`{"text":""}`)
 		req := httptest.NewRequest("POST", "/api/todos", body)
@@ -134,7 +141,7 @@ func TestCreateTextValidation(t *testing.T) {
 	})
 
 	t.Run("Text over 200 characters", func(t *testing.T) {
-		handler := &Handler{db: nil}
+		handler := &Handler{}
 
 		longText := make([]byte, 201)
 		for i := range longText {
@@ -160,7 +167,7 @@ func TestCreateTextValidation(t *testing.T) {
 
 // TestUpdateEndpointUnauthorized tests that Update fails without auth
 func TestUpdateEndpointUnauthorized(t *testing.T) {
-	handler := &Handler{db: nil}
+	handler := &Handler{}
 
 	body := bytes.NewBufferString(`{"status":"done"}`)
 	req := httptest.NewRequest("PUT", "/api/todos/123", body)
@@ -204,7 +211,7 @@ func TestStatusValidation(t *testing.T) {
 
 // TestReorderEndpointUnauthorized tests that Reorder fails without auth
 func TestReorderEndpointUnauthorized(t *testing.T) {
-	handler := &Handler{db: nil}
+	handler := &Handler{}
 
 	body := bytes.NewBufferString(`{"ids":["1","2","3"]}`)
 	req := httptest.NewRequest("PUT", "/api/todos/reorder", body)
@@ -220,7 +227,7 @@ func TestReorderEndpointUnauthorized(t *testing.T) {
 
 // TestDeleteEndpointUnauthorized tests that Delete fails without auth
 func TestDeleteEndpointUnauthorized(t *testing.T) {
-	handler := &Handler{db: nil}
+	handler := &Handler{}
 
 	req := httptest.NewRequest("DELETE", "/api/todos/123", nil)
 	w := httptest.NewRecorder()
@@ -278,3 +285,1337 @@ func TestSharedWithAdminDefault(t *testing.T) {
 		}
 	})
 }
+
+// newTestServer returns a mux serving the todo routes backed by an in-memory store.
+func newTestServer() (*http.ServeMux, *memory.Store) {
+	db := memory.New()
+	mux := http.NewServeMux()
+	NewHandler(db, db).RegisterRoutes(mux, func(next http.HandlerFunc) http.HandlerFunc { return next })
+	return mux, db
+}
+
+// do performs a request as the given user and returns the recorder.
+func do(mux *http.ServeMux, method, path, body, userID, role string) *httptest.ResponseRecorder {
+	return doIfMatch(mux, method, path, body, userID, role, "")
+}
+
+// doIfMatch is like do, adding an If-Match header when etag is set.
+func doIfMatch(mux *http.ServeMux, method, path, body, userID, role, etag string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	if etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
+	req = req.WithContext(auth.SetUserContext(req.Context(), userID, role))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+// listTodos returns the user's todo list, failing the test on error.
+func listTodos(t *testing.T, mux *http.ServeMux, userID string) []models.Todo {
+	t.Helper()
+	w := do(mux, "GET", "/api/todos", "", userID, "user")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d listing todos, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var resp struct {
+		Todos []models.Todo `json:"todos"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode list response: %v", err)
+	}
+	return resp.Todos
+}
+
+// seedTodo inserts a todo directly into the store.
+func seedTodo(t *testing.T, db *memory.Store, todo models.Todo) models.Todo {
+	t.Helper()
+	if todo.Status == "" {
+		todo.Status = string(models.StatusPending)
+	}
+	if todo.Created.IsZero() {
+		todo.Created = time.Now()
+	}
+	if err := db.CreateTodo(context.Background(), &todo); err != nil {
+		t.Fatalf("Failed to seed todo: %v", err)
+	}
+	return todo
+}
+
+func strPtr(s string) *string { return &s }
+
+// TestPersonalTodoLifecycle tests create, update and delete of a personal todo
+func TestPersonalTodoLifecycle(t *testing.T) {
+	mux, _ := newTestServer()
+
+	w := do(mux, "POST", "/api/todos", `{"text":"Pack passport"}`, "user-1", "user")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created models.Todo
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if !created.SharedWithAdmin {
+		t.Error("Expected new todo to be shared with admin by default")
+	}
+
+	w = do(mux, "PUT", "/api/todos/"+created.ID, `{"text":"Pack passport and visa","status":"in-progress"}`, "user-1", "user")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var updated models.Todo
+	json.Unmarshal(w.Body.Bytes(), &updated)
+	if updated.Text != "Pack passport and visa" || updated.Status != "in-progress" {
+		t.Errorf("Unexpected updated todo: %+v", updated)
+	}
+
+	if todos := listTodos(t, mux, "user-2"); len(todos) != 0 {
+		t.Errorf("Expected other user to see no todos, got %d", len(todos))
+	}
+
+	w = do(mux, "DELETE", "/api/todos/"+created.ID, "", "user-1", "user")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if todos := listTodos(t, mux, "user-1"); len(todos) != 0 {
+		t.Errorf("Expected no todos after delete, got %d", len(todos))
+	}
+}
+
+// TestUpdatePermissions tests who may change which fields of a todo
+func TestUpdatePermissions(t *testing.T) {
+	mux, db := newTestServer()
+
+	own := seedTodo(t, db, models.Todo{ID: "own", Text: "Mine", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+	assigned := seedTodo(t, db, models.Todo{ID: "assigned", Text: "From admin", UserID: strPtr("user-1"), CreatedByUserID: strPtr("admin-1"), SharedWithAdmin: true})
+	def := seedTodo(t, db, models.Todo{ID: "default", Text: "Everyone", IsDefaultTask: true, CreatedByUserID: strPtr("admin-1")})
+
+	tests := []struct {
+		name     string
+		id       string
+		body     string
+		userID   string
+		expected int
+	}{
+		{"Owner edits text", own.ID, `{"text":"Still mine"}`, "user-1", http.StatusOK},
+		{"Other user edits text", own.ID, `{"text":"Not mine"}`, "user-2", http.StatusForbidden},
+		{"User edits text of admin-assigned task", assigned.ID, `{"text":"Changed"}`, "user-1", http.StatusForbidden},
+		{"User changes sharing of admin-assigned task", assigned.ID, `{"shared_with_admin":false}`, "user-1", http.StatusForbidden},
+		{"User updates status of admin-assigned task", assigned.ID, `{"status":"done"}`, "user-1", http.StatusOK},
+		{"User updates status of default task", def.ID, `{"status":"done"}`, "user-1", http.StatusOK},
+		{"Invalid status", own.ID, `{"status":"finished"}`, "user-1", http.StatusBadRequest},
+		{"Missing todo", "missing", `{"status":"done"}`, "user-1", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "PUT", "/api/todos/"+tt.id, tt.body, tt.userID, "user")
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	t.Run("Default task status is tracked per user", func(t *testing.T) {
+		got, _ := db.GetUserTodo(context.Background(), def.ID, "user-1")
+		if got.Status != "done" {
+			t.Errorf("Expected user-1 status 'done', got '%s'", got.Status)
+		}
+		got, _ = db.GetUserTodo(context.Background(), def.ID, "user-2")
+		if got.Status != "pending" {
+			t.Errorf("Expected user-2 status 'pending', got '%s'", got.Status)
+		}
+	})
+}
+
+// TestDeletePermissions tests who may delete which todos
+func TestDeletePermissions(t *testing.T) {
+	tests := []struct {
+		name     string
+		todo     models.Todo
+		userID   string
+		role     string
+		expected int
+	}{
+		{"Owner deletes own todo", models.Todo{UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")}, "user-1", "user", http.StatusOK},
+		{"Other user deletes todo", models.Todo{UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")}, "user-2", "user", http.StatusForbidden},
+		{"User deletes admin-assigned task", models.Todo{UserID: strPtr("user-1"), CreatedByUserID: strPtr("admin-1")}, "user-1", "user", http.StatusForbidden},
+		{"User deletes default task", models.Todo{IsDefaultTask: true, CreatedByUserID: strPtr("admin-1")}, "user-1", "user", http.StatusForbidden},
+		{"Admin deletes default task", models.Todo{IsDefaultTask: true, CreatedByUserID: strPtr("admin-1")}, "admin-1", "admin", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux, db := newTestServer()
+			tt.todo.ID = "todo-1"
+			tt.todo.Text = "Task"
+			seedTodo(t, db, tt.todo)
+
+			w := do(mux, "DELETE", "/api/todos/todo-1", "", tt.userID, tt.role)
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestListVisibility tests which todos appear in a user's list
+func TestListVisibility(t *testing.T) {
+	mux, db := newTestServer()
+
+	seedTodo(t, db, models.Todo{ID: "own", Text: "Mine", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+	seedTodo(t, db, models.Todo{ID: "hidden", Text: "Admin notes", UserID: strPtr("user-1"), CreatedByUserID: strPtr("admin-1"), HiddenFromUser: true})
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Everyone", IsDefaultTask: true, CreatedByUserID: strPtr("admin-1")})
+	seedTodo(t, db, models.Todo{ID: "other", Text: "Theirs", UserID: strPtr("user-2"), CreatedByUserID: strPtr("user-2")})
+
+	ids := map[string]bool{}
+	for _, todo := range listTodos(t, mux, "user-1") {
+		ids[todo.ID] = true
+	}
+	if !ids["own"] || !ids["default"] {
+		t.Errorf("Expected own and default todos, got %v", ids)
+	}
+	if ids["hidden"] || ids["other"] {
+		t.Errorf("Expected hidden and other users' todos to be excluded, got %v", ids)
+	}
+}
+
+// TestReorderDefaultTask tests that reordering stores a per-user position
+func TestReorderDefaultTask(t *testing.T) {
+	mux, db := newTestServer()
+
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Everyone", IsDefaultTask: true})
+	seedTodo(t, db, models.Todo{ID: "own", Text: "Mine", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+	seedTodo(t, db, models.Todo{ID: "draft", Text: "Draft", IsDefaultTask: true, Draft: true})
+
+	w := do(mux, "PUT", "/api/todos/reorder", `{"ids":["own","default"]}`, "user-1", "user")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if w := do(mux, "PUT", "/api/todos/reorder", `{"ids":["draft"]}`, "user-1", "user"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d reordering an unpublished task, got %d", http.StatusNotFound, w.Code)
+	}
+
+	todos := listTodos(t, mux, "user-1")
+	if len(todos) != 2 || todos[0].ID != "own" || todos[1].ID != "default" {
+		t.Errorf("Unexpected order after reorder: %+v", todos)
+	}
+	if todos[1].Status != "pending" {
+		t.Errorf("Expected reordered default task to stay pending, got '%s'", todos[1].Status)
+	}
+
+	// Another user's order is unaffected
+	got, _ := db.GetUserTodo(context.Background(), "default", "user-2")
+	if got.Position != -models.PositionIncrement {
+		t.Errorf("Expected global position for user-2, got %v", got.Position)
+	}
+}
+
+// TestTrash tests deleting, listing and restoring personal todos
+func TestTrash(t *testing.T) {
+	mux, db := newTestServer()
+
+	seedTodo(t, db, models.Todo{ID: "own", Text: "Mine", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+	seedTodo(t, db, models.Todo{ID: "other", Text: "Theirs", UserID: strPtr("user-2"), CreatedByUserID: strPtr("user-2")})
+	seedTodo(t, db, models.Todo{ID: "assigned", Text: "Assigned", UserID: strPtr("user-1"), CreatedByUserID: strPtr("admin-1")})
+
+	if w := do(mux, "DELETE", "/api/todos/own", "", "user-1", "user"); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	db.DeleteTodo(context.Background(), "other", nil)
+	db.DeleteTodo(context.Background(), "assigned", nil)
+
+	if todos := listTodos(t, mux, "user-1"); len(todos) != 0 {
+		t.Errorf("Expected deleted todos to leave the list, got %+v", todos)
+	}
+
+	w := do(mux, "GET", "/api/todos/trash", "", "user-1", "user")
+	var resp struct {
+		Todos []models.Todo `json:"todos"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Todos) != 2 {
+		t.Errorf("Expected 2 todos in user-1's trash, got %+v", resp.Todos)
+	}
+
+	tests := []struct {
+		name     string
+		todoID   string
+		userID   string
+		expected int
+	}{
+		{"Other user's todo", "other", "user-1", http.StatusForbidden},
+		{"Admin-assigned task", "assigned", "user-1", http.StatusForbidden},
+		{"Own todo", "own", "user-1", http.StatusOK},
+		{"Already restored", "own", "user-1", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "POST", "/api/todos/"+tt.todoID+"/restore", "", tt.userID, "user")
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	if todos := listTodos(t, mux, "user-1"); len(todos) != 1 || todos[0].ID != "own" {
+		t.Errorf("Expected restored todo back in the list, got %+v", todos)
+	}
+}
+
+// TestHistory tests viewing a todo's revision history
+func TestHistory(t *testing.T) {
+	mux, db := newTestServer()
+
+	seedTodo(t, db, models.Todo{ID: "own", Text: "Mine", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+	seedTodo(t, db, models.Todo{ID: "other", Text: "Theirs", UserID: strPtr("user-2"), CreatedByUserID: strPtr("user-2")})
+	seedTodo(t, db, models.Todo{ID: "hidden", Text: "Hidden", UserID: strPtr("user-1"), CreatedByUserID: strPtr("admin-1"), HiddenFromUser: true})
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Default", IsDefaultTask: true})
+
+	if w := do(mux, "PUT", "/api/todos/own", `{"status":"done"}`, "user-1", "user"); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		name     string
+		todoID   string
+		expected int
+	}{
+		{"Own todo", "own", http.StatusOK},
+		{"Default task", "default", http.StatusOK},
+		{"Other user's todo", "other", http.StatusForbidden},
+		{"Hidden todo", "hidden", http.StatusForbidden},
+		{"Missing todo", "missing", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "GET", "/api/todos/"+tt.todoID+"/history", "", "user-1", "user")
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	w := do(mux, "GET", "/api/todos/own/history", "", "user-1", "user")
+	var resp struct {
+		Revisions []models.TodoRevision `json:"revisions"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Revisions) != 2 {
+		t.Fatalf("Expected 2 revisions, got %+v", resp.Revisions)
+	}
+	change, ok := resp.Revisions[1].Changes["status"]
+	if !ok || change.From != "pending" || change.To != "done" || len(resp.Revisions[1].Changes) != 1 {
+		t.Errorf("Expected only a status change from pending to done, got %+v", resp.Revisions[1].Changes)
+	}
+	if by := resp.Revisions[1].ChangedBy; by == nil || *by != "user-1" {
+		t.Errorf("Expected the change to be attributed to user-1, got %v", by)
+	}
+}
+
+// TestConditionalUpdates tests ETags on reads and If-Match on updates and deletes
+func TestConditionalUpdates(t *testing.T) {
+	mux, db := newTestServer()
+
+	seedTodo(t, db, models.Todo{ID: "own", Text: "Mine", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Default", IsDefaultTask: true})
+
+	w := do(mux, "GET", "/api/todos/own", "", "user-1", "user")
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"1"` {
+		t.Fatalf("Expected status %d with ETag \"1\", got %d with %q", http.StatusOK, w.Code, w.Header().Get("ETag"))
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		todoID   string
+		body     string
+		etag     string
+		expected int
+		newETag  string
+	}{
+		{"Matching version", "PUT", "own", `{"status":"done"}`, `"1"`, http.StatusOK, `"2"`},
+		{"Stale version", "PUT", "own", `{"status":"pending"}`, `"1"`, http.StatusPreconditionFailed, ""},
+		{"One of several versions", "PUT", "own", `{"status":"pending"}`, `"1", "2"`, http.StatusOK, `"3"`},
+		{"Any version", "PUT", "own", `{"status":"done"}`, "*", http.StatusOK, `"4"`},
+		{"No precondition", "PUT", "own", `{"status":"pending"}`, "", http.StatusOK, `"5"`},
+		{"Default task", "PUT", "default", `{"status":"done"}`, `"1"`, http.StatusOK, `"1.1"`},
+		{"Stale default task", "PUT", "default", `{"status":"pending"}`, `"1"`, http.StatusPreconditionFailed, ""},
+		{"Default task status", "PUT", "default", `{"status":"pending"}`, `"1.1"`, http.StatusOK, `"1.2"`},
+		{"Stale delete", "DELETE", "own", "", `"4"`, http.StatusPreconditionFailed, ""},
+		{"Delete", "DELETE", "own", "", `"5"`, http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doIfMatch(mux, tt.method, "/api/todos/"+tt.todoID, tt.body, "user-1", "user", tt.etag)
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+			if got := w.Header().Get("ETag"); got != tt.newETag {
+				t.Errorf("Expected ETag %q, got %q", tt.newETag, got)
+			}
+		})
+	}
+
+	got, _ := db.GetUserTodo(context.Background(), "default", "user-1")
+	if got.Status != "pending" {
+		t.Errorf("Expected the stale update to be rejected, got status '%s'", got.Status)
+	}
+
+	// An admin edit of the task makes the user's ETag stale too
+	text := "Edited"
+	if err := db.UpdateTodo(context.Background(), "default", store.TodoUpdate{Text: &text}); err != nil {
+		t.Fatalf("Failed to edit the default task: %v", err)
+	}
+	body := `{"status":"in-progress","due_at":"2030-01-01T00:00:00Z"}`
+	if w := doIfMatch(mux, "PUT", "/api/todos/default", body, "user-1", "user", `"1.2"`); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected status %d after an admin edit, got %d: %s", http.StatusPreconditionFailed, w.Code, w.Body.String())
+	}
+	if got, _ = db.GetUserTodo(context.Background(), "default", "user-1"); got.Status != "pending" || got.DueAt != nil {
+		t.Errorf("Expected the stale update to change nothing, got %s due %v", got.Status, got.DueAt)
+	}
+	if w := doIfMatch(mux, "PUT", "/api/todos/default", body, "user-1", "user", `"2.2"`); w.Code != http.StatusOK || w.Header().Get("ETag") != `"2.4"` {
+		t.Errorf("Expected status %d with ETag \"2.4\", got %d with %q", http.StatusOK, w.Code, w.Header().Get("ETag"))
+	}
+}
+
+// TestSearch tests searching the user's own list
+func TestSearch(t *testing.T) {
+	mux, db := newTestServer()
+
+	seedTodo(t, db, models.Todo{ID: "own", Text: "Renew passport", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+	seedTodo(t, db, models.Todo{ID: "other", Text: "Passport copy", UserID: strPtr("user-2"), CreatedByUserID: strPtr("user-2"), SharedWithAdmin: true})
+	seedTodo(t, db, models.Todo{ID: "hidden", Text: "Check passport", UserID: strPtr("user-1"), CreatedByUserID: strPtr("admin-1"), HiddenFromUser: true})
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Bring passport", IsDefaultTask: true})
+
+	tests := []struct {
+		name     string
+		query    string
+		role     string
+		expected int
+		results  int
+	}{
+		{"User", "?q=passport", "user", http.StatusOK, 2},
+		{"Admin without default tasks", "?q=passport", "admin", http.StatusOK, 2},
+		{"Limit", "?q=passport&limit=1", "user", http.StatusOK, 1},
+		{"Missing query", "?q=+", "user", http.StatusBadRequest, 0},
+		{"Invalid limit", "?q=passport&limit=0", "user", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "GET", "/api/todos/search"+tt.query, "", "user-1", tt.role)
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+			var resp struct {
+				Todos []models.Todo `json:"todos"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			if len(resp.Todos) != tt.results {
+				t.Errorf("Expected %d results, got %+v", tt.results, resp.Todos)
+			}
+		})
+	}
+}
+
+// TestListFilters tests filtering and paging the todo list
+func TestListFilters(t *testing.T) {
+	mux, db := newTestServer()
+
+	old := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	seedTodo(t, db, models.Todo{ID: "own", Text: "Own", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1"), Created: old})
+	seedTodo(t, db, models.Todo{ID: "done", Text: "Done", Status: string(models.StatusDone), UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+	seedTodo(t, db, models.Todo{ID: "added", Text: "Added", UserID: strPtr("user-1"), CreatedByUserID: strPtr("admin-1")})
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Default", IsDefaultTask: true})
+
+	tests := []struct {
+		name     string
+		query    string
+		expected int
+		todos    int
+	}{
+		{"Everything", "", http.StatusOK, 4},
+		{"Status", "?status=done", http.StatusOK, 1},
+		{"Default source", "?source=default", http.StatusOK, 1},
+		{"Admin source", "?source=admin", http.StatusOK, 1},
+		{"Personal source", "?source=personal", http.StatusOK, 2},
+		{"Created from", "?created_from=2025-01-01T00:00:00Z", http.StatusOK, 3},
+		{"Created to", "?created_to=2025-01-01T00:00:00Z", http.StatusOK, 1},
+		{"Invalid status", "?status=archived", http.StatusBadRequest, 0},
+		{"Invalid source", "?source=other", http.StatusBadRequest, 0},
+		{"Invalid time", "?created_from=yesterday", http.StatusBadRequest, 0},
+		{"Invalid cursor", "?cursor=nope", http.StatusBadRequest, 0},
+		{"Invalid limit", "?limit=501", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "GET", "/api/todos"+tt.query, "", "user-1", "user")
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+			var resp struct {
+				Todos []models.Todo `json:"todos"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			if len(resp.Todos) != tt.todos {
+				t.Errorf("Expected %d todos, got %+v", tt.todos, resp.Todos)
+			}
+		})
+	}
+
+	// Following next_cursor visits every todo once
+	seen := map[string]bool{}
+	path := "/api/todos?limit=3"
+	for pages := 0; path != ""; pages++ {
+		if pages == 2 {
+			t.Fatal("Expected two pages")
+		}
+		w := do(mux, "GET", path, "", "user-1", "user")
+		var resp struct {
+			Todos      []models.Todo `json:"todos"`
+			NextCursor string        `json:"next_cursor"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		for _, todo := range resp.Todos {
+			if seen[todo.ID] {
+				t.Errorf("Todo %s listed twice", todo.ID)
+			}
+			seen[todo.ID] = true
+		}
+		path = ""
+		if resp.NextCursor != "" {
+			path = "/api/todos?limit=3&cursor=" + resp.NextCursor
+		}
+	}
+	if len(seen) != 4 {
+		t.Errorf("Expected 4 todos across pages, got %v", seen)
+	}
+}
+
+// TestDueDates tests setting due dates and listing overdue todos
+func TestDueDates(t *testing.T) {
+	mux, db := newTestServer()
+
+	past := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	future := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	seedTodo(t, db, models.Todo{ID: "own", Text: "Own", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+	seedTodo(t, db, models.Todo{ID: "added", Text: "Added", UserID: strPtr("user-1"), CreatedByUserID: strPtr("admin-1"), DueAt: &future})
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Default", IsDefaultTask: true, DueAt: &future})
+
+	tests := []struct {
+		name     string
+		id       string
+		body     string
+		expected int
+		due      *time.Time
+	}{
+		{"Set own due date", "own", `{"due_at":"` + past.Format(time.RFC3339) + `"}`, http.StatusOK, &past},
+		{"Override default task", "default", `{"due_at":"` + past.Format(time.RFC3339) + `"}`, http.StatusOK, &past},
+		{"Admin-assigned task", "added", `{"due_at":"` + past.Format(time.RFC3339) + `"}`, http.StatusForbidden, &future},
+		{"Set and clear", "own", `{"due_at":"` + past.Format(time.RFC3339) + `","clear_due_at":true}`, http.StatusBadRequest, &past},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "PUT", "/api/todos/"+tt.id, tt.body, "user-1", "user")
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+			todo, _ := db.GetUserTodo(context.Background(), tt.id, "user-1")
+			if todo.DueAt == nil || !todo.DueAt.Equal(*tt.due) {
+				t.Errorf("Expected due date %v, got %v", tt.due, todo.DueAt)
+			}
+		})
+	}
+
+	// Both the own todo and the user's default task are now overdue
+	w := do(mux, "GET", "/api/todos?overdue=true", "", "user-1", "user")
+	var resp struct {
+		Todos []models.Todo `json:"todos"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Todos) != 2 || !resp.Todos[0].Overdue || !resp.Todos[1].Overdue {
+		t.Errorf("Expected 2 overdue todos, got %+v", resp.Todos)
+	}
+
+	// Other users keep the task's own due date
+	if todo, _ := db.GetUserTodo(context.Background(), "default", "user-2"); todo.DueAt == nil || !todo.DueAt.Equal(future) {
+		t.Errorf("Expected user-2 to keep due date %v, got %v", future, todo.DueAt)
+	}
+
+	// Clearing the override restores the task's own due date
+	w = do(mux, "PUT", "/api/todos/default", `{"clear_due_at":true}`, "user-1", "user")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if todo, _ := db.GetUserTodo(context.Background(), "default", "user-1"); todo.DueAt == nil || !todo.DueAt.Equal(future) {
+		t.Errorf("Expected due date %v after clearing, got %v", future, todo.DueAt)
+	}
+
+	w = do(mux, "GET", "/api/todos?sort=due", "", "user-1", "user")
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Todos) != 3 || resp.Todos[0].ID != "own" {
+		t.Errorf("Expected the overdue todo first, got %+v", resp.Todos)
+	}
+}
+
+// TestSubtasks tests creating, reordering and restoring subtasks
+func TestSubtasks(t *testing.T) {
+	mux, db := newTestServer()
+
+	seedTodo(t, db, models.Todo{ID: "bag", Text: "Bag", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1"), SharedWithAdmin: true})
+	seedTodo(t, db, models.Todo{ID: "private", Text: "Private", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+	seedTodo(t, db, models.Todo{ID: "other", Text: "Other", UserID: strPtr("user-2"), CreatedByUserID: strPtr("user-2")})
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Default", IsDefaultTask: true})
+	seedTodo(t, db, models.Todo{ID: "draft", Text: "Draft", IsDefaultTask: true, Draft: true})
+	seedTodo(t, db, models.Todo{ID: "socks", Text: "Socks", Status: string(models.StatusDone), UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1"), ParentID: strPtr("bag")})
+
+	tests := []struct {
+		name     string
+		body     string
+		expected int
+		shared   bool
+	}{
+		{"Own todo", `{"text":"Shirts","parent_id":"bag"}`, http.StatusCreated, true},
+		{"Inherits sharing", `{"text":"Notes","parent_id":"private"}`, http.StatusCreated, false},
+		{"Explicit sharing", `{"text":"Notes","parent_id":"private","shared_with_admin":true}`, http.StatusCreated, true},
+		{"Default task", `{"text":"Mine","parent_id":"default"}`, http.StatusForbidden, false},
+		{"Other user's todo", `{"text":"Mine","parent_id":"other"}`, http.StatusBadRequest, false},
+		{"Missing parent", `{"text":"Mine","parent_id":"nope"}`, http.StatusBadRequest, false},
+		{"Unpublished default task", `{"text":"Mine","parent_id":"draft"}`, http.StatusBadRequest, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "POST", "/api/todos", tt.body, "user-1", "user")
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusCreated {
+				return
+			}
+			var created models.Todo
+			json.Unmarshal(w.Body.Bytes(), &created)
+			if created.ParentID == nil || created.SharedWithAdmin != tt.shared {
+				t.Errorf("Expected a subtask shared=%v, got %+v", tt.shared, created)
+			}
+		})
+	}
+
+	// The parent rolls up its subtasks' progress
+	w := do(mux, "GET", "/api/todos/bag", "", "user-1", "user")
+	var bag models.Todo
+	json.Unmarshal(w.Body.Bytes(), &bag)
+	if bag.Subtasks == nil || *bag.Subtasks != (models.Subtasks{Total: 2, Done: 1, Status: models.StatusInProgress}) {
+		t.Errorf("Expected 1 of 2 subtasks done, got %+v", bag.Subtasks)
+	}
+
+	w = do(mux, "GET", "/api/todos?parent_id=bag", "", "user-1", "user")
+	var resp struct {
+		Todos []models.Todo `json:"todos"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Todos) != 2 {
+		t.Fatalf("Expected bag's 2 subtasks, got %+v", resp.Todos)
+	}
+
+	// Subtasks are reordered within their parent only
+	w = do(mux, "PUT", "/api/todos/reorder", `{"ids":["socks","bag"]}`, "user-1", "user")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 reordering across parents, got %d", w.Code)
+	}
+	w = do(mux, "PUT", "/api/todos/reorder", `{"ids":["socks","`+resp.Todos[0].ID+`"]}`, "user-1", "user")
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 reordering subtasks, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// A subtask deleted with its parent comes back with it
+	if w := do(mux, "DELETE", "/api/todos/bag", "", "user-1", "user"); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 deleting, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(mux, "POST", "/api/todos/socks/restore", "", "user-1", "user"); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 restoring a subtask of a trashed todo, got %d", w.Code)
+	}
+	if w := do(mux, "POST", "/api/todos/bag/restore", "", "user-1", "user"); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 restoring, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := db.GetTodo(context.Background(), "socks"); err != nil {
+		t.Errorf("Expected socks restored with bag, got %v", err)
+	}
+}
+
+// TestTags tests tagging todos and filtering by tag
+func TestTags(t *testing.T) {
+	mux, db := newTestServer()
+
+	seedTodo(t, db, models.Todo{ID: "own", Text: "Own", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+	seedTodo(t, db, models.Todo{ID: "added", Text: "Added", UserID: strPtr("user-1"), CreatedByUserID: strPtr("admin-1"), Tags: []string{"work"}})
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Default", IsDefaultTask: true, Tags: []string{"work"}})
+
+	tests := []struct {
+		name     string
+		id       string
+		body     string
+		expected int
+		tags     []string
+	}{
+		{"Tag own todo", "own", `{"tags":["Travel"," docs ","travel"]}`, http.StatusOK, []string{"docs", "travel"}},
+		{"Invalid tag", "own", `{"tags":["a,b"]}`, http.StatusBadRequest, []string{"docs", "travel"}},
+		{"Admin-assigned task", "added", `{"tags":["mine"]}`, http.StatusForbidden, []string{"work"}},
+		{"Default task", "default", `{"tags":["mine"]}`, http.StatusForbidden, []string{"work"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "PUT", "/api/todos/"+tt.id, tt.body, "user-1", "user")
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+			todo, _ := db.GetTodo(context.Background(), tt.id)
+			if !slices.Equal(todo.Tags, tt.tags) {
+				t.Errorf("Expected tags %v, got %v", tt.tags, todo.Tags)
+			}
+		})
+	}
+
+	w := do(mux, "POST", "/api/todos", `{"text":"New","tags":["work"]}`, "user-1", "user")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = do(mux, "GET", "/api/todos?tag=work", "", "user-1", "user")
+	var resp struct {
+		Todos []models.Todo `json:"todos"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Todos) != 3 {
+		t.Errorf("Expected 3 todos tagged work, got %+v", resp.Todos)
+	}
+	w = do(mux, "GET", "/api/todos?tag=docs&tag=travel", "", "user-1", "user")
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Todos) != 1 || resp.Todos[0].ID != "own" {
+		t.Errorf("Expected only own tagged docs and travel, got %+v", resp.Todos)
+	}
+	if w := do(mux, "GET", "/api/todos?tag=", "", "user-1", "user"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an empty tag, got %d", w.Code)
+	}
+
+	// Users see the curated palette only
+	db.SaveCuratedTag(context.Background(), models.Tag{Name: "urgent"})
+	w = do(mux, "GET", "/api/tags", "", "user-1", "user")
+	var tags struct {
+		Tags []models.Tag `json:"tags"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &tags)
+	if len(tags.Tags) != 1 || tags.Tags[0].Name != "urgent" {
+		t.Errorf("Expected the palette [urgent], got %+v", tags.Tags)
+	}
+}
+
+func TestPriorities(t *testing.T) {
+	mux, db := newTestServer()
+
+	seedTodo(t, db, models.Todo{ID: "own", Text: "Own", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+	seedTodo(t, db, models.Todo{ID: "added", Text: "Added", UserID: strPtr("user-1"), CreatedByUserID: strPtr("admin-1"), Priority: "high"})
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Default", IsDefaultTask: true})
+
+	tests := []struct {
+		name     string
+		id       string
+		body     string
+		expected int
+		priority string
+	}{
+		{"Own todo", "own", `{"priority":"urgent"}`, http.StatusOK, "urgent"},
+		{"Invalid priority", "own", `{"priority":"asap"}`, http.StatusBadRequest, "urgent"},
+		{"Admin-assigned task", "added", `{"priority":"low"}`, http.StatusForbidden, "high"},
+		{"Default task", "default", `{"priority":"low"}`, http.StatusForbidden, "normal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "PUT", "/api/todos/"+tt.id, tt.body, "user-1", "user")
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+			if todo, _ := db.GetTodo(context.Background(), tt.id); todo.Priority != tt.priority {
+				t.Errorf("Expected priority %s, got %s", tt.priority, todo.Priority)
+			}
+		})
+	}
+
+	w := do(mux, "POST", "/api/todos", `{"text":"New"}`, "user-1", "user")
+	var created models.Todo
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.Priority != "normal" {
+		t.Errorf("Expected new todos to default to normal priority, got %q", created.Priority)
+	}
+	if w := do(mux, "POST", "/api/todos", `{"text":"New","priority":"asap"}`, "user-1", "user"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid priority, got %d", w.Code)
+	}
+
+	w = do(mux, "GET", "/api/todos?sort=priority", "", "user-1", "user")
+	var resp struct {
+		Todos []models.Todo `json:"todos"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	var got []string
+	for _, todo := range resp.Todos {
+		got = append(got, todo.ID)
+	}
+	if len(got) != 4 || got[0] != "own" || got[1] != "added" {
+		t.Errorf("Expected own and added first by priority, got %v", got)
+	}
+}
+
+func TestComments(t *testing.T) {
+	mux, db := newTestServer()
+	ctx := context.Background()
+
+	seedTodo(t, db, models.Todo{ID: "own", Text: "Own", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+	seedTodo(t, db, models.Todo{ID: "other", Text: "Other", UserID: strPtr("user-2"), CreatedByUserID: strPtr("user-2")})
+	seedTodo(t, db, models.Todo{ID: "hidden", Text: "Hidden", UserID: strPtr("user-1"), CreatedByUserID: strPtr("admin-1"), HiddenFromUser: true})
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Default", IsDefaultTask: true})
+
+	tests := []struct {
+		name     string
+		id       string
+		body     string
+		expected int
+	}{
+		{"Own todo", "own", `{"body":"Where do I get this?"}`, http.StatusCreated},
+		{"Default task", "default", `{"body":"Done already"}`, http.StatusCreated},
+		{"Other user's todo", "other", `{"body":"Hi"}`, http.StatusForbidden},
+		{"Hidden task", "hidden", `{"body":"Hi"}`, http.StatusForbidden},
+		{"Missing todo", "missing", `{"body":"Hi"}`, http.StatusNotFound},
+		{"Empty body", "own", `{"body":"  "}`, http.StatusBadRequest},
+		{"Too long", "own", `{"body":"` + strings.Repeat("x", models.MaxCommentLength+1) + `"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "POST", "/api/todos/"+tt.id+"/comments", tt.body, "user-1", "user")
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// Each user has their own thread on a default task
+	do(mux, "POST", "/api/todos/default/comments", `{"body":"Not yet"}`, "user-2", "user")
+	list := func(userID, id string) []models.Comment {
+		t.Helper()
+		w := do(mux, "GET", "/api/todos/"+id+"/comments", "", userID, "user")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d listing comments, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var resp struct {
+			Comments []models.Comment `json:"comments"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Comments
+	}
+	comments := list("user-1", "default")
+	if len(comments) != 1 || comments[0].Body != "Done already" || *comments[0].AuthorID != "user-1" {
+		t.Fatalf("Expected user-1's comment only, got %+v", comments)
+	}
+	if todo, _ := db.GetUserTodo(ctx, "default", "user-2"); todo.CommentCount != 1 {
+		t.Errorf("Expected 1 comment in user-2's thread, got %d", todo.CommentCount)
+	}
+	for _, todo := range listTodos(t, mux, "user-1") {
+		if todo.ID == "own" && todo.CommentCount != 1 {
+			t.Errorf("Expected the list to count 1 comment on own, got %d", todo.CommentCount)
+		}
+	}
+
+	// Only the author can change a comment
+	reply := models.Comment{ID: "reply", TodoID: "own", UserID: "user-1", AuthorID: strPtr("admin-1"), Body: "At the office", CreatedAt: time.Now()}
+	if err := db.CreateComment(ctx, reply); err != nil {
+		t.Fatalf("Failed to seed comment: %v", err)
+	}
+	id := comments[0].ID
+	changes := []struct {
+		name     string
+		method   string
+		path     string
+		expected int
+	}{
+		{"Edit own comment", "PUT", "/api/todos/default/comments/" + id, http.StatusOK},
+		{"Edit admin's reply", "PUT", "/api/todos/own/comments/reply", http.StatusForbidden},
+		{"Delete admin's reply", "DELETE", "/api/todos/own/comments/reply", http.StatusForbidden},
+		{"Comment on another todo", "PUT", "/api/todos/own/comments/" + id, http.StatusNotFound},
+		{"Missing comment", "DELETE", "/api/todos/own/comments/missing", http.StatusNotFound},
+		{"Delete own comment", "DELETE", "/api/todos/default/comments/" + id, http.StatusOK},
+	}
+	for _, tt := range changes {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, tt.method, tt.path, `{"body":"Edited"}`, "user-1", "user")
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+	if comments := list("user-1", "default"); len(comments) != 0 {
+		t.Errorf("Expected the comment deleted, got %+v", comments)
+	}
+}
+
+// upload posts a file as multipart form data as the given user.
+func upload(mux *http.ServeMux, path, filename string, data []byte, userID string) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", filename)
+	part.Write(data)
+	form.Close()
+
+	req := httptest.NewRequest("POST", path, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req = req.WithContext(auth.SetUserContext(req.Context(), userID, "user"))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+func TestAttachments(t *testing.T) {
+	mux, db := newTestServer()
+	ctx := context.Background()
+
+	seedTodo(t, db, models.Todo{ID: "own", Text: "Own", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+	seedTodo(t, db, models.Todo{ID: "other", Text: "Other", UserID: strPtr("user-2"), CreatedByUserID: strPtr("user-2")})
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Submit ID", IsDefaultTask: true})
+
+	pdf := []byte("%PDF-1.4\nscanned passport")
+	png := []byte("\x89PNG\r\n\x1a\nimage data")
+	tests := []struct {
+		name     string
+		id       string
+		filename string
+		data     []byte
+		expected int
+	}{
+		{"PDF on own todo", "own", "passport.pdf", pdf, http.StatusCreated},
+		{"Image on default task", "default", "photo.png", png, http.StatusCreated},
+		{"Other user's todo", "other", "passport.pdf", pdf, http.StatusForbidden},
+		{"Missing todo", "missing", "passport.pdf", pdf, http.StatusNotFound},
+		{"Unsupported type", "own", "page.html", []byte("<html><body>hi</body></html>"), http.StatusUnsupportedMediaType},
+		{"Empty file", "own", "empty.txt", nil, http.StatusBadRequest},
+		{"Too large", "own", "big.txt", bytes.Repeat([]byte("x"), models.MaxAttachmentSize+1), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := upload(mux, "/api/todos/"+tt.id+"/attachments", tt.filename, tt.data, "user-1")
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+	if w := do(mux, "POST", "/api/todos/own/attachments", `{"file":"passport.pdf"}`, "user-1", "user"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a JSON upload, got %d", w.Code)
+	}
+
+	list := func(userID, id string) []models.Attachment {
+		t.Helper()
+		w := do(mux, "GET", "/api/todos/"+id+"/attachments", "", userID, "user")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d listing attachments, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var resp struct {
+			Attachments []models.Attachment `json:"attachments"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Attachments
+	}
+	attachments := list("user-1", "own")
+	if len(attachments) != 1 || attachments[0].Filename != "passport.pdf" || attachments[0].ContentType != "application/pdf" || attachments[0].Size != int64(len(pdf)) {
+		t.Fatalf("Expected the PDF listed, got %+v", attachments)
+	}
+
+	// Files on a default task are only seen by the user who attached them
+	if attachments := list("user-2", "default"); len(attachments) != 0 {
+		t.Errorf("Expected no attachments in user-2's copy, got %+v", attachments)
+	}
+
+	id := attachments[0].ID
+	w := do(mux, "GET", "/api/todos/own/attachments/"+id, "", "user-1", "user")
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), pdf) {
+		t.Fatalf("Expected the PDF downloaded, got %d: %q", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename=passport.pdf` {
+		t.Errorf("Expected a download named passport.pdf, got %q", got)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/pdf" {
+		t.Errorf("Expected content type application/pdf, got %q", got)
+	}
+
+	// Only the uploader can delete an attachment
+	added := models.Attachment{ID: "added", TodoID: "own", UserID: "user-1", UploadedBy: strPtr("admin-1"), Filename: "form.pdf", ContentType: "application/pdf", CreatedAt: time.Now()}
+	if err := db.CreateAttachment(ctx, added); err != nil {
+		t.Fatalf("Failed to seed attachment: %v", err)
+	}
+	changes := []struct {
+		name     string
+		method   string
+		path     string
+		userID   string
+		expected int
+	}{
+		{"Download from another todo", "GET", "/api/todos/default/attachments/" + id, "user-1", http.StatusNotFound},
+		{"Download another user's", "GET", "/api/todos/own/attachments/" + id, "user-2", http.StatusForbidden},
+		{"Delete admin's attachment", "DELETE", "/api/todos/own/attachments/added", "user-1", http.StatusForbidden},
+		{"Delete own attachment", "DELETE", "/api/todos/own/attachments/" + id, "user-1", http.StatusOK},
+		{"Download deleted", "GET", "/api/todos/own/attachments/" + id, "user-1", http.StatusNotFound},
+	}
+	for _, tt := range changes {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, tt.method, tt.path, "", tt.userID, "user")
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+	if _, err := db.OpenBlob(ctx, id); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected the contents deleted with the attachment, got %v", err)
+	}
+}
+
+func TestRecurrence(t *testing.T) {
+	mux, db := newTestServer()
+	ctx := context.Background()
+
+	seedTodo(t, db, models.Todo{ID: "own", Text: "Own", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+	seedTodo(t, db, models.Todo{ID: "added", Text: "Added", UserID: strPtr("user-1"), CreatedByUserID: strPtr("admin-1"), Recurrence: "FREQ=DAILY"})
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Default", IsDefaultTask: true, Recurrence: "FREQ=WEEKLY"})
+
+	tests := []struct {
+		name       string
+		id         string
+		body       string
+		expected   int
+		recurrence string
+	}{
+		{"Own todo", "own", `{"recurrence":"rrule:freq=monthly;interval=1"}`, http.StatusOK, "FREQ=MONTHLY"},
+		{"Invalid rule", "own", `{"recurrence":"FREQ=HOURLY"}`, http.StatusBadRequest, "FREQ=MONTHLY"},
+		{"Unsupported part", "own", `{"recurrence":"FREQ=DAILY;COUNT=3"}`, http.StatusBadRequest, "FREQ=MONTHLY"},
+		{"Admin-assigned task", "added", `{"recurrence":""}`, http.StatusForbidden, "FREQ=DAILY"},
+		{"Default task", "default", `{"recurrence":"FREQ=DAILY"}`, http.StatusForbidden, "FREQ=WEEKLY"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "PUT", "/api/todos/"+tt.id, tt.body, "user-1", "user")
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+			if todo, _ := db.GetTodo(ctx, tt.id); todo.Recurrence != tt.recurrence {
+				t.Errorf("Expected recurrence %q, got %q", tt.recurrence, todo.Recurrence)
+			}
+		})
+	}
+
+	w := do(mux, "POST", "/api/todos", `{"text":"Weekly","recurrence":"FREQ=WEEKLY;INTERVAL=2"}`, "user-1", "user")
+	var created models.Todo
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if w.Code != http.StatusCreated || created.Recurrence != "FREQ=WEEKLY;INTERVAL=2" {
+		t.Errorf("Expected a recurring todo to be created, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(mux, "POST", "/api/todos", `{"text":"New","recurrence":"sometimes"}`, "user-1", "user"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid rule, got %d", w.Code)
+	}
+
+	// Completing an admin-assigned recurring task brings it back, still
+	// assigned by the admin
+	if w := do(mux, "PUT", "/api/todos/added", `{"status":"done"}`, "user-1", "user"); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	todos, _ := db.ListUserTodos(ctx, "user-1", false, store.TodoFilter{})
+	next := todos[0]
+	if next.Text != "Added" || next.Status != "pending" || next.Occurrence != 1 || next.Source() != models.SourceAdmin {
+		t.Errorf("Expected the next occurrence of the admin-assigned task at the top, got %+v", next)
+	}
+
+	// Completing a recurring default task moves only this user on
+	w = do(mux, "PUT", "/api/todos/default", `{"status":"done"}`, "user-1", "user")
+	var updated models.Todo
+	json.Unmarshal(w.Body.Bytes(), &updated)
+	if w.Code != http.StatusOK || updated.Status != "pending" || updated.Occurrence != 1 || updated.DueAt == nil {
+		t.Errorf("Expected the user on the next occurrence, got %d: %s", w.Code, w.Body.String())
+	}
+	if theirs, _ := db.GetUserTodo(ctx, "default", "user-2"); theirs.Occurrence != 0 {
+		t.Errorf("Expected other users unaffected, got occurrence %d", theirs.Occurrence)
+	}
+}
+
+func TestWorkflow(t *testing.T) {
+	mux, db := newTestServer()
+	ctx := context.Background()
+
+	workflow, _ := models.NormalizeWorkflow(models.Workflow{
+		Statuses: []string{"blocked"},
+		Transitions: map[string][]string{
+			"pending":     {"in-progress", "blocked"},
+			"blocked":     {"pending"},
+			"in-progress": {"done"},
+		},
+	})
+	if err := db.SaveWorkflow(ctx, workflow); err != nil {
+		t.Fatalf("Failed to save workflow: %v", err)
+	}
+	seedTodo(t, db, models.Todo{ID: "own", Text: "Own", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Default", IsDefaultTask: true})
+
+	w := do(mux, "GET", "/api/workflow", "", "user-1", "user")
+	var got models.Workflow
+	json.Unmarshal(w.Body.Bytes(), &got)
+	if w.Code != http.StatusOK || !slices.Equal(got.Statuses, []string{"pending", "in-progress", "done", "blocked"}) || !slices.Equal(got.Transitions["pending"], []string{"in-progress", "blocked"}) {
+		t.Errorf("Expected the saved workflow, got %d: %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		name     string
+		id       string
+		status   string
+		expected int
+	}{
+		{"Custom status", "own", "blocked", http.StatusOK},
+		{"Unknown status", "own", "archived", http.StatusBadRequest},
+		{"Disallowed move", "own", "done", http.StatusConflict},
+		{"Same status", "own", "blocked", http.StatusOK},
+		{"Allowed move", "own", "pending", http.StatusOK},
+		{"Default task", "default", "in-progress", http.StatusOK},
+		{"Default task disallowed move", "default", "blocked", http.StatusConflict},
+		{"Default task allowed move", "default", "done", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "PUT", "/api/todos/"+tt.id, `{"status":"`+tt.status+`"}`, "user-1", "user")
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// Other users follow the workflow from their own status
+	if w := do(mux, "PUT", "/api/todos/default", `{"status":"blocked"}`, "user-2", "user"); w.Code != http.StatusOK {
+		t.Errorf("Expected user-2 to block their pending task, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := do(mux, "GET", "/api/todos?status=blocked", "", "user-1", "user"); w.Code != http.StatusOK {
+		t.Errorf("Expected custom statuses to be filterable, got %d", w.Code)
+	}
+}
+
+func TestDependencies(t *testing.T) {
+	mux, db := newTestServer()
+	ctx := context.Background()
+
+	seedTodo(t, db, models.Todo{ID: "pack", Text: "Pack", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+	seedTodo(t, db, models.Todo{ID: "buy", Text: "Buy", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+	seedTodo(t, db, models.Todo{ID: "added", Text: "Added", UserID: strPtr("user-1"), CreatedByUserID: strPtr("admin-1")})
+	seedTodo(t, db, models.Todo{ID: "theirs", Text: "Theirs", UserID: strPtr("user-2"), CreatedByUserID: strPtr("user-2")})
+	seedTodo(t, db, models.Todo{ID: "book", Text: "Book", IsDefaultTask: true})
+	seedTodo(t, db, models.Todo{ID: "go", Text: "Go", IsDefaultTask: true, BlockedBy: []string{"book"}})
+	seedTodo(t, db, models.Todo{ID: "draft", Text: "Draft", IsDefaultTask: true, Draft: true})
+
+	tests := []struct {
+		name      string
+		id        string
+		body      string
+		expected  int
+		blockedBy []string
+	}{
+		{"Own todo", "pack", `{"blocked_by":["buy","book","buy"]}`, http.StatusOK, []string{"book", "buy"}},
+		{"Cycle", "buy", `{"blocked_by":["pack"]}`, http.StatusBadRequest, nil},
+		{"Itself", "buy", `{"blocked_by":["buy"]}`, http.StatusBadRequest, nil},
+		{"Other user's todo", "buy", `{"blocked_by":["theirs"]}`, http.StatusBadRequest, nil},
+		{"Unknown todo", "buy", `{"blocked_by":["missing"]}`, http.StatusBadRequest, nil},
+		{"Unpublished default task", "buy", `{"blocked_by":["draft"]}`, http.StatusBadRequest, nil},
+		{"Admin-assigned task", "added", `{"blocked_by":["buy"]}`, http.StatusForbidden, nil},
+		{"Default task", "book", `{"blocked_by":["buy"]}`, http.StatusForbidden, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "PUT", "/api/todos/"+tt.id, tt.body, "user-1", "user")
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+			if todo, _ := db.GetTodo(ctx, tt.id); !slices.Equal(todo.BlockedBy, tt.blockedBy) {
+				t.Errorf("Expected blocked_by %v, got %v", tt.blockedBy, todo.BlockedBy)
+			}
+		})
+	}
+
+	w := do(mux, "POST", "/api/todos", `{"text":"Leave","blocked_by":["pack"]}`, "user-1", "user")
+	var created models.Todo
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if w.Code != http.StatusCreated || !slices.Equal(created.BlockedBy, []string{"pack"}) {
+		t.Errorf("Expected a blocked todo to be created, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(mux, "POST", "/api/todos", `{"text":"Leave","blocked_by":["theirs"]}`, "user-1", "user"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for another user's blocker, got %d", w.Code)
+	}
+	if w := do(mux, "POST", "/api/todos", `{"text":"Leave","blocked_by":["draft"]}`, "user-1", "user"); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "not found") {
+		t.Errorf("Expected status 400 for an unpublished blocker, got %d: %s", w.Code, w.Body.String())
+	}
+
+	blocked := map[string]bool{}
+	for _, todo := range listTodos(t, mux, "user-1") {
+		blocked[todo.ID] = todo.Blocked
+	}
+	if !blocked["pack"] || blocked["buy"] || !blocked["go"] {
+		t.Errorf("Expected pack and go to be listed as blocked, got %v", blocked)
+	}
+
+	// Blocked todos cannot be finished until their blockers are
+	if w := do(mux, "PUT", "/api/todos/pack", `{"status":"done"}`, "user-1", "user"); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 finishing a blocked todo, got %d", w.Code)
+	}
+	if w := do(mux, "PUT", "/api/todos/pack", `{"status":"in-progress"}`, "user-1", "user"); w.Code != http.StatusOK {
+		t.Errorf("Expected blocked todos to be started, got %d: %s", w.Code, w.Body.String())
+	}
+	do(mux, "PUT", "/api/todos/buy", `{"status":"done"}`, "user-1", "user")
+	do(mux, "PUT", "/api/todos/book", `{"status":"done"}`, "user-1", "user")
+	if w := do(mux, "PUT", "/api/todos/pack", `{"status":"done"}`, "user-1", "user"); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 once the blockers are done, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Default tasks are blocked by each user's own progress
+	if w := do(mux, "PUT", "/api/todos/go", `{"status":"done"}`, "user-1", "user"); w.Code != http.StatusOK {
+		t.Errorf("Expected user-1 to finish go, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(mux, "PUT", "/api/todos/go", `{"status":"done"}`, "user-2", "user"); w.Code != http.StatusConflict {
+		t.Errorf("Expected user-2 to still be blocked, got %d", w.Code)
+	}
+}
+
+func TestDescriptions(t *testing.T) {
+	mux, db := newTestServer()
+	ctx := context.Background()
+
+	seedTodo(t, db, models.Todo{ID: "own", Text: "Own", UserID: strPtr("user-1"), CreatedByUserID: strPtr("user-1")})
+	seedTodo(t, db, models.Todo{ID: "added", Text: "Added", Description: "From the admin", UserID: strPtr("user-1"), CreatedByUserID: strPtr("admin-1")})
+	seedTodo(t, db, models.Todo{ID: "default", Text: "Default", Description: "# Steps", IsDefaultTask: true})
+
+	tests := []struct {
+		name        string
+		id          string
+		body        string
+		expected    int
+		description string
+	}{
+		{"Own todo", "own", `{"description":"Pack **light**"}`, http.StatusOK, "Pack **light**"},
+		{"Too long", "own", `{"description":"` + strings.Repeat("a", models.MaxDescriptionLength+1) + `"}`, http.StatusBadRequest, "Pack **light**"},
+		{"Admin-assigned task", "added", `{"description":""}`, http.StatusForbidden, "From the admin"},
+		{"Default task", "default", `{"description":"Mine"}`, http.StatusForbidden, "# Steps"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(mux, "PUT", "/api/todos/"+tt.id, tt.body, "user-1", "user")
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+			if todo, _ := db.GetTodo(ctx, tt.id); todo.Description != tt.description {
+				t.Errorf("Expected description %q, got %q", tt.description, todo.Description)
+			}
+		})
+	}
+
+	w := do(mux, "POST", "/api/todos", `{"text":"New","description":"*hi*\n\n<script>alert(1)</script>"}`, "user-1", "user")
+	var created models.Todo
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if w.Code != http.StatusCreated || created.Description != "*hi*\n\n<script>alert(1)</script>" || created.DescriptionHTML != "" {
+		t.Errorf("Expected the markdown stored as sent, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// HTML is only rendered on request, and sanitized
+	w = do(mux, "GET", "/api/todos/"+created.ID+"?render=html", "", "user-1", "user")
+	var got models.Todo
+	json.Unmarshal(w.Body.Bytes(), &got)
+	if w.Code != http.StatusOK || !strings.Contains(got.DescriptionHTML, "<em>hi</em>") || strings.Contains(got.DescriptionHTML, "<script") {
+		t.Errorf("Expected sanitized HTML, got %d: %s", w.Code, w.Body.String())
+	}
+	for _, todo := range listTodos(t, mux, "user-1") {
+		if todo.DescriptionHTML != "" {
+			t.Errorf("Expected no HTML unless asked for, got %q", todo.DescriptionHTML)
+		}
+	}
+	w = do(mux, "GET", "/api/todos?render=html", "", "user-1", "user")
+	var resp struct {
+		Todos []models.Todo `json:"todos"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	for _, todo := range resp.Todos {
+		if todo.ID == "default" && todo.DescriptionHTML != "<h1>Steps</h1>\n" {
+			t.Errorf("Expected the default task's description rendered, got %q", todo.DescriptionHTML)
+		}
+	}
+	if w := do(mux, "GET", "/api/todos?render=pdf", "", "user-1", "user"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown rendering, got %d", w.Code)
+	}
+}
+
+func TestGroups(t *testing.T) {
+	mux, db := newTestServer()
+	ctx := context.Background()
+	if err := db.SaveGroup(ctx, models.Group{ID: "pro", Name: "Pro", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to seed group: %v", err)
+	}
+	if err := db.AddGroupMember(ctx, "pro", "user-1"); err != nil {
+		t.Fatalf("Failed to seed member: %v", err)
+	}
+	seedTodo(t, db, models.Todo{ID: "everyone", Text: "Everyone", IsDefaultTask: true})
+	seedTodo(t, db, models.Todo{ID: "pro-only", Text: "Pro only", IsDefaultTask: true, Groups: []string{"pro"}})
+
+	tests := []struct {
+		userID   string
+		expected []string
+		get      int
+	}{
+		{"user-1", []string{"pro-only", "everyone"}, http.StatusOK},
+		{"user-2", []string{"everyone"}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.userID, func(t *testing.T) {
+			var got []string
+			for _, todo := range listTodos(t, mux, tt.userID) {
+				got = append(got, todo.ID)
+			}
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+			if w := do(mux, "GET", "/api/todos/pro-only", "", tt.userID, "user"); w.Code != tt.get {
+				t.Errorf("Expected status %d reading the pro task, got %d", tt.get, w.Code)
+			}
+			if w := do(mux, "PUT", "/api/todos/pro-only", `{"status":"done"}`, tt.userID, "user"); w.Code != tt.get {
+				t.Errorf("Expected status %d updating the pro task, got %d", tt.get, w.Code)
+			}
+		})
+	}
+}
+
+func TestPublication(t *testing.T) {
+	mux, db := newTestServer()
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	seedTodo(t, db, models.Todo{ID: "live", Text: "Live", IsDefaultTask: true, PublishAt: &past, ExpiresAt: &future})
+	seedTodo(t, db, models.Todo{ID: "draft", Text: "Draft", IsDefaultTask: true, Draft: true})
+	seedTodo(t, db, models.Todo{ID: "scheduled", Text: "Scheduled", IsDefaultTask: true, PublishAt: &future})
+	seedTodo(t, db, models.Todo{ID: "expired", Text: "Expired", IsDefaultTask: true, ExpiresAt: &past})
+
+	var got []string
+	for _, todo := range listTodos(t, mux, "user-1") {
+		got = append(got, todo.ID)
+	}
+	if !slices.Equal(got, []string{"live"}) {
+		t.Errorf("Expected only the live task, got %v", got)
+	}
+	for _, id := range []string{"draft", "scheduled", "expired"} {
+		if w := do(mux, "GET", "/api/todos/"+id, "", "user-1", "user"); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 reading the %s task, got %d", id, w.Code)
+		}
+		if w := do(mux, "PUT", "/api/todos/"+id, `{"status":"done"}`, "user-1", "user"); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 updating the %s task, got %d", id, w.Code)
+		}
+	}
+}
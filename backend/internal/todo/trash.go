@@ -0,0 +1,118 @@
+package todo
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/akhilmk/packup/internal/audit"
+	"github.com/akhilmk/packup/internal/auth"
+	"github.com/akhilmk/packup/internal/httputil"
+	"github.com/akhilmk/packup/internal/models"
+	"github.com/akhilmk/packup/internal/store"
+)
+
+// ListTrash lists deleted todos
+// @Summary List deleted todos
+// @Description Get the authenticated user's deleted personal todos, most recently deleted first. They can be restored until the trash retention period ends.
+// @Tags todos
+// @Produce  json
+// @Success 200 {object} map[string][]models.Todo
+// @Failure 401 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/todos/trash [get]
+func (h *Handler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r.Context())
+	if !ok {
+		httputil.Unauthorized(w)
+		return
+	}
+
+	todos, err := h.todos.ListDeletedTodos(r.Context(), userID)
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+
+	httputil.WriteJSON(w, map[string]any{"todos": todos}, http.StatusOK)
+}
+
+// Restore todo
+// @Summary Restore todo
+// @Description Move a deleted todo out of the trash, along with the subtasks deleted with it. The same permissions as deleting apply. A subtask can't be restored while its parent is in the trash.
+// @Tags todos
+// @Produce  json
+// @Param id path string true "Todo ID"
+// @Success 200 {object} models.Todo
+// @Failure 400 {object} httputil.APIError
+// @Failure 403 {object} httputil.APIError
+// @Failure 404 {object} httputil.APIError
+// @Failure 409 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/todos/{id}/restore [post]
+func (h *Handler) Restore(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r.Context())
+	if !ok {
+		httputil.Unauthorized(w)
+		return
+	}
+
+	userRole, _ := auth.GetUserRole(r.Context())
+
+	id := r.PathValue("id")
+	if id == "" {
+		httputil.BadRequest(w, "id required")
+		return
+	}
+
+	t, err := h.todos.GetDeletedTodo(r.Context(), id)
+	if err != nil {
+		httputil.NotFound(w, "todo not found in trash")
+		return
+	}
+
+	// Only admins can restore default tasks
+	if t.IsDefaultTask && userRole != "admin" {
+		httputil.Forbidden(w, "forbidden: only admins can restore default tasks")
+		return
+	}
+
+	// Regular users can only restore their own todos
+	if !t.IsDefaultTask && (t.UserID == nil || *t.UserID != userID) {
+		httputil.Forbidden(w, "forbidden")
+		return
+	}
+
+	// Admin-assigned tasks are restored by admins, who deleted them
+	if !t.IsDefaultTask && userRole != "admin" {
+		if t.CreatedByUserID != nil && *t.CreatedByUserID != userID {
+			httputil.Forbidden(w, "forbidden: cannot restore admin-assigned task")
+			return
+		}
+	}
+
+	if t.ParentID != nil {
+		if _, err := h.todos.GetDeletedTodo(r.Context(), *t.ParentID); err == nil {
+			httputil.Conflict(w, "restore the parent todo first")
+			return
+		}
+	}
+
+	if err := h.todos.RestoreTodo(r.Context(), id); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			httputil.NotFound(w, "todo not found in trash")
+			return
+		}
+		httputil.InternalError(w, err.Error())
+		return
+	}
+
+	// Return the todo with the user's own progress, which survived the trash
+	restored, err := h.todos.GetUserTodo(r.Context(), id, userID)
+	if err != nil {
+		httputil.InternalError(w, err.Error())
+		return
+	}
+	h.audit.Record(r.Context(), audit.Event{ActorID: userID, Action: models.AuditTodoRestore, UserID: audit.TodoOwner(t, ""), TodoID: id, Before: t, After: restored})
+
+	httputil.WriteJSON(w, restored, http.StatusOK)
+}
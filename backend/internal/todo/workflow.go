@@ -0,0 +1,20 @@
+package todo
+
+import (
+	"net/http"
+
+	"github.com/akhilmk/packup/internal/httputil"
+)
+
+// GetWorkflow returns the status workflow
+// @Summary Get status workflow
+// @Description Get the statuses todos can be in, built-in ones first, and the statuses each can move to. Without transitions, any status can move to any other.
+// @Tags todos
+// @Produce  json
+// @Success 200 {object} models.Workflow
+// @Failure 401 {object} httputil.APIError
+// @Failure 500 {object} httputil.APIError
+// @Router /api/workflow [get]
+func (h *Handler) GetWorkflow(w http.ResponseWriter, r *http.Request) {
+	httputil.WriteWorkflow(w, r, h.workflow)
+}
@@ -0,0 +1,53 @@
+// Package web serves the Svelte frontend, embedded into the binary at build
+// time by copying frontend/build into this package's build directory.
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+//go:embed all:build
+var embedded embed.FS
+
+// FS returns the frontend files to serve. If dir is set the files are read
+// from disk (useful during frontend development), otherwise the embedded
+// build is used.
+func FS(dir string) fs.FS {
+	if dir != "" {
+		return os.DirFS(dir)
+	}
+	sub, _ := fs.Sub(embedded, "build")
+	return sub
+}
+
+// Available reports whether fsys contains a built frontend.
+func Available(fsys fs.FS) bool {
+	_, err := fs.Stat(fsys, "index.html")
+	return err == nil
+}
+
+// Handler serves static files from fsys, falling back to index.html for
+// unknown paths so client-side SPA routes work on reload.
+func Handler(fsys fs.FS) http.Handler {
+	fileServer := http.FileServerFS(fsys)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(path.Clean(r.URL.Path), "/")
+		if name == "" {
+			name = "."
+		}
+
+		// Check if file exists
+		if info, err := fs.Stat(fsys, name); err == nil && !info.IsDir() {
+			fileServer.ServeHTTP(w, r)
+			return
+		}
+
+		// Serve index.html for SPA routing
+		http.ServeFileFS(w, r, fsys, "index.html")
+	})
+}
@@ -0,0 +1,56 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+// TestHandlerSPAFallback tests static file serving with index.html fallback
+func TestHandlerSPAFallback(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":     {Data: []byte("<html>app</html>")},
+		"_app/start.js":  {Data: []byte("console.log('start')")},
+		"logo.svg":       {Data: []byte("<svg/>")},
+		"_app/empty/.ok": {Data: []byte("")},
+	}
+	handler := Handler(fsys)
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"Root serves index", "/", "<html>app</html>"},
+		{"Static asset", "/_app/start.js", "console.log('start')"},
+		{"Client-side route falls back to index", "/admin/users/123", "<html>app</html>"},
+		{"Directory falls back to index", "/_app/empty", "<html>app</html>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+			}
+			if w.Body.String() != tt.expected {
+				t.Errorf("Expected body %q, got %q", tt.expected, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestAvailable tests detection of a built frontend
+func TestAvailable(t *testing.T) {
+	if Available(fstest.MapFS{}) {
+		t.Error("Expected empty FS to be unavailable")
+	}
+	if !Available(fstest.MapFS{"index.html": {Data: []byte("x")}}) {
+		t.Error("Expected FS with index.html to be available")
+	}
+}
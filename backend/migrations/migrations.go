@@ -0,0 +1,28 @@
+// Package migrations embeds the versioned schema migrations so the server
+// binary can migrate the database without any files on disk.
+//
+// Each backend has its own directory of NNNNNN_name.up.sql / .down.sql
+// files. Both directories must describe the same schema versions.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+// Postgres holds the PostgreSQL migrations.
+var Postgres = sub("postgres")
+
+// SQLite holds the SQLite migrations.
+var SQLite = sub("sqlite")
+
+func sub(dir string) fs.FS {
+	fsys, err := fs.Sub(files, dir)
+	if err != nil {
+		panic(err)
+	}
+	return fsys
+}
@@ -0,0 +1,6 @@
+-- Revert the initial PackUp schema
+
+DROP TABLE IF EXISTS user_todo_state;
+DROP TABLE IF EXISTS todos;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS users;
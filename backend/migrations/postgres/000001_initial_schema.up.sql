@@ -1,5 +1,6 @@
--- Database schema for Packup
--- Run this manually when needed during development
+-- Initial PackUp schema
+-- Written idempotently so databases created before versioned migrations
+-- can be adopted without losing data.
 
 CREATE TABLE IF NOT EXISTS users (
     id TEXT PRIMARY KEY,
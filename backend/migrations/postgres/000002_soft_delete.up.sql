@@ -0,0 +1,5 @@
+-- Deleted todos stay in the trash (with every user's progress on them)
+-- until they are restored or purged after the retention period.
+ALTER TABLE todos ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX idx_todos_deleted_at ON todos(deleted_at) WHERE deleted_at IS NOT NULL;
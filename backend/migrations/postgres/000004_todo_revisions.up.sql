@@ -0,0 +1,17 @@
+-- Snapshot of a todo's global fields after every change, numbered per todo.
+CREATE TABLE todo_revisions (
+    todo_id TEXT NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    shared_with_admin BOOLEAN NOT NULL,
+    hidden_from_user BOOLEAN NOT NULL,
+    changed_by TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (todo_id, revision)
+);
+
+-- Existing todos start their history at their current state
+INSERT INTO todo_revisions (todo_id, revision, text, status, shared_with_admin, hidden_from_user, changed_by, created_at)
+SELECT id, 1, text, status, shared_with_admin, hidden_from_user, created_by_user_id, created
+FROM todos;
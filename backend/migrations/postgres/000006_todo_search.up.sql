@@ -0,0 +1,5 @@
+-- Full-text search over todo text
+ALTER TABLE todos ADD COLUMN search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector('english', text)) STORED;
+
+CREATE INDEX idx_todos_search ON todos USING GIN (search_vector);
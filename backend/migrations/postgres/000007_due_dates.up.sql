@@ -0,0 +1,6 @@
+-- Optional due dates. A user's row in user_todo_state can override the due
+-- date of a default task for that user alone.
+ALTER TABLE todos ADD COLUMN due_at TIMESTAMPTZ;
+ALTER TABLE user_todo_state ADD COLUMN due_at TIMESTAMPTZ;
+
+CREATE INDEX idx_todos_due_at ON todos(due_at) WHERE due_at IS NOT NULL;
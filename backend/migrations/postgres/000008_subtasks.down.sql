@@ -0,0 +1,5 @@
+-- Subtasks would become top-level todos once the column is gone, so remove them first.
+DELETE FROM todos WHERE parent_id IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_todos_parent_id;
+ALTER TABLE todos DROP COLUMN parent_id;
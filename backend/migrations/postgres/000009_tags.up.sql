@@ -0,0 +1,17 @@
+-- Tags, shared by name across todos. Curated tags form the palette admins
+-- offer to users, each with an optional display color.
+CREATE TABLE tags (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    color TEXT NOT NULL DEFAULT '',
+    curated BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE todo_tags (
+    todo_id TEXT NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    tag_id BIGINT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (todo_id, tag_id)
+);
+
+CREATE INDEX idx_todo_tags_tag ON todo_tags(tag_id);
@@ -0,0 +1,14 @@
+-- Comment threads on todos, one per todo and user. Comments go with their
+-- todo when it is purged from the trash, and with the user whose thread
+-- they are in; an author's deleted account leaves their comments behind.
+CREATE TABLE todo_comments (
+    id TEXT PRIMARY KEY,
+    todo_id TEXT NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    author_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    edited_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_todo_comments_thread ON todo_comments(todo_id, user_id, created_at);
@@ -0,0 +1,14 @@
+-- Files attached to todos, per user like comment threads. The contents live
+-- in blob storage under the attachment's ID.
+CREATE TABLE todo_attachments (
+    id TEXT PRIMARY KEY,
+    todo_id TEXT NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    uploaded_by TEXT REFERENCES users(id) ON DELETE SET NULL,
+    filename TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_todo_attachments_todo ON todo_attachments(todo_id, user_id, created_at);
@@ -0,0 +1,3 @@
+ALTER TABLE user_todo_state DROP COLUMN occurrence;
+ALTER TABLE todos DROP COLUMN occurrence;
+ALTER TABLE todos DROP COLUMN recurrence;
@@ -0,0 +1,9 @@
+-- Dependencies between todos: a todo cannot be marked done while any of its
+-- blockers is unfinished.
+CREATE TABLE todo_dependencies (
+    todo_id TEXT NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    blocker_id TEXT NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    PRIMARY KEY (todo_id, blocker_id)
+);
+
+CREATE INDEX idx_todo_dependencies_blocker ON todo_dependencies(blocker_id);
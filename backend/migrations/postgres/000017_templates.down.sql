@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_todos_template_task;
+ALTER TABLE todos DROP COLUMN template_task_id;
+
+DROP TABLE IF EXISTS template_users;
+DROP TABLE IF EXISTS template_tasks;
+DROP TABLE IF EXISTS templates;
@@ -0,0 +1,30 @@
+-- Checklist templates: ordered tasks admins apply to selected users as
+-- admin-created todos. Todos remember the task they were added from, so they
+-- can be re-synced when it changes, and stay behind if it is removed.
+CREATE TABLE templates (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE template_tasks (
+    id TEXT PRIMARY KEY,
+    template_id TEXT NOT NULL REFERENCES templates(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    priority TEXT NOT NULL DEFAULT 'normal'
+);
+
+CREATE INDEX idx_template_tasks_template ON template_tasks(template_id, position);
+
+CREATE TABLE template_users (
+    template_id TEXT NOT NULL REFERENCES templates(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (template_id, user_id)
+);
+
+ALTER TABLE todos ADD COLUMN template_task_id TEXT REFERENCES template_tasks(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_todos_template_task ON todos(template_task_id) WHERE template_task_id IS NOT NULL;
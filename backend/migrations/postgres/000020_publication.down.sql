@@ -0,0 +1,3 @@
+ALTER TABLE todos DROP COLUMN expires_at;
+ALTER TABLE todos DROP COLUMN publish_at;
+ALTER TABLE todos DROP COLUMN draft;
@@ -0,0 +1,48 @@
+-- Initial PackUp schema (SQLite)
+-- Timestamps are stored as UTC text in the format written by the driver
+-- (_time_format=sqlite), so they sort and compare lexicographically.
+
+CREATE TABLE IF NOT EXISTS users (
+    id TEXT PRIMARY KEY,
+    google_id TEXT UNIQUE NOT NULL,
+    email TEXT UNIQUE NOT NULL,
+    name TEXT,
+    avatar_url TEXT,
+    role TEXT NOT NULL DEFAULT 'user',
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+    token TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    expires_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS todos (
+    id TEXT PRIMARY KEY,
+    text TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    created DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    position REAL NOT NULL DEFAULT 0,
+    user_id TEXT REFERENCES users(id) ON DELETE CASCADE,
+    created_by_user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+    is_default_task BOOLEAN NOT NULL DEFAULT false,
+    shared_with_admin BOOLEAN NOT NULL DEFAULT false,
+    hidden_from_user BOOLEAN NOT NULL DEFAULT false
+);
+
+-- Junction table for per-user state of default tasks
+CREATE TABLE IF NOT EXISTS user_todo_state (
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    todo_id TEXT NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending',
+    position REAL NOT NULL DEFAULT 0,
+    updated_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    PRIMARY KEY (user_id, todo_id)
+);
+
+-- Create indexes for efficient querying
+CREATE INDEX IF NOT EXISTS idx_todos_default ON todos(is_default_task) WHERE is_default_task = true;
+CREATE INDEX IF NOT EXISTS idx_user_todo_state_user ON user_todo_state(user_id);
+CREATE INDEX IF NOT EXISTS idx_user_todo_state_todo ON user_todo_state(todo_id);
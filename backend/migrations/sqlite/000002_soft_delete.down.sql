@@ -0,0 +1,5 @@
+-- Trashed todos would reappear once the column is gone, so purge them first.
+DELETE FROM todos WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_todos_deleted_at;
+ALTER TABLE todos DROP COLUMN deleted_at;
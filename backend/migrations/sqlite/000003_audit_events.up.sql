@@ -0,0 +1,17 @@
+-- Append-only log of who changed which todo or user, and how.
+-- No foreign keys: events must outlive purged todos.
+CREATE TABLE audit_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    actor_id TEXT,
+    user_id TEXT,
+    todo_id TEXT,
+    action TEXT NOT NULL,
+    before_value TEXT,
+    after_value TEXT,
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE INDEX idx_audit_events_created_at ON audit_events(created_at);
+CREATE INDEX idx_audit_events_user ON audit_events(user_id, created_at);
+CREATE INDEX idx_audit_events_todo ON audit_events(todo_id, created_at);
+CREATE INDEX idx_audit_events_actor ON audit_events(actor_id, created_at);
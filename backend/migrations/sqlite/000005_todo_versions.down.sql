@@ -0,0 +1,2 @@
+ALTER TABLE user_todo_state DROP COLUMN version;
+ALTER TABLE todos DROP COLUMN version;
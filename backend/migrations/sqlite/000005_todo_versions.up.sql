@@ -0,0 +1,5 @@
+-- Versions for optimistic concurrency control. Todos count changes to their
+-- global fields; user_todo_state counts changes to a user's own status, so
+-- rows created only by reordering stay at 0.
+ALTER TABLE todos ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE user_todo_state ADD COLUMN version INTEGER NOT NULL DEFAULT 0;
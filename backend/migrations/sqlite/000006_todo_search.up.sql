@@ -0,0 +1,18 @@
+-- Full-text search over todo text, kept in sync with todos by triggers.
+-- Todos are keyed by a text ID, so the index stores it rather than
+-- relying on the (unstable) rowid.
+CREATE VIRTUAL TABLE todos_fts USING fts5(todo_id UNINDEXED, text, tokenize = 'porter unicode61');
+
+INSERT INTO todos_fts (todo_id, text) SELECT id, text FROM todos;
+
+CREATE TRIGGER todos_fts_insert AFTER INSERT ON todos BEGIN
+    INSERT INTO todos_fts (todo_id, text) VALUES (new.id, new.text);
+END;
+
+CREATE TRIGGER todos_fts_update AFTER UPDATE OF text ON todos BEGIN
+    UPDATE todos_fts SET text = new.text WHERE todo_id = old.id;
+END;
+
+CREATE TRIGGER todos_fts_delete AFTER DELETE ON todos BEGIN
+    DELETE FROM todos_fts WHERE todo_id = old.id;
+END;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_todos_due_at;
+ALTER TABLE user_todo_state DROP COLUMN due_at;
+ALTER TABLE todos DROP COLUMN due_at;
@@ -0,0 +1,5 @@
+-- Subtasks. A subtask belongs to the same list as its parent; purging a
+-- todo from the trash removes its subtasks with it.
+ALTER TABLE todos ADD COLUMN parent_id TEXT REFERENCES todos(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_todos_parent_id ON todos(parent_id) WHERE parent_id IS NOT NULL;
@@ -0,0 +1,2 @@
+-- Priority levels: low, normal, high or urgent. Existing todos are normal.
+ALTER TABLE todos ADD COLUMN priority TEXT NOT NULL DEFAULT 'normal';
@@ -0,0 +1,6 @@
+-- Recurring todos: an RRULE subset such as FREQ=WEEKLY;INTERVAL=2, and a
+-- count of the occurrences done so far. Users keep their own count for
+-- recurring default tasks.
+ALTER TABLE todos ADD COLUMN recurrence TEXT NOT NULL DEFAULT '';
+ALTER TABLE todos ADD COLUMN occurrence INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE user_todo_state ADD COLUMN occurrence INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,13 @@
+-- Statuses admins add to the built-in pending, in-progress and done, in
+-- display order, and the moves allowed between statuses. Without any
+-- transitions, any status can move to any other.
+CREATE TABLE workflow_statuses (
+    name TEXT PRIMARY KEY,
+    position INTEGER NOT NULL
+);
+
+CREATE TABLE workflow_transitions (
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    PRIMARY KEY (from_status, to_status)
+);
@@ -0,0 +1,2 @@
+-- Long-form markdown descriptions, alongside the short text of a todo.
+ALTER TABLE todos ADD COLUMN description TEXT NOT NULL DEFAULT '';
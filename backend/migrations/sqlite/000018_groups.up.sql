@@ -0,0 +1,24 @@
+-- User groups, such as the customers on a plan or in a region. Default tasks
+-- that target groups are only shown to their members; those without targets
+-- are shown to everyone. A group cannot be deleted while tasks target it.
+CREATE TABLE user_groups (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE TABLE user_group_members (
+    group_id TEXT NOT NULL REFERENCES user_groups(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (group_id, user_id)
+);
+
+CREATE INDEX idx_user_group_members_user ON user_group_members(user_id);
+
+CREATE TABLE todo_groups (
+    todo_id TEXT NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    group_id TEXT NOT NULL REFERENCES user_groups(id),
+    PRIMARY KEY (todo_id, group_id)
+);
+
+CREATE INDEX idx_todo_groups_group ON todo_groups(group_id);
@@ -0,0 +1 @@
+ALTER TABLE user_todo_state DROP COLUMN updated_since_done;
@@ -0,0 +1,3 @@
+-- Admins changing a default task can move users back to pending. Users who
+-- had completed it are flagged until they change their status again.
+ALTER TABLE user_todo_state ADD COLUMN updated_since_done BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,5 @@
+-- Default tasks can be kept as drafts, or published and expired on a
+-- schedule. Users only see them while they are live.
+ALTER TABLE todos ADD COLUMN draft BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE todos ADD COLUMN publish_at DATETIME;
+ALTER TABLE todos ADD COLUMN expires_at DATETIME;
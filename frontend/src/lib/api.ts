@@ -1,11 +1,19 @@
 // REST API client for Todo backend
 const API_BASE_URL = "/api";
 
-export type TodoStatus = 'pending' | 'in-progress' | 'done';
+// Built-in statuses, plus any an admin adds to the workflow.
+export type TodoStatus = 'pending' | 'in-progress' | 'done' | (string & {});
+
+export type TodoPriority = 'low' | 'normal' | 'high' | 'urgent';
+
+// Where a default task stands in its publishing schedule.
+export type Publication = 'draft' | 'scheduled' | 'live' | 'expired';
 
 export interface Todo {
     id: string;
     text: string;
+    description?: string; // markdown
+    description_html?: string; // sanitized, with render=html
     status: TodoStatus;
     created: string; // ISO date string
     position?: number;
@@ -13,14 +21,104 @@ export interface Todo {
     shared_with_admin?: boolean;
     hidden_from_user?: boolean;
     created_by_user_id?: string;
+    due_at?: string; // ISO date string
+    overdue?: boolean;
+    parent_id?: string;
+    subtasks?: Subtasks;
+    tags?: string[];
+    priority?: TodoPriority;
+    comment_count?: number;
+    recurrence?: string; // e.g. FREQ=WEEKLY;INTERVAL=2 or FREQ=MONTHLY;BYMONTHDAY=31
+    occurrence?: number;
+    template_task_id?: string; // set on todos added from a checklist template
+    blocked_by?: string[];
+    blocked?: boolean; // some todo in blocked_by is not done yet
+    groups?: string[]; // IDs of the groups a default task is shown to
+    updated_since_done?: boolean; // an admin changed it after you completed it
+    draft?: boolean;
+    publish_at?: string; // ISO date string
+    expires_at?: string; // ISO date string
+    publication?: Publication; // default tasks only
+}
+
+// A tag, in the palette curated by admins if curated is set.
+export interface Tag {
+    name: string;
+    color?: string;
+    curated: boolean;
+}
+
+// A comment in the thread between a user and the admins on a todo.
+export interface Comment {
+    id: string;
+    todo_id: string;
+    user_id: string;
+    author_id?: string;
+    body: string;
+    created_at: string; // ISO date string
+    edited_at?: string; // ISO date string
+}
+
+// A file attached to a todo, downloaded from its attachments endpoint.
+export interface Attachment {
+    id: string;
+    todo_id: string;
+    user_id: string;
+    uploaded_by?: string;
+    filename: string;
+    content_type: string;
+    size: number;
+    created_at: string; // ISO date string
+}
+
+// The statuses todos can be in and the statuses each can move to. Without
+// transitions, any status can move to any other. Done, the one status in
+// which a todo counts as finished, is always included.
+export interface Workflow {
+    statuses: TodoStatus[];
+    transitions?: Record<string, TodoStatus[]>;
+}
+
+// A named checklist admins apply to users, adding its tasks to their lists.
+export interface Template {
+    id: string;
+    name: string;
+    tasks: TemplateTask[];
+    created_at: string; // ISO date string
+    updated_at: string; // ISO date string
+}
+
+export interface TemplateTask {
+    id: string;
+    text: string;
+    description?: string; // markdown
+    priority: TodoPriority;
+}
+
+// A set of users that default tasks can be targeted to.
+export interface Group {
+    id: string;
+    name: string;
+    member_count: number;
+    created_at: string; // ISO date string
+}
+
+// Progress of a todo's direct subtasks.
+export interface Subtasks {
+    total: number;
+    done: number;
+    status: TodoStatus;
 }
 
 // ... existing code ...
 
 
 
-interface ListTodosResponse {
-    todos: Todo[];
+// A page of a list endpoint: the items under their key, plus the cursor of
+// the next page if there is one.
+interface Page {
+    next_cursor?: string;
+    [key: string]: unknown;
 }
 
 class ApiError extends Error {
@@ -38,15 +136,27 @@ async function handleResponse<T>(response: Response): Promise<T> {
     return response.json();
 }
 
+// Fetches every page of a paginated list endpoint by following next_cursor.
+async function listAll<T>(url: string, key: string): Promise<T[]> {
+    const items: T[] = [];
+    let cursor: string | undefined;
+    do {
+        const sep = url.includes("?") ? "&" : "?";
+        const response = await fetch(cursor ? `${url}${sep}cursor=${encodeURIComponent(cursor)}` : url);
+        const data = await handleResponse<Page>(response);
+        items.push(...(data[key] as T[]));
+        cursor = data.next_cursor;
+    } while (cursor);
+    return items;
+}
+
 export const api = {
     async listTodos(options?: { excludeAdminTodos?: boolean }): Promise<Todo[]> {
         let url = `${API_BASE_URL}/todos`;
         if (options?.excludeAdminTodos) {
             url += `?exclude_admin_todos=true`;
         }
-        const response = await fetch(url);
-        const data = await handleResponse<ListTodosResponse>(response);
-        return data.todos;
+        return listAll<Todo>(url, "todos");
     },
 
     async createTodo(text: string, sharedWithAdmin: boolean = true): Promise<Todo> {
@@ -89,6 +199,11 @@ export const api = {
         await handleResponse<{ success: boolean }>(response);
     },
 
+    async getWorkflow(): Promise<Workflow> {
+        const response = await fetch(`${API_BASE_URL}/workflow`);
+        return handleResponse<Workflow>(response);
+    },
+
     // Auth
     async getMe(): Promise<User> {
         const response = await fetch(`${API_BASE_URL}/auth/me`);
@@ -104,21 +219,15 @@ export const api = {
 
     // Admin endpoints
     async listUsers(): Promise<User[]> {
-        const response = await fetch(`${API_BASE_URL}/admin/users`);
-        const data = await handleResponse<{ users: User[] }>(response);
-        return data.users;
+        return listAll<User>(`${API_BASE_URL}/admin/users`, "users");
     },
 
     async listDefaultTasks(): Promise<Todo[]> {
-        const response = await fetch(`${API_BASE_URL}/admin/todos`);
-        const data = await handleResponse<{ todos: Todo[] }>(response);
-        return data.todos;
+        return listAll<Todo>(`${API_BASE_URL}/admin/todos`, "todos");
     },
 
     async listUserTodos(userId: string): Promise<Todo[]> {
-        const response = await fetch(`${API_BASE_URL}/admin/users/${userId}/todos`);
-        const data = await handleResponse<{ todos: Todo[] }>(response);
-        return data.todos;
+        return listAll<Todo>(`${API_BASE_URL}/admin/users/${userId}/todos`, "todos");
     },
 
     async updateUserTodo(userId: string, todoId: string, updates: { text?: string; status?: TodoStatus; hidden_from_user?: boolean }): Promise<void> {